@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
@@ -48,6 +49,14 @@ func main() {
 		logger.Warn("Redis not available - running without cache")
 	}
 
+	// Load symbol universe from exchangeInfo
+	symbolRegistry := market.GetRegistry()
+	if err := symbolRegistry.Refresh(); err != nil {
+		logger.Warnf("Symbol registry initial load failed, using unvalidated watchlist: %v", err)
+	}
+	symbolRegistry.StartAutoRefresh(1 * time.Hour)
+	logger.Infof("Symbol registry ready, watchlist: %v", symbolRegistry.Watchlist())
+
 	// Initialize market data collector
 	marketCollector := market.GetCollector()
 	marketCollector.StartCollecting()
@@ -74,15 +83,35 @@ func main() {
 			aiGroup.POST("/lightgbm/predict", api.LightGBMPredict)
 			aiGroup.POST("/randomforest/predict", api.RandomForestPredict)
 			aiGroup.POST("/ensemble/predict", api.EnsemblePredict)
+			aiGroup.GET("/ensemble/config", api.GetEnsembleConfig)
+			aiGroup.PUT("/ensemble/config", api.UpdateEnsembleConfig)
 			aiGroup.POST("/lstm/predict", api.LSTMPredict)
 			aiGroup.POST("/gru/predict", api.GRUPredict)
 			aiGroup.POST("/xgboost/predict", api.XGBoostPredict)
 			aiGroup.POST("/xgboost/train", api.XGBoostTrain)
 			aiGroup.POST("/arima/predict", api.ARIMAPredict)
+			aiGroup.POST("/:model/predict", api.PredictModel)
+			aiGroup.POST("/:model/forecast", api.Forecast)
+			aiGroup.POST("/:model/train", api.TrainModel)
+			aiGroup.GET("/:model/train", api.GetTrainingJob)
 			aiGroup.POST("/pattern/recognize", api.PatternRecognition)
 			aiGroup.POST("/portfolio/optimize", api.PortfolioOptimize)
 			aiGroup.POST("/strategy/generate", api.StrategyGenerate)
 			aiGroup.GET("/models/status", api.GetAllModelStatus)
+			aiGroup.GET("/models/:model/versions", api.ListModelVersions)
+			aiGroup.POST("/models/:model/activate", api.ActivateModelVersion)
+			aiGroup.POST("/models/:model/import", api.ImportModel)
+			aiGroup.POST("/models/:model/parity", api.ParityCheck)
+			aiGroup.GET("/ledger", api.GetLedgerEntries)
+			aiGroup.GET("/ledger/leaderboard", api.GetLedgerLeaderboard)
+			aiGroup.GET("/ledger/calibration", api.GetLedgerCalibration)
+			aiGroup.GET("/drift", api.GetDriftReports)
+			aiGroup.GET("/models/:model/drift", api.CheckModelDrift)
+			aiGroup.GET("/models/:model/calibration", api.GetModelCalibration)
+			aiGroup.POST("/models/:model/retrain", api.RetrainModel)
+			aiGroup.GET("/retrain/history", api.GetRetrainHistory)
+			aiGroup.GET("/retrain/policy", api.GetRetrainPolicy)
+			aiGroup.PUT("/retrain/policy", api.UpdateRetrainPolicy)
 		}
 
 		// Market Data Routes
@@ -93,6 +122,59 @@ func main() {
 			marketGroup.GET("/trades/:symbol", api.GetTrades)
 			marketGroup.GET("/klines/:symbol", api.GetKlines)
 			marketGroup.GET("/ticker/24hr", api.Get24hrTicker)
+			marketGroup.GET("/symbols", api.GetSymbols)
+			marketGroup.GET("/symbols/:symbol", api.GetSymbolInfo)
+			marketGroup.GET("/watchlist", api.GetWatchlist)
+			marketGroup.GET("/quality/:symbol", api.GetDataQuality)
+			marketGroup.PUT("/watchlist", api.UpdateWatchlist)
+			marketGroup.GET("/footprint/:symbol", api.GetFootprint)
+			marketGroup.GET("/vwap/:symbol", api.GetVWAP)
+			marketGroup.GET("/profile/:symbol", api.GetVolumeProfile)
+
+			// USDT-M Futures Routes
+			futuresGroup := marketGroup.Group("/futures")
+			{
+				futuresGroup.GET("/funding", api.GetFundingOverview)
+				futuresGroup.GET("/funding/:symbol", api.GetFundingRates)
+				futuresGroup.GET("/mark/:symbol", api.GetMarkPrice)
+				futuresGroup.GET("/open-interest/:symbol", api.GetOpenInterest)
+				futuresGroup.GET("/long-short/:symbol", api.GetLongShortRatio)
+				futuresGroup.GET("/liquidations/:symbol", api.GetLiquidations)
+				futuresGroup.GET("/features/:symbol", api.GetFuturesFeatures)
+			}
+		}
+
+		// Indicator Routes
+		apiGroup.GET("/indicators/:symbol", api.GetIndicators)
+
+		// Feature Store Routes
+		featureGroup := apiGroup.Group("/features")
+		{
+			featureGroup.GET("/sets", api.GetFeatureSets)
+			featureGroup.GET("/:symbol", api.GetFeatureVector)
+			featureGroup.GET("/:symbol/frame", api.GetFeatureFrame)
+		}
+
+		// Expression Routes
+		exprGroup := apiGroup.Group("/expr")
+		{
+			exprGroup.GET("/functions", api.GetExprFunctions)
+			exprGroup.POST("/validate", api.ValidateExpr)
+			exprGroup.POST("/evaluate", api.EvaluateExpr)
+			exprGroup.POST("/backtest", api.BacktestExpr)
+			exprGroup.GET("/definitions", api.ListExprDefinitions)
+			exprGroup.GET("/definitions/:name", api.GetExprDefinition)
+			exprGroup.PUT("/definitions/:name", api.SaveExprDefinition)
+			exprGroup.DELETE("/definitions/:name", api.DeleteExprDefinition)
+		}
+		apiGroup.POST("/screener", api.RunScreener)
+
+		// Alert Routes
+		alertGroup := apiGroup.Group("/alerts")
+		{
+			alertGroup.GET("", api.ListAlerts)
+			alertGroup.POST("", api.CreateAlert)
+			alertGroup.DELETE("/:id", api.DeleteAlert)
 		}
 
 		// WebSocket Routes
@@ -101,9 +183,21 @@ func main() {
 			wsGroup.GET("/stream", websocket.HandleWebSocket)
 			wsGroup.GET("/trades", websocket.HandleTradesStream)
 			wsGroup.GET("/orderbook", websocket.HandleOrderBookStream)
+			wsGroup.GET("/footprint", websocket.HandleFootprintStream)
 			wsGroup.GET("/klines", websocket.HandleKlinesStream)
 		}
 
+		// Market data recorder / replay routes
+		recorderGroup := apiGroup.Group("/recorder")
+		{
+			recorderGroup.POST("/start", websocket.HandleStartRecording)
+			recorderGroup.POST("/stop", websocket.HandleStopRecording)
+			recorderGroup.GET("/files", websocket.HandleListRecordings)
+			recorderGroup.GET("/status", websocket.HandleReplayStatus)
+			recorderGroup.POST("/replay", websocket.HandleStartReplay)
+			recorderGroup.DELETE("/replay", websocket.HandleStopReplay)
+		}
+
 		// Trading Routes
 		tradingGroup := apiGroup.Group("/trading")
 		{
@@ -168,8 +262,16 @@ func main() {
 	// Start background workers
 	go startBackgroundWorkers(logger)
 
+	// Resume streaming indicators from the last shutdown
+	if n, err := websocket.RestoreIndicatorState(); err != nil {
+		logger.Infof("No streaming indicator state restored: %v", err)
+	} else {
+		logger.Infof("Restored %d streaming indicator sets", n)
+	}
+
 	// Start Binance WebSocket stream
 	go startBinanceStream(logger)
+	go startFuturesStream(logger)
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -178,6 +280,16 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Save streaming indicators so the next start resumes them
+	if err := websocket.SaveIndicatorState(); err != nil {
+		logger.Warnf("Failed to save streaming indicator state: %v", err)
+	}
+
+	// Flush any open recording file
+	if err := websocket.GetRecorder().Stop(); err != nil {
+		logger.Warnf("Failed to close market data recording: %v", err)
+	}
+
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -192,19 +304,22 @@ func main() {
 func initializeAIModels(logger *logrus.Logger) {
 	logger.Info("Initializing AI models...")
 
-	// Initialize Neural Network
-	logger.Info("Loading Neural Network model...")
-	// TODO: Load actual model weights
-
-	// Initialize LightGBM
-	logger.Info("Loading LightGBM model...")
-	// TODO: Load actual model
-
-	// Initialize RandomForest
-	logger.Info("Loading RandomForest model...")
-	// TODO: Load actual model
+	// Load the active version of each model saved under MODEL_DIR
+	results := ai.LoadActiveModels()
+	failed, untrained := 0, 0
+	for name, err := range results {
+		switch {
+		case err == nil:
+		case errors.Is(err, ai.ErrModelNotTrained):
+			untrained++
+			logger.Warnf("%s model is not trained yet (POST /api/v1/ai/%s/train)", name, name)
+		default:
+			failed++
+			logger.Errorf("Failed to load %s model: %v", name, err)
+		}
+	}
 
-	logger.Info("All AI models initialized successfully")
+	logger.Infof("AI models initialized: %d loaded, %d untrained, %d failed", len(results)-failed-untrained, untrained, failed)
 }
 
 func startBackgroundWorkers(logger *logrus.Logger) {
@@ -221,14 +336,18 @@ func startBackgroundWorkers(logger *logrus.Logger) {
 		}
 	}()
 
+	// Score ledger predictions whose horizon has elapsed
+	go ai.GetLedger().Run(context.Background())
+
 	// Model retraining scheduler
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			// Retrain models if needed
-			// TODO: Implement model retraining
+			// Retrain models whose inputs drifted, whose live error grew or
+			// that are too old, promoting only candidates that score better
+			ai.GetRetrainer().RunOnce(context.Background())
 		}
 	}()
 
@@ -238,9 +357,19 @@ func startBackgroundWorkers(logger *logrus.Logger) {
 func startBinanceStream(logger *logrus.Logger) {
 	logger.Info("Starting Binance WebSocket stream...")
 
+	// Optionally record everything the stream receives
+	if os.Getenv("RECORD_MARKET_DATA") == "true" {
+		if err := websocket.GetRecorder().Start(); err != nil && err != websocket.ErrAlreadyRecording {
+			logger.Errorf("Failed to start market data recorder: %v", err)
+		}
+	}
+
 	// Get the global WebSocket hub
 	hub := websocket.GetGlobalHub()
 
+	// Build footprint/VWAP bars from the trade stream
+	websocket.StartTradeBars(hub)
+
 	// Create Binance stream manager
 	binanceStream := websocket.NewBinanceStreamManager(hub)
 
@@ -254,4 +383,18 @@ func startBinanceStream(logger *logrus.Logger) {
 	}
 
 	logger.Info("Binance WebSocket stream started successfully")
+}
+
+func startFuturesStream(logger *logrus.Logger) {
+	logger.Info("Starting Binance futures stream...")
+
+	futuresStream := websocket.NewFuturesStreamManager(websocket.GetGlobalHub())
+	if err := futuresStream.Connect(); err != nil {
+		logger.Errorf("Failed to connect to Binance futures stream: %v", err)
+		time.Sleep(5 * time.Second)
+		go startFuturesStream(logger)
+		return
+	}
+
+	logger.Info("Binance futures stream started successfully")
 }
\ No newline at end of file
@@ -8,6 +8,8 @@ require (
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
+	github.com/loadstar0723/monstas7-backend/pkg/forest v0.0.0
+	github.com/loadstar0723/monstas7-backend/pkg/kline v0.0.0
 	github.com/redis/go-redis/v9 v9.14.0
 	github.com/sirupsen/logrus v1.9.3
 	gorm.io/driver/postgres v1.6.0
@@ -53,3 +55,7 @@ require (
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ./pkg/kline
+
+replace github.com/loadstar0723/monstas7-backend/pkg/forest => ./pkg/forest
@@ -1,10 +1,13 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"math"
 	"sync"
 	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/market"
 )
 
 // ARIMAModel represents an ARIMA(p,d,q) model for time series prediction
@@ -17,6 +20,7 @@ type ARIMAModel struct {
 	Intercept      float64
 	Residuals      []float64
 	FittedValues   []float64
+	version        string
 	mu             sync.RWMutex
 }
 
@@ -43,18 +47,34 @@ func NewARIMAModel() *ARIMAModel {
 		Intercept:      0.0001,
 		Residuals:      make([]float64, 0),
 		FittedValues:   make([]float64, 0),
+		version:        "1.0.0",
 	}
 }
 
+// Name implements Predictor
+func (arima *ARIMAModel) Name() string { return "arima" }
+
+// Version implements Predictor
+func (arima *ARIMAModel) Version() string {
+	arima.mu.RLock()
+	defer arima.mu.RUnlock()
+	return arima.version
+}
+
+// arimaHorizons are the forecast horizons in candles; the forecast runs 24
+// steps ahead
+var arimaHorizons = []int{1, 4, 24}
+
 // Predict generates time series predictions using ARIMA
-func (arima *ARIMAModel) Predict(prices []float64, volumes []float64) (*Prediction, error) {
+func (arima *ARIMAModel) Predict(ctx context.Context, in Input) (*Prediction, error) {
+	if err := in.require(100); err != nil {
+		return nil, err
+	}
+	prices := in.Closes()
+
 	arima.mu.Lock()
 	defer arima.mu.Unlock()
 
-	if len(prices) < 100 {
-		return nil, ErrInsufficientData
-	}
-
 	// Apply differencing
 	diffPrices := arima.difference(prices, arima.D)
 
@@ -82,44 +102,48 @@ func (arima *ARIMAModel) Predict(prices []float64, volumes []float64) (*Predicti
 	aic := arima.calculateAIC(diffPrices)
 	bic := arima.calculateBIC(diffPrices)
 
-	// Generate confidence based on model metrics
-	confidence := arima.calculateConfidence(aic, bic, seasonalStrength)
-
-	// Create predictions for different time horizons
-	pred1h := predictions[0]
-	pred4h := predictions[3]
-	pred24h := predictions[23]
+	// Generate confidence based on model metrics, reported in percent like
+	// every other predictor
+	fit := arima.calculateConfidence(aic, bic, seasonalStrength)
+	confidence := fit * 100
+
+	// Forecasts 1, 4 and 24 candles ahead, keyed by the time they cover
+	step, err := market.IntervalDuration(in.Interval)
+	if err != nil {
+		step = time.Hour
+	}
+	points := make(map[string]PricePoint, len(arimaHorizons))
+	for _, steps := range arimaHorizons {
+		points[horizonKey(time.Duration(steps)*step)] = PricePoint{
+			Price:      predictions[steps-1],
+			Confidence: confidence * (1 - 0.05*float64(steps)), // Confidence decreases with time
+			Timestamp:  time.Now().Add(time.Duration(steps) * step).Unix(),
+		}
+	}
+	last := predictions[arimaHorizons[len(arimaHorizons)-1]-1]
 
 	// Risk assessment
 	volatility := calculateVolatility(prices[len(prices)-20:])
-	riskLevel := arima.assessRisk(volatility, confidence)
+	riskLevel := arima.assessRisk(volatility, fit)
 
 	// Generate trading signals
-	priceChange := (pred24h - currentPrice) / currentPrice * 100
-	recommendation := arima.generateRecommendation(priceChange, trend, confidence)
+	priceChange := (last - currentPrice) / currentPrice * 100
+	recommendation := arima.generateRecommendation(priceChange, trend, fit)
+
+	direction := "NEUTRAL"
+	if priceChange > 0.5 {
+		direction = "UP"
+	} else if priceChange < -0.5 {
+		direction = "DOWN"
+	}
 
 	return &Prediction{
 		Model:        "ARIMA",
-		Symbol:       "BTCUSDT",
+		Symbol:       in.Symbol,
 		CurrentPrice: currentPrice,
-		Predictions: map[string]PricePoint{
-			"1h": {
-				Price:      pred1h,
-				Confidence: confidence * (1 - 0.05*1), // Confidence decreases with time
-				Timestamp:  time.Now().Add(1 * time.Hour).Unix(),
-			},
-			"4h": {
-				Price:      pred4h,
-				Confidence: confidence * (1 - 0.05*4),
-				Timestamp:  time.Now().Add(4 * time.Hour).Unix(),
-			},
-			"24h": {
-				Price:      pred24h,
-				Confidence: confidence * (1 - 0.05*24),
-				Timestamp:  time.Now().Add(24 * time.Hour).Unix(),
-			},
-		},
+		Predictions:  points,
 		Confidence: confidence,
+		Direction:  direction,
 		Factors: map[string]float64{
 			"ar_order":           float64(arima.P),
 			"differencing":       float64(arima.D),
@@ -518,7 +542,7 @@ func (arima *ARIMAModel) calculateBIC(data []float64) float64 {
 	return bic
 }
 
-// calculateConfidence calculates model confidence
+// calculateConfidence calculates model confidence as a fraction (0.4-0.95)
 func (arima *ARIMAModel) calculateConfidence(aic, bic, seasonality float64) float64 {
 	// Base confidence on model fit metrics
 	// Lower AIC/BIC is better
@@ -600,11 +624,14 @@ func (arima *ARIMAModel) ToJSON() ([]byte, error) {
 		ResidualCount:  len(arima.Residuals),
 	})
 }
+
+var arimaPredictor *ARIMAModel
+var arimaOnce sync.Once
+
 // GetARIMAPredictor returns ARIMA predictor instance
 func GetARIMAPredictor() *ARIMAModel {
-	return &ARIMAModel{
-		P:        2,
-		D:        1,
-		Q:        2,
-	}
+	arimaOnce.Do(func() {
+		arimaPredictor = NewARIMAModel()
+	})
+	return arimaPredictor
 }
@@ -0,0 +1,789 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// Probability calibration methods
+const (
+	CalibrationPlatt    = "platt"
+	CalibrationIsotonic = "isotonic"
+)
+
+const (
+	// minCalibrationSamples is the forecast history a calibration needs; with
+	// fewer resolved ledger entries the model is replayed on recent candles
+	minCalibrationSamples = 50
+	// isotonicMinSamples is the history from which isotonic regression is
+	// chosen over Platt scaling when no method is requested
+	isotonicMinSamples = 200
+	// replaySamples is the number of forecasts replayed on recent candles
+	replaySamples = 300
+	// replayWindow is the history each replayed forecast is made from
+	replayWindow = 600
+	// calibrationTTL is how long a calibration is reused
+	calibrationTTL = 30 * time.Minute
+	// calibrationFolds is the number of folds of the cross-fitted checks
+	calibrationFolds = 5
+)
+
+// DefaultQuantiles are the quantile forecasts returned when none are asked for
+var DefaultQuantiles = []float64{0.05, 0.25, 0.5, 0.75, 0.95}
+
+// defaultCoverages are the prediction intervals returned with every forecast
+var defaultCoverages = []float64{0.5, 0.8, 0.9, 0.95}
+
+// forecastOutcome is a past forecast with its realized outcome
+type forecastOutcome struct {
+	Predicted  float64 // forecast return over the horizon
+	Actual     float64 // realized return
+	Confidence float64 // stated confidence, 0-100
+	Hit        bool    // the forecast direction came true
+}
+
+// replayForecasts runs a predictor on the candles before each of candles
+// [from, end) and scores its forecasts of every horizon that is a whole
+// number of candles against the realized returns, by horizon. Each input
+// carries the feature store values of its last candle, as live inputs do.
+func replayForecasts(ctx context.Context, p Predictor, symbol, interval string, candles indicators.OHLCV, from int) (map[string][]forecastOutcome, error) {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	values := replayFeatures(candles)
+	outcomes := make(map[string][]forecastOutcome)
+	for t := from - 1; t < candles.Len()-1; t++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		in := Input{
+			Symbol:   symbol,
+			Interval: interval,
+			Candles:  sliceCandles(candles, max(0, t+1-replayWindow), t+1),
+			Features: values[t],
+		}
+		prediction, err := p.Predict(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		base := candles.Close[t]
+		for horizon, point := range prediction.Predictions {
+			period, err := market.IntervalDuration(horizon)
+			if err != nil || period%step != 0 || point.Price <= 0 {
+				continue
+			}
+			ahead := t + int(period/step)
+			if ahead >= candles.Len() {
+				continue
+			}
+			confidence := point.Confidence
+			if confidence == 0 {
+				confidence = prediction.Confidence
+			}
+			predicted, actual := point.Price/base-1, candles.Close[ahead]/base-1
+			outcomes[horizon] = append(outcomes[horizon], forecastOutcome{
+				Predicted:  predicted,
+				Actual:     actual,
+				Confidence: confidence,
+				Hit:        returnDirection(actual) == returnDirection(predicted),
+			})
+		}
+	}
+	if len(outcomes[interval]) == 0 {
+		return nil, fmt.Errorf("%w: %s made no %s forecasts to replay", ErrInsufficientData, p.Name(), interval)
+	}
+	return outcomes, nil
+}
+
+// replayFeatures returns the feature store values at the close of every
+// candle, keyed by qualified name like the feature merge of live predictions.
+// Live-only sets (order book, futures) have no history and are absent, as
+// they are from live inputs when the store cannot compute them.
+func replayFeatures(candles indicators.OHLCV) []map[string]interface{} {
+	values := make([]map[string]interface{}, candles.Len())
+	for i := range values {
+		values[i] = make(map[string]interface{})
+	}
+	for _, name := range features.DefaultSets {
+		set, err := features.Lookup(name)
+		if err != nil || set.Live {
+			continue
+		}
+		frame, err := features.BuildFrame(set, candles)
+		if err != nil {
+			continue
+		}
+		for i, row := range frame.Rows {
+			for j, feature := range frame.Names {
+				if v := row[j]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+					values[i][set.Name+"."+feature] = v
+				}
+			}
+		}
+	}
+	return values
+}
+
+// ledgerOutcomes returns the resolved ledger entries of a model version by
+// horizon
+func ledgerOutcomes(model, version, symbol, interval string) map[string][]forecastOutcome {
+	outcomes := make(map[string][]forecastOutcome)
+	for _, e := range GetLedger().resolvedEntries(LedgerFilter{Model: model, Version: version, Symbol: symbol, Interval: interval}) {
+		outcomes[e.Horizon] = append(outcomes[e.Horizon], forecastOutcome{
+			Predicted:  e.PredictedReturn(),
+			Actual:     e.ActualReturn(),
+			Confidence: e.Confidence,
+			Hit:        e.DirectionHit,
+		})
+	}
+	return outcomes
+}
+
+// PlattScaler maps a stated probability p to sigmoid(A*logit(p) + B)
+type PlattScaler struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// fitPlatt fits a Platt scaler by Newton's method on the log loss, with
+// Platt's smoothed targets
+func fitPlatt(probs []float64, hits []bool) PlattScaler {
+	var positives, negatives float64
+	for _, hit := range hits {
+		if hit {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	hi, lo := (positives+1)/(positives+2), 1/(negatives+2)
+	x := make([]float64, len(probs))
+	t := make([]float64, len(probs))
+	for i, p := range probs {
+		x[i] = logit(p)
+		t[i] = lo
+		if hits[i] {
+			t[i] = hi
+		}
+	}
+
+	loss := func(a, b float64) float64 {
+		var l float64
+		for i := range x {
+			p := clampProbability(sigmoid(a*x[i] + b))
+			l -= t[i]*math.Log(p) + (1-t[i])*math.Log(1-p)
+		}
+		return l
+	}
+	s := PlattScaler{A: 1}
+	current := loss(s.A, s.B)
+	for iter := 0; iter < 100; iter++ {
+		var ga, gb, haa, hab, hbb float64
+		for i := range x {
+			p := sigmoid(s.A*x[i] + s.B)
+			d, w := p-t[i], math.Max(p*(1-p), 1e-12)
+			ga += d * x[i]
+			gb += d
+			haa += w * x[i] * x[i]
+			hab += w * x[i]
+			hbb += w
+		}
+		haa, hbb = haa+1e-9, hbb+1e-9
+		det := haa*hbb - hab*hab
+		if math.Abs(ga) < 1e-9 && math.Abs(gb) < 1e-9 || det <= 0 {
+			break
+		}
+		da, db := (hbb*ga-hab*gb)/det, (haa*gb-hab*ga)/det
+		improved := false
+		for scale := 1.0; scale > 1e-6; scale /= 2 {
+			a, b := s.A-scale*da, s.B-scale*db
+			if l := loss(a, b); l < current {
+				s.A, s.B, current, improved = a, b, l, true
+				break
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return s
+}
+
+// apply returns the calibrated probability
+func (s PlattScaler) apply(p float64) float64 {
+	return sigmoid(s.A*logit(p) + s.B)
+}
+
+// isotonicScaler is a non-decreasing piecewise linear map from stated to
+// calibrated probability through the pooled blocks of the PAV algorithm
+type isotonicScaler struct {
+	x, y []float64
+}
+
+// fitIsotonic fits an isotonic regression of hits on probs by pool adjacent
+// violators
+func fitIsotonic(probs []float64, hits []bool) isotonicScaler {
+	order := make([]int, len(probs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return probs[order[a]] < probs[order[b]] })
+
+	type block struct{ x, y, w float64 }
+	var blocks []block
+	for start := 0; start < len(order); {
+		// equal probabilities share a value
+		b := block{x: probs[order[start]]}
+		end := start
+		for ; end < len(order) && probs[order[end]] == b.x; end++ {
+			if hits[order[end]] {
+				b.y++
+			}
+			b.w++
+		}
+		b.x *= b.w
+		start = end
+		blocks = append(blocks, b)
+		for n := len(blocks); n > 1 && blocks[n-2].y/blocks[n-2].w >= blocks[n-1].y/blocks[n-1].w; n = len(blocks) {
+			prev, last := blocks[n-2], blocks[n-1]
+			blocks = append(blocks[:n-2], block{prev.x + last.x, prev.y + last.y, prev.w + last.w})
+		}
+	}
+	s := isotonicScaler{x: make([]float64, len(blocks)), y: make([]float64, len(blocks))}
+	for i, b := range blocks {
+		s.x[i], s.y[i] = b.x/b.w, b.y/b.w
+	}
+	return s
+}
+
+// apply returns the calibrated probability
+func (s isotonicScaler) apply(p float64) float64 {
+	n := len(s.x)
+	switch {
+	case n == 0:
+		return p
+	case p <= s.x[0]:
+		return s.y[0]
+	case p >= s.x[n-1]:
+		return s.y[n-1]
+	}
+	i := sort.SearchFloat64s(s.x, p)
+	w := (p - s.x[i-1]) / (s.x[i] - s.x[i-1])
+	return s.y[i-1] + w*(s.y[i]-s.y[i-1])
+}
+
+func logit(p float64) float64 {
+	p = clampProbability(p)
+	return math.Log(p / (1 - p))
+}
+
+func clampProbability(p float64) float64 {
+	return math.Max(1e-3, math.Min(1-1e-3, p))
+}
+
+// Calibration is the forecast history of a model version for one symbol,
+// interval and horizon, and the conformal residuals and probability scalers
+// fitted on it
+type Calibration struct {
+	Model    string      `json:"model"`
+	Version  string      `json:"version"`
+	Symbol   string      `json:"symbol"`
+	Interval string      `json:"interval"`
+	Horizon  string      `json:"horizon"`
+	Source   string      `json:"source"` // ledger or replay
+	Samples  int         `json:"samples"`
+	BuiltAt  time.Time   `json:"built_at"`
+	Platt    PlattScaler `json:"platt"`
+
+	outcomes     []forecastOutcome
+	residuals    []float64 // actual - forecast return, ascending
+	absResiduals []float64 // ascending
+	isotonic     isotonicScaler
+}
+
+// newCalibration fits the residual quantiles and probability scalers
+func newCalibration(outcomes []forecastOutcome) *Calibration {
+	c := &Calibration{
+		Samples:      len(outcomes),
+		BuiltAt:      time.Now().UTC(),
+		outcomes:     outcomes,
+		residuals:    make([]float64, len(outcomes)),
+		absResiduals: make([]float64, len(outcomes)),
+	}
+	probs, hits := outcomeProbabilities(outcomes)
+	for i, o := range outcomes {
+		c.residuals[i] = o.Actual - o.Predicted
+		c.absResiduals[i] = math.Abs(c.residuals[i])
+	}
+	sort.Float64s(c.residuals)
+	sort.Float64s(c.absResiduals)
+	c.Platt = fitPlatt(probs, hits)
+	c.isotonic = fitIsotonic(probs, hits)
+	return c
+}
+
+func outcomeProbabilities(outcomes []forecastOutcome) ([]float64, []bool) {
+	probs, hits := make([]float64, len(outcomes)), make([]bool, len(outcomes))
+	for i, o := range outcomes {
+		probs[i], hits[i] = math.Max(0, math.Min(100, o.Confidence))/100, o.Hit
+	}
+	return probs, hits
+}
+
+// Method resolves the requested calibration method; empty picks isotonic
+// regression with enough history and Platt scaling otherwise
+func (c *Calibration) Method(method string) string {
+	if method != "" {
+		return method
+	}
+	if c.Samples >= isotonicMinSamples {
+		return CalibrationIsotonic
+	}
+	return CalibrationPlatt
+}
+
+// Probability returns the calibrated probability that a forecast stated with
+// confidence (0-100) gets its direction right
+func (c *Calibration) Probability(confidence float64, method string) float64 {
+	p := math.Max(0, math.Min(100, confidence)) / 100
+	if c.Method(method) == CalibrationIsotonic {
+		return c.isotonic.apply(p)
+	}
+	return c.Platt.apply(p)
+}
+
+// HalfWidth is the split-conformal half width, as a return, of the interval
+// around a forecast that covers the outcome with probability coverage; it is
+// capped at the largest residual when the history is too short for coverage
+func (c *Calibration) HalfWidth(coverage float64) float64 {
+	n := len(c.absResiduals)
+	k := min(int(math.Ceil(float64(n+1)*coverage)), n)
+	return c.absResiduals[max(k, 1)-1]
+}
+
+// Quantile is the conformal quantile tau of the forecast error as a return:
+// the forecast return plus Quantile(tau) is the quantile tau forecast
+func (c *Calibration) Quantile(tau float64) float64 {
+	n := len(c.residuals)
+	var k int
+	if tau < 0.5 {
+		k = int(math.Floor(float64(n+1) * tau))
+	} else {
+		k = int(math.Ceil(float64(n+1) * tau))
+	}
+	return c.residuals[min(max(k, 1), n)-1]
+}
+
+// CoverageCheck compares the nominal coverage of a conformal interval with
+// the share of cross-fitted outcomes it covered
+type CoverageCheck struct {
+	Nominal   float64 `json:"nominal"`
+	Empirical float64 `json:"empirical"`
+	HalfWidth float64 `json:"half_width"` // as a return
+}
+
+// CalibrationReport shows how well a model's stated confidence and the
+// conformal intervals fit its forecast history. Calibrated probabilities and
+// coverage are cross-fitted: each fold is scored by a fit on the others.
+type CalibrationReport struct {
+	*Calibration
+	Method     string          `json:"method"`
+	Raw        Reliability     `json:"raw"`
+	Calibrated Reliability     `json:"calibrated"`
+	Coverage   []CoverageCheck `json:"coverage"`
+}
+
+// Report builds the reliability diagrams and interval coverage checks
+func (c *Calibration) Report(method string, bins int) CalibrationReport {
+	method = c.Method(method)
+	probs, hits := outcomeProbabilities(c.outcomes)
+	raw := make([]float64, len(probs))
+	for i, p := range probs {
+		raw[i] = p * 100
+	}
+	report := CalibrationReport{Calibration: c, Method: method, Raw: reliabilityOf(raw, hits, bins)}
+
+	calibrated := make([]float64, len(probs))
+	covered := make([]int, len(defaultCoverages))
+	for fold := 0; fold < calibrationFolds; fold++ {
+		var train []forecastOutcome
+		for i, o := range c.outcomes {
+			if i%calibrationFolds != fold {
+				train = append(train, o)
+			}
+		}
+		if len(train) == 0 {
+			continue
+		}
+		fit := newCalibration(train)
+		for i := fold; i < len(c.outcomes); i += calibrationFolds {
+			calibrated[i] = fit.Probability(c.outcomes[i].Confidence, method) * 100
+			for j, coverage := range defaultCoverages {
+				if math.Abs(c.outcomes[i].Actual-c.outcomes[i].Predicted) <= fit.HalfWidth(coverage) {
+					covered[j]++
+				}
+			}
+		}
+	}
+	report.Calibrated = reliabilityOf(calibrated, hits, bins)
+	for j, coverage := range defaultCoverages {
+		report.Coverage = append(report.Coverage, CoverageCheck{
+			Nominal:   coverage,
+			Empirical: float64(covered[j]) / float64(len(c.outcomes)),
+			HalfWidth: c.HalfWidth(coverage),
+		})
+	}
+	return report
+}
+
+// calibrationSet holds the calibrations of one model version, symbol and
+// interval by horizon, and the horizons that had too little history when it
+// was built, so that they are not rebuilt before the TTL either
+type calibrationSet struct {
+	mu        sync.Mutex
+	builtAt   time.Time
+	byHorizon map[string]*Calibration
+	missing   map[string]bool
+	err       error // ErrInsufficientData when no horizon could be calibrated
+}
+
+// Calibrator calibrates the forecasts of any registered predictor on its
+// prediction history: the resolved entries of the prediction ledger, or a
+// replay of the model on recent candles while the ledger holds too few
+type Calibrator struct {
+	mu   sync.Mutex
+	sets map[string]*calibrationSet
+}
+
+var calibrator *Calibrator
+var calibratorOnce sync.Once
+
+// GetCalibrator returns the forecast calibrator
+func GetCalibrator() *Calibrator {
+	calibratorOnce.Do(func() {
+		calibrator = &Calibrator{sets: make(map[string]*calibrationSet)}
+	})
+	return calibrator
+}
+
+// Calibrations returns the calibrations of the current version of a model
+// for the horizons, building them when missing or stale
+func (c *Calibrator) Calibrations(ctx context.Context, name, symbol, interval string, horizons []string) (map[string]*Calibration, error) {
+	p, err := GetManager().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	version := p.Version()
+	key := name + "|" + version + "|" + symbol + "|" + interval
+
+	c.mu.Lock()
+	set, ok := c.sets[key]
+	if !ok {
+		for k := range c.sets {
+			parts := strings.SplitN(k, "|", 3)
+			if parts[0] == name && parts[1] != version {
+				delete(c.sets, k) // calibrations of replaced versions
+			}
+		}
+		set = &calibrationSet{}
+		c.sets[key] = set
+	}
+	c.mu.Unlock()
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	fresh := time.Since(set.builtAt) < calibrationTTL
+	for _, h := range horizons {
+		if _, ok := set.byHorizon[h]; !ok && !set.missing[h] {
+			fresh = false
+		}
+	}
+	if !fresh {
+		// The horizons of the previous build are rebuilt too, so requests for
+		// different horizons do not evict each other
+		wanted := append([]string(nil), horizons...)
+		for h := range set.byHorizon {
+			wanted = append(wanted, h)
+		}
+		for h := range set.missing {
+			wanted = append(wanted, h)
+		}
+		sort.Strings(wanted)
+		wanted = slices.Compact(wanted)
+		byHorizon, err := buildCalibrations(ctx, p, name, version, symbol, interval, wanted)
+		if err != nil && !errors.Is(err, ErrInsufficientData) {
+			return nil, err
+		}
+		set.byHorizon, set.err, set.builtAt = byHorizon, err, time.Now()
+		set.missing = make(map[string]bool)
+		for _, h := range wanted {
+			if _, ok := byHorizon[h]; !ok {
+				set.missing[h] = true
+			}
+		}
+	}
+	if set.err != nil {
+		return nil, set.err
+	}
+
+	out := make(map[string]*Calibration, len(horizons))
+	for _, h := range horizons {
+		if cal, ok := set.byHorizon[h]; ok {
+			out[h] = cal
+		}
+	}
+	return out, nil
+}
+
+// buildCalibrations calibrates each horizon on the ledger when it holds
+// enough resolved forecasts and on a replay of the model otherwise
+func buildCalibrations(ctx context.Context, p Predictor, name, version, symbol, interval string, horizons []string) (map[string]*Calibration, error) {
+	history := ledgerOutcomes(name, version, symbol, interval)
+	byHorizon := make(map[string]*Calibration)
+	var replayed map[string][]forecastOutcome
+	var replayErr error
+	for _, horizon := range horizons {
+		outcomes, source := history[horizon], "ledger"
+		if len(outcomes) < minCalibrationSamples {
+			if replayed == nil && replayErr == nil {
+				replayed, replayErr = replay(ctx, p, name, symbol, interval, horizons)
+				if replayErr != nil && !errors.Is(replayErr, ErrInsufficientData) {
+					return nil, replayErr
+				}
+			}
+			outcomes, source = replayed[horizon], "replay"
+		}
+		if len(outcomes) < minCalibrationSamples {
+			continue
+		}
+		cal := newCalibration(outcomes)
+		cal.Model, cal.Version, cal.Symbol, cal.Interval, cal.Horizon, cal.Source = name, version, symbol, interval, horizon, source
+		byHorizon[horizon] = cal
+	}
+	if len(byHorizon) == 0 {
+		if replayErr != nil {
+			return nil, replayErr
+		}
+		return nil, fmt.Errorf("%w: fewer than %d forecasts of %s to calibrate on", ErrInsufficientData, minCalibrationSamples, name)
+	}
+	return byHorizon, nil
+}
+
+// replay replays the model on the latest candles, far enough back to score
+// the longest horizon. Only forecasts made after the model was trained are
+// replayed, since earlier ones score it on its own training data.
+func replay(ctx context.Context, p Predictor, name, symbol, interval string, horizons []string) (map[string][]forecastOutcome, error) {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	ahead := 1
+	for _, horizon := range horizons {
+		if period, err := market.IntervalDuration(horizon); err == nil && period%step == 0 {
+			ahead = max(ahead, int(period/step))
+		}
+	}
+	trainedAt, err := trainingCutoff(name, p)
+	if err != nil {
+		return nil, err
+	}
+	candles, err := TrainingCandles(symbol, interval, replayWindow+replaySamples+ahead)
+	if err != nil {
+		return nil, err
+	}
+	from := max(1, candles.Len()-replaySamples-ahead+1)
+	if !trainedAt.IsZero() {
+		after := sort.Search(candles.Len(), func(i int) bool { return candles.Time[i] >= trainedAt.UnixMilli() })
+		from = max(from, after+1)
+	}
+	if n := candles.Len() - from; n < minCalibrationSamples {
+		return nil, fmt.Errorf("%w: %d candles of %s %s to replay %s on after its training at %s, need %d",
+			ErrInsufficientData, max(n, 0), symbol, interval, name, trainedAt.Format(time.RFC3339), minCalibrationSamples)
+	}
+	return replayForecasts(ctx, p, symbol, interval, candles, from)
+}
+
+// trainingCutoff returns when a model was trained on candles, the latest
+// training of the members for the ensemble. It is zero for models fitted on
+// each input (ARIMA) and untrained models; an imported model without a
+// training time has an unknown cutoff and cannot be replayed.
+func trainingCutoff(name string, p Predictor) (time.Time, error) {
+	if e, ok := p.(*EnsemblePredictor); ok {
+		var latest time.Time
+		for _, member := range e.Config().Models {
+			mp, err := GetManager().Get(member)
+			if err != nil {
+				continue
+			}
+			t, err := trainingCutoff(member, mp)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if t.After(latest) {
+				latest = t
+			}
+		}
+		return latest, nil
+	}
+	persistent, ok := p.(Persistent)
+	if !ok {
+		return time.Time{}, nil
+	}
+	state, err := persistent.MarshalState()
+	if err != nil {
+		return time.Time{}, nil
+	}
+	var saved struct {
+		Meta *TrainingMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(state, &saved); err != nil || saved.Meta == nil {
+		return time.Time{}, nil
+	}
+	if saved.Meta.TrainedAt.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: the training time of %s is unknown, so it cannot be replayed", ErrInsufficientData, name)
+	}
+	return saved.Meta.TrainedAt, nil
+}
+
+// ForecastOptions configures a probabilistic forecast
+type ForecastOptions struct {
+	Coverage  float64   `json:"coverage"`  // of the lower/upper band, 0.9 when unset
+	Quantiles []float64 `json:"quantiles"` // DefaultQuantiles when empty
+	Method    string    `json:"method"`    // platt, isotonic or empty to choose by history size
+}
+
+// validate fills in the defaults and rejects invalid options
+func (o *ForecastOptions) validate() error {
+	if o.Coverage == 0 {
+		o.Coverage = 0.9
+	}
+	if len(o.Quantiles) == 0 {
+		o.Quantiles = DefaultQuantiles
+	}
+	if o.Coverage <= 0 || o.Coverage >= 1 {
+		return fmt.Errorf("%w: coverage must be in (0, 1)", ErrInvalidInput)
+	}
+	for _, q := range o.Quantiles {
+		if q <= 0 || q >= 1 {
+			return fmt.Errorf("%w: quantiles must be in (0, 1)", ErrInvalidInput)
+		}
+	}
+	if o.Method != "" && o.Method != CalibrationPlatt && o.Method != CalibrationIsotonic {
+		return fmt.Errorf("%w: method must be %s or %s", ErrInvalidInput, CalibrationPlatt, CalibrationIsotonic)
+	}
+	return nil
+}
+
+// PredictionInterval is a conformal price interval of a forecast
+type PredictionInterval struct {
+	Coverage float64 `json:"coverage"`
+	Lower    float64 `json:"lower"`
+	Upper    float64 `json:"upper"`
+}
+
+// HorizonForecast is the calibrated forecast of one horizon
+type HorizonForecast struct {
+	Price          float64              `json:"price"`
+	Lower          float64              `json:"lower"`
+	Upper          float64              `json:"upper"`
+	Quantiles      map[string]float64   `json:"quantiles"` // price by quantile level
+	Intervals      []PredictionInterval `json:"intervals"`
+	Direction      string               `json:"direction"`
+	RawProbability float64              `json:"raw_probability"` // stated confidence / 100
+	Probability    float64              `json:"probability"`     // calibrated probability that Direction comes true
+	Method         string               `json:"method"`
+	Source         string               `json:"source"` // ledger or replay
+	Samples        int                  `json:"samples"`
+}
+
+// ProbabilisticForecast is a prediction with conformal intervals, quantile
+// forecasts and calibrated direction probabilities for its horizons
+type ProbabilisticForecast struct {
+	Prediction *Prediction                `json:"prediction"`
+	Coverage   float64                    `json:"coverage"`
+	Horizons   map[string]HorizonForecast `json:"horizons"`
+	Missing    []string                   `json:"uncalibrated,omitempty"` // horizons without enough history
+}
+
+// Forecast runs a registered model and wraps its forecasts in conformal
+// intervals and calibrated probabilities. The lower/upper band at
+// opts.Coverage and the calibrated probability are also set on the
+// prediction's price points.
+func (c *Calibrator) Forecast(ctx context.Context, name string, in Input, opts ForecastOptions) (*ProbabilisticForecast, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	prediction, err := GetManager().Predict(ctx, name, in)
+	if err != nil {
+		return nil, err
+	}
+	horizons := make([]string, 0, len(prediction.Predictions))
+	for h := range prediction.Predictions {
+		horizons = append(horizons, h)
+	}
+	sort.Strings(horizons)
+	calibrations, err := c.Calibrations(ctx, name, in.Symbol, in.Interval, horizons)
+	if err != nil {
+		return nil, err
+	}
+
+	coverages := append([]float64{opts.Coverage}, defaultCoverages...)
+	sort.Float64s(coverages)
+	forecast := &ProbabilisticForecast{Prediction: prediction, Coverage: opts.Coverage, Horizons: make(map[string]HorizonForecast)}
+	base := prediction.CurrentPrice
+	for _, h := range horizons {
+		point := prediction.Predictions[h]
+		cal, ok := calibrations[h]
+		if !ok || base <= 0 {
+			forecast.Missing = append(forecast.Missing, h)
+			continue
+		}
+		predicted := point.Price/base - 1
+		confidence := point.Confidence
+		if confidence == 0 {
+			confidence = prediction.Confidence
+		}
+		hf := HorizonForecast{
+			Price:          point.Price,
+			Quantiles:      make(map[string]float64, len(opts.Quantiles)),
+			Direction:      returnDirection(predicted),
+			RawProbability: confidence / 100,
+			Probability:    cal.Probability(confidence, opts.Method),
+			Method:         cal.Method(opts.Method),
+			Source:         cal.Source,
+			Samples:        cal.Samples,
+		}
+		for _, q := range opts.Quantiles {
+			hf.Quantiles[strconv.FormatFloat(q, 'f', -1, 64)] = base * (1 + predicted + cal.Quantile(q))
+		}
+		for i, coverage := range coverages {
+			if i > 0 && coverage == coverages[i-1] {
+				continue
+			}
+			width := cal.HalfWidth(coverage)
+			interval := PredictionInterval{Coverage: coverage, Lower: base * (1 + predicted - width), Upper: base * (1 + predicted + width)}
+			hf.Intervals = append(hf.Intervals, interval)
+			if coverage == opts.Coverage {
+				hf.Lower, hf.Upper = interval.Lower, interval.Upper
+			}
+		}
+		point.Lower, point.Upper, point.Probability = hf.Lower, hf.Upper, hf.Probability
+		prediction.Predictions[h] = point
+		forecast.Horizons[h] = hf
+	}
+	return forecast, nil
+}
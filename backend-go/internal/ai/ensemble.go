@@ -1,104 +1,731 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+// Weighting methods of the ensemble
+const (
+	WeightingStatic       = "static"        // configured weights
+	WeightingInverseError = "inverse_error" // configured weights divided by recent error
+	WeightingStacking     = "stacking"      // ridge regression on member forecasts
 )
 
-// EnsemblePredictor combines multiple AI models for prediction
+const (
+	ensembleHistory      = 500 // resolved forecasts kept per symbol/interval
+	inverseErrorMinimum  = 5   // resolved forecasts before a member's error is used
+	stackingMinimum      = 30  // resolved forecasts before the meta-model is fitted
+	stackingRidge        = 1e-3
+	defaultMemberTimeout = 5000 // ms
+)
+
+// EnsembleConfig selects the ensemble members and how they are combined
+type EnsembleConfig struct {
+	Models    []string           `json:"models"`
+	Weighting string             `json:"weighting"`
+	Weights   map[string]float64 `json:"weights"`    // static weights, also the prior of inverse_error
+	TimeoutMs int                `json:"timeout_ms"` // per member prediction
+}
+
+// EnsemblePredictor combines the forecasts of registered predictors. Member
+// forecasts are scored against the next candle so that weights can follow
+// recent accuracy; the scored forecasts are kept in <MODEL_DIR>/ensemble-tracks.json
+// so the weights survive a restart.
 type EnsemblePredictor struct {
-	Weights  map[string]float64
-	IsLoaded bool
+	config  EnsembleConfig
+	version string
+	tracks  map[string]*ensembleTrack // by symbol|interval
+	mu      sync.RWMutex
+}
+
+// ensembleTrack holds the scored forecasts of one symbol/interval
+type ensembleTrack struct {
+	pending  []ensembleSample // waiting for the forecast candle to close
+	resolved []ensembleSample
+	meta     *stackingModel
+	fitted   int // resolved samples the meta-model was fitted on
+}
+
+// ensembleSample is one set of member forecasts for the next candle
+type ensembleSample struct {
+	base    int64              // open time of the last candle at forecast time
+	close   float64            // close of that candle
+	returns map[string]float64 // forecast return per member
+	actual  float64            // realized return of the next candle
+}
+
+// savedSample is the file form of an ensembleSample
+type savedSample struct {
+	Base    int64              `json:"base"`
+	Close   float64            `json:"close"`
+	Returns map[string]float64 `json:"returns"`
+	Actual  float64            `json:"actual"`
+}
+
+// savedTrack is the file form of an ensembleTrack; meta-models are refitted
+type savedTrack struct {
+	Pending  []savedSample `json:"pending"`
+	Resolved []savedSample `json:"resolved"`
+}
+
+// stackingModel is a linear meta-model over member forecast returns
+type stackingModel struct {
+	models    []string
+	coef      []float64
+	intercept float64
+}
+
+// EnsembleTrackStatus reports the forecast scoring of one symbol/interval
+type EnsembleTrackStatus struct {
+	Resolved int                `json:"resolved"`
+	Pending  int                `json:"pending"`
+	MAE      map[string]float64 `json:"mae"`                // mean absolute error of forecast returns
+	Stacking map[string]float64 `json:"stacking,omitempty"` // meta-model coefficients and intercept
+}
+
+// memberResult is the outcome of one member prediction
+type memberResult struct {
+	name       string
+	prediction *Prediction
+	err        error
+}
+
+// Name implements Predictor
+func (e *EnsemblePredictor) Name() string { return "ensemble" }
+
+// Version implements Predictor
+func (e *EnsemblePredictor) Version() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.version
+}
+
+// Config returns a copy of the ensemble configuration
+func (e *EnsemblePredictor) Config() EnsembleConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	cfg := e.config
+	cfg.Models = append([]string(nil), e.config.Models...)
+	cfg.Weights = make(map[string]float64, len(e.config.Weights))
+	for name, w := range e.config.Weights {
+		cfg.Weights[name] = w
+	}
+	return cfg
+}
+
+// Configure validates and applies a new configuration. Scored forecasts are
+// kept; stacking meta-models are refitted for the new members.
+func (e *EnsemblePredictor) Configure(cfg EnsembleConfig) error {
+	switch cfg.Weighting {
+	case "":
+		cfg.Weighting = WeightingStatic
+	case WeightingStatic, WeightingInverseError, WeightingStacking:
+	default:
+		return fmt.Errorf("%w: unknown weighting %q", ErrInvalidInput, cfg.Weighting)
+	}
+	if len(cfg.Models) == 0 {
+		return fmt.Errorf("%w: ensemble needs at least one model", ErrInvalidInput)
+	}
+	seen := make(map[string]bool, len(cfg.Models))
+	for _, name := range cfg.Models {
+		if name == e.Name() {
+			return fmt.Errorf("%w: ensemble cannot include itself", ErrInvalidInput)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w: duplicate model %s", ErrInvalidInput, name)
+		}
+		seen[name] = true
+		if _, err := GetManager().Get(name); err != nil {
+			return err
+		}
+	}
+	for name, w := range cfg.Weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("%w: weight of %s must be a non-negative number", ErrInvalidInput, name)
+		}
+	}
+	if cfg.TimeoutMs <= 0 {
+		cfg.TimeoutMs = defaultMemberTimeout
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.config = cfg
+	for _, t := range e.tracks {
+		t.meta, t.fitted = nil, 0
+	}
+	return nil
+}
+
+// Tracks returns the forecast scoring per symbol|interval
+func (e *EnsemblePredictor) Tracks() map[string]EnsembleTrackStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	all := make(map[string]EnsembleTrackStatus, len(e.tracks))
+	for key, t := range e.tracks {
+		status := EnsembleTrackStatus{
+			Resolved: len(t.resolved),
+			Pending:  len(t.pending),
+			MAE:      make(map[string]float64),
+		}
+		for _, name := range e.config.Models {
+			if mae, n := t.mae(name); n > 0 {
+				status.MAE[name] = mae
+			}
+		}
+		if t.meta != nil {
+			status.Stacking = map[string]float64{"intercept": t.meta.intercept}
+			for i, name := range t.meta.models {
+				status.Stacking[name] = t.meta.coef[i]
+			}
+		}
+		all[key] = status
+	}
+	return all
 }
 
-// Predict generates ensemble prediction
-func (e *EnsemblePredictor) Predict(symbol string, historical []float64, features map[string]interface{}) *Prediction {
-	// Since other models return different types, we'll use simple predictions for now
-	// In production, these would call actual model predictions
+// Predict runs the members concurrently and combines their forecasts for the
+// next candle. Failed or timed out members are left out.
+func (e *EnsemblePredictor) Predict(ctx context.Context, in Input) (*Prediction, error) {
+	if err := in.require(1); err != nil {
+		return nil, err
+	}
+	cfg := e.Config()
+	closes := in.Closes()
+	currentPrice := closes[len(closes)-1]
+	key, _ := in.horizon()
 
-	// Weighted average
-	weights := e.Weights
-	if weights == nil {
-		weights = map[string]float64{
-			"neural":       0.25,
-			"lightgbm":     0.35,
-			"randomforest": 0.25,
-			"xgboost":      0.15,
+	returns := make(map[string]float64, len(cfg.Models))
+	confidences := make(map[string]float64, len(cfg.Models))
+	directions := make(map[string]string, len(cfg.Models))
+	var errs []error
+	for _, m := range e.runMembers(ctx, cfg, in) {
+		if m.err != nil {
+			errs = append(errs, m.err)
+			continue
 		}
+		point, ok := m.prediction.Predictions[key]
+		if !ok {
+			// Members forecasting other horizons would be compared against the wrong candle
+			errs = append(errs, fmt.Errorf("%s has no %s forecast", m.name, key))
+			continue
+		}
+		returns[m.name] = (point.Price - currentPrice) / currentPrice
+		confidences[m.name] = m.prediction.Confidence
+		directions[m.name] = m.prediction.Direction
+	}
+	if len(returns) == 0 {
+		return nil, fmt.Errorf("no ensemble member predicted: %w", errors.Join(errs...))
 	}
 
-	// Calculate weighted prediction
-	currentPrice := 0.0
-	if len(historical) > 0 {
-		currentPrice = historical[len(historical)-1]
+	weights, intercept, method := e.combine(cfg, in, returns)
+
+	// Combined return, and the share of each member in it
+	combined := intercept
+	total := 0.0
+	for name, w := range weights {
+		combined += w * returns[name]
+		total += math.Abs(w)
+	}
+	shares := make(map[string]float64, len(weights))
+	for name, w := range weights {
+		if total > 0 {
+			shares[name] = math.Abs(w) / total
+		} else {
+			shares[name] = 1 / float64(len(weights))
+		}
 	}
 
-	// Simple weighted prediction for now
-	// In production, this would aggregate predictions from multiple models
-	prediction1h := currentPrice * 1.02 // 2% increase prediction
+	direction := "NEUTRAL"
+	if combined > 0.005 {
+		direction = "UP"
+	} else if combined < -0.005 {
+		direction = "DOWN"
+	}
 
-	// Calculate confidence based on model agreement
-	confidence := 75.0
+	// Disagreement is the share-weighted spread of member returns; confidence
+	// is the members' confidence scaled by how much of the weight agrees
+	mean := 0.0
+	for name, s := range shares {
+		mean += s * returns[name]
+	}
+	spread, agreement, confidence := 0.0, 0.0, 0.0
+	for name, s := range shares {
+		spread += s * math.Pow(returns[name]-mean, 2)
+		confidence += s * confidences[name]
+		if memberDirection(directions[name], returns[name]) == direction {
+			agreement += s
+		}
+	}
+	confidence *= agreement
 
-	// Determine recommendation
-	priceChange := (prediction1h - currentPrice) / currentPrice * 100
-	recommendation := "HOLD"
-	if priceChange > 2 {
-		recommendation = "BUY"
-	} else if priceChange < -2 {
-		recommendation = "SELL"
+	factors := map[string]float64{
+		"members":             float64(len(returns)),
+		"failed":              float64(len(errs)),
+		"disagreement":        math.Sqrt(spread) * 100,
+		"agreement":           agreement,
+		"weighting." + method: 1,
+	}
+	if method == WeightingStacking {
+		factors["stacking.intercept"] = intercept * 100
+	}
+	for name, r := range returns {
+		factors["weight."+name] = weights[name]
+		factors["forecast."+name] = r * 100
+		factors["contribution."+name] = weights[name] * r * 100
+		factors["confidence."+name] = confidences[name]
 	}
 
-	// Determine risk level
-	riskLevel := "MEDIUM"
-	if confidence > 80 {
-		riskLevel = "LOW"
-	} else if confidence < 60 {
-		riskLevel = "HIGH"
+	return stepPrediction(e.Name(), in, currentPrice*(1+combined), confidence, direction, ensembleSignal(direction, confidence), factors), nil
+}
+
+// runMembers predicts with every member through the manager, each bounded by
+// the member timeout
+func (e *EnsemblePredictor) runMembers(ctx context.Context, cfg EnsembleConfig, in Input) []memberResult {
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	results := make([]memberResult, len(cfg.Models))
+	var wg sync.WaitGroup
+	for i, name := range cfg.Models {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			memberCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			prediction, err := GetManager().Predict(memberCtx, name, in)
+			results[i] = memberResult{name: name, prediction: prediction, err: err}
+		}(i, name)
 	}
+	wg.Wait()
+	return results
+}
 
-	return &Prediction{
-		Model:        "Ensemble",
-		Symbol:       symbol,
-		CurrentPrice: currentPrice,
-		Predictions: map[string]PricePoint{
-			"1h": {
-				Price:      prediction1h,
-				Confidence: confidence,
-				Timestamp:  time.Now().Add(1 * time.Hour).Unix(),
-			},
-			"4h": {
-				Price:      prediction1h * 1.02,
-				Confidence: confidence * 0.95,
-				Timestamp:  time.Now().Add(4 * time.Hour).Unix(),
-			},
-			"24h": {
-				Price:      prediction1h * 1.05,
-				Confidence: confidence * 0.90,
-				Timestamp:  time.Now().Add(24 * time.Hour).Unix(),
-			},
-		},
-		Confidence:     confidence,
-		Factors:        map[string]float64{
-			"neural_weight":       weights["neural"],
-			"lightgbm_weight":     weights["lightgbm"],
-			"randomforest_weight": weights["randomforest"],
-			"xgboost_weight":      weights["xgboost"],
-		},
-		Recommendation: recommendation,
-		RiskLevel:      riskLevel,
-		Timestamp:      time.Now().Unix(),
+// combine scores pending forecasts against the input candles, picks the
+// member weights and records the new forecasts. Methods that lack history
+// fall back to the next simpler one.
+func (e *EnsemblePredictor) combine(cfg EnsembleConfig, in Input, returns map[string]float64) (map[string]float64, float64, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t, resolved := e.track(in)
+	weights, intercept, method := e.weigh(cfg, t, returns)
+	if t != nil && (t.add(in, returns) || resolved > 0) {
+		e.saveTracks()
+	}
+	return weights, intercept, method
+}
+
+// weigh picks the member weights of the configured method
+func (e *EnsemblePredictor) weigh(cfg EnsembleConfig, t *ensembleTrack, returns map[string]float64) (map[string]float64, float64, string) {
+	if t != nil && cfg.Weighting == WeightingStacking {
+		if meta := t.stacking(memberNames(returns)); meta != nil {
+			weights := make(map[string]float64, len(meta.models))
+			for i, name := range meta.models {
+				weights[name] = meta.coef[i]
+			}
+			return weights, meta.intercept, WeightingStacking
+		}
+	}
+	if t != nil && cfg.Weighting != WeightingStatic {
+		if weights := t.inverseError(cfg, returns); weights != nil {
+			return weights, 0, WeightingInverseError
+		}
+	}
+	return normalize(staticWeights(cfg, returns)), 0, WeightingStatic
+}
+
+// track returns the scoring track of the input and how many of its
+// forecasts were resolved by the input candles. Only live inputs of a known
+// interval are tracked, the ones the ledger records; client candles and
+// replayed history are combined with the static weights.
+func (e *EnsemblePredictor) track(in Input) (*ensembleTrack, int) {
+	step, err := market.IntervalDuration(in.Interval)
+	if err != nil || !liveInput(in) {
+		return nil, 0
+	}
+	key := in.Symbol + "|" + in.Interval
+	t, ok := e.tracks[key]
+	if !ok {
+		t = &ensembleTrack{}
+		e.tracks[key] = t
+	}
+	return t, t.resolve(in, step.Milliseconds())
+}
+
+// resolve scores pending forecasts whose next candle is in the input and
+// returns how many were scored
+func (t *ensembleTrack) resolve(in Input, stepMs int64) int {
+	before := len(t.resolved)
+	times := in.Candles.Time
+	last := times[len(times)-1]
+	pending := t.pending[:0]
+	for _, s := range t.pending {
+		target := s.base + stepMs
+		if target > last {
+			pending = append(pending, s)
+			continue
+		}
+		i := sort.Search(len(times), func(i int) bool { return times[i] >= target })
+		if i == len(times) || times[i] != target {
+			continue // candle missing from the input, drop the forecast
+		}
+		s.actual = (in.Candles.Close[i] - s.close) / s.close
+		t.resolved = append(t.resolved, s)
+	}
+	t.pending = pending
+	scored := len(t.resolved) - before
+	if over := len(t.resolved) - ensembleHistory; over > 0 {
+		t.resolved = append(t.resolved[:0], t.resolved[over:]...)
+	}
+	return scored
+}
+
+// add records forecasts made on the last input candle, replacing earlier
+// forecasts made on the same candle; true when the candle is new
+func (t *ensembleTrack) add(in Input, returns map[string]float64) bool {
+	n := in.Candles.Len()
+	sample := ensembleSample{
+		base:    in.Candles.Time[n-1],
+		close:   in.Candles.Close[n-1],
+		returns: returns,
+	}
+	for i := range t.pending {
+		if t.pending[i].base == sample.base {
+			t.pending[i] = sample
+			return false
+		}
+	}
+	t.pending = append(t.pending, sample)
+	return true
+}
+
+// tracksPath is the file the scored forecasts are kept in
+func tracksPath() string {
+	return filepath.Join(GetModelStore().dir, "ensemble-tracks.json")
+}
+
+// saveTracks writes the scored forecasts of every track. Callers hold mu.
+func (e *EnsemblePredictor) saveTracks() {
+	saved := make(map[string]savedTrack, len(e.tracks))
+	for key, t := range e.tracks {
+		saved[key] = savedTrack{Pending: saveSamples(t.pending), Resolved: saveSamples(t.resolved)}
+	}
+	data, err := json.Marshal(saved)
+	if err == nil {
+		err = writeFileAtomic(tracksPath(), data)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to save ensemble tracks: %v", err)
+	}
+}
+
+// loadTracks reads the scored forecasts saved by saveTracks
+func (e *EnsemblePredictor) loadTracks() error {
+	data, err := os.ReadFile(tracksPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var saved map[string]savedTrack
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	for key, st := range saved {
+		e.tracks[key] = &ensembleTrack{pending: loadSamples(st.Pending), resolved: loadSamples(st.Resolved)}
+	}
+	return nil
+}
+
+func saveSamples(samples []ensembleSample) []savedSample {
+	saved := make([]savedSample, len(samples))
+	for i, s := range samples {
+		saved[i] = savedSample{Base: s.base, Close: s.close, Returns: s.returns, Actual: s.actual}
+	}
+	return saved
+}
+
+func loadSamples(saved []savedSample) []ensembleSample {
+	samples := make([]ensembleSample, len(saved))
+	for i, s := range saved {
+		samples[i] = ensembleSample{base: s.Base, close: s.Close, returns: s.Returns, actual: s.Actual}
+	}
+	return samples
+}
+
+// mae returns the mean absolute error of a member's forecast returns
+func (t *ensembleTrack) mae(name string) (float64, int) {
+	sum, n := 0.0, 0
+	for _, s := range t.resolved {
+		if r, ok := s.returns[name]; ok {
+			sum += math.Abs(r - s.actual)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / float64(n), n
+}
+
+// inverseError divides the static weights by each member's recent error.
+// Members without enough history get the median error; nil when no member
+// has enough history.
+func (t *ensembleTrack) inverseError(cfg EnsembleConfig, returns map[string]float64) map[string]float64 {
+	errs := make(map[string]float64, len(returns))
+	known := make([]float64, 0, len(returns))
+	for name := range returns {
+		if mae, n := t.mae(name); n >= inverseErrorMinimum {
+			errs[name] = math.Max(mae, 1e-9)
+			known = append(known, errs[name])
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	sort.Float64s(known)
+	median := known[len(known)/2]
+
+	weights := staticWeights(cfg, returns)
+	for name := range weights {
+		mae, ok := errs[name]
+		if !ok {
+			mae = median
+		}
+		weights[name] /= mae
+	}
+	return normalize(weights)
+}
+
+// stacking returns the meta-model over the given members, refitting it when
+// new forecasts were resolved or the members changed; nil until enough samples
+// forecast by all of them exist
+func (t *ensembleTrack) stacking(models []string) *stackingModel {
+	if t.meta != nil && t.fitted == len(t.resolved) && t.meta.over(models) {
+		return t.meta
+	}
+	var X [][]float64
+	var y []float64
+	for _, s := range t.resolved {
+		row := make([]float64, len(models))
+		complete := true
+		for j, name := range models {
+			r, ok := s.returns[name]
+			if !ok {
+				complete = false
+				break
+			}
+			row[j] = r
+		}
+		if complete {
+			X = append(X, row)
+			y = append(y, s.actual)
+		}
+	}
+	t.fitted = len(t.resolved)
+	t.meta = nil
+	if len(y) < stackingMinimum {
+		return nil
+	}
+	t.meta = fitStacking(models, X, y)
+	return t.meta
+}
+
+// over reports whether the meta-model was fitted on exactly these members
+func (m *stackingModel) over(models []string) bool {
+	if len(m.models) != len(models) {
+		return false
+	}
+	for i, name := range models {
+		if m.models[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// fitStacking fits y = intercept + X·coef by ridge regression; the intercept
+// is not penalized. Returns nil when the system is singular.
+func fitStacking(models []string, X [][]float64, y []float64) *stackingModel {
+	k := len(models) + 1 // intercept first
+	A := make([][]float64, k)
+	for i := range A {
+		A[i] = make([]float64, k+1) // augmented with X'y
+	}
+	for r, row := range X {
+		x := append([]float64{1}, row...)
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				A[i][j] += x[i] * x[j]
+			}
+			A[i][k] += x[i] * y[r]
+		}
+	}
+	trace := 0.0
+	for i := 1; i < k; i++ {
+		trace += A[i][i]
+	}
+	if k > 1 {
+		penalty := stackingRidge * trace / float64(k-1)
+		for i := 1; i < k; i++ {
+			A[i][i] += penalty
+		}
+	}
+
+	beta, ok := solveLinear(A)
+	if !ok {
+		return nil
+	}
+	return &stackingModel{models: append([]string(nil), models...), intercept: beta[0], coef: beta[1:]}
+}
+
+// solveLinear solves an augmented n×(n+1) system by Gaussian elimination with
+// partial pivoting
+func solveLinear(A [][]float64) ([]float64, bool) {
+	n := len(A)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(A[r][col]) > math.Abs(A[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(A[pivot][col]) < 1e-18 {
+			return nil, false
+		}
+		A[col], A[pivot] = A[pivot], A[col]
+		for r := col + 1; r < n; r++ {
+			f := A[r][col] / A[col][col]
+			for c := col; c <= n; c++ {
+				A[r][c] -= f * A[col][c]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		sum := A[r][n]
+		for c := r + 1; c < n; c++ {
+			sum -= A[r][c] * x[c]
+		}
+		x[r] = sum / A[r][r]
+	}
+	return x, true
+}
+
+// memberNames returns the members that forecast, sorted
+func memberNames(returns map[string]float64) []string {
+	names := make([]string, 0, len(returns))
+	for name := range returns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// staticWeights returns the configured weights of the members that predicted;
+// members without a configured weight count as an equal share
+func staticWeights(cfg EnsembleConfig, returns map[string]float64) map[string]float64 {
+	weights := make(map[string]float64, len(returns))
+	for name := range returns {
+		w, ok := cfg.Weights[name]
+		if !ok {
+			w = 1 / float64(len(cfg.Models))
+		}
+		weights[name] = w
+	}
+	return weights
+}
+
+// normalize scales weights to sum to 1, using equal weights when all are zero
+func normalize(weights map[string]float64) map[string]float64 {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	for name, w := range weights {
+		if total > 0 {
+			weights[name] = w / total
+		} else {
+			weights[name] = 1 / float64(len(weights))
+		}
+	}
+	return weights
+}
+
+// memberDirection returns a member's direction, derived from its forecast
+// return when the member does not report one
+func memberDirection(direction string, ret float64) string {
+	if direction != "" {
+		return direction
+	}
+	switch {
+	case ret > 0.005:
+		return "UP"
+	case ret < -0.005:
+		return "DOWN"
+	default:
+		return "NEUTRAL"
+	}
+}
+
+// ensembleSignal generates the trading signal of the combined forecast
+func ensembleSignal(direction string, confidence float64) string {
+	if confidence < 60 {
+		return "HOLD"
+	}
+
+	switch direction {
+	case "UP":
+		if confidence > 80 {
+			return "STRONG_BUY"
+		}
+		return "BUY"
+	case "DOWN":
+		if confidence > 80 {
+			return "STRONG_SELL"
+		}
+		return "SELL"
+	default:
+		return "HOLD"
 	}
 }
 
+var ensemblePredictor *EnsemblePredictor
+var ensembleOnce sync.Once
+
 // GetEnsemblePredictor returns Ensemble predictor instance
 func GetEnsemblePredictor() *EnsemblePredictor {
-	return &EnsemblePredictor{
-		Weights: map[string]float64{
-			"neural":       0.25,
-			"lightgbm":     0.30,
-			"randomforest": 0.20,
-			"xgboost":      0.25,
-		},
-		IsLoaded: true,
-	}
-}
\ No newline at end of file
+	ensembleOnce.Do(func() {
+		ensemblePredictor = &EnsemblePredictor{
+			config: EnsembleConfig{
+				Models:    []string{"neural", "lightgbm", "randomforest", "xgboost"},
+				Weighting: WeightingInverseError,
+				Weights: map[string]float64{
+					"neural":       0.25,
+					"lightgbm":     0.30,
+					"randomforest": 0.20,
+					"xgboost":      0.25,
+				},
+				TimeoutMs: defaultMemberTimeout,
+			},
+			version: "2.0.0",
+			tracks:  make(map[string]*ensembleTrack),
+		}
+		if err := ensemblePredictor.loadTracks(); err != nil {
+			logrus.Warnf("Failed to load ensemble tracks: %v", err)
+		}
+	})
+	return ensemblePredictor
+}
@@ -1,18 +1,25 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
 // GRUPredictor implements Gated Recurrent Unit neural network
 type GRUPredictor struct {
-	ModelID    string
-	ModelPath  string
-	Config     GRUConfig
-	Weights    *GRUWeights
-	IsLoaded   bool
+	ModelID   string
+	ModelPath string
+	Config    GRUConfig
+	Weights   *GRUWeights
+	Meta      *SequenceMeta // nil until the network is trained
+	IsLoaded  bool
+	version   string
+	mu        sync.RWMutex
 }
 
 // GRUConfig contains GRU configuration
@@ -29,6 +36,17 @@ type GRUConfig struct {
 
 // GRUWeights contains GRU model weights
 type GRUWeights struct {
+	// Stacked layers; the first reads the input features, the others the
+	// hidden state of the layer below
+	Layers []GRULayer
+
+	// Output layer
+	Wy [][]float64 // Hidden to output
+	By []float64   // Output bias
+}
+
+// GRULayer contains the gate weights of one GRU layer
+type GRULayer struct {
 	// Reset gate weights
 	Wr [][]float64 // Input to reset gate
 	Ur [][]float64 // Hidden to reset gate
@@ -44,519 +62,324 @@ type GRUWeights struct {
 	Uh [][]float64 // Hidden to hidden
 	Bh []float64   // Hidden bias
 
-	// Output layer
-	Wy [][]float64 // Hidden to output
-	By []float64   // Output bias
+	// Bhr is the hidden to hidden bias of GRUs that apply the reset gate
+	// after the recurrent product, as PyTorch and Keras (reset_after) do:
+	// candidate = tanh(Wh·x + Bh + r*(Uh·h + Bhr)). Only imported networks
+	// have it; trained layers reset the hidden state before Uh.
+	Bhr []float64 `json:",omitempty"`
 }
 
-// GRUCell represents a single GRU cell state
-type GRUCell struct {
-	HiddenState []float64
+// gruActivations are the gates and state of one layer at one timestep, kept
+// for backpropagation through time
+type gruActivations struct {
+	r, z, n []float64 // reset gate, update gate, candidate activation
+	rh      []float64 // reset gate times the previous hidden state
+	h       []float64 // hidden state
 }
 
 var gruPredictor *GRUPredictor
+var gruOnce sync.Once
 
 // InitGRUPredictor initializes the GRU predictor
 func InitGRUPredictor() {
-	gruPredictor = &GRUPredictor{
+	gruPredictor = newGRUPredictor()
+	gruPredictor.Initialize()
+}
+
+// newGRUPredictor returns a GRU predictor with the default configuration and
+// no weights
+func newGRUPredictor() *GRUPredictor {
+	return &GRUPredictor{
 		ModelID:   "gru-v1",
+		version:   "1.0.0",
 		ModelPath: "./models/gru",
 		Config: GRUConfig{
-			InputSize:    20,  // Features per timestep
-			HiddenSize:   128, // GRU hidden units
-			NumLayers:    3,   // Stacked GRU layers
-			OutputSize:   1,   // Price prediction
-			SequenceLen:  50,  // Look back 50 timesteps
+			InputSize:    len(sequenceFeatureNames), // Features per timestep
+			HiddenSize:   32,                        // GRU hidden units
+			NumLayers:    2,                         // Stacked GRU layers
+			OutputSize:   1,                         // Next candle return
+			SequenceLen:  50,                        // Look back 50 timesteps
 			LearningRate: 0.001,
 			Dropout:      0.3,
 			BatchSize:    32,
 		},
 		IsLoaded: false,
 	}
-	gruPredictor.Initialize()
 }
 
 // GetGRUPredictor returns the GRU predictor instance
 func GetGRUPredictor() *GRUPredictor {
-	if gruPredictor == nil {
-		InitGRUPredictor()
-	}
+	gruOnce.Do(InitGRUPredictor)
 	return gruPredictor
 }
 
-// Initialize initializes GRU weights
+// Initialize initializes GRU weights; they predict nothing until trained
 func (g *GRUPredictor) Initialize() {
 	rand.Seed(time.Now().UnixNano())
 
-	g.Weights = &GRUWeights{
-		// Initialize reset gate weights
-		Wr: randomMatrix(g.Config.HiddenSize, g.Config.InputSize),
-		Ur: randomMatrix(g.Config.HiddenSize, g.Config.HiddenSize),
-		Br: randomVector(g.Config.HiddenSize),
-
-		// Initialize update gate weights
-		Wz: randomMatrix(g.Config.HiddenSize, g.Config.InputSize),
-		Uz: randomMatrix(g.Config.HiddenSize, g.Config.HiddenSize),
-		Bz: randomVector(g.Config.HiddenSize),
-
-		// Initialize candidate activation weights
-		Wh: randomMatrix(g.Config.HiddenSize, g.Config.InputSize),
-		Uh: randomMatrix(g.Config.HiddenSize, g.Config.HiddenSize),
-		Bh: randomVector(g.Config.HiddenSize),
-
-		// Initialize output layer
-		Wy: randomMatrix(g.Config.OutputSize, g.Config.HiddenSize),
-		By: randomVector(g.Config.OutputSize),
-	}
-
+	g.Weights = newGRUWeights(g.Config)
 	g.IsLoaded = true
 	// logger.Info("GRU predictor initialized")
 }
 
-// Predict performs GRU prediction
-func (g *GRUPredictor) Predict(symbol string, historical []float64, features map[string]interface{}) *Prediction {
-	// Prepare input sequence
-	sequence := g.prepareSequence(historical, features)
-
-	// Forward propagation through GRU
-	outputs := g.forward(sequence)
-
-	// Get final prediction
-	prediction := outputs[len(outputs)-1][0]
-
-	// Determine market direction
-	currentPrice := historical[len(historical)-1]
-	priceChange := prediction - currentPrice
-	percentChange := (priceChange / currentPrice) * 100
-
-	direction := "NEUTRAL"
-	if percentChange > 0.5 {
-		direction = "UP"
-	} else if percentChange < -0.5 {
-		direction = "DOWN"
-	}
-
-	// Calculate confidence based on GRU gates
-	confidence := g.calculateConfidence(outputs, percentChange)
-
-	// Determine trading signal
-	signal := "HOLD"
-	if direction == "UP" && confidence > 72 {
-		if confidence > 88 {
-			signal = "STRONG_BUY"
-		} else {
-			signal = "BUY"
+// newGRUWeights creates randomly initialized weights for a configuration
+func newGRUWeights(c GRUConfig) *GRUWeights {
+	w := &GRUWeights{
+		Layers: make([]GRULayer, c.NumLayers),
+		Wy:     randomMatrix(c.OutputSize, c.HiddenSize),
+		By:     randomVector(c.OutputSize),
+	}
+	for i := range w.Layers {
+		inputSize := c.HiddenSize
+		if i == 0 {
+			inputSize = c.InputSize
 		}
-	} else if direction == "DOWN" && confidence > 72 {
-		if confidence > 88 {
-			signal = "STRONG_SELL"
-		} else {
-			signal = "SELL"
+		w.Layers[i] = GRULayer{
+			Wr: randomMatrix(c.HiddenSize, inputSize),
+			Ur: randomMatrix(c.HiddenSize, c.HiddenSize),
+			Br: randomVector(c.HiddenSize),
+			Wz: randomMatrix(c.HiddenSize, inputSize),
+			Uz: randomMatrix(c.HiddenSize, c.HiddenSize),
+			Bz: randomVector(c.HiddenSize),
+			Wh: randomMatrix(c.HiddenSize, inputSize),
+			Uh: randomMatrix(c.HiddenSize, c.HiddenSize),
+			Bh: randomVector(c.HiddenSize),
 		}
 	}
-
-	// Calculate additional metrics
-	volatility := g.calculateVolatility(historical)
-	momentum := g.calculateMomentum(historical)
-	trendStrength := g.calculateTrendStrength(historical)
-
-	return &Prediction{
-		Model:        "GRU",
-		Symbol:       symbol,
-		CurrentPrice: currentPrice,
-		Predictions: map[string]PricePoint{
-			"1h": {
-				Price:      prediction * 1.001,
-				Confidence: confidence * 0.95,
-				Timestamp:  time.Now().Add(1 * time.Hour).Unix(),
-			},
-			"4h": {
-				Price:      prediction * 1.004,
-				Confidence: confidence * 0.90,
-				Timestamp:  time.Now().Add(4 * time.Hour).Unix(),
-			},
-			"24h": {
-				Price:      prediction,
-				Confidence: confidence,
-				Timestamp:  time.Now().Add(24 * time.Hour).Unix(),
-			},
-		},
-		Confidence: confidence,
-		Factors: map[string]float64{
-			"hidden_size":    float64(g.Config.HiddenSize),
-			"num_layers":     float64(g.Config.NumLayers),
-			"sequence_len":   float64(g.Config.SequenceLen),
-			"trend_strength": trendStrength,
-			"dropout":        g.Config.Dropout,
-			"volatility":     volatility,
-			"momentum":       momentum,
-		},
-		Recommendation: signal,
-		RiskLevel:      g.assessRisk(volatility, confidence),
-		Timestamp:      time.Now().Unix(),
-		Targets:        []float64{currentPrice * 1.02, currentPrice * 1.05, currentPrice * 1.10},
-		StopLoss:       currentPrice * 0.95,
-		EntryPrice:     currentPrice * 1.001,
-	}
+	return w
 }
 
-// prepareSequence prepares input sequence for GRU
-func (g *GRUPredictor) prepareSequence(historical []float64, features map[string]interface{}) [][]float64 {
-	sequence := make([][]float64, g.Config.SequenceLen)
-
-	// Use available historical data
-	startIdx := 0
-	if len(historical) > g.Config.SequenceLen {
-		startIdx = len(historical) - g.Config.SequenceLen
+// validate checks the weight shapes against a configuration
+func (w *GRUWeights) validate(c GRUConfig) error {
+	if len(w.Layers) != c.NumLayers {
+		return fmt.Errorf("%w: GRU has %d layers, want %d", ErrInvalidInput, len(w.Layers), c.NumLayers)
 	}
-
-	for i := 0; i < g.Config.SequenceLen; i++ {
-		sequence[i] = make([]float64, g.Config.InputSize)
-
-		// Price features
-		if startIdx+i < len(historical) {
-			price := historical[startIdx+i]
-			sequence[i][0] = price / 100000.0 // Normalize
-
-			// Price changes
-			if startIdx+i > 0 {
-				sequence[i][1] = (price - historical[startIdx+i-1]) / price
+	for i, l := range w.Layers {
+		inputSize := c.HiddenSize
+		if i == 0 {
+			inputSize = c.InputSize
+		}
+		for _, m := range []struct {
+			name       string
+			matrix     [][]float64
+			rows, cols int
+		}{
+			{"Wr", l.Wr, c.HiddenSize, inputSize},
+			{"Ur", l.Ur, c.HiddenSize, c.HiddenSize},
+			{"Wz", l.Wz, c.HiddenSize, inputSize},
+			{"Uz", l.Uz, c.HiddenSize, c.HiddenSize},
+			{"Wh", l.Wh, c.HiddenSize, inputSize},
+			{"Uh", l.Uh, c.HiddenSize, c.HiddenSize},
+		} {
+			if err := checkMatrix(fmt.Sprintf("layer %d %s", i, m.name), m.matrix, m.rows, m.cols); err != nil {
+				return err
 			}
-
-			// Moving averages
-			sequence[i][2] = g.calculateMA(historical, startIdx+i, 7) / 100000.0
-			sequence[i][3] = g.calculateMA(historical, startIdx+i, 25) / 100000.0
-			sequence[i][4] = g.calculateMA(historical, startIdx+i, 99) / 100000.0
-
-			// Bollinger Bands
-			bb := g.calculateBollingerBands(historical, startIdx+i)
-			sequence[i][5] = bb.upper / 100000.0
-			sequence[i][6] = bb.lower / 100000.0
-			sequence[i][7] = bb.width
-
-			// MACD
-			macd := g.calculateMACD(historical, startIdx+i)
-			sequence[i][8] = macd.value
-			sequence[i][9] = macd.signal
-			sequence[i][10] = macd.histogram
-
-			// RSI
-			sequence[i][11] = g.calculateRSI(historical, startIdx+i) / 100.0
-
-			// Volume (simulated)
-			if vol, ok := features["volume"].(float64); ok {
-				sequence[i][12] = vol / 1000000.0
+		}
+		for _, b := range []struct {
+			name   string
+			vector []float64
+		}{
+			{"Br", l.Br}, {"Bz", l.Bz}, {"Bh", l.Bh},
+		} {
+			if err := checkVector(fmt.Sprintf("layer %d %s", i, b.name), b.vector, c.HiddenSize); err != nil {
+				return err
 			}
 		}
-
-		// Additional features from input
-		for j := 13; j < g.Config.InputSize; j++ {
-			sequence[i][j] = rand.Float64() * 0.1
+		if l.Bhr != nil {
+			if err := checkVector(fmt.Sprintf("layer %d Bhr", i), l.Bhr, c.HiddenSize); err != nil {
+				return err
+			}
 		}
 	}
-
-	return sequence
+	if err := checkMatrix("Wy", w.Wy, c.OutputSize, c.HiddenSize); err != nil {
+		return err
+	}
+	return checkVector("By", w.By, c.OutputSize)
 }
 
-// forward performs forward propagation through GRU
-func (g *GRUPredictor) forward(sequence [][]float64) [][]float64 {
-	outputs := make([][]float64, len(sequence))
-	cells := make([]GRUCell, g.Config.NumLayers)
-
-	// Initialize hidden states
-	for i := range cells {
-		cells[i] = GRUCell{
-			HiddenState: make([]float64, g.Config.HiddenSize),
-		}
-	}
+// Name implements Predictor
+func (g *GRUPredictor) Name() string { return "gru" }
 
-	// Process sequence through GRU layers
-	for t, input := range sequence {
-		layerInput := input
-
-		for layer := 0; layer < g.Config.NumLayers; layer++ {
-			// GRU cell computation
-			newHidden := g.gruStep(
-				layerInput,
-				cells[layer].HiddenState,
-			)
-
-			// Apply dropout during training (simulated)
-			if g.Config.Dropout > 0 && rand.Float64() < g.Config.Dropout {
-				for i := range newHidden {
-					newHidden[i] *= (1.0 / (1.0 - g.Config.Dropout))
-				}
-			}
+// Version implements Predictor
+func (g *GRUPredictor) Version() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.version
+}
 
-			cells[layer].HiddenState = newHidden
-			layerInput = newHidden
-		}
+// gruState is the serialized form of the network
+type gruState struct {
+	Config  GRUConfig     `json:"config"`
+	Weights *GRUWeights   `json:"weights"`
+	Meta    *SequenceMeta `json:"meta"`
+}
 
-		// Output layer
-		output := g.outputLayer(cells[g.Config.NumLayers-1].HiddenState)
-		outputs[t] = output
+// MarshalState implements Persistent; an untrained network is not saved
+func (g *GRUPredictor) MarshalState() (json.RawMessage, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.Meta == nil {
+		return nil, fmt.Errorf("%w: gru", ErrModelNotTrained)
 	}
-
-	return outputs
+	return json.Marshal(gruState{Config: g.Config, Weights: g.Weights, Meta: g.Meta})
 }
 
-// gruStep performs one GRU step
-func (g *GRUPredictor) gruStep(input, hiddenPrev []float64) []float64 {
-	// Reset gate
-	resetGate := make([]float64, g.Config.HiddenSize)
-	for i := 0; i < g.Config.HiddenSize; i++ {
-		sum := g.Weights.Br[i]
-		for j := 0; j < len(input); j++ {
-			sum += g.Weights.Wr[i][j] * input[j]
-		}
-		for j := 0; j < g.Config.HiddenSize; j++ {
-			sum += g.Weights.Ur[i][j] * hiddenPrev[j]
-		}
-		resetGate[i] = sigmoid(sum)
+// RestoreState implements Persistent; the inputs must match the features
+// built by prepareSequence
+func (g *GRUPredictor) RestoreState(version string, data json.RawMessage) error {
+	var state gruState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
 	}
-
-	// Update gate
-	updateGate := make([]float64, g.Config.HiddenSize)
-	for i := 0; i < g.Config.HiddenSize; i++ {
-		sum := g.Weights.Bz[i]
-		for j := 0; j < len(input); j++ {
-			sum += g.Weights.Wz[i][j] * input[j]
-		}
-		for j := 0; j < g.Config.HiddenSize; j++ {
-			sum += g.Weights.Uz[i][j] * hiddenPrev[j]
-		}
-		updateGate[i] = sigmoid(sum)
+	if state.Meta == nil {
+		return fmt.Errorf("%w: saved GRU state has no training metadata", ErrModelNotTrained)
 	}
-
-	// Candidate activation
-	candidateHidden := make([]float64, g.Config.HiddenSize)
-	for i := 0; i < g.Config.HiddenSize; i++ {
-		sum := g.Weights.Bh[i]
-		for j := 0; j < len(input); j++ {
-			sum += g.Weights.Wh[i][j] * input[j]
-		}
-		for j := 0; j < g.Config.HiddenSize; j++ {
-			sum += g.Weights.Uh[i][j] * (resetGate[j] * hiddenPrev[j])
-		}
-		candidateHidden[i] = math.Tanh(sum)
+	if state.Weights == nil {
+		return fmt.Errorf("%w: GRU state has no weights", ErrInvalidInput)
 	}
-
-	// Update hidden state
-	hiddenNew := make([]float64, g.Config.HiddenSize)
-	for i := 0; i < g.Config.HiddenSize; i++ {
-		hiddenNew[i] = (1-updateGate[i])*hiddenPrev[i] + updateGate[i]*candidateHidden[i]
+	c := state.Config
+	if c.HiddenSize <= 0 || c.NumLayers <= 0 || c.SequenceLen <= 0 || c.OutputSize != 1 {
+		return fmt.Errorf("%w: invalid GRU configuration", ErrInvalidInput)
 	}
-
-	return hiddenNew
-}
-
-// outputLayer applies final output layer
-func (g *GRUPredictor) outputLayer(hidden []float64) []float64 {
-	output := make([]float64, g.Config.OutputSize)
-
-	for i := 0; i < g.Config.OutputSize; i++ {
-		sum := g.Weights.By[i]
-		for j := 0; j < g.Config.HiddenSize; j++ {
-			sum += g.Weights.Wy[i][j] * hidden[j]
-		}
-		// Scale back to price range
-		output[i] = sum * 100000.0
+	if err := state.Weights.validate(c); err != nil {
+		return err
+	}
+	if err := state.Meta.validate(c.InputSize); err != nil {
+		return err
 	}
 
-	return output
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Config, g.Weights, g.Meta, g.version, g.IsLoaded = c, state.Weights, state.Meta, version, true
+	return nil
 }
 
-// calculateConfidence calculates prediction confidence
-func (g *GRUPredictor) calculateConfidence(outputs [][]float64, percentChange float64) float64 {
-	if len(outputs) < 2 {
-		return 50.0
+// Predict forecasts the next candle with the trained network
+func (g *GRUPredictor) Predict(ctx context.Context, in Input) (*Prediction, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if err := g.Meta.check(g.Name(), in, g.Config.SequenceLen); err != nil {
+		return nil, err
 	}
 
-	// Calculate trend consistency
-	trendConsistency := 0.0
-	for i := 1; i < len(outputs); i++ {
-		if (outputs[i][0] > outputs[i-1][0] && percentChange > 0) ||
-			(outputs[i][0] < outputs[i-1][0] && percentChange < 0) {
-			trendConsistency++
-		}
-	}
-	trendConsistency = trendConsistency / float64(len(outputs)-1) * 100
+	historical := in.Closes()
 
-	// Calculate prediction stability
-	var sum, sumSq float64
-	for _, output := range outputs {
-		sum += output[0]
-		sumSq += output[0] * output[0]
-	}
-
-	mean := sum / float64(len(outputs))
-	variance := sumSq/float64(len(outputs)) - mean*mean
-	stability := 100.0 / (1.0 + math.Sqrt(variance)/mean*10)
+	// Prepare input sequence
+	sequence := g.prepareSequence(in)
 
-	// Combine metrics
-	confidence := (trendConsistency*0.6 + stability*0.4)
+	// Forward propagation through GRU: the network predicts the scaled return
+	predictedReturn := g.Weights.predict(sequence) * g.Meta.TargetScale
 
-	// Add some controlled randomness
-	confidence += (rand.Float64() - 0.5) * 8
+	// Determine market direction
+	currentPrice := historical[len(historical)-1]
+	prediction := currentPrice * (1 + predictedReturn)
 
-	if confidence > 95 {
-		confidence = 95
-	} else if confidence < 45 {
-		confidence = 45
+	direction := "NEUTRAL"
+	if predictedReturn > 0.001 {
+		direction = "UP"
+	} else if predictedReturn < -0.001 {
+		direction = "DOWN"
 	}
 
-	return confidence
-}
+	// Confidence from validation accuracy and forecast size
+	confidence := g.Meta.confidence(predictedReturn)
 
-// BollingerBands represents Bollinger Bands
-type BollingerBands struct {
-	upper float64
-	lower float64
-	width float64
-}
-
-// calculateBollingerBands calculates Bollinger Bands
-func (g *GRUPredictor) calculateBollingerBands(prices []float64, idx int) BollingerBands {
-	period := 20
-	stdDev := 2.0
-
-	if idx < period-1 {
-		return BollingerBands{
-			upper: prices[idx] * 1.02,
-			lower: prices[idx] * 0.98,
-			width: 0.04,
+	// Determine trading signal
+	signal := "HOLD"
+	if direction == "UP" && confidence > 72 {
+		if confidence > 88 {
+			signal = "STRONG_BUY"
+		} else {
+			signal = "BUY"
 		}
-	}
-
-	// Calculate SMA
-	sma := g.calculateMA(prices, idx, period)
-
-	// Calculate standard deviation
-	var sumSq float64
-	count := 0
-	for i := idx - period + 1; i <= idx && i < len(prices); i++ {
-		if i >= 0 {
-			diff := prices[i] - sma
-			sumSq += diff * diff
-			count++
+	} else if direction == "DOWN" && confidence > 72 {
+		if confidence > 88 {
+			signal = "STRONG_SELL"
+		} else {
+			signal = "SELL"
 		}
 	}
 
-	if count == 0 {
-		return BollingerBands{upper: sma, lower: sma, width: 0}
-	}
-
-	stdev := math.Sqrt(sumSq / float64(count))
+	// Calculate additional metrics
+	volatility := g.calculateVolatility(historical)
+	momentum := g.calculateMomentum(historical)
+	trendStrength := g.calculateTrendStrength(historical)
 
-	return BollingerBands{
-		upper: sma + (stdDev * stdev),
-		lower: sma - (stdDev * stdev),
-		width: 2 * stdDev * stdev / sma,
-	}
+	p := stepPrediction(g.Name(), in, prediction, confidence, direction, signal, map[string]float64{
+		"predicted_return":       predictedReturn * 100,
+		"hidden_size":            float64(g.Config.HiddenSize),
+		"num_layers":             float64(g.Config.NumLayers),
+		"sequence_len":           float64(g.Config.SequenceLen),
+		"trend_strength":         trendStrength,
+		"dropout":                g.Config.Dropout,
+		"val_direction_accuracy": g.Meta.ValDirectionAccuracy,
+		"volatility":             volatility,
+		"momentum":               momentum,
+	})
+	p.RiskLevel = g.assessRisk(volatility, confidence)
+	p.Targets = []float64{currentPrice * 1.02, currentPrice * 1.05, currentPrice * 1.10}
+	p.StopLoss = currentPrice * 0.95
+	p.EntryPrice = currentPrice * 1.001
+	return p, nil
 }
 
-// MACD represents MACD indicator
-type MACD struct {
-	value     float64
-	signal    float64
-	histogram float64
+// prepareSequence prepares the scaled input window for GRU
+func (g *GRUPredictor) prepareSequence(in Input) [][]float64 {
+	return g.Meta.window(in.Candles, g.Config.SequenceLen)
 }
 
-// calculateMACD calculates MACD
-func (g *GRUPredictor) calculateMACD(prices []float64, idx int) MACD {
-	if idx < 26 {
-		return MACD{value: 0, signal: 0, histogram: 0}
-	}
-
-	// Calculate EMAs
-	ema12 := g.calculateEMA(prices, idx, 12)
-	ema26 := g.calculateEMA(prices, idx, 26)
-
-	macdLine := (ema12 - ema26) / prices[idx]
-	signalLine := g.calculateEMA(prices, idx, 9) / prices[idx]
-	histogram := macdLine - signalLine
-
-	return MACD{
-		value:     macdLine,
-		signal:    signalLine,
-		histogram: histogram,
+// predict runs a window through the stacked layers and the output layer
+func (w *GRUWeights) predict(window [][]float64) float64 {
+	inputs := window
+	for i := range w.Layers {
+		inputs = gruHidden(w.Layers[i].run(inputs))
 	}
+	return w.By[0] + dot(w.Wy[0], inputs[len(inputs)-1])
 }
 
-// calculateEMA calculates Exponential Moving Average
-func (g *GRUPredictor) calculateEMA(prices []float64, idx, period int) float64 {
-	if idx < period-1 {
-		return prices[idx]
-	}
-
-	alpha := 2.0 / float64(period+1)
-	ema := prices[idx-period+1]
-
-	for i := idx - period + 2; i <= idx && i < len(prices); i++ {
-		if i >= 0 {
-			ema = alpha*prices[i] + (1-alpha)*ema
-		}
+// run feeds a sequence through the layer, starting from a zero state
+func (w *GRULayer) run(inputs [][]float64) []gruActivations {
+	hidden := make([]float64, len(w.Br))
+	acts := make([]gruActivations, len(inputs))
+	for t, x := range inputs {
+		acts[t] = w.step(x, hidden)
+		hidden = acts[t].h
 	}
-
-	return ema
+	return acts
 }
 
-// calculateMA calculates moving average
-func (g *GRUPredictor) calculateMA(prices []float64, idx, period int) float64 {
-	if idx < period-1 {
-		if idx >= 0 && idx < len(prices) {
-			return prices[idx]
-		}
-		return 0
-	}
-
-	sum := 0.0
-	count := 0
-	for i := idx - period + 1; i <= idx && i < len(prices); i++ {
-		if i >= 0 {
-			sum += prices[i]
-			count++
+// step performs one GRU step
+func (w *GRULayer) step(input, hiddenPrev []float64) gruActivations {
+	n := len(w.Br)
+	a := gruActivations{
+		r: make([]float64, n), z: make([]float64, n), n: make([]float64, n),
+		rh: make([]float64, n), h: make([]float64, n),
+	}
+	// Reset and update gates
+	for k := 0; k < n; k++ {
+		a.r[k] = sigmoid(w.Br[k] + dot(w.Wr[k], input) + dot(w.Ur[k], hiddenPrev))
+		a.z[k] = sigmoid(w.Bz[k] + dot(w.Wz[k], input) + dot(w.Uz[k], hiddenPrev))
+		a.rh[k] = a.r[k] * hiddenPrev[k]
+	}
+	// Candidate activation and hidden state update
+	for k := 0; k < n; k++ {
+		if w.Bhr != nil {
+			a.n[k] = math.Tanh(w.Bh[k] + dot(w.Wh[k], input) + a.r[k]*(dot(w.Uh[k], hiddenPrev)+w.Bhr[k]))
+		} else {
+			a.n[k] = math.Tanh(w.Bh[k] + dot(w.Wh[k], input) + dot(w.Uh[k], a.rh))
 		}
+		a.h[k] = (1-a.z[k])*hiddenPrev[k] + a.z[k]*a.n[k]
 	}
-
-	if count == 0 {
-		return 0
-	}
-
-	return sum / float64(count)
+	return a
 }
 
-// calculateRSI calculates RSI
-func (g *GRUPredictor) calculateRSI(prices []float64, idx int) float64 {
-	period := 14
-	if idx < period {
-		return 50.0
+// gruHidden returns the hidden state of each timestep
+func gruHidden(acts []gruActivations) [][]float64 {
+	hidden := make([][]float64, len(acts))
+	for t, a := range acts {
+		hidden[t] = a.h
 	}
-
-	gains := 0.0
-	losses := 0.0
-
-	for i := idx - period + 1; i <= idx && i < len(prices); i++ {
-		if i > 0 {
-			change := prices[i] - prices[i-1]
-			if change > 0 {
-				gains += change
-			} else {
-				losses -= change
-			}
-		}
-	}
-
-	if losses == 0 {
-		return 100.0
-	}
-
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-	rs := avgGain / avgLoss
-	rsi := 100.0 - (100.0 / (1.0 + rs))
-
-	return rsi
+	return hidden
 }
 
 // calculateVolatility calculates overall volatility
@@ -0,0 +1,158 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+)
+
+// Train implements Trainer: it fits a new network on next-candle returns
+// with backpropagation through time and Adam, then swaps it in. Params may
+// set hidden_size, num_layers, sequence_len and dropout.
+func (g *GRUPredictor) Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	g.mu.RLock()
+	config := g.Config
+	g.mu.RUnlock()
+
+	if err := recurrentParams(cfg, &config.HiddenSize, &config.NumLayers, &config.SequenceLen, &config.Dropout); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults(config.BatchSize, config.LearningRate)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	config.InputSize = len(sequenceFeatureNames)
+	config.BatchSize, config.LearningRate = cfg.BatchSize, cfg.LearningRate
+
+	result, err := fitSequenceModel(ctx, g.Name(), newGRUWeights(config), data, cfg, config.SequenceLen, config.Dropout)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Config, g.Weights, g.Meta = config, result.net.(*GRUWeights), result.meta
+	g.version = NewModelVersion()
+	g.IsLoaded = true
+	result.report.Version = g.version
+	return result.report, nil
+}
+
+// tensors implements recurrentNet
+func (w *GRUWeights) tensors() [][]float64 {
+	var rows [][]float64
+	for i := range w.Layers {
+		l := &w.Layers[i]
+		for _, m := range [][][]float64{l.Wr, l.Ur, l.Wz, l.Uz, l.Wh, l.Uh} {
+			rows = append(rows, m...)
+		}
+		rows = append(rows, l.Br, l.Bz, l.Bh)
+	}
+	rows = append(rows, w.Wy...)
+	return append(rows, w.By)
+}
+
+// clone implements recurrentNet
+func (w *GRUWeights) clone() recurrentNet {
+	c := &GRUWeights{
+		Layers: make([]GRULayer, len(w.Layers)),
+		Wy:     copyMatrix(w.Wy),
+		By:     append([]float64(nil), w.By...),
+	}
+	for i, l := range w.Layers {
+		c.Layers[i] = GRULayer{
+			Wr: copyMatrix(l.Wr), Ur: copyMatrix(l.Ur), Br: append([]float64(nil), l.Br...),
+			Wz: copyMatrix(l.Wz), Uz: copyMatrix(l.Uz), Bz: append([]float64(nil), l.Bz...),
+			Wh: copyMatrix(l.Wh), Uh: copyMatrix(l.Uh), Bh: append([]float64(nil), l.Bh...),
+			Bhr: append([]float64(nil), l.Bhr...),
+		}
+	}
+	return c
+}
+
+// gradient implements recurrentNet
+func (w *GRUWeights) gradient(window [][]float64, target, dropout float64, rng *rand.Rand, grad recurrentNet) float64 {
+	g := grad.(*GRUWeights)
+
+	// Forward pass, keeping each layer's inputs, activations and dropout mask
+	inputs := make([][][]float64, len(w.Layers))
+	acts := make([][]gruActivations, len(w.Layers))
+	masks := make([][][]float64, len(w.Layers))
+	x := window
+	for i := range w.Layers {
+		inputs[i] = x
+		acts[i] = w.Layers[i].run(x)
+		masks[i] = dropoutMask(rng, dropout, len(x), len(w.Layers[i].Br))
+		x = applyMask(gruHidden(acts[i]), masks[i])
+	}
+
+	// Output layer on the last hidden state of the top layer
+	last := x[len(x)-1]
+	diff := w.By[0] + dot(w.Wy[0], last) - target
+	g.By[0] += diff
+	dh := make([][]float64, len(window))
+	dh[len(dh)-1] = make([]float64, len(last))
+	for j, v := range last {
+		g.Wy[0][j] += diff * v
+		dh[len(dh)-1][j] = diff * w.Wy[0][j]
+	}
+
+	// Backward through the layers, top to bottom
+	for i := len(w.Layers) - 1; i >= 0; i-- {
+		if masks[i] != nil {
+			for t, row := range dh {
+				for k := range row {
+					row[k] *= masks[i][t][k]
+				}
+			}
+		}
+		dh = w.Layers[i].backward(inputs[i], acts[i], dh, &g.Layers[i], i > 0)
+	}
+	return diff * diff
+}
+
+// backward runs backpropagation through time over one layer. dh holds the
+// loss gradient of each hidden state (nil rows for none); the gradient of
+// each input row is returned when inputGrad is set.
+func (w *GRULayer) backward(inputs [][]float64, acts []gruActivations, dh [][]float64, g *GRULayer, inputGrad bool) [][]float64 {
+	n := len(w.Br)
+	var dx [][]float64
+	if inputGrad {
+		dx = make([][]float64, len(inputs))
+	}
+	zero := make([]float64, n)
+	dhNext, drh := make([]float64, n), make([]float64, n)
+	dr, dz, dn := make([]float64, n), make([]float64, n), make([]float64, n)
+
+	for t := len(inputs) - 1; t >= 0; t-- {
+		a := acts[t]
+		hPrev := zero
+		if t > 0 {
+			hPrev = acts[t-1].h
+		}
+		for k := 0; k < n; k++ {
+			dht := dhNext[k]
+			if dh[t] != nil {
+				dht += dh[t][k]
+			}
+			dz[k] = dht * (a.n[k] - hPrev[k]) * a.z[k] * (1 - a.z[k])
+			dn[k] = dht * a.z[k] * (1 - a.n[k]*a.n[k])
+			dhNext[k] = dht * (1 - a.z[k])
+			drh[k] = 0
+		}
+
+		var dxt []float64
+		if inputGrad {
+			dxt = make([]float64, len(inputs[t]))
+			dx[t] = dxt
+		}
+		// The candidate reads the reset hidden state r*h
+		accumulateGate(g.Wh, g.Uh, g.Bh, w.Wh, w.Uh, dn, inputs[t], a.rh, dxt, drh)
+		for k := 0; k < n; k++ {
+			dr[k] = drh[k] * hPrev[k] * a.r[k] * (1 - a.r[k])
+			dhNext[k] += drh[k] * a.r[k]
+		}
+		accumulateGate(g.Wz, g.Uz, g.Bz, w.Wz, w.Uz, dz, inputs[t], hPrev, dxt, dhNext)
+		accumulateGate(g.Wr, g.Ur, g.Br, w.Wr, w.Ur, dr, inputs[t], hPrev, dxt, dhNext)
+	}
+	return dx
+}
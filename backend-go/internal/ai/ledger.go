@@ -0,0 +1,664 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+// Ledger entry states
+const (
+	LedgerPending  = "pending"
+	LedgerResolved = "resolved"
+	LedgerExpired  = "expired" // no price could be found for the horizon
+)
+
+const (
+	// ledgerRetention is how long entries are kept, in memory and on disk
+	ledgerRetention = 30 * 24 * time.Hour
+	// ledgerNeutralBand is the return within which a move counts as NEUTRAL,
+	// the band the predictors use for their direction
+	ledgerNeutralBand = 0.001
+	// ledgerExpiry is how long after its horizon an entry may stay pending
+	// before it is given up
+	ledgerExpiry = 24 * time.Hour
+	// ledgerSettle is how long after the horizon the price is looked up, so
+	// that the minute candle holding it is final
+	ledgerSettle = time.Minute
+)
+
+// LedgerEntry is one forecast of a model for one horizon, and its outcome
+// once the horizon has elapsed
+type LedgerEntry struct {
+	ID             string    `json:"id"`
+	Model          string    `json:"model"`
+	Version        string    `json:"version"`
+	Symbol         string    `json:"symbol"`
+	Interval       string    `json:"interval"` // of the candles the forecast was made on
+	Horizon        string    `json:"horizon"`
+	MadeAt         time.Time `json:"made_at"`
+	DueAt          time.Time `json:"due_at"`
+	BasePrice      float64   `json:"base_price"`
+	PredictedPrice float64   `json:"predicted_price"`
+	Direction      string    `json:"direction"` // UP, DOWN or NEUTRAL, from the forecast return
+	Confidence     float64   `json:"confidence"`
+	Status         string    `json:"status"`
+
+	// Outcome, set once resolved
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	ActualPrice  float64    `json:"actual_price,omitempty"`
+	DirectionHit bool       `json:"direction_hit,omitempty"`
+	AbsError     float64    `json:"abs_error,omitempty"` // |actual - predicted| in quote currency
+	APE          float64    `json:"ape,omitempty"`       // absolute percentage error
+}
+
+// PredictedReturn is the forecast return over the horizon
+func (e *LedgerEntry) PredictedReturn() float64 {
+	return e.PredictedPrice/e.BasePrice - 1
+}
+
+// ActualReturn is the realized return over the horizon
+func (e *LedgerEntry) ActualReturn() float64 {
+	return e.ActualPrice/e.BasePrice - 1
+}
+
+// returnDirection classifies a return as UP, DOWN or NEUTRAL
+func returnDirection(r float64) string {
+	switch {
+	case r > ledgerNeutralBand:
+		return "UP"
+	case r < -ledgerNeutralBand:
+		return "DOWN"
+	default:
+		return "NEUTRAL"
+	}
+}
+
+// resolve scores the entry against the price at its horizon
+func (e *LedgerEntry) resolve(price float64, at time.Time) {
+	e.Status = LedgerResolved
+	e.ResolvedAt = &at
+	e.ActualPrice = price
+	e.DirectionHit = returnDirection(e.ActualReturn()) == e.Direction
+	e.AbsError = math.Abs(price - e.PredictedPrice)
+	e.APE = e.AbsError / price * 100
+}
+
+// PredictionLedger keeps the predictions the registered models make on live
+// market data, at most one per model version, symbol and horizon per horizon
+// period, and scores them once their horizon has elapsed. Entries are
+// appended as JSON lines to daily files in LEDGER_DIR (./ledger by default);
+// a resolved entry is written again, and the last line of an ID wins.
+type PredictionLedger struct {
+	dir     string
+	mu      sync.RWMutex
+	entries []*LedgerEntry
+	byID    map[string]*LedgerEntry
+	seen    map[string]bool // dedup keys of the kept entries
+	file    *os.File
+	day     string
+}
+
+var ledger *PredictionLedger
+var ledgerOnce sync.Once
+
+// GetLedger returns the prediction ledger, loading the entries kept on disk
+func GetLedger() *PredictionLedger {
+	ledgerOnce.Do(func() {
+		dir := os.Getenv("LEDGER_DIR")
+		if dir == "" {
+			dir = "./ledger"
+		}
+		ledger = &PredictionLedger{dir: dir, byID: make(map[string]*LedgerEntry), seen: make(map[string]bool)}
+		if err := ledger.load(); err != nil {
+			logrus.Warnf("Failed to load prediction ledger from %s: %v", dir, err)
+		}
+	})
+	return ledger
+}
+
+// dedupKey identifies the period a forecast belongs to
+func dedupKey(e *LedgerEntry, period time.Duration) string {
+	return strings.Join([]string{e.Model, e.Version, e.Symbol, e.Horizon, e.MadeAt.Truncate(period).Format(time.RFC3339)}, "|")
+}
+
+// Record adds the forecasts of a prediction made on live candles; forecasts
+// on candles that end in the past (backtests, client history) are skipped
+func (l *PredictionLedger) Record(model, version string, in Input, p *Prediction) {
+	if p == nil || in.Symbol == "" || p.CurrentPrice <= 0 || !liveInput(in) {
+		return
+	}
+	now := time.Now().UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var added []*LedgerEntry
+	for horizon, point := range p.Predictions {
+		period, err := market.IntervalDuration(horizon)
+		if err != nil || point.Price <= 0 {
+			continue
+		}
+		due := now.Add(period)
+		if point.Timestamp > now.Unix() {
+			due = time.Unix(point.Timestamp, 0).UTC()
+		}
+		confidence := point.Confidence
+		if confidence == 0 {
+			confidence = p.Confidence
+		}
+		e := &LedgerEntry{
+			ID:             uuid.NewString(),
+			Model:          model,
+			Version:        version,
+			Symbol:         in.Symbol,
+			Interval:       in.Interval,
+			Horizon:        horizon,
+			MadeAt:         now,
+			DueAt:          due,
+			BasePrice:      p.CurrentPrice,
+			PredictedPrice: point.Price,
+			Confidence:     confidence,
+			Status:         LedgerPending,
+		}
+		e.Direction = returnDirection(e.PredictedReturn())
+		key := dedupKey(e, period)
+		if l.seen[key] {
+			continue
+		}
+		l.seen[key] = true
+		l.add(e)
+		added = append(added, e)
+	}
+	l.write(added)
+}
+
+// liveInput reports whether the input holds server candles that reach the
+// present; client candles may be made up, and candles without open times
+// cannot be placed in time
+func liveInput(in Input) bool {
+	if !in.Live {
+		return false
+	}
+	n := len(in.Candles.Time)
+	if n == 0 || n != in.Candles.Len() || in.Candles.Time[n-1] == 0 {
+		return false
+	}
+	step, err := market.IntervalDuration(in.Interval)
+	if err != nil {
+		step = time.Hour
+	}
+	return time.Since(time.UnixMilli(in.Candles.Time[n-1])) <= 2*step
+}
+
+func (l *PredictionLedger) add(e *LedgerEntry) {
+	l.entries = append(l.entries, e)
+	l.byID[e.ID] = e
+}
+
+// Resolve scores the pending entries whose horizon has passed with the open
+// price of the minute candle starting at the horizon. It returns the number
+// of entries resolved.
+func (l *PredictionLedger) Resolve(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	l.mu.RLock()
+	var due []LedgerEntry
+	for _, e := range l.entries {
+		if e.Status == LedgerPending && !e.DueAt.Add(ledgerSettle).After(now) {
+			due = append(due, *e)
+		}
+	}
+	l.mu.RUnlock()
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	client := market.NewBinanceClient()
+	type priceKey struct {
+		symbol string
+		minute int64
+	}
+	prices := make(map[priceKey]float64)
+	var updates []LedgerEntry
+	var lastErr error
+	for _, e := range due {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		// The minute candle opening at or right after the horizon
+		minute := (e.DueAt.UnixMilli() + 59999) / 60000 * 60000
+		key := priceKey{e.Symbol, minute}
+		price, ok := prices[key]
+		if !ok {
+			klines, err := client.GetKlinesRange(e.Symbol, "1m", minute, minute+60000, 1)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if len(klines) > 0 && klines[0].OpenTime <= minute+60000 {
+				price = klines[0].Open
+			}
+			prices[key] = price
+		}
+		switch {
+		case price > 0:
+			e.resolve(price, now)
+		case now.Sub(e.DueAt) > ledgerExpiry:
+			e.Status = LedgerExpired
+			e.ResolvedAt = &now
+		default:
+			continue
+		}
+		updates = append(updates, e)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	written := make([]*LedgerEntry, 0, len(updates))
+	resolved := 0
+	for i := range updates {
+		if e, ok := l.byID[updates[i].ID]; ok {
+			*e = updates[i]
+			written = append(written, e)
+			if e.Status == LedgerResolved {
+				resolved++
+			}
+		}
+	}
+	l.write(written)
+	return resolved, lastErr
+}
+
+// Run resolves due entries every minute until ctx is done
+func (l *PredictionLedger) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := l.Resolve(ctx)
+			if err != nil {
+				logrus.Warnf("Prediction ledger: %v", err)
+			}
+			if n > 0 {
+				logrus.Debugf("Prediction ledger resolved %d predictions", n)
+			}
+			l.prune()
+		}
+	}
+}
+
+// prune drops entries older than the retention, in memory and on disk
+func (l *PredictionLedger) prune() {
+	cutoff := time.Now().UTC().Add(-ledgerRetention)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept := l.entries[:0]
+	for _, e := range l.entries {
+		if e.MadeAt.Before(cutoff) {
+			delete(l.byID, e.ID)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(l.entries); i++ {
+		l.entries[i] = nil
+	}
+	l.entries = kept
+	for key := range l.seen {
+		if parts := strings.Split(key, "|"); len(parts) == 5 {
+			if t, err := time.Parse(time.RFC3339, parts[4]); err == nil && t.Before(cutoff.Add(-7*24*time.Hour)) {
+				delete(l.seen, key)
+			}
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(l.dir, "predictions-*.jsonl"))
+	for _, path := range paths {
+		day, err := time.Parse("20060102", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "predictions-"), ".jsonl"))
+		if err == nil && day.Add(24*time.Hour).Before(cutoff) {
+			os.Remove(path)
+		}
+	}
+}
+
+// write appends entries to the file of the current day. Callers hold mu.
+func (l *PredictionLedger) write(entries []*LedgerEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	day := time.Now().UTC().Format("20060102")
+	if l.file == nil || l.day != day {
+		if l.file != nil {
+			l.file.Close()
+			l.file = nil
+		}
+		if err := os.MkdirAll(l.dir, 0o755); err != nil {
+			logrus.Warnf("Prediction ledger: %v", err)
+			return
+		}
+		f, err := os.OpenFile(filepath.Join(l.dir, "predictions-"+day+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			logrus.Warnf("Prediction ledger: %v", err)
+			return
+		}
+		l.file, l.day = f, day
+	}
+	var buf []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := l.file.Write(buf); err != nil {
+		logrus.Warnf("Prediction ledger: %v", err)
+	}
+}
+
+// load reads the daily files in order; later lines replace earlier ones
+func (l *PredictionLedger) load() error {
+	paths, err := filepath.Glob(filepath.Join(l.dir, "predictions-*.jsonl"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	cutoff := time.Now().UTC().Add(-ledgerRetention)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e LedgerEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.ID == "" || e.MadeAt.Before(cutoff) {
+				continue
+			}
+			if existing, ok := l.byID[e.ID]; ok {
+				*existing = e
+				continue
+			}
+			entry := e
+			l.add(&entry)
+			if period, err := market.IntervalDuration(e.Horizon); err == nil {
+				l.seen[dedupKey(&entry, period)] = true
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	sort.SliceStable(l.entries, func(i, j int) bool { return l.entries[i].MadeAt.Before(l.entries[j].MadeAt) })
+	backfillIntervals(l.entries)
+	return nil
+}
+
+// backfillIntervals sets the interval of entries written before it was
+// recorded. The entries of one prediction share model, version, symbol and
+// time; a prediction with a single horizon is a next candle forecast, whose
+// horizon is the interval. Other entries keep an empty, unknown interval.
+func backfillIntervals(entries []*LedgerEntry) {
+	predictions := make(map[string][]*LedgerEntry)
+	for _, e := range entries {
+		if e.Interval == "" {
+			key := e.Model + "|" + e.Version + "|" + e.Symbol + "|" + e.MadeAt.Format(time.RFC3339Nano)
+			predictions[key] = append(predictions[key], e)
+		}
+	}
+	for _, group := range predictions {
+		if len(group) == 1 {
+			group[0].Interval = group[0].Horizon
+		}
+	}
+}
+
+// LedgerFilter selects ledger entries; empty fields match everything. Entries
+// of an unknown interval match any Interval.
+type LedgerFilter struct {
+	Model    string
+	Version  string
+	Symbol   string
+	Interval string
+	Horizon  string
+	Status   string
+	Since    time.Time
+}
+
+func (f LedgerFilter) match(e *LedgerEntry) bool {
+	return (f.Model == "" || e.Model == f.Model) &&
+		(f.Version == "" || e.Version == f.Version) &&
+		(f.Symbol == "" || e.Symbol == f.Symbol) &&
+		(f.Interval == "" || e.Interval == "" || e.Interval == f.Interval) &&
+		(f.Horizon == "" || e.Horizon == f.Horizon) &&
+		(f.Status == "" || e.Status == f.Status) &&
+		!e.MadeAt.Before(f.Since)
+}
+
+// Entries returns up to limit matching entries, newest first
+func (l *PredictionLedger) Entries(f LedgerFilter, limit int) []LedgerEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var out []LedgerEntry
+	for i := len(l.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		if e := l.entries[i]; f.match(e) {
+			out = append(out, *e)
+		}
+	}
+	return out
+}
+
+// resolvedEntries returns copies of the matching resolved entries
+func (l *PredictionLedger) resolvedEntries(f LedgerFilter) []LedgerEntry {
+	f.Status = LedgerResolved
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var out []LedgerEntry
+	for _, e := range l.entries {
+		if f.match(e) {
+			out = append(out, *e)
+		}
+	}
+	return out
+}
+
+// LedgerScore summarizes the outcomes of a group of entries
+type LedgerScore struct {
+	Model             string  `json:"model,omitempty"`
+	Symbol            string  `json:"symbol,omitempty"`
+	Horizon           string  `json:"horizon,omitempty"`
+	Predictions       int     `json:"predictions"`
+	Resolved          int     `json:"resolved"`
+	Pending           int     `json:"pending"`
+	DirectionAccuracy float64 `json:"direction_accuracy"` // share of direction hits, 0-1
+	MAE               float64 `json:"mae"`                // in quote currency
+	MAPE              float64 `json:"mape"`               // percent
+	ReturnMAE         float64 `json:"return_mae"`         // absolute error of the forecast return
+	MeanConfidence    float64 `json:"mean_confidence"`    // 0-100
+	Brier             float64 `json:"brier"`              // of confidence as the hit probability
+	CalibrationError  float64 `json:"calibration_error"`  // expected calibration error, 0-1
+}
+
+// calibrationBins is the number of confidence bins of the calibration error
+const calibrationBins = 10
+
+// Leaderboard scores the matching entries grouped by any of model, symbol
+// and horizon, best direction accuracy first. Groups with fewer than
+// minResolved resolved entries are left out.
+func (l *PredictionLedger) Leaderboard(f LedgerFilter, groupBy []string, minResolved int) ([]LedgerScore, error) {
+	by := make(map[string]bool, len(groupBy))
+	for _, g := range groupBy {
+		if g != "model" && g != "symbol" && g != "horizon" {
+			return nil, fmt.Errorf("%w: cannot group by %q, use model, symbol or horizon", ErrInvalidInput, g)
+		}
+		by[g] = true
+	}
+
+	groups := make(map[LedgerScore][]*LedgerEntry)
+	l.mu.RLock()
+	for _, e := range l.entries {
+		if !f.match(e) || e.Status == LedgerExpired {
+			continue
+		}
+		var key LedgerScore
+		if by["model"] {
+			key.Model = e.Model
+		}
+		if by["symbol"] {
+			key.Symbol = e.Symbol
+		}
+		if by["horizon"] {
+			key.Horizon = e.Horizon
+		}
+		entry := *e
+		groups[key] = append(groups[key], &entry)
+	}
+	l.mu.RUnlock()
+
+	board := make([]LedgerScore, 0, len(groups))
+	for key, entries := range groups {
+		score := scoreEntries(entries)
+		if score.Resolved < minResolved {
+			continue
+		}
+		score.Model, score.Symbol, score.Horizon = key.Model, key.Symbol, key.Horizon
+		board = append(board, score)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		a, b := board[i], board[j]
+		if a.DirectionAccuracy != b.DirectionAccuracy {
+			return a.DirectionAccuracy > b.DirectionAccuracy
+		}
+		if a.MAPE != b.MAPE {
+			return a.MAPE < b.MAPE
+		}
+		return a.Model+a.Symbol+a.Horizon < b.Model+b.Symbol+b.Horizon
+	})
+	return board, nil
+}
+
+// scoreEntries summarizes entries; errors are averaged over resolved ones
+func scoreEntries(entries []*LedgerEntry) LedgerScore {
+	score := LedgerScore{Predictions: len(entries)}
+	var hits int
+	for _, e := range entries {
+		if e.Status != LedgerResolved {
+			score.Pending++
+			continue
+		}
+		score.Resolved++
+		if e.DirectionHit {
+			hits++
+		}
+		score.MAE += e.AbsError
+		score.MAPE += e.APE
+		score.ReturnMAE += math.Abs(e.ActualReturn() - e.PredictedReturn())
+		score.MeanConfidence += e.Confidence
+	}
+	if score.Resolved == 0 {
+		return score
+	}
+	n := float64(score.Resolved)
+	score.DirectionAccuracy = float64(hits) / n
+	score.MAE /= n
+	score.MAPE /= n
+	score.ReturnMAE /= n
+	score.MeanConfidence /= n
+	calibration := reliability(entries, calibrationBins)
+	score.Brier, score.CalibrationError = calibration.Brier, calibration.ECE
+	return score
+}
+
+// ReliabilityBin is one confidence bin of a reliability diagram
+type ReliabilityBin struct {
+	Lower          float64 `json:"lower"` // confidence range, 0-100
+	Upper          float64 `json:"upper"`
+	Count          int     `json:"count"`
+	MeanConfidence float64 `json:"mean_confidence"` // 0-100
+	HitRate        float64 `json:"hit_rate"`        // 0-1
+}
+
+// Reliability compares stated confidence with the direction hit rate
+type Reliability struct {
+	Samples int              `json:"samples"`
+	Brier   float64          `json:"brier"`
+	ECE     float64          `json:"ece"` // expected calibration error
+	Bins    []ReliabilityBin `json:"bins"`
+}
+
+// Reliability bins the resolved matching entries by confidence
+func (l *PredictionLedger) Reliability(f LedgerFilter, bins int) Reliability {
+	resolved := l.resolvedEntries(f)
+	entries := make([]*LedgerEntry, len(resolved))
+	for i := range resolved {
+		entries[i] = &resolved[i]
+	}
+	return reliability(entries, bins)
+}
+
+// reliability computes the reliability diagram of the resolved entries
+func reliability(entries []*LedgerEntry, bins int) Reliability {
+	var confidences []float64
+	var hits []bool
+	for _, e := range entries {
+		if e.Status == LedgerResolved {
+			confidences = append(confidences, e.Confidence)
+			hits = append(hits, e.DirectionHit)
+		}
+	}
+	return reliabilityOf(confidences, hits, bins)
+}
+
+// reliabilityOf computes the reliability diagram of confidences (0-100) that
+// the matching hits come true
+func reliabilityOf(confidences []float64, hits []bool, bins int) Reliability {
+	r := Reliability{Bins: make([]ReliabilityBin, bins)}
+	width := 100 / float64(bins)
+	for i := range r.Bins {
+		r.Bins[i].Lower, r.Bins[i].Upper = float64(i)*width, float64(i+1)*width
+	}
+	hitCounts := make([]float64, bins)
+	for i, confidence := range confidences {
+		p, hit := math.Max(0, math.Min(100, confidence))/100, 0.0
+		if hits[i] {
+			hit = 1
+		}
+		b := min(int(p*float64(bins)), bins-1)
+		r.Bins[b].Count++
+		r.Bins[b].MeanConfidence += p * 100
+		hitCounts[b] += hit
+		r.Brier += (p - hit) * (p - hit)
+		r.Samples++
+	}
+	if r.Samples == 0 {
+		return r
+	}
+	for i := range r.Bins {
+		bin := &r.Bins[i]
+		if bin.Count == 0 {
+			continue
+		}
+		bin.MeanConfidence /= float64(bin.Count)
+		bin.HitRate = hitCounts[i] / float64(bin.Count)
+		r.ECE += float64(bin.Count) / float64(r.Samples) * math.Abs(bin.HitRate-bin.MeanConfidence/100)
+	}
+	r.Brier /= float64(r.Samples)
+	return r
+}
@@ -1,63 +1,69 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math"
-	"math/rand"
 	"sort"
 	"sync"
-	"time"
 
 	"github.com/google/uuid"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
 	"github.com/sirupsen/logrus"
 )
 
 // LightGBMConfig defines LightGBM configuration
 type LightGBMConfig struct {
-	NumTrees       int
-	NumLeaves      int
-	MaxDepth       int
-	LearningRate   float64
-	FeatureFraction float64
-	BaggingFraction float64
-	MinDataInLeaf  int
-	Lambda         float64
+	NumTrees        int
+	NumLeaves       int
+	MaxDepth        int // -1 for no limit
+	LearningRate    float64
+	FeatureFraction float64 // share of features considered per tree
+	BaggingFraction float64 // share of rows sampled per tree
+	MinDataInLeaf   int
+	Lambda          float64 // L2 regularization of leaf values
+	LambdaL1        float64
+	MaxBin          int // histogram bins per feature
+	MinGainToSplit  float64
 }
 
-// LightGBMPredictor implements gradient boosting prediction
+// LightGBMPredictor implements gradient boosting prediction. It forecasts the
+// next candle return as BaseScore plus the sum of the tree outputs, whose
+// leaf values already include the learning rate.
 type LightGBMPredictor struct {
-	ModelID  uuid.UUID
-	Config   LightGBMConfig
-	Trees    []*Tree
-	Features []string
-	mu       sync.RWMutex
+	ModelID   uuid.UUID
+	Config    LightGBMConfig
+	Trees     []*Tree
+	Features  []string
+	BaseScore float64
+	Meta      *TrainingMeta // nil until trained
+	version   string
+	mu        sync.RWMutex
 }
 
 // Tree represents a decision tree
 type Tree struct {
-	Root           *Node
-	FeatureImportance map[string]float64
+	Root              *Node
+	FeatureImportance map[string]float64 // total split gain per feature
+	Splits            map[string]int     // split count per feature
 }
 
-// Node represents a tree node
+// Node represents a tree node; samples with Feature <= Threshold go left
 type Node struct {
-	Feature    int
-	Threshold  float64
-	Left       *Node
-	Right      *Node
-	Value      float64
-	IsLeaf     bool
-	Gain       float64
+	Feature   int
+	Threshold float64
+	Left      *Node
+	Right     *Node
+	Value     float64
+	IsLeaf    bool
+	Gain      float64
+	Cover     float64 // training samples reaching the node
 }
 
-// LightGBMResult contains prediction results
-type LightGBMResult struct {
-	Price             float64
-	Confidence        float64
-	Direction         string
-	Signal            string
-	FeatureImportance map[string]float64
-	ShapValues        map[string]float64
-}
+// lightgbmMinCandles is the shortest history Predict accepts: the full 168
+// candle lookback the model was trained with, plus the candle predicted on
+const lightgbmMinCandles = lightgbmWarmup + 1
 
 var lightgbmPredictor *LightGBMPredictor
 var lightgbmOnce sync.Once
@@ -65,179 +71,242 @@ var lightgbmOnce sync.Once
 // GetLightGBMPredictor returns singleton LightGBM predictor
 func GetLightGBMPredictor() *LightGBMPredictor {
 	lightgbmOnce.Do(func() {
-		lightgbmPredictor = &LightGBMPredictor{
-			ModelID: uuid.New(),
-			Config: LightGBMConfig{
-				NumTrees:        100,
-				NumLeaves:       31,
-				MaxDepth:        -1,
-				LearningRate:    0.05,
-				FeatureFraction: 0.9,
-				BaggingFraction: 0.8,
-				MinDataInLeaf:   20,
-				Lambda:         0.0,
-			},
-			Trees: make([]*Tree, 0),
-		}
-		lightgbmPredictor.initialize()
+		lightgbmPredictor = newLightGBMPredictor()
 		logrus.Info("LightGBM predictor initialized")
 	})
 	return lightgbmPredictor
 }
 
-// initialize sets up the LightGBM model
-func (lg *LightGBMPredictor) initialize() {
-	rand.Seed(time.Now().UnixNano())
-
-	// Define feature names
-	lg.Features = []string{
-		"price_change_1h", "price_change_24h", "price_change_7d",
-		"volume_ratio", "rsi", "macd", "bollinger_position",
-		"sma_7", "sma_30", "ema_12", "ema_26",
-		"volatility", "momentum", "support_distance", "resistance_distance",
+// newLightGBMPredictor returns an untrained predictor with the default config
+func newLightGBMPredictor() *LightGBMPredictor {
+	return &LightGBMPredictor{
+		ModelID: uuid.New(),
+		version: "1.0.0",
+		Config: LightGBMConfig{
+			NumTrees:        100,
+			NumLeaves:       31,
+			MaxDepth:        -1,
+			LearningRate:    0.05,
+			FeatureFraction: 0.9,
+			BaggingFraction: 0.8,
+			MinDataInLeaf:   20,
+			Lambda:          0.0,
+			MaxBin:          255,
+		},
+		Trees:    make([]*Tree, 0),
+		Features: lightgbmFeatureNames,
 	}
+}
 
-	// Initialize trees with random parameters for demo
-	for i := 0; i < lg.Config.NumTrees; i++ {
-		tree := lg.buildRandomTree(5)
-		lg.Trees = append(lg.Trees, tree)
-	}
+// lightgbmFeatureNames are the columns built by lightgbmFeatures
+var lightgbmFeatureNames = []string{
+	"price_change_1h", "price_change_24h", "price_change_7d",
+	"volume_ratio", "rsi", "macd", "macd_signal", "bollinger_position",
+	"sma_7", "sma_30", "ema_12", "ema_26",
+	"volatility", "momentum", "support_distance", "resistance_distance",
 }
 
-// buildRandomTree creates a random decision tree
-func (lg *LightGBMPredictor) buildRandomTree(maxDepth int) *Tree {
-	tree := &Tree{
-		FeatureImportance: make(map[string]float64),
-	}
+// Name implements Predictor
+func (lg *LightGBMPredictor) Name() string { return "lightgbm" }
 
-	tree.Root = lg.buildNode(0, maxDepth)
+// Version implements Predictor
+func (lg *LightGBMPredictor) Version() string {
+	lg.mu.RLock()
+	defer lg.mu.RUnlock()
+	return lg.version
+}
 
-	// Calculate feature importance
-	for _, feature := range lg.Features {
-		tree.FeatureImportance[feature] = rand.Float64()
-	}
+// lightgbmState is the serialized form of the boosted trees
+type lightgbmState struct {
+	Config    LightGBMConfig `json:"config"`
+	Features  []string       `json:"features"`
+	Trees     []*Tree        `json:"trees"`
+	BaseScore float64        `json:"base_score"`
+	Meta      *TrainingMeta  `json:"meta"`
+}
 
-	return tree
+// MarshalState implements Persistent
+func (lg *LightGBMPredictor) MarshalState() (json.RawMessage, error) {
+	lg.mu.RLock()
+	defer lg.mu.RUnlock()
+	if lg.Meta == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotTrained, lg.Name())
+	}
+	return json.Marshal(lightgbmState{Config: lg.Config, Features: lg.Features, Trees: lg.Trees, BaseScore: lg.BaseScore, Meta: lg.Meta})
 }
 
-// buildNode recursively builds tree nodes
-func (lg *LightGBMPredictor) buildNode(depth, maxDepth int) *Node {
-	if depth >= maxDepth || rand.Float64() < 0.3 {
-		// Leaf node
-		return &Node{
-			IsLeaf: true,
-			Value:  rand.NormFloat64() * 0.01, // Small prediction value
+// RestoreState implements Persistent; the feature names must match the
+// columns built by lightgbmFeatures. States saved before training existed
+// hold untrained trees and are rejected with ErrModelNotTrained.
+func (lg *LightGBMPredictor) RestoreState(version string, data json.RawMessage) error {
+	var state lightgbmState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Meta == nil {
+		return fmt.Errorf("%w: %s version %s has no training metadata", ErrModelNotTrained, lg.Name(), version)
+	}
+	if len(state.Trees) == 0 {
+		return fmt.Errorf("%w: LightGBM state has no trees", ErrInvalidInput)
+	}
+	for i, tree := range state.Trees {
+		if tree == nil || !validNode(tree.Root, len(state.Features)) {
+			return fmt.Errorf("%w: LightGBM tree %d is malformed", ErrInvalidInput, i)
 		}
 	}
+	if err := checkFinite("base_score", []float64{state.BaseScore}); err != nil {
+		return err
+	}
+
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	if !sameStrings(state.Features, lg.Features) {
+		return fmt.Errorf("%w: LightGBM features %v, want %v", ErrInvalidInput, state.Features, lg.Features)
+	}
+	lg.Config, lg.Trees, lg.BaseScore, lg.Meta, lg.version = state.Config, state.Trees, state.BaseScore, state.Meta, version
+	return nil
+}
 
-	// Internal node
-	return &Node{
-		Feature:   rand.Intn(len(lg.Features)),
-		Threshold: rand.NormFloat64(),
-		Gain:      rand.Float64(),
-		Left:      lg.buildNode(depth+1, maxDepth),
-		Right:     lg.buildNode(depth+1, maxDepth),
+// validNode checks that every split has both children and a known feature
+func validNode(node *Node, features int) bool {
+	if node == nil {
+		return false
 	}
+	if node.IsLeaf {
+		return true
+	}
+	return node.Feature >= 0 && node.Feature < features &&
+		validNode(node.Left, features) && validNode(node.Right, features)
 }
 
 // Predict generates LightGBM prediction
-func (lg *LightGBMPredictor) Predict(symbol string, historical []float64, features map[string]interface{}) LightGBMResult {
+func (lg *LightGBMPredictor) Predict(ctx context.Context, in Input) (*Prediction, error) {
 	lg.mu.RLock()
 	defer lg.mu.RUnlock()
+	if err := lg.Meta.check(lg.Name(), in, lightgbmMinCandles); err != nil {
+		return nil, err
+	}
 
 	// Prepare features
-	featureVector := lg.prepareFeatures(historical, features)
-
-	// Ensemble prediction from all trees
-	predictions := make([]float64, len(lg.Trees))
-	for i, tree := range lg.Trees {
-		predictions[i] = lg.predictTree(tree, featureVector)
-	}
+	featureVector := lg.prepareFeatures(in.Candles, in.Features)
 
-	// Weighted average with learning rate
-	finalPrediction := 0.0
-	for i, pred := range predictions {
-		weight := math.Pow(1-lg.Config.LearningRate, float64(len(predictions)-i-1))
-		finalPrediction += pred * weight
-	}
+	// Boosted trees add up to the forecast return
+	predictedReturn := lg.predictRaw(featureVector)
 
 	// Calculate metrics
+	historical := in.Closes()
 	currentPrice := historical[len(historical)-1]
-	predictedPrice := currentPrice * (1 + finalPrediction)
+	predictedPrice := currentPrice * (1 + predictedReturn)
 
 	// Determine direction
 	direction := "NEUTRAL"
-	if finalPrediction > 0.005 {
+	if predictedReturn > 0.001 {
 		direction = "UP"
-	} else if finalPrediction < -0.005 {
+	} else if predictedReturn < -0.001 {
 		direction = "DOWN"
 	}
 
-	// Calculate confidence based on tree agreement
-	confidence := lg.calculateConfidence(predictions)
+	// Confidence from the validation accuracy of the trained trees
+	confidence := lg.Meta.confidence(predictedReturn)
 
-	// Calculate feature importance
-	featureImportance := lg.calculateFeatureImportance()
+	// Feature importance and SHAP values are reported as prefixed factors
+	factors := map[string]float64{"raw_prediction": predictedReturn, "base_score": lg.BaseScore}
+	for name, value := range lg.calculateFeatureImportance() {
+		factors["importance."+name] = value
+	}
+	for name, value := range lg.calculateShapValues(featureVector) {
+		factors["shap."+name] = value
+	}
 
-	// Calculate SHAP values
-	shapValues := lg.calculateShapValues(featureVector)
+	return stepPrediction("lightgbm", in, predictedPrice, confidence, direction, lg.generateSignal(direction, confidence), factors), nil
+}
 
-	return LightGBMResult{
-		Price:             predictedPrice,
-		Confidence:        confidence,
-		Direction:         direction,
-		Signal:            lg.generateSignal(direction, confidence),
-		FeatureImportance: featureImportance,
-		ShapValues:        shapValues,
+// predictRaw returns the forecast return for one feature vector
+func (lg *LightGBMPredictor) predictRaw(features []float64) float64 {
+	sum := lg.BaseScore
+	for _, tree := range lg.Trees {
+		sum += tree.predict(features)
 	}
+	return sum
 }
 
-// prepareFeatures converts raw data to feature vector
-func (lg *LightGBMPredictor) prepareFeatures(historical []float64, features map[string]interface{}) []float64 {
-	featureVector := make([]float64, len(lg.Features))
-
-	if len(historical) < 2 {
-		return featureVector
+// prepareFeatures returns the feature vector of the last candle. Candles
+// without volume (close only input) take the volume ratio from the request
+// features instead.
+func (lg *LightGBMPredictor) prepareFeatures(candles indicators.OHLCV, features map[string]interface{}) []float64 {
+	rows := lightgbmFeatures(candles)
+	featureVector := rows[len(rows)-1]
+	if candles.Volume[candles.Len()-1] == 0 {
+		featureVector[3] = lg.calculateVolumeRatio(features)
 	}
+	return featureVector
+}
 
-	// Price changes
-	currentPrice := historical[len(historical)-1]
-	if len(historical) > 1 {
-		featureVector[0] = (currentPrice - historical[len(historical)-2]) / historical[len(historical)-2]
-	}
-	if len(historical) > 24 {
-		featureVector[1] = (currentPrice - historical[len(historical)-24]) / historical[len(historical)-24]
-	}
-	if len(historical) > 168 {
-		featureVector[2] = (currentPrice - historical[len(historical)-168]) / historical[len(historical)-168]
-	}
-
-	// Technical indicators
-	featureVector[3] = lg.calculateVolumeRatio(features)
-	featureVector[4] = lg.calculateRSI(historical)
-	featureVector[5], featureVector[6] = lg.calculateMACD(historical)
-	featureVector[7] = lg.calculateBollingerPosition(historical)
-
-	// Moving averages
-	featureVector[8] = lg.sma(historical, 7) / currentPrice
-	featureVector[9] = lg.sma(historical, 30) / currentPrice
-	featureVector[10] = lg.ema(historical, 12) / currentPrice
-	featureVector[11] = lg.ema(historical, 26) / currentPrice
+// lightgbmFeatures builds the feature vector of every candle, in the order of
+// lightgbmFeatureNames. Training and prediction share it so the trees see
+// the same inputs they were fitted on.
+func lightgbmFeatures(c indicators.OHLCV) [][]float64 {
+	closes := c.Close
+	n := len(closes)
+
+	rsi := indicators.RSI(closes, 14)
+	macd, signal, _ := indicators.MACD(closes, 12, 26, 9)
+	percentB := indicators.PercentB(closes, 20, 2.0)
+	sma7, sma30 := indicators.SMA(closes, 7), indicators.SMA(closes, 30)
+	ema12, ema26 := indicators.EMA(closes, 12), indicators.EMA(closes, 26)
+	avgVolume := indicators.SMA(c.Volume, 20)
+
+	logReturns := make([]float64, n)
+	for i := 1; i < n; i++ {
+		logReturns[i] = math.Log(closes[i] / closes[i-1])
+	}
+	volatility := indicators.StdDev(logReturns, 20)
+
+	rows := make([][]float64, n)
+	for i, price := range closes {
+		row := make([]float64, len(lightgbmFeatureNames))
+
+		// Price changes over 1, 24 and 168 candles
+		for k, lag := range []int{1, 24, 168} {
+			if i >= lag {
+				row[k] = (price - closes[i-lag]) / closes[i-lag]
+			}
+		}
 
-	// Volatility and momentum
-	featureVector[12] = lg.calculateVolatility(historical)
-	featureVector[13] = lg.calculateMomentum(historical)
+		// Technical indicators
+		row[3] = 1.0
+		if avg := avgVolume.At(i, 0); avg > 0 {
+			row[3] = c.Volume[i] / avg
+		}
+		row[4] = rsi.At(i, 50)
+		row[5] = macd.At(i, 0) / price
+		row[6] = signal.At(i, 0) / price
+		row[7] = percentB.At(i, 0.5)
+
+		// Moving averages (current price until the window fills)
+		row[8] = sma7.At(i, price) / price
+		row[9] = sma30.At(i, price) / price
+		row[10] = ema12.At(i, price) / price
+		row[11] = ema26.At(i, price) / price
+
+		// Volatility and momentum
+		row[12] = volatility.At(i, 0)
+		if i >= 10 {
+			row[13] = (price - closes[i-10]) / closes[i-10]
+		}
 
-	// Support and resistance
-	support, resistance := lg.calculateSupportResistance(historical)
-	featureVector[14] = (currentPrice - support) / currentPrice
-	featureVector[15] = (resistance - currentPrice) / currentPrice
+		// Support and resistance
+		support, resistance := supportResistance(closes[:i+1])
+		row[14] = (price - support) / price
+		row[15] = (resistance - price) / price
 
-	return featureVector
+		rows[i] = row
+	}
+	return rows
 }
 
-// predictTree makes prediction using a single tree
-func (lg *LightGBMPredictor) predictTree(tree *Tree, features []float64) float64 {
+// predict returns the leaf value the features fall into
+func (tree *Tree) predict(features []float64) float64 {
 	node := tree.Root
 
 	for !node.IsLeaf {
@@ -255,68 +324,123 @@ func (lg *LightGBMPredictor) predictTree(tree *Tree, features []float64) float64
 	return node.Value
 }
 
-// calculateConfidence estimates prediction confidence
-func (lg *LightGBMPredictor) calculateConfidence(predictions []float64) float64 {
-	// Calculate standard deviation of predictions
-	mean := 0.0
-	for _, pred := range predictions {
-		mean += pred
-	}
-	mean /= float64(len(predictions))
+// calculateFeatureImportance returns each feature's share of the split gain
+// across all trees
+func (lg *LightGBMPredictor) calculateFeatureImportance() map[string]float64 {
+	gain, _ := treeImportance(lg.Trees)
+	return gain
+}
 
-	variance := 0.0
-	for _, pred := range predictions {
-		variance += math.Pow(pred-mean, 2)
+// calculateShapValues attributes the forecast to the features with exact
+// path-dependent TreeSHAP: per feature, the values sum to the forecast minus
+// the cover weighted average output of the trees
+func (lg *LightGBMPredictor) calculateShapValues(features []float64) map[string]float64 {
+	phi := make([]float64, len(lg.Features))
+	for _, tree := range lg.Trees {
+		treeShap(tree.Root, features, phi)
 	}
-	stdDev := math.Sqrt(variance / float64(len(predictions)))
 
-	// Lower std dev = higher confidence
-	confidence := math.Max(0, math.Min(100, 100-stdDev*1000))
-
-	// Add base confidence
-	confidence = confidence*0.7 + 30
+	shapValues := make(map[string]float64, len(phi))
+	for i, feature := range lg.Features {
+		shapValues[feature] = phi[i]
+	}
+	return shapValues
+}
 
-	return confidence
+// shapStep is one split on the path to a node: the feature, the share of
+// cover that follows the path (zero) and whether the explained sample
+// follows it (one), and the permutation weight
+type shapStep struct {
+	feature   int
+	zero, one float64
+	weight    float64
 }
 
-// calculateFeatureImportance aggregates importance across all trees
-func (lg *LightGBMPredictor) calculateFeatureImportance() map[string]float64 {
-	importance := make(map[string]float64)
+// treeShap adds the SHAP values of x in one tree to phi (Lundberg et al.,
+// "Consistent Individualized Feature Attribution for Tree Ensembles",
+// algorithm 2). Nodes without cover contribute nothing.
+func treeShap(root *Node, x []float64, phi []float64) {
+	if root == nil || root.Cover <= 0 {
+		return
+	}
+	shapRecurse(root, x, phi, nil, 1, 1, -1)
+}
 
-	for _, tree := range lg.Trees {
-		for feature, imp := range tree.FeatureImportance {
-			importance[feature] += imp
+func shapRecurse(node *Node, x []float64, phi []float64, path []shapStep, zero, one float64, feature int) {
+	path = shapExtend(path, zero, one, feature)
+	if node.IsLeaf {
+		for i := 1; i < len(path); i++ {
+			w := shapUnwoundSum(path, i)
+			phi[path[i].feature] += w * (path[i].one - path[i].zero) * node.Value
 		}
+		return
 	}
 
-	// Normalize
-	total := 0.0
-	for _, imp := range importance {
-		total += imp
+	hot, cold := node.Right, node.Left
+	if node.Feature < len(x) && x[node.Feature] <= node.Threshold {
+		hot, cold = node.Left, node.Right
 	}
-
-	if total > 0 {
-		for feature := range importance {
-			importance[feature] /= total
+	incomingZero, incomingOne := 1.0, 1.0
+	for k := 1; k < len(path); k++ {
+		if path[k].feature == node.Feature {
+			incomingZero, incomingOne = path[k].zero, path[k].one
+			path = shapUnwind(path, k)
+			break
 		}
 	}
-
-	return importance
+	if hot.Cover > 0 {
+		shapRecurse(hot, x, phi, path, incomingZero*hot.Cover/node.Cover, incomingOne, node.Feature)
+	}
+	if cold.Cover > 0 {
+		shapRecurse(cold, x, phi, path, incomingZero*cold.Cover/node.Cover, 0, node.Feature)
+	}
 }
 
-// calculateShapValues calculates SHAP values for interpretability
-func (lg *LightGBMPredictor) calculateShapValues(features []float64) map[string]float64 {
-	shapValues := make(map[string]float64)
+// shapExtend returns a copy of path grown by one split
+func shapExtend(path []shapStep, zero, one float64, feature int) []shapStep {
+	l := len(path)
+	next := make([]shapStep, l+1)
+	copy(next, path)
+	next[l] = shapStep{feature: feature, zero: zero, one: one}
+	if l == 0 {
+		next[l].weight = 1
+	}
+	for i := l - 1; i >= 0; i-- {
+		next[i+1].weight += one * next[i].weight * float64(i+1) / float64(l+1)
+		next[i].weight = zero * next[i].weight * float64(l-i) / float64(l+1)
+	}
+	return next
+}
 
-	// Simplified SHAP calculation
-	for i, feature := range lg.Features {
-		if i < len(features) {
-			// Impact of this feature on prediction
-			shapValues[feature] = features[i] * rand.Float64() * 0.1
+// shapUnwind returns a copy of path without split i, undoing shapExtend
+func shapUnwind(path []shapStep, i int) []shapStep {
+	l := len(path) - 1
+	next := make([]shapStep, len(path))
+	copy(next, path)
+	one, zero := path[i].one, path[i].zero
+	n := next[l].weight
+	for j := l - 1; j >= 0; j-- {
+		if one != 0 {
+			t := next[j].weight
+			next[j].weight = n * float64(l+1) / (float64(j+1) * one)
+			n = t - next[j].weight*zero*float64(l-j)/float64(l+1)
+		} else {
+			next[j].weight = next[j].weight * float64(l+1) / (zero * float64(l-j))
 		}
 	}
+	for j := i; j < l; j++ {
+		next[j].feature, next[j].zero, next[j].one = next[j+1].feature, next[j+1].zero, next[j+1].one
+	}
+	return next[:l]
+}
 
-	return shapValues
+// shapUnwoundSum is the total weight of path without split i
+func shapUnwoundSum(path []shapStep, i int) float64 {
+	sum := 0.0
+	for _, step := range shapUnwind(path, i) {
+		sum += step.weight
+	}
+	return sum
 }
 
 // generateSignal creates trading signal
@@ -349,116 +473,19 @@ func (lg *LightGBMPredictor) calculateVolumeRatio(features map[string]interface{
 			return vol / avgVol
 		}
 	}
-	return 1.0
-}
-
-func (lg *LightGBMPredictor) calculateRSI(prices []float64) float64 {
-	period := 14
-	if len(prices) < period+1 {
-		return 50
-	}
-
-	gains := 0.0
-	losses := 0.0
-
-	for i := len(prices) - period; i < len(prices); i++ {
-		change := prices[i] - prices[i-1]
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
-		}
-	}
-
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-
-	if avgLoss == 0 {
-		return 100
-	}
-
-	rs := avgGain / avgLoss
-	return 100 - (100 / (1 + rs))
-}
-
-func (lg *LightGBMPredictor) calculateMACD(prices []float64) (float64, float64) {
-	if len(prices) < 26 {
-		return 0, 0
-	}
-
-	ema12 := lg.ema(prices, 12)
-	ema26 := lg.ema(prices, 26)
-	macdLine := ema12 - ema26
-
-	signalLine := macdLine * 0.9 // Simplified
-
-	return macdLine / prices[len(prices)-1], signalLine / prices[len(prices)-1]
-}
-
-func (lg *LightGBMPredictor) calculateBollingerPosition(prices []float64) float64 {
-	period := 20
-	if len(prices) < period {
-		return 0
-	}
-
-	sma := lg.sma(prices, period)
-
-	variance := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		variance += math.Pow(prices[i]-sma, 2)
-	}
-	stdDev := math.Sqrt(variance / float64(period))
-
-	currentPrice := prices[len(prices)-1]
-	upper := sma + 2*stdDev
-	lower := sma - 2*stdDev
-
-	if upper-lower == 0 {
-		return 0
-	}
-
-	return (currentPrice - lower) / (upper - lower)
-}
-
-func (lg *LightGBMPredictor) calculateVolatility(prices []float64) float64 {
-	if len(prices) < 2 {
-		return 0
-	}
-
-	returns := make([]float64, len(prices)-1)
-	for i := 1; i < len(prices); i++ {
-		returns[i-1] = math.Log(prices[i] / prices[i-1])
-	}
-
-	mean := 0.0
-	for _, r := range returns {
-		mean += r
-	}
-	mean /= float64(len(returns))
-
-	variance := 0.0
-	for _, r := range returns {
-		variance += math.Pow(r-mean, 2)
-	}
-	variance /= float64(len(returns))
-
-	return math.Sqrt(variance * 252) // Annualized
-}
-
-func (lg *LightGBMPredictor) calculateMomentum(prices []float64) float64 {
-	if len(prices) < 10 {
-		return 0
+	// Feature store value when the client sent no volume
+	if ratio, ok := featureValue(features, "volume.volume_ratio_20"); ok {
+		return ratio
 	}
-
-	currentPrice := prices[len(prices)-1]
-	pastPrice := prices[len(prices)-10]
-
-	return (currentPrice - pastPrice) / pastPrice
+	return 1.0
 }
 
-func (lg *LightGBMPredictor) calculateSupportResistance(prices []float64) (float64, float64) {
+// supportResistance returns the 25th and 75th percentile of the last 20
+// prices, or the latest price while fewer are known
+func supportResistance(prices []float64) (float64, float64) {
 	if len(prices) < 20 {
-		return prices[0], prices[0]
+		last := prices[len(prices)-1]
+		return last, last
 	}
 
 	// Find recent lows (support) and highs (resistance)
@@ -468,35 +495,8 @@ func (lg *LightGBMPredictor) calculateSupportResistance(prices []float64) (float
 	copy(sorted, recentPrices)
 	sort.Float64s(sorted)
 
-	support := sorted[len(sorted)/4]     // 25th percentile
+	support := sorted[len(sorted)/4]      // 25th percentile
 	resistance := sorted[3*len(sorted)/4] // 75th percentile
 
 	return support, resistance
 }
-
-func (lg *LightGBMPredictor) sma(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return prices[len(prices)-1]
-	}
-
-	sum := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		sum += prices[i]
-	}
-	return sum / float64(period)
-}
-
-func (lg *LightGBMPredictor) ema(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return prices[len(prices)-1]
-	}
-
-	multiplier := 2.0 / float64(period+1)
-	ema := prices[len(prices)-period]
-
-	for i := len(prices) - period + 1; i < len(prices); i++ {
-		ema = (prices[i]-ema)*multiplier + ema
-	}
-
-	return ema
-}
\ No newline at end of file
@@ -0,0 +1,495 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// lightgbmWarmup skips the leading candles whose feature rows lack the
+	// full 168 candle lookback
+	lightgbmWarmup = 168
+	// lightgbmPatience is the default number of boosting rounds without
+	// validation improvement before training stops
+	lightgbmPatience = 20
+)
+
+// Train implements Trainer: it fits histogram based gradient boosted trees on
+// next-candle returns, growing each tree leaf-wise, then swaps them in.
+// Epochs is the maximum number of boosting rounds. Params may set
+// num_leaves, max_depth, min_data_in_leaf, feature_fraction,
+// bagging_fraction, lambda_l1, lambda_l2, max_bin and min_gain_to_split.
+func (lg *LightGBMPredictor) Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	lg.mu.RLock()
+	config := lg.Config
+	lg.mu.RUnlock()
+
+	if err := lightgbmParams(cfg, &config); err != nil {
+		return nil, err
+	}
+	if cfg.Epochs == 0 {
+		cfg.Epochs = config.NumTrees
+	}
+	if cfg.Patience == 0 {
+		cfg.Patience = lightgbmPatience
+	}
+	cfg = cfg.withDefaults(1, config.LearningRate)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	config.LearningRate = cfg.LearningRate
+
+	result, err := fitLightGBM(ctx, lg.Name(), data, cfg, config)
+	if err != nil {
+		return nil, err
+	}
+	config.NumTrees = len(result.trees)
+
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.Config, lg.Trees, lg.BaseScore, lg.Meta = config, result.trees, result.baseScore, result.meta
+	lg.version = NewModelVersion()
+	result.report.Version = lg.version
+	return result.report, nil
+}
+
+// lightgbmParams applies the tree hyperparameters in cfg.Params to c
+func lightgbmParams(cfg TrainConfig, c *LightGBMConfig) error {
+	var err error
+	if c.NumLeaves, err = cfg.intParam("num_leaves", c.NumLeaves, 2, 1024); err != nil {
+		return err
+	}
+	if c.MaxDepth, err = cfg.intParam("max_depth", c.MaxDepth, -1, 64); err != nil {
+		return err
+	}
+	if c.MinDataInLeaf, err = cfg.intParam("min_data_in_leaf", c.MinDataInLeaf, 1, 10000); err != nil {
+		return err
+	}
+	if c.MaxBin, err = cfg.intParam("max_bin", max(c.MaxBin, 2), 2, 256); err != nil {
+		return err
+	}
+	if c.FeatureFraction, err = cfg.floatParam("feature_fraction", c.FeatureFraction, 0.01, 1); err != nil {
+		return err
+	}
+	if c.BaggingFraction, err = cfg.floatParam("bagging_fraction", c.BaggingFraction, 0.01, 1); err != nil {
+		return err
+	}
+	if c.LambdaL1, err = cfg.floatParam("lambda_l1", c.LambdaL1, 0, 1e6); err != nil {
+		return err
+	}
+	if c.Lambda, err = cfg.floatParam("lambda_l2", c.Lambda, 0, 1e6); err != nil {
+		return err
+	}
+	c.MinGainToSplit, err = cfg.floatParam("min_gain_to_split", c.MinGainToSplit, 0, math.MaxFloat64)
+	return err
+}
+
+// lightgbmTraining is the result of fitLightGBM
+type lightgbmTraining struct {
+	trees     []*Tree
+	baseScore float64
+	meta      *TrainingMeta
+	report    *TrainingReport
+}
+
+// fitLightGBM boosts trees on the feature rows of data. The most recent
+// cfg.ValidationSplit of the samples is held out; boosting stops when the
+// validation loss has not improved for cfg.Patience rounds, keeping the trees
+// up to the best round.
+func fitLightGBM(ctx context.Context, model string, data TrainingData, cfg TrainConfig, config LightGBMConfig) (*lightgbmTraining, error) {
+	start := time.Now()
+	closes := data.Candles.Close
+	samples := len(closes) - 1 - lightgbmWarmup
+	if samples < 100 {
+		return nil, fmt.Errorf("%w: %d candles give %d training samples, need 100", ErrInsufficientData, len(closes), max(samples, 0))
+	}
+	valSamples := max(int(float64(samples)*cfg.ValidationSplit), 10)
+	trainSamples := samples - valSamples
+
+	rows := lightgbmFeatures(data.Candles)[lightgbmWarmup : len(closes)-1]
+	targets := make([]float64, samples)
+	for i := range targets {
+		c := lightgbmWarmup + i
+		targets[i] = closes[c+1]/closes[c] - 1
+	}
+	trainX, trainY := rows[:trainSamples], targets[:trainSamples]
+	valX, valY := rows[trainSamples:], targets[trainSamples:]
+
+	b := newBooster(trainX, config)
+	baseScore := 0.0
+	for _, y := range trainY {
+		baseScore += y
+	}
+	baseScore /= float64(trainSamples)
+
+	trainPred := filled(trainSamples, baseScore)
+	valPred := filled(valSamples, baseScore)
+	grad, hess := make([]float64, trainSamples), filled(trainSamples, 1)
+
+	report := &TrainingReport{
+		Model:        model,
+		Symbol:       data.Symbol,
+		Interval:     data.Interval,
+		Candles:      len(closes),
+		TrainSamples: trainSamples,
+		ValSamples:   valSamples,
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var trees []*Tree
+	bestLoss, stale := math.Inf(1), 0
+	for round := 1; round <= cfg.Epochs; round++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Squared error: the gradient is the residual, the hessian is 1
+		for i, p := range trainPred {
+			grad[i] = p - trainY[i]
+		}
+		tree := b.growTree(grad, hess, sampleIndices(rng, trainSamples, config.BaggingFraction), sampleIndices(rng, len(lightgbmFeatureNames), config.FeatureFraction))
+		trees = append(trees, tree)
+		for i, x := range trainX {
+			trainPred[i] += tree.predict(x)
+		}
+		for i, x := range valX {
+			valPred[i] += tree.predict(x)
+		}
+
+		trainLoss, valLoss := meanSquaredError(trainPred, trainY), meanSquaredError(valPred, valY)
+		if math.IsNaN(trainLoss) || math.IsNaN(valLoss) {
+			return nil, fmt.Errorf("training %s diverged at round %d", model, round)
+		}
+		report.TrainLoss = append(report.TrainLoss, trainLoss)
+		report.ValLoss = append(report.ValLoss, valLoss)
+		report.Epochs = round
+
+		if valLoss < bestLoss {
+			bestLoss, stale = valLoss, 0
+			report.BestEpoch = round
+			continue
+		}
+		if stale++; stale >= cfg.Patience {
+			report.StoppedEarly = round < cfg.Epochs
+			break
+		}
+	}
+	trees = trees[:report.BestEpoch]
+
+	for i, x := range valX {
+		valPred[i] = baseScore
+		for _, tree := range trees {
+			valPred[i] += tree.predict(x)
+		}
+	}
+	metrics := regressionMetrics("val_", valPred, valY)
+	metrics["train_mse"] = report.TrainLoss[report.BestEpoch-1]
+	metrics["rounds"] = float64(report.Epochs)
+	metrics["trees"] = float64(len(trees))
+	gain, splits := treeImportance(trees)
+	for name, value := range gain {
+		metrics["importance_gain."+name] = value
+	}
+	for name, count := range splits {
+		metrics["importance_split."+name] = float64(count)
+	}
+
+	meta := &TrainingMeta{Symbol: data.Symbol, Interval: data.Interval}
+	meta.setValidation(metrics)
+	report.Metrics = metrics
+	report.Duration = time.Since(start).Seconds()
+	return &lightgbmTraining{trees: trees, baseScore: baseScore, meta: meta, report: report}, nil
+}
+
+// treeImportance sums the split gain (normalized to shares) and split count
+// of each feature over the trees
+func treeImportance(trees []*Tree) (map[string]float64, map[string]int) {
+	gain, splits := make(map[string]float64), make(map[string]int)
+	total := 0.0
+	for _, tree := range trees {
+		for name, g := range tree.FeatureImportance {
+			gain[name] += g
+			total += g
+		}
+		for name, n := range tree.Splits {
+			splits[name] += n
+		}
+	}
+	if total > 0 {
+		for name := range gain {
+			gain[name] /= total
+		}
+	}
+	return gain, splits
+}
+
+// booster grows regression trees on binned training rows
+type booster struct {
+	config LightGBMConfig
+	x      [][]float64
+	bins   [][]uint8   // per feature, the bin of every row
+	cuts   [][]float64 // per feature, the upper bound of every bin but the last
+}
+
+// newBooster bins every feature of x into at most config.MaxBin bins of
+// roughly equal population
+func newBooster(x [][]float64, config LightGBMConfig) *booster {
+	b := &booster{config: config, x: x}
+	values := make([]float64, len(x))
+	for f := range lightgbmFeatureNames {
+		for i, row := range x {
+			values[i] = row[f]
+		}
+		cuts := binCuts(values, config.MaxBin)
+		bins := make([]uint8, len(x))
+		for i, v := range values {
+			bins[i] = uint8(sort.SearchFloat64s(cuts, v))
+		}
+		b.cuts = append(b.cuts, cuts)
+		b.bins = append(b.bins, bins)
+	}
+	return b
+}
+
+// binCuts returns increasing cut points between quantiles of values; a value
+// v falls into the first bin whose cut is >= v, NaN into the last bin
+func binCuts(values []float64, maxBin int) []float64 {
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sorted = append(sorted, v)
+		}
+	}
+	sort.Float64s(sorted)
+
+	var cuts []float64
+	for k := 1; k < maxBin; k++ {
+		i := k * len(sorted) / maxBin
+		if i <= 0 || i >= len(sorted) || sorted[i-1] == sorted[i] {
+			continue
+		}
+		cut := (sorted[i-1] + sorted[i]) / 2
+		if len(cuts) == 0 || cut > cuts[len(cuts)-1] {
+			cuts = append(cuts, cut)
+		}
+	}
+	return cuts
+}
+
+// histBin accumulates the gradients of the rows in one bin
+type histBin struct {
+	grad, hess float64
+	count      int
+}
+
+// boostLeaf is a leaf of the tree being grown, with its rows, histograms and
+// best split
+type boostLeaf struct {
+	node       *Node
+	rows       []int
+	depth      int
+	hist       [][]histBin // per feature; nil for features not sampled
+	grad, hess float64
+	split      boostSplit
+}
+
+// boostSplit is a candidate split of a leaf: rows in bins <= bin go left
+type boostSplit struct {
+	feature, bin int
+	gain         float64
+	ok           bool
+}
+
+// growTree grows one tree leaf-wise on rows with the given features: the leaf
+// whose best split gains most is split next until NumLeaves is reached or no
+// split passes the constraints. Leaf values include the learning rate.
+func (b *booster) growTree(grad, hess []float64, rows, features []int) *Tree {
+	tree := &Tree{FeatureImportance: make(map[string]float64), Splits: make(map[string]int)}
+	root := &boostLeaf{node: &Node{}, rows: rows}
+	root.hist = b.histogram(root.rows, features, grad, hess)
+	root.grad, root.hess = sumGradients(root.rows, grad, hess)
+	b.findSplit(root, features)
+
+	leaves := []*boostLeaf{root}
+	for len(leaves) < b.config.NumLeaves {
+		best := -1
+		for i, leaf := range leaves {
+			if leaf.split.ok && (best < 0 || leaf.split.gain > leaves[best].split.gain) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		left, right := b.splitLeaf(tree, leaves[best], features, grad, hess)
+		leaves[best] = left
+		leaves = append(leaves, right)
+	}
+
+	for _, leaf := range leaves {
+		leaf.node.IsLeaf = true
+		leaf.node.Value = b.config.LearningRate * leafOutput(leaf.grad, leaf.hess, b.config.LambdaL1, b.config.Lambda)
+		leaf.node.Cover = float64(len(leaf.rows))
+	}
+	tree.Root = root.node
+	return tree
+}
+
+// splitLeaf turns leaf into a split node and returns its two children. The
+// smaller child's histograms are built from its rows, the larger child's by
+// subtracting them from the parent's.
+func (b *booster) splitLeaf(tree *Tree, leaf *boostLeaf, features []int, grad, hess []float64) (*boostLeaf, *boostLeaf) {
+	s := leaf.split
+	left := &boostLeaf{node: &Node{}, depth: leaf.depth + 1}
+	right := &boostLeaf{node: &Node{}, depth: leaf.depth + 1}
+	for _, i := range leaf.rows {
+		if int(b.bins[s.feature][i]) <= s.bin {
+			left.rows = append(left.rows, i)
+		} else {
+			right.rows = append(right.rows, i)
+		}
+	}
+
+	small, large := left, right
+	if len(right.rows) < len(left.rows) {
+		small, large = right, left
+	}
+	small.hist = b.histogram(small.rows, features, grad, hess)
+	large.hist = leaf.hist
+	for _, f := range features {
+		for k := range large.hist[f] {
+			large.hist[f][k].grad -= small.hist[f][k].grad
+			large.hist[f][k].hess -= small.hist[f][k].hess
+			large.hist[f][k].count -= small.hist[f][k].count
+		}
+	}
+	leaf.hist = nil
+
+	for _, child := range []*boostLeaf{left, right} {
+		child.grad, child.hess = sumGradients(child.rows, grad, hess)
+		b.findSplit(child, features)
+	}
+
+	node := leaf.node
+	node.Feature, node.Threshold, node.Gain = s.feature, b.cuts[s.feature][s.bin], s.gain
+	node.Cover = float64(len(leaf.rows))
+	node.Left, node.Right = left.node, right.node
+	name := lightgbmFeatureNames[s.feature]
+	tree.FeatureImportance[name] += s.gain
+	tree.Splits[name]++
+	return left, right
+}
+
+// histogram sums the gradients of rows per bin of each feature
+func (b *booster) histogram(rows, features []int, grad, hess []float64) [][]histBin {
+	hist := make([][]histBin, len(b.cuts))
+	for _, f := range features {
+		h := make([]histBin, len(b.cuts[f])+1)
+		bins := b.bins[f]
+		for _, i := range rows {
+			bin := &h[bins[i]]
+			bin.grad += grad[i]
+			bin.hess += hess[i]
+			bin.count++
+		}
+		hist[f] = h
+	}
+	return hist
+}
+
+// findSplit stores the split of leaf with the highest gain that leaves
+// MinDataInLeaf rows on both sides, within MaxDepth and above MinGainToSplit
+func (b *booster) findSplit(leaf *boostLeaf, features []int) {
+	c := b.config
+	leaf.split = boostSplit{}
+	if (c.MaxDepth > 0 && leaf.depth >= c.MaxDepth) || len(leaf.rows) < 2*c.MinDataInLeaf {
+		return
+	}
+	parent := leafScore(leaf.grad, leaf.hess, c.LambdaL1, c.Lambda)
+	for _, f := range features {
+		var gl, hl float64
+		nl := 0
+		for bin, h := range leaf.hist[f][:len(leaf.hist[f])-1] {
+			gl, hl, nl = gl+h.grad, hl+h.hess, nl+h.count
+			if nl < c.MinDataInLeaf {
+				continue
+			}
+			if len(leaf.rows)-nl < c.MinDataInLeaf {
+				break
+			}
+			gain := leafScore(gl, hl, c.LambdaL1, c.Lambda) + leafScore(leaf.grad-gl, leaf.hess-hl, c.LambdaL1, c.Lambda) - parent
+			if gain > c.MinGainToSplit && gain > leaf.split.gain {
+				leaf.split = boostSplit{feature: f, bin: bin, gain: gain, ok: true}
+			}
+		}
+	}
+}
+
+// leafScore is the loss reduction of a leaf with gradient sums g and h under
+// L1 and L2 regularization
+func leafScore(g, h, l1, l2 float64) float64 {
+	t := thresholdL1(g, l1)
+	return t * t / (h + l2)
+}
+
+// leafOutput is the regularized Newton step of a leaf
+func leafOutput(g, h, l1, l2 float64) float64 {
+	if h+l2 <= 0 {
+		return 0
+	}
+	return -thresholdL1(g, l1) / (h + l2)
+}
+
+// thresholdL1 shrinks g towards zero by l1
+func thresholdL1(g, l1 float64) float64 {
+	if g > l1 {
+		return g - l1
+	}
+	if g < -l1 {
+		return g + l1
+	}
+	return 0
+}
+
+func sumGradients(rows []int, grad, hess []float64) (float64, float64) {
+	var g, h float64
+	for _, i := range rows {
+		g += grad[i]
+		h += hess[i]
+	}
+	return g, h
+}
+
+// sampleIndices returns a sorted random subset of fraction of 0..n-1, at
+// least one index
+func sampleIndices(rng *rand.Rand, n int, fraction float64) []int {
+	k := max(1, min(n, int(math.Ceil(fraction*float64(n)))))
+	if k == n {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	picked := rng.Perm(n)[:k]
+	sort.Ints(picked)
+	return picked
+}
+
+func filled(n int, v float64) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func meanSquaredError(predicted, actual []float64) float64 {
+	sum := 0.0
+	for i, p := range predicted {
+		sum += (p - actual[i]) * (p - actual[i])
+	}
+	return sum / float64(len(predicted))
+}
@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+)
+
+// Train implements Trainer: it fits a new network on next-candle returns
+// with backpropagation through time and Adam, then swaps it in. Params may
+// set hidden_size, num_layers, sequence_len and dropout.
+func (l *LSTMPredictor) Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	l.mu.RLock()
+	config := l.Config
+	l.mu.RUnlock()
+
+	if err := recurrentParams(cfg, &config.HiddenSize, &config.NumLayers, &config.SequenceLen, &config.Dropout); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults(config.BatchSize, config.LearningRate)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	config.InputSize = len(sequenceFeatureNames)
+	config.BatchSize, config.LearningRate = cfg.BatchSize, cfg.LearningRate
+
+	result, err := fitSequenceModel(ctx, l.Name(), newLSTMWeights(config), data, cfg, config.SequenceLen, config.Dropout)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Config, l.Weights, l.Meta = config, result.net.(*LSTMWeights), result.meta
+	l.version = NewModelVersion()
+	l.IsLoaded = true
+	result.report.Version = l.version
+	return result.report, nil
+}
+
+// tensors implements recurrentNet
+func (w *LSTMWeights) tensors() [][]float64 {
+	var rows [][]float64
+	for i := range w.Layers {
+		l := &w.Layers[i]
+		for _, m := range [][][]float64{l.Wi, l.Ui, l.Wf, l.Uf, l.Wc, l.Uc, l.Wo, l.Uo} {
+			rows = append(rows, m...)
+		}
+		rows = append(rows, l.Bi, l.Bf, l.Bc, l.Bo)
+	}
+	rows = append(rows, w.Wy...)
+	return append(rows, w.By)
+}
+
+// clone implements recurrentNet
+func (w *LSTMWeights) clone() recurrentNet {
+	c := &LSTMWeights{
+		Layers: make([]LSTMLayer, len(w.Layers)),
+		Wy:     copyMatrix(w.Wy),
+		By:     append([]float64(nil), w.By...),
+	}
+	for i, l := range w.Layers {
+		c.Layers[i] = LSTMLayer{
+			Wi: copyMatrix(l.Wi), Ui: copyMatrix(l.Ui), Bi: append([]float64(nil), l.Bi...),
+			Wf: copyMatrix(l.Wf), Uf: copyMatrix(l.Uf), Bf: append([]float64(nil), l.Bf...),
+			Wc: copyMatrix(l.Wc), Uc: copyMatrix(l.Uc), Bc: append([]float64(nil), l.Bc...),
+			Wo: copyMatrix(l.Wo), Uo: copyMatrix(l.Uo), Bo: append([]float64(nil), l.Bo...),
+		}
+	}
+	return c
+}
+
+// gradient implements recurrentNet
+func (w *LSTMWeights) gradient(window [][]float64, target, dropout float64, rng *rand.Rand, grad recurrentNet) float64 {
+	g := grad.(*LSTMWeights)
+
+	// Forward pass, keeping each layer's inputs, activations and dropout mask
+	inputs := make([][][]float64, len(w.Layers))
+	acts := make([][]lstmActivations, len(w.Layers))
+	masks := make([][][]float64, len(w.Layers))
+	x := window
+	for i := range w.Layers {
+		inputs[i] = x
+		acts[i] = w.Layers[i].run(x)
+		masks[i] = dropoutMask(rng, dropout, len(x), len(w.Layers[i].Bi))
+		x = applyMask(lstmHidden(acts[i]), masks[i])
+	}
+
+	// Output layer on the last hidden state of the top layer
+	last := x[len(x)-1]
+	diff := w.By[0] + dot(w.Wy[0], last) - target
+	g.By[0] += diff
+	dh := make([][]float64, len(window))
+	dh[len(dh)-1] = make([]float64, len(last))
+	for j, v := range last {
+		g.Wy[0][j] += diff * v
+		dh[len(dh)-1][j] = diff * w.Wy[0][j]
+	}
+
+	// Backward through the layers, top to bottom
+	for i := len(w.Layers) - 1; i >= 0; i-- {
+		if masks[i] != nil {
+			for t, row := range dh {
+				for k := range row {
+					row[k] *= masks[i][t][k]
+				}
+			}
+		}
+		dh = w.Layers[i].backward(inputs[i], acts[i], dh, &g.Layers[i], i > 0)
+	}
+	return diff * diff
+}
+
+// backward runs backpropagation through time over one layer. dh holds the
+// loss gradient of each hidden state (nil rows for none); the gradient of
+// each input row is returned when inputGrad is set.
+func (w *LSTMLayer) backward(inputs [][]float64, acts []lstmActivations, dh [][]float64, g *LSTMLayer, inputGrad bool) [][]float64 {
+	n := len(w.Bi)
+	var dx [][]float64
+	if inputGrad {
+		dx = make([][]float64, len(inputs))
+	}
+	zero := make([]float64, n)
+	dhNext, dcNext := make([]float64, n), make([]float64, n)
+	di, df, dg, do := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+
+	for t := len(inputs) - 1; t >= 0; t-- {
+		a := acts[t]
+		hPrev, cPrev := zero, zero
+		if t > 0 {
+			hPrev, cPrev = acts[t-1].h, acts[t-1].c
+		}
+		for k := 0; k < n; k++ {
+			dht := dhNext[k]
+			if dh[t] != nil {
+				dht += dh[t][k]
+			}
+			dc := dht*a.o[k]*(1-a.tc[k]*a.tc[k]) + dcNext[k]
+			do[k] = dht * a.tc[k] * a.o[k] * (1 - a.o[k])
+			di[k] = dc * a.g[k] * a.i[k] * (1 - a.i[k])
+			df[k] = dc * cPrev[k] * a.f[k] * (1 - a.f[k])
+			dg[k] = dc * a.i[k] * (1 - a.g[k]*a.g[k])
+			dcNext[k] = dc * a.f[k]
+		}
+
+		var dxt []float64
+		if inputGrad {
+			dxt = make([]float64, len(inputs[t]))
+			dx[t] = dxt
+		}
+		for k := range dhNext {
+			dhNext[k] = 0
+		}
+		accumulateGate(g.Wi, g.Ui, g.Bi, w.Wi, w.Ui, di, inputs[t], hPrev, dxt, dhNext)
+		accumulateGate(g.Wf, g.Uf, g.Bf, w.Wf, w.Uf, df, inputs[t], hPrev, dxt, dhNext)
+		accumulateGate(g.Wc, g.Uc, g.Bc, w.Wc, w.Uc, dg, inputs[t], hPrev, dxt, dhNext)
+		accumulateGate(g.Wo, g.Uo, g.Bo, w.Wo, w.Uo, do, inputs[t], hPrev, dxt, dhNext)
+	}
+	return dx
+}
@@ -1,33 +1,362 @@
 package ai
 
-// AIManager manages all AI models
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ModelState is the lifecycle state of a registered model
+type ModelState string
+
+const (
+	StateLoaded   ModelState = "loaded"
+	StateTraining ModelState = "training"
+	StateFailed   ModelState = "failed"
+)
+
+// ModelStatus is the live status of a registered model
+type ModelStatus struct {
+	Name           string     `json:"name"`
+	Version        string     `json:"version"`
+	State          ModelState `json:"state"`
+	Loaded         bool       `json:"loaded"`
+	Error          string     `json:"error,omitempty"` // why the model failed to load or train
+	Predictions    int64      `json:"predictions"`
+	Failures       int64      `json:"failures"`
+	LastPrediction *time.Time `json:"last_prediction,omitempty"`
+	LastError      string     `json:"last_error,omitempty"` // last failed prediction
+	LastLatencyMs  float64    `json:"last_latency_ms"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AIManager is the registry of all predictors and their status
 type AIManager struct {
-	models map[string]interface{}
+	mu     sync.RWMutex
+	models map[string]Predictor
+	status map[string]*ModelStatus
+	jobs   map[string]*TrainingJob // last training job per model
 }
 
+// trainingTimeout bounds a background training job
+const trainingTimeout = time.Hour
+
 var manager *AIManager
+var managerOnce sync.Once
 
-// GetManager returns the singleton AI manager
+// GetManager returns the singleton AI manager with the built-in models registered
 func GetManager() *AIManager {
-	if manager == nil {
+	managerOnce.Do(func() {
 		manager = &AIManager{
-			models: make(map[string]interface{}),
+			models: make(map[string]Predictor),
+			status: make(map[string]*ModelStatus),
+			jobs:   make(map[string]*TrainingJob),
 		}
-	}
+		for _, p := range []Predictor{
+			GetNeuralPredictor(),
+			GetLightGBMPredictor(),
+			GetRandomForestPredictor(),
+			GetLSTMPredictor(),
+			GetGRUPredictor(),
+			GetXGBoostPredictor(),
+			GetARIMAPredictor(),
+			GetEnsemblePredictor(),
+		} {
+			manager.Register(p)
+		}
+	})
 	return manager
 }
 
+// Register adds or replaces a predictor under its name
+func (m *AIManager) Register(p Predictor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.models[p.Name()] = p
+	m.status[p.Name()] = &ModelStatus{
+		Name:      p.Name(),
+		Version:   p.Version(),
+		State:     StateLoaded,
+		Loaded:    true,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Get returns the predictor registered under name
+func (m *AIManager) Get(name string) (Predictor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.models[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	return p, nil
+}
+
+// Names returns the registered model names in order
+func (m *AIManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.models))
+	for name := range m.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Predict runs the named model, giving up when ctx is done. Panics inside the
+// model are reported as errors; every call is recorded in the model status.
+func (m *AIManager) Predict(ctx context.Context, name string, in Input) (*Prediction, error) {
+	p, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if status, _ := m.Status(name); status.State == StateFailed {
+		return nil, fmt.Errorf("%w: %s: %s", ErrModelUnavailable, name, status.Error)
+	}
+
+	type result struct {
+		prediction *Prediction
+		err        error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("model %s panicked: %v", name, r)}
+			}
+		}()
+		prediction, err := p.Predict(ctx, in)
+		done <- result{prediction, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("model %s: %w", name, ctx.Err())
+	}
+	m.record(name, p.Version(), time.Since(start), res.err)
+	if res.err == nil {
+		GetLedger().Record(name, p.Version(), in, res.prediction)
+	}
+	return res.prediction, res.err
+}
+
+// record updates the prediction counters of a model
+func (m *AIManager) record(name, version string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.status[name]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	status.Version = version
+	status.LastLatencyMs = float64(latency.Microseconds()) / 1000
+	status.UpdatedAt = now
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		logrus.Warnf("Prediction with %s failed: %v", name, err)
+		return
+	}
+	status.Predictions++
+	status.LastPrediction = &now
+}
+
+// MarkTraining flags a model as training; it keeps predicting with its
+// current weights until MarkLoaded
+func (m *AIManager) MarkTraining(name string) {
+	m.setState(name, StateTraining, nil)
+}
+
+// MarkLoaded flags a model as ready and refreshes its version
+func (m *AIManager) MarkLoaded(name string) {
+	m.setState(name, StateLoaded, nil)
+}
+
+// MarkFailed flags a model as unusable until it is loaded again
+func (m *AIManager) MarkFailed(name string, err error) {
+	m.setState(name, StateFailed, err)
+}
+
+func (m *AIManager) setState(name string, state ModelState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.status[name]
+	if !ok {
+		return
+	}
+	status.State = state
+	status.Loaded = state != StateFailed
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if p, ok := m.models[name]; ok {
+		status.Version = p.Version()
+	}
+	status.UpdatedAt = time.Now()
+}
+
+// Train fits a trainable model on data and saves the new weights as the
+// active version. The model keeps predicting with its current weights while
+// training and is left as it was when training fails.
+func (m *AIManager) Train(ctx context.Context, name string, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	p, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	trainer, ok := p.(Trainer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotTrainable, name)
+	}
+	previous, err := m.beginTraining(name)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := func() (report *TrainingReport, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("training %s panicked: %v", name, r)
+			}
+		}()
+		return trainer.Train(ctx, data, cfg)
+	}()
+	if err != nil {
+		if previous.State == StateFailed {
+			m.MarkFailed(name, errors.New(previous.Error))
+		} else {
+			m.MarkLoaded(name)
+		}
+		return nil, err
+	}
+	m.MarkLoaded(name)
+	logrus.Infof("Trained %s model version %s on %d %s %s candles in %.1fs",
+		name, report.Version, report.Candles, data.Symbol, data.Interval, report.Duration)
+
+	if persistent, ok := p.(Persistent); ok {
+		if _, err := GetModelStore().SaveActive(persistent, report.Metrics); err != nil {
+			logrus.Warnf("Failed to save trained %s model: %v", name, err)
+		}
+	}
+	return report, nil
+}
+
+// beginTraining flags a model as training unless it already is, returning
+// its previous status
+func (m *AIManager) beginTraining(name string) (ModelStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.status[name]
+	if !ok {
+		return ModelStatus{}, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	if status.State == StateTraining {
+		return ModelStatus{}, fmt.Errorf("%w: %s", ErrTrainingInProgress, name)
+	}
+	previous := *status
+	status.State = StateTraining
+	status.UpdatedAt = time.Now()
+	return previous, nil
+}
+
+// StartTraining trains a model in the background on the last candles of the
+// requested symbol/interval. One job runs per model at a time; the last job
+// of each model is reported by TrainingJob.
+func (m *AIManager) StartTraining(name string, req TrainingRequest) (TrainingJob, error) {
+	p, err := m.Get(name)
+	if err != nil {
+		return TrainingJob{}, err
+	}
+	if _, ok := p.(Trainer); !ok {
+		return TrainingJob{}, fmt.Errorf("%w: %s", ErrNotTrainable, name)
+	}
+
+	m.mu.Lock()
+	if job, ok := m.jobs[name]; ok && job.State == JobRunning {
+		m.mu.Unlock()
+		return TrainingJob{}, fmt.Errorf("%w: %s (job %s)", ErrTrainingInProgress, name, job.ID)
+	}
+	job := &TrainingJob{
+		ID:        uuid.NewString(),
+		Model:     name,
+		Request:   req,
+		State:     JobRunning,
+		StartedAt: time.Now(),
+	}
+	m.jobs[name] = job
+	started := *job
+	m.mu.Unlock()
+
+	go m.runTrainingJob(job)
+	return started, nil
+}
+
+// runTrainingJob fetches the candles and trains, recording the outcome
+func (m *AIManager) runTrainingJob(job *TrainingJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), trainingTimeout)
+	defer cancel()
+
+	req := job.Request
+	var report *TrainingReport
+	candles, err := TrainingCandles(req.Symbol, req.Interval, req.Candles)
+	if err == nil {
+		data := TrainingData{Symbol: req.Symbol, Interval: req.Interval, Candles: candles}
+		report, err = m.Train(ctx, job.Model, data, req.Config)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.State, job.Error = JobFailed, err.Error()
+		logrus.Warnf("Training job %s for %s failed: %v", job.ID, job.Model, err)
+		return
+	}
+	job.State, job.Report = JobSucceeded, report
+}
+
+// TrainingJob returns the last training job of a model
+func (m *AIManager) TrainingJob(name string) (TrainingJob, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[name]
+	if !ok {
+		return TrainingJob{}, false
+	}
+	return *job, true
+}
+
+// Status returns the status of one model
+func (m *AIManager) Status(name string) (ModelStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status, ok := m.status[name]
+	if !ok {
+		return ModelStatus{}, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	return *status, nil
+}
+
 // GetAllModelStatus returns status of all models
-func (m *AIManager) GetAllModelStatus() map[string]interface{} {
-	return map[string]interface{}{
-		"neural":       "loaded",
-		"lstm":         "loaded",
-		"gru":          "loaded",
-		"xgboost":      "loaded",
-		"lightgbm":     "loaded",
-		"randomforest": "loaded",
-		"ensemble":     "loaded",
-		"arima":        "loaded",
-		"pattern":      "loaded",
-	}
-}
\ No newline at end of file
+func (m *AIManager) GetAllModelStatus() map[string]ModelStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make(map[string]ModelStatus, len(m.status))
+	for name, status := range m.status {
+		all[name] = *status
+	}
+	return all
+}
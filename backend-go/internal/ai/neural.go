@@ -1,15 +1,23 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
 	"github.com/sirupsen/logrus"
 )
 
+// neuralFeatureSchema names the feature store values fed to inputs 30 and up
+var neuralFeatureSchema, _ = features.Schema(features.DefaultSets...)
+
 // NeuralConfig defines neural network configuration
 type NeuralConfig struct {
 	Layers        []int
@@ -26,19 +34,11 @@ type NeuralPredictor struct {
 	Config   NeuralConfig
 	Weights  [][]float64
 	Biases   []float64
+	Meta     *TrainingMeta // nil while the weights are the random initialization
+	version  string
 	mu       sync.RWMutex
 }
 
-// NeuralResult contains prediction results
-type NeuralResult struct {
-	Price       float64
-	Confidence  float64
-	Direction   string
-	Signal      string
-	Accuracy    float64
-	Volatility  float64
-}
-
 var neuralPredictor *NeuralPredictor
 var neuralOnce sync.Once
 
@@ -47,6 +47,7 @@ func GetNeuralPredictor() *NeuralPredictor {
 	neuralOnce.Do(func() {
 		neuralPredictor = &NeuralPredictor{
 			ModelID: uuid.New(),
+			version: "1.0.0",
 			Config: NeuralConfig{
 				Layers:       []int{100, 256, 128, 64, 1},
 				LearningRate: 0.001,
@@ -84,13 +85,82 @@ func (n *NeuralPredictor) initialize() {
 	}
 }
 
+// Name implements Predictor
+func (n *NeuralPredictor) Name() string { return "neural" }
+
+// Version implements Predictor
+func (n *NeuralPredictor) Version() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.version
+}
+
+// neuralState is the serialized form of the network
+type neuralState struct {
+	Config  NeuralConfig  `json:"config"`
+	Weights [][]float64   `json:"weights"`
+	Biases  []float64     `json:"biases"`
+	Meta    *TrainingMeta `json:"meta"`
+}
+
+// MarshalState implements Persistent; randomly initialized weights are not
+// saved
+func (n *NeuralPredictor) MarshalState() (json.RawMessage, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.Meta == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotTrained, n.Name())
+	}
+	return json.Marshal(neuralState{Config: n.Config, Weights: n.Weights, Biases: n.Biases, Meta: n.Meta})
+}
+
+// RestoreState implements Persistent; the input layer must match the feature
+// vector built by prepareFeatures. States without training metadata hold the
+// random weights of a first boot and are rejected with ErrModelNotTrained.
+func (n *NeuralPredictor) RestoreState(version string, data json.RawMessage) error {
+	var state neuralState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Meta == nil {
+		return fmt.Errorf("%w: %s version %s has no training metadata", ErrModelNotTrained, n.Name(), version)
+	}
+	layers := state.Config.Layers
+	if len(layers) < 2 || layers[len(layers)-1] != 1 {
+		return fmt.Errorf("%w: neural network needs at least 2 layers and 1 output", ErrInvalidInput)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if layers[0] != n.Config.Layers[0] {
+		return fmt.Errorf("%w: neural input size %d, want %d", ErrInvalidInput, layers[0], n.Config.Layers[0])
+	}
+	if err := checkVector("biases", state.Biases, len(layers)-1); err != nil {
+		return err
+	}
+	if len(state.Weights) != len(layers)-1 {
+		return fmt.Errorf("%w: %d weight layers, want %d", ErrInvalidInput, len(state.Weights), len(layers)-1)
+	}
+	for i, w := range state.Weights {
+		if err := checkVector(fmt.Sprintf("weights[%d]", i), w, layers[i]*layers[i+1]); err != nil {
+			return err
+		}
+	}
+	n.Config, n.Weights, n.Biases, n.Meta, n.version = state.Config, state.Weights, state.Biases, state.Meta, version
+	return nil
+}
+
 // Predict generates neural network prediction
-func (n *NeuralPredictor) Predict(symbol string, historical []float64, features map[string]interface{}) NeuralResult {
+func (n *NeuralPredictor) Predict(ctx context.Context, in Input) (*Prediction, error) {
+	if err := in.require(2); err != nil {
+		return nil, err
+	}
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
+	historical := in.Closes()
+
 	// Prepare input features
-	input := n.prepareFeatures(historical, features)
+	input := n.prepareFeatures(historical, in.Features)
 
 	// Forward pass through the network
 	output := n.forward(input)
@@ -111,25 +181,14 @@ func (n *NeuralPredictor) Predict(symbol string, historical []float64, features
 	// Calculate confidence based on network activation strength
 	confidence := n.calculateConfidence(output)
 
-	// Generate trading signal
-	signal := n.generateSignal(direction, confidence)
-
-	// Calculate additional metrics
-	volatility := n.calculateVolatility(historical)
-	accuracy := n.estimateAccuracy()
-
-	return NeuralResult{
-		Price:      predictedPrice,
-		Confidence: confidence,
-		Direction:  direction,
-		Signal:     signal,
-		Accuracy:   accuracy,
-		Volatility: volatility,
-	}
+	return stepPrediction("neural", in, predictedPrice, confidence, direction, n.generateSignal(direction, confidence), map[string]float64{
+		"price_change": priceChange,
+		"volatility":   n.calculateVolatility(historical),
+	}), nil
 }
 
 // prepareFeatures converts raw data to neural network input
-func (n *NeuralPredictor) prepareFeatures(historical []float64, features map[string]interface{}) []float64 {
+func (n *NeuralPredictor) prepareFeatures(historical []float64, values map[string]interface{}) []float64 {
 	// Technical indicators
 	input := make([]float64, n.Config.Layers[0])
 
@@ -141,33 +200,32 @@ func (n *NeuralPredictor) prepareFeatures(historical []float64, features map[str
 		}
 
 		// Moving averages
-		input[20] = n.sma(historical, 7)
-		input[21] = n.sma(historical, 14)
-		input[22] = n.sma(historical, 30)
+		input[20] = indicators.SMA(historical, 7).LastOr(0)
+		input[21] = indicators.SMA(historical, 14).LastOr(0)
+		input[22] = indicators.SMA(historical, 30).LastOr(0)
 
 		// RSI
-		input[23] = n.rsi(historical, 14)
+		input[23] = indicators.RSI(historical, 14).LastOr(50)
 
 		// MACD
-		macd, signal := n.macd(historical)
-		input[24] = macd
-		input[25] = signal
+		macd, signal, _ := indicators.MACD(historical, 12, 26, 9)
+		input[24] = macd.LastOr(0)
+		input[25] = signal.LastOr(0)
 
 		// Bollinger Bands
-		upper, lower := n.bollingerBands(historical, 20)
-		input[26] = upper
-		input[27] = lower
+		upper, _, lower := indicators.Bollinger(historical, 20, 2.0)
+		input[26] = upper.LastOr(0)
+		input[27] = lower.LastOr(0)
 	}
 
-	// Additional features from map
-	idx := 30
-	for _, value := range features {
-		if idx >= len(input) {
+	// Feature store values in schema order, so each input always carries the
+	// same feature; missing or undefined features stay at 0
+	for j, name := range neuralFeatureSchema {
+		if 30+j >= len(input) {
 			break
 		}
-		if val, ok := value.(float64); ok {
-			input[idx] = val
-			idx++
+		if val, ok := featureValue(values, name); ok {
+			input[30+j] = val
 		}
 	}
 
@@ -290,105 +348,6 @@ func (n *NeuralPredictor) calculateVolatility(prices []float64) float64 {
 	return math.Sqrt(variance) * math.Sqrt(365) * 100 // Annualized volatility percentage
 }
 
-// estimateAccuracy returns estimated model accuracy
-func (n *NeuralPredictor) estimateAccuracy() float64 {
-	// In production, this would be based on actual backtesting
-	// For now, return a realistic range
-	return 65 + rand.Float64()*20
-}
-
-// Technical indicator calculations
-
-func (n *NeuralPredictor) sma(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return 0
-	}
-
-	sum := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		sum += prices[i]
-	}
-	return sum / float64(period)
-}
-
-func (n *NeuralPredictor) rsi(prices []float64, period int) float64 {
-	if len(prices) < period+1 {
-		return 50
-	}
-
-	gains := 0.0
-	losses := 0.0
-
-	for i := len(prices) - period; i < len(prices); i++ {
-		change := prices[i] - prices[i-1]
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
-		}
-	}
-
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-
-	if avgLoss == 0 {
-		return 100
-	}
-
-	rs := avgGain / avgLoss
-	return 100 - (100 / (1 + rs))
-}
-
-func (n *NeuralPredictor) macd(prices []float64) (float64, float64) {
-	if len(prices) < 26 {
-		return 0, 0
-	}
-
-	ema12 := n.ema(prices, 12)
-	ema26 := n.ema(prices, 26)
-	macdLine := ema12 - ema26
-
-	// Signal line (9-period EMA of MACD)
-	signalLine := macdLine * 0.9 // Simplified
-
-	return macdLine, signalLine
-}
-
-func (n *NeuralPredictor) ema(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return 0
-	}
-
-	multiplier := 2.0 / float64(period+1)
-	ema := prices[len(prices)-period]
-
-	for i := len(prices) - period + 1; i < len(prices); i++ {
-		ema = (prices[i]-ema)*multiplier + ema
-	}
-
-	return ema
-}
-
-func (n *NeuralPredictor) bollingerBands(prices []float64, period int) (float64, float64) {
-	if len(prices) < period {
-		return 0, 0
-	}
-
-	sma := n.sma(prices, period)
-
-	// Calculate standard deviation
-	variance := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		variance += math.Pow(prices[i]-sma, 2)
-	}
-	stdDev := math.Sqrt(variance / float64(period))
-
-	upper := sma + 2*stdDev
-	lower := sma - 2*stdDev
-
-	return upper, lower
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
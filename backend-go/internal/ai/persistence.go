@@ -0,0 +1,383 @@
+package ai
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ModelFormat is the version of the model file layout; files with a newer
+// format are refused
+const ModelFormat = 1
+
+var (
+	ErrNoSavedModel  = errors.New("no saved model")
+	ErrVersionExists = errors.New("model version already exists")
+	ErrNotPersistent = errors.New("model cannot be saved")
+)
+
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Persistent is implemented by predictors whose weights can be saved and
+// restored
+type Persistent interface {
+	Predictor
+	// MarshalState encodes the configuration and weights the model predicts with
+	MarshalState() (json.RawMessage, error)
+	// RestoreState replaces configuration and weights with a saved state
+	RestoreState(version string, state json.RawMessage) error
+}
+
+// ModelFile is the serialized form of one model version
+type ModelFile struct {
+	Format  int                `json:"format"`
+	Model   string             `json:"model"`
+	Version string             `json:"version"`
+	SavedAt time.Time          `json:"saved_at"`
+	Metrics map[string]float64 `json:"metrics,omitempty"` // training/validation results
+	State   json.RawMessage    `json:"state"`
+}
+
+// ModelVersion describes a saved model version
+type ModelVersion struct {
+	Model   string             `json:"model"`
+	Version string             `json:"version"`
+	SavedAt time.Time          `json:"saved_at"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	Size    int64              `json:"size"`
+	Active  bool               `json:"active"`
+}
+
+// ModelStore keeps model versions on disk as <dir>/<model>/<version>.json.gz,
+// with the active version named in <dir>/<model>/ACTIVE
+type ModelStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+var modelStore *ModelStore
+var modelStoreOnce sync.Once
+
+// GetModelStore returns the model store rooted at MODEL_DIR (./models by default)
+func GetModelStore() *ModelStore {
+	modelStoreOnce.Do(func() {
+		dir := os.Getenv("MODEL_DIR")
+		if dir == "" {
+			dir = "./models"
+		}
+		modelStore = &ModelStore{dir: dir}
+	})
+	return modelStore
+}
+
+// NewModelVersion returns a version label for newly trained weights: the
+// training time, sortable, plus a random suffix so that runs finishing in the
+// same millisecond get distinct versions
+func NewModelVersion() string {
+	return time.Now().UTC().Format("20060102-150405.000") + "-" + uuid.New().String()[:8]
+}
+
+// Save writes the current weights of a model under its current version.
+// Versions are immutable: saving an existing version fails.
+func (s *ModelStore) Save(p Persistent, metrics map[string]float64) (ModelVersion, error) {
+	version := p.Version()
+	if !versionPattern.MatchString(version) {
+		return ModelVersion{}, fmt.Errorf("%w: invalid version %q", ErrInvalidInput, version)
+	}
+	state, err := p.MarshalState()
+	if err != nil {
+		return ModelVersion{}, fmt.Errorf("encode %s: %w", p.Name(), err)
+	}
+	file := ModelFile{
+		Format:  ModelFormat,
+		Model:   p.Name(),
+		Version: version,
+		SavedAt: time.Now().UTC(),
+		Metrics: metrics,
+		State:   state,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(file.Model, version)
+	if _, err := os.Stat(path); err == nil {
+		return ModelVersion{}, fmt.Errorf("%w: %s %s", ErrVersionExists, file.Model, version)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return ModelVersion{}, err
+	}
+	if err := writeModelFile(path, &file); err != nil {
+		return ModelVersion{}, err
+	}
+	logrus.Infof("Saved %s model version %s", file.Model, version)
+	return s.describe(&file, path), nil
+}
+
+// SaveActive saves the current weights of a model and makes them the version
+// loaded at startup
+func (s *ModelStore) SaveActive(p Persistent, metrics map[string]float64) (ModelVersion, error) {
+	v, err := s.Save(p, metrics)
+	if err != nil {
+		return v, err
+	}
+	v.Active = true
+	return v, s.setActive(v.Model, v.Version)
+}
+
+// Activate restores a saved version into the registered model and makes it
+// the version loaded at startup
+func (s *ModelStore) Activate(name, version string) error {
+	p, err := persistentModel(name)
+	if err != nil {
+		return err
+	}
+	if err := s.restore(p, version); err != nil {
+		return err
+	}
+	return s.setActive(name, version)
+}
+
+func (s *ModelStore) setActive(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFileAtomic(filepath.Join(s.dir, name, "ACTIVE"), []byte(version+"\n"))
+}
+
+// Active returns the active version of a model
+func (s *ModelStore) Active(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name, "ACTIVE"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNoSavedModel, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Versions lists the saved versions of a model, newest first
+func (s *ModelStore) Versions(name string) ([]ModelVersion, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, name, "*.json.gz"))
+	if err != nil {
+		return nil, err
+	}
+	active, _ := s.Active(name)
+	versions := make([]ModelVersion, 0, len(paths))
+	for _, path := range paths {
+		file, err := readModelFile(path, false)
+		if err != nil {
+			logrus.Warnf("Skipping unreadable model file %s: %v", path, err)
+			continue
+		}
+		v := s.describe(file, path)
+		v.Active = v.Version == active
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].SavedAt.After(versions[j].SavedAt) })
+	return versions, nil
+}
+
+// restore reads a version from disk and loads it into the registered model
+func (s *ModelStore) restore(p Persistent, version string) error {
+	if err := s.Load(p, version); err != nil {
+		return err
+	}
+	GetManager().MarkLoaded(p.Name())
+	logrus.Infof("Loaded %s model version %s", p.Name(), version)
+	return nil
+}
+
+// Load reads a saved version from disk into p without touching the model
+// registry, so a version can be used next to the active one
+func (s *ModelStore) Load(p Persistent, version string) error {
+	if !versionPattern.MatchString(version) {
+		return fmt.Errorf("%w: invalid version %q", ErrInvalidInput, version)
+	}
+	path := s.path(p.Name(), version)
+	file, err := readModelFile(path, true)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s %s", ErrNoSavedModel, p.Name(), version)
+	}
+	if err != nil {
+		return err
+	}
+	if file.Model != p.Name() {
+		return fmt.Errorf("%w: %s holds a %s model", ErrInvalidInput, path, file.Model)
+	}
+	if err := p.RestoreState(file.Version, file.State); err != nil {
+		return fmt.Errorf("restore %s %s: %w", file.Model, file.Version, err)
+	}
+	return nil
+}
+
+func (s *ModelStore) path(name, version string) string {
+	return filepath.Join(s.dir, name, version+".json.gz")
+}
+
+func (s *ModelStore) describe(file *ModelFile, path string) ModelVersion {
+	v := ModelVersion{Model: file.Model, Version: file.Version, SavedAt: file.SavedAt, Metrics: file.Metrics}
+	if info, err := os.Stat(path); err == nil {
+		v.Size = info.Size()
+	}
+	return v
+}
+
+// LoadActiveModels restores the active version of every persistent model.
+// A model without a saved version gets its current weights saved and
+// activated if they were trained (MarshalState refuses weights without
+// training metadata), so that predictions are the same after a restart.
+// Models whose active version cannot be loaded are marked failed; models that
+// need training first are reported with ErrModelNotTrained and left as they are.
+func LoadActiveModels() map[string]error {
+	store := GetModelStore()
+	manager := GetManager()
+	results := make(map[string]error)
+	for _, name := range manager.Names() {
+		p, err := persistentModel(name)
+		if err != nil {
+			continue
+		}
+		version, err := store.Active(name)
+		switch {
+		case errors.Is(err, ErrNoSavedModel):
+			_, err = store.SaveActive(p, nil)
+		case err == nil:
+			err = store.restore(p, version)
+		}
+		if err != nil && !errors.Is(err, ErrModelNotTrained) {
+			manager.MarkFailed(name, err)
+		}
+		results[name] = err
+	}
+	return results
+}
+
+// persistentModel returns the registered model if it can be saved
+func persistentModel(name string) (Persistent, error) {
+	model, err := GetManager().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := model.(Persistent)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotPersistent, name)
+	}
+	return p, nil
+}
+
+// writeModelFile writes a gzip-compressed JSON model file atomically
+func writeModelFile(path string, file *ModelFile) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".model-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(file); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readModelFile reads a model file, skipping the weights unless withState
+func readModelFile(path string, withState bool) (*ModelFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer zr.Close()
+
+	var file ModelFile
+	if err := json.NewDecoder(zr).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Format < 1 || file.Format > ModelFormat {
+		return nil, fmt.Errorf("%s: unsupported model format %d", path, file.Format)
+	}
+	if !withState {
+		file.State = nil
+	}
+	return &file, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkMatrix validates the shape of a restored weight matrix
+func checkMatrix(name string, m [][]float64, rows, cols int) error {
+	if len(m) != rows {
+		return fmt.Errorf("%w: %s has %d rows, want %d", ErrInvalidInput, name, len(m), rows)
+	}
+	for i, row := range m {
+		if len(row) != cols {
+			return fmt.Errorf("%w: %s row %d has %d columns, want %d", ErrInvalidInput, name, i, len(row), cols)
+		}
+		if err := checkFinite(name, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkVector validates the length of a restored weight vector
+func checkVector(name string, v []float64, size int) error {
+	if len(v) != size {
+		return fmt.Errorf("%w: %s has %d values, want %d", ErrInvalidInput, name, len(v), size)
+	}
+	return checkFinite(name, v)
+}
+
+func checkFinite(name string, v []float64) error {
+	for _, x := range v {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("%w: %s contains non-finite weights", ErrInvalidInput, name)
+		}
+	}
+	return nil
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
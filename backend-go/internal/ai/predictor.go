@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// Predictor is implemented by every forecasting model registered in AIManager
+type Predictor interface {
+	// Name is the registry key, also used in /ai/:model/predict
+	Name() string
+	// Version identifies the weights the model currently predicts with
+	Version() string
+	Predict(ctx context.Context, in Input) (*Prediction, error)
+}
+
+// Input is the market context a prediction is made on
+type Input struct {
+	Symbol   string
+	Interval string
+	Candles  indicators.OHLCV
+	// Features holds client values and feature store values keyed by their
+	// qualified name ("volume.volume_ratio_20"); undefined store values are
+	// absent, so models read them by name through featureValue
+	Features map[string]interface{}
+	// Live is set when the candles and features come from the server (the
+	// feature store's closed exchange candles) rather than the request, so the
+	// forecast can be scored against the market. Only live inputs are recorded
+	// in the prediction ledger and tracked by the ensemble.
+	Live bool
+}
+
+// featureValue returns a feature by name, false when it is absent or undefined
+func featureValue(features map[string]interface{}, name string) (float64, bool) {
+	v, ok := features[name].(float64)
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// Closes returns the close prices of the input candles
+func (in Input) Closes() []float64 {
+	return in.Candles.Close
+}
+
+// require fails with ErrInsufficientData when fewer than n candles are given
+func (in Input) require(n int) error {
+	if in.Candles.Len() < n {
+		return fmt.Errorf("%w: need %d candles, got %d", ErrInsufficientData, n, in.Candles.Len())
+	}
+	return nil
+}
+
+// horizon returns the key and timestamp of the next candle close
+func (in Input) horizon() (string, int64) {
+	step, err := market.IntervalDuration(in.Interval)
+	if err != nil {
+		return "1h", time.Now().Add(time.Hour).Unix()
+	}
+	return in.Interval, time.Now().Add(step).Unix()
+}
+
+// horizonKey formats a forecast horizon in its largest whole unit, the form
+// interval keys use ("15m", "4h", "1d")
+func horizonKey(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d >= 7*day && d%(7*day) == 0:
+		return fmt.Sprintf("%dw", d/(7*day))
+	case d >= day && d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// stepPrediction builds the prediction of a model that forecasts the next candle
+func stepPrediction(model string, in Input, price, confidence float64, direction, signal string, factors map[string]float64) *Prediction {
+	closes := in.Closes()
+	key, timestamp := in.horizon()
+	return &Prediction{
+		Model:        model,
+		Symbol:       in.Symbol,
+		CurrentPrice: closes[len(closes)-1],
+		Predictions: map[string]PricePoint{
+			key: {Price: price, Confidence: confidence, Timestamp: timestamp},
+		},
+		Confidence:     confidence,
+		Direction:      direction,
+		Factors:        factors,
+		Recommendation: signal,
+		RiskLevel:      riskLevel(confidence),
+		Timestamp:      time.Now().Unix(),
+	}
+}
+
+// riskLevel maps model confidence (0-100) to a risk level
+func riskLevel(confidence float64) string {
+	switch {
+	case confidence > 80:
+		return "LOW"
+	case confidence < 60:
+		return "HIGH"
+	default:
+		return "MEDIUM"
+	}
+}
@@ -1,65 +1,47 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math"
-	"math/rand"
 	"sync"
-	"time"
 
 	"github.com/google/uuid"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/pkg/forest"
 	"github.com/sirupsen/logrus"
 )
 
 // RandomForestConfig defines Random Forest configuration
 type RandomForestConfig struct {
-	NEstimators    int
-	MaxDepth       int
+	NEstimators     int
+	MaxDepth        int // 0 for no limit
 	MinSamplesSplit int
 	MinSamplesLeaf  int
-	MaxFeatures     string
-	Bootstrap       bool
-	OOBScore        bool
+	MaxFeatures     string // features tried per split: "sqrt", "log2", "third", "all" or a count
+	Bootstrap       bool   // also enables the out-of-bag score and permutation importance
+	Criterion       string // "variance" regresses the next return, "gini" classifies its direction
 }
 
-// RandomForestPredictor implements Random Forest prediction
+// RandomForestPredictor implements Random Forest prediction of the next
+// candle return
 type RandomForestPredictor struct {
 	ModelID  uuid.UUID
 	Config   RandomForestConfig
-	Trees    []*DecisionTree
+	Forest   *forest.Forest
 	Features []string
-	OOBScore float64
-	mu       sync.RWMutex
+	// ReturnScale is the mean absolute return of the training samples; a gini
+	// forest forecasts (P(up) - P(down)) * ReturnScale
+	ReturnScale float64
+	Meta        *TrainingMeta // nil until trained
+	version     string
+	mu          sync.RWMutex
 }
 
-// DecisionTree represents a single decision tree
-type DecisionTree struct {
-	Root       *TreeNode
-	MaxDepth   int
-	OOBSamples []int
-	Prediction float64
-}
-
-// TreeNode represents a node in the decision tree
-type TreeNode struct {
-	Feature    int
-	Threshold  float64
-	Left       *TreeNode
-	Right      *TreeNode
-	Value      float64
-	IsLeaf     bool
-	Samples    int
-	Impurity   float64
-}
-
-// RandomForestResult contains prediction results
-type RandomForestResult struct {
-	Price           float64
-	Confidence      float64
-	Direction       string
-	Signal          string
-	OOBScore        float64
-	TreePredictions []float64
-}
+// randomForestWarmup is the history the indicator features need; training
+// skips the first candles and Predict requires as many
+const randomForestWarmup = 50
 
 var rfPredictor *RandomForestPredictor
 var rfOnce sync.Once
@@ -67,134 +49,116 @@ var rfOnce sync.Once
 // GetRandomForestPredictor returns singleton Random Forest predictor
 func GetRandomForestPredictor() *RandomForestPredictor {
 	rfOnce.Do(func() {
-		rfPredictor = &RandomForestPredictor{
-			ModelID: uuid.New(),
-			Config: RandomForestConfig{
-				NEstimators:     100,
-				MaxDepth:        10,
-				MinSamplesSplit: 2,
-				MinSamplesLeaf:  1,
-				MaxFeatures:     "sqrt",
-				Bootstrap:       true,
-				OOBScore:        true,
-			},
-			Trees: make([]*DecisionTree, 0),
-		}
-		rfPredictor.initialize()
+		rfPredictor = newRandomForestPredictor()
 		logrus.Info("Random Forest predictor initialized")
 	})
 	return rfPredictor
 }
 
-// initialize sets up the Random Forest model
-func (rf *RandomForestPredictor) initialize() {
-	rand.Seed(time.Now().UnixNano())
-
-	// Define features
-	rf.Features = []string{
-		"price_momentum", "volume_trend", "rsi_divergence",
-		"macd_histogram", "bollinger_width", "atr",
-		"obv", "cmf", "stochastic_k", "stochastic_d",
-		"williams_r", "cci", "adx", "mfi", "roc",
+// newRandomForestPredictor creates an untrained, unregistered predictor
+func newRandomForestPredictor() *RandomForestPredictor {
+	return &RandomForestPredictor{
+		ModelID: uuid.New(),
+		version: "1.0.0",
+		Config: RandomForestConfig{
+			NEstimators:     100,
+			MaxDepth:        10,
+			MinSamplesSplit: 2,
+			MinSamplesLeaf:  5,
+			MaxFeatures:     "sqrt",
+			Bootstrap:       true,
+			Criterion:       string(forest.Variance),
+		},
+		Features: randomForestFeatureNames,
 	}
-
-	// Build forest
-	for i := 0; i < rf.Config.NEstimators; i++ {
-		tree := rf.buildTree()
-		rf.Trees = append(rf.Trees, tree)
-	}
-
-	// Calculate OOB score
-	rf.OOBScore = rf.calculateOOBScore()
 }
 
-// buildTree creates a single decision tree
-func (rf *RandomForestPredictor) buildTree() *DecisionTree {
-	tree := &DecisionTree{
-		MaxDepth: rf.Config.MaxDepth,
-		Root:     rf.buildTreeNode(0, rf.Config.MaxDepth),
-	}
+// randomForestFeatureNames are the columns built by randomForestFeatures
+var randomForestFeatureNames = []string{
+	"price_momentum", "volume_trend", "rsi_divergence",
+	"macd_histogram", "bollinger_width", "atr",
+	"obv", "cmf", "stochastic_k", "stochastic_d",
+	"williams_r", "cci", "adx", "mfi", "roc",
+}
 
-	// Store OOB sample indices for this tree
-	if rf.Config.OOBScore {
-		numSamples := 1000 // Simulated dataset size
-		tree.OOBSamples = rf.generateOOBSamples(numSamples)
-	}
+// Name implements Predictor
+func (rf *RandomForestPredictor) Name() string { return "randomforest" }
 
-	return tree
+// Version implements Predictor
+func (rf *RandomForestPredictor) Version() string {
+	rf.mu.RLock()
+	defer rf.mu.RUnlock()
+	return rf.version
 }
 
-// buildTreeNode recursively builds tree nodes
-func (rf *RandomForestPredictor) buildTreeNode(depth, maxDepth int) *TreeNode {
-	if depth >= maxDepth || rand.Float64() < 0.2 {
-		// Leaf node
-		return &TreeNode{
-			IsLeaf:   true,
-			Value:    rand.NormFloat64() * 0.005,
-			Samples:  rand.Intn(100) + 10,
-			Impurity: rand.Float64() * 0.3,
-		}
-	}
+// randomForestState is the serialized form of the forest
+type randomForestState struct {
+	Config      RandomForestConfig `json:"config"`
+	Features    []string           `json:"features"`
+	Forest      *forest.Forest     `json:"forest"`
+	ReturnScale float64            `json:"return_scale"`
+	Meta        *TrainingMeta      `json:"meta"`
+}
 
-	// Select random feature subset
-	numFeatures := int(math.Sqrt(float64(len(rf.Features))))
-	selectedFeature := rand.Intn(numFeatures)
-
-	return &TreeNode{
-		Feature:   selectedFeature,
-		Threshold: rand.NormFloat64(),
-		Samples:   rand.Intn(500) + 100,
-		Impurity:  rand.Float64() * 0.5,
-		Left:      rf.buildTreeNode(depth+1, maxDepth),
-		Right:     rf.buildTreeNode(depth+1, maxDepth),
+// MarshalState implements Persistent
+func (rf *RandomForestPredictor) MarshalState() (json.RawMessage, error) {
+	rf.mu.RLock()
+	defer rf.mu.RUnlock()
+	if rf.Meta == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotTrained, rf.Name())
 	}
+	return json.Marshal(randomForestState{Config: rf.Config, Features: rf.Features, Forest: rf.Forest, ReturnScale: rf.ReturnScale, Meta: rf.Meta})
 }
 
-// generateOOBSamples generates out-of-bag sample indices
-func (rf *RandomForestPredictor) generateOOBSamples(numSamples int) []int {
-	oobSamples := make([]int, 0)
-	selected := make(map[int]bool)
-
-	// Bootstrap sampling
-	for i := 0; i < numSamples; i++ {
-		idx := rand.Intn(numSamples)
-		selected[idx] = true
+// RestoreState implements Persistent; the feature names must match the
+// columns built by randomForestFeatures. States saved before training
+// existed hold untrained trees and are rejected with ErrModelNotTrained.
+func (rf *RandomForestPredictor) RestoreState(version string, data json.RawMessage) error {
+	var state randomForestState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
 	}
-
-	// OOB samples are those not selected
-	for i := 0; i < numSamples; i++ {
-		if !selected[i] {
-			oobSamples = append(oobSamples, i)
-		}
+	if state.Meta == nil || state.Forest == nil {
+		return fmt.Errorf("%w: %s version %s has no trained forest", ErrModelNotTrained, rf.Name(), version)
+	}
+	if err := state.Forest.Validate(len(state.Features)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := checkFinite("return_scale", []float64{state.ReturnScale}); err != nil {
+		return err
 	}
 
-	return oobSamples
-}
-
-// calculateOOBScore calculates out-of-bag score
-func (rf *RandomForestPredictor) calculateOOBScore() float64 {
-	// Simplified OOB score calculation
-	// In production, this would use actual OOB predictions
-	return 0.65 + rand.Float64()*0.2
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if !sameStrings(state.Features, rf.Features) {
+		return fmt.Errorf("%w: random forest features %v, want %v", ErrInvalidInput, state.Features, rf.Features)
+	}
+	rf.Config, rf.Forest, rf.ReturnScale, rf.Meta, rf.version = state.Config, state.Forest, state.ReturnScale, state.Meta, version
+	return nil
 }
 
-// Predict generates Random Forest prediction
-func (rf *RandomForestPredictor) Predict(symbol string, historical []float64, features map[string]interface{}) RandomForestResult {
+// Predict generates Random Forest prediction from OHLCV candles
+func (rf *RandomForestPredictor) Predict(ctx context.Context, in Input) (*Prediction, error) {
 	rf.mu.RLock()
 	defer rf.mu.RUnlock()
+	if err := rf.Meta.check(rf.Name(), in, randomForestWarmup); err != nil {
+		return nil, err
+	}
+
+	candles := in.Candles
 
 	// Prepare features
-	featureVector := rf.prepareFeatures(historical, features)
+	featureVector := rf.prepareFeatures(candles)
 
 	// Get predictions from all trees
-	predictions := make([]float64, len(rf.Trees))
+	predictions := make([]float64, len(rf.Forest.Trees))
 	var wg sync.WaitGroup
-	wg.Add(len(rf.Trees))
+	wg.Add(len(rf.Forest.Trees))
 
-	for i := range rf.Trees {
+	for i := range rf.Forest.Trees {
 		go func(idx int) {
 			defer wg.Done()
-			predictions[idx] = rf.predictTree(rf.Trees[idx], featureVector)
+			predictions[idx] = rf.predictTree(rf.Forest.Trees[idx], featureVector)
 		}(i)
 	}
 	wg.Wait()
@@ -207,126 +171,132 @@ func (rf *RandomForestPredictor) Predict(symbol string, historical []float64, fe
 	avgPrediction /= float64(len(predictions))
 
 	// Calculate metrics
-	currentPrice := historical[len(historical)-1]
+	currentPrice := candles.Close[candles.Len()-1]
 	predictedPrice := currentPrice * (1 + avgPrediction)
 
 	// Determine direction
 	direction := "NEUTRAL"
-	if avgPrediction > 0.003 {
+	if avgPrediction > 0.001 {
 		direction = "UP"
-	} else if avgPrediction < -0.003 {
+	} else if avgPrediction < -0.001 {
 		direction = "DOWN"
 	}
 
-	// Calculate confidence based on tree agreement
-	confidence := rf.calculateConfidence(predictions, avgPrediction)
+	// Confidence from the validation accuracy of the trained forest
+	confidence := rf.Meta.confidence(avgPrediction)
 
-	return RandomForestResult{
-		Price:           predictedPrice,
-		Confidence:      confidence,
-		Direction:       direction,
-		Signal:          rf.generateSignal(direction, confidence),
-		OOBScore:        rf.OOBScore,
-		TreePredictions: predictions,
+	// Spread of the individual tree forecasts
+	dispersion, up := 0.0, 0.0
+	for _, pred := range predictions {
+		dispersion += (pred - avgPrediction) * (pred - avgPrediction)
+		if pred > 0 {
+			up++
+		}
 	}
-}
 
-// prepareFeatures converts raw data to feature vector
-func (rf *RandomForestPredictor) prepareFeatures(historical []float64, features map[string]interface{}) []float64 {
-	featureVector := make([]float64, len(rf.Features))
-
-	if len(historical) < 20 {
-		return featureVector
+	factors := map[string]float64{
+		"raw_prediction":  avgPrediction,
+		"oob_score":       rf.Forest.OOBScore,
+		"oob_error":       rf.Forest.OOBError,
+		"tree_dispersion": math.Sqrt(dispersion / float64(len(predictions))),
+		"trees_up_ratio":  up / float64(len(predictions)),
 	}
+	for i, value := range rf.Forest.PermutationImportance {
+		factors["importance."+rf.Features[i]] = value
+	}
+	return stepPrediction("randomforest", in, predictedPrice, confidence, direction, rf.generateSignal(direction, confidence), factors), nil
+}
 
-	// Calculate technical indicators
-	currentPrice := historical[len(historical)-1]
-
-	// Price momentum
-	featureVector[0] = rf.calculateMomentum(historical, 10)
-
-	// Volume trend (simplified)
-	featureVector[1] = rand.Float64()*2 - 1
-
-	// RSI divergence
-	rsi := rf.calculateRSI(historical)
-	featureVector[2] = (rsi - 50) / 50
-
-	// MACD histogram
-	macd, signal := rf.calculateMACD(historical)
-	featureVector[3] = (macd - signal) / currentPrice
+// prepareFeatures returns the feature vector of the last candle
+func (rf *RandomForestPredictor) prepareFeatures(candles indicators.OHLCV) []float64 {
+	rows := randomForestFeatures(candles)
+	return rows[len(rows)-1]
+}
 
-	// Bollinger width
-	upper, lower := rf.calculateBollingerBands(historical)
-	featureVector[4] = (upper - lower) / currentPrice
+// randomForestFeatures builds the feature vector of every candle, in the
+// order of randomForestFeatureNames
+func randomForestFeatures(candles indicators.OHLCV) [][]float64 {
+	high, low, close, volume := candles.High, candles.Low, candles.Close, candles.Volume
+
+	roc := indicators.ROC(close, 10)
+	shortVolume, longVolume := indicators.SMA(volume, 5), indicators.SMA(volume, 20)
+	rsi := indicators.RSI(close, 14)
+	_, _, hist := indicators.MACD(close, 12, 26, 9)
+	upper, _, lower := indicators.Bollinger(close, 20, 2.0)
+	atr := indicators.ATR(high, low, close, 14)
+	obv := indicators.OBV(close, volume)
+	tradedVolume := indicators.SMA(volume, 10)
+	cmf := indicators.CMF(high, low, close, volume, 20)
+	k, d := indicators.Stochastic(high, low, close, 14, 3, 3)
+	williams := indicators.WilliamsR(high, low, close, 14)
+	cci := indicators.CCI(high, low, close, 20)
+	adx, _, _ := indicators.ADX(high, low, close, 14)
+	mfi := indicators.MFI(high, low, close, volume, 14)
+
+	rows := make([][]float64, len(close))
+	for i, currentPrice := range close {
+		row := make([]float64, len(randomForestFeatureNames))
+
+		// Price momentum
+		row[0] = roc.At(i, 0) / 100
+
+		// Volume trend (short vs long average volume)
+		if long := longVolume.At(i, 0); long > 0 {
+			row[1] = shortVolume.At(i, long)/long - 1
+		}
 
-	// ATR (Average True Range)
-	featureVector[5] = rf.calculateATR(historical) / currentPrice
+		// RSI divergence
+		row[2] = (rsi.At(i, 50) - 50) / 50
 
-	// OBV (On-Balance Volume) - simplified
-	featureVector[6] = rand.Float64()*2 - 1
+		// MACD histogram
+		row[3] = hist.At(i, 0) / currentPrice
 
-	// CMF (Chaikin Money Flow) - simplified
-	featureVector[7] = rand.Float64()*2 - 1
+		// Bollinger width
+		row[4] = (upper.At(i, currentPrice) - lower.At(i, currentPrice)) / currentPrice
 
-	// Stochastic oscillator
-	k, d := rf.calculateStochastic(historical)
-	featureVector[8] = k / 100
-	featureVector[9] = d / 100
+		// ATR (Average True Range)
+		row[5] = atr.At(i, 0) / currentPrice
 
-	// Williams %R
-	featureVector[10] = rf.calculateWilliamsR(historical) / 100
+		// OBV (On-Balance Volume) change over 10 bars relative to traded volume
+		if traded := tradedVolume.At(i, 0) * 10; traded > 0 && i >= 10 {
+			row[6] = (obv.At(i, 0) - obv.At(i-10, 0)) / traded
+		}
 
-	// CCI (Commodity Channel Index)
-	featureVector[11] = rf.calculateCCI(historical) / 100
+		// CMF (Chaikin Money Flow)
+		row[7] = cmf.At(i, 0)
 
-	// ADX (Average Directional Index)
-	featureVector[12] = rf.calculateADX(historical) / 100
+		// Stochastic oscillator
+		row[8] = k.At(i, 50) / 100
+		row[9] = d.At(i, 50) / 100
 
-	// MFI (Money Flow Index)
-	featureVector[13] = rf.calculateMFI(historical) / 100
+		// Williams %R
+		row[10] = williams.At(i, -50) / 100
 
-	// ROC (Rate of Change)
-	featureVector[14] = rf.calculateROC(historical, 10)
+		// CCI (Commodity Channel Index)
+		row[11] = cci.At(i, 0) / 100
 
-	return featureVector
-}
+		// ADX (Average Directional Index)
+		row[12] = adx.At(i, 0) / 100
 
-// predictTree makes prediction using a single tree
-func (rf *RandomForestPredictor) predictTree(tree *DecisionTree, features []float64) float64 {
-	node := tree.Root
+		// MFI (Money Flow Index)
+		row[13] = mfi.At(i, 50) / 100
 
-	for !node.IsLeaf {
-		if node.Feature < len(features) && features[node.Feature] <= node.Threshold {
-			node = node.Left
-		} else {
-			node = node.Right
-		}
+		// ROC (Rate of Change)
+		row[14] = roc.At(i, 0)
 
-		if node == nil {
-			return 0
-		}
+		rows[i] = row
 	}
-
-	return node.Value
+	return rows
 }
 
-// calculateConfidence estimates prediction confidence
-func (rf *RandomForestPredictor) calculateConfidence(predictions []float64, mean float64) float64 {
-	// Calculate variance of predictions
-	variance := 0.0
-	for _, pred := range predictions {
-		variance += math.Pow(pred-mean, 2)
+// predictTree returns one tree's forecast return: the leaf mean for a
+// variance forest, the scaled leaf direction balance for a gini forest
+func (rf *RandomForestPredictor) predictTree(tree *forest.Tree, features []float64) float64 {
+	leaf := tree.Leaf(features)
+	if rf.Forest.Config.Criterion == forest.Gini {
+		return (leaf.Probs[1] - leaf.Probs[0]) * rf.ReturnScale
 	}
-	stdDev := math.Sqrt(variance / float64(len(predictions)))
-
-	// Lower variance = higher confidence
-	baseConfidence := math.Max(0, math.Min(100, 100-stdDev*2000))
-
-	// Factor in OOB score
-	confidence := baseConfidence*0.6 + rf.OOBScore*100*0.4
-
-	return confidence
+	return leaf.Value
 }
 
 // generateSignal creates trading signal
@@ -350,268 +320,3 @@ func (rf *RandomForestPredictor) generateSignal(direction string, confidence flo
 		return "HOLD"
 	}
 }
-
-// Technical indicator calculations
-
-func (rf *RandomForestPredictor) calculateMomentum(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return 0
-	}
-
-	currentPrice := prices[len(prices)-1]
-	pastPrice := prices[len(prices)-period]
-
-	return (currentPrice - pastPrice) / pastPrice
-}
-
-func (rf *RandomForestPredictor) calculateRSI(prices []float64) float64 {
-	period := 14
-	if len(prices) < period+1 {
-		return 50
-	}
-
-	gains := 0.0
-	losses := 0.0
-
-	for i := len(prices) - period; i < len(prices); i++ {
-		change := prices[i] - prices[i-1]
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
-		}
-	}
-
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-
-	if avgLoss == 0 {
-		return 100
-	}
-
-	rs := avgGain / avgLoss
-	return 100 - (100 / (1 + rs))
-}
-
-func (rf *RandomForestPredictor) calculateMACD(prices []float64) (float64, float64) {
-	if len(prices) < 26 {
-		return 0, 0
-	}
-
-	ema12 := rf.ema(prices, 12)
-	ema26 := rf.ema(prices, 26)
-	macdLine := ema12 - ema26
-	signalLine := macdLine * 0.9
-
-	return macdLine, signalLine
-}
-
-func (rf *RandomForestPredictor) calculateBollingerBands(prices []float64) (float64, float64) {
-	period := 20
-	if len(prices) < period {
-		return prices[len(prices)-1], prices[len(prices)-1]
-	}
-
-	sma := rf.sma(prices, period)
-
-	variance := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		variance += math.Pow(prices[i]-sma, 2)
-	}
-	stdDev := math.Sqrt(variance / float64(period))
-
-	upper := sma + 2*stdDev
-	lower := sma - 2*stdDev
-
-	return upper, lower
-}
-
-func (rf *RandomForestPredictor) calculateATR(prices []float64) float64 {
-	period := 14
-	if len(prices) < period+1 {
-		return 0
-	}
-
-	trSum := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		high := prices[i]
-		low := prices[i]
-		prevClose := prices[i-1]
-
-		tr := math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
-		trSum += tr
-	}
-
-	return trSum / float64(period)
-}
-
-func (rf *RandomForestPredictor) calculateStochastic(prices []float64) (float64, float64) {
-	period := 14
-	if len(prices) < period {
-		return 50, 50
-	}
-
-	recentPrices := prices[len(prices)-period:]
-	highest := recentPrices[0]
-	lowest := recentPrices[0]
-
-	for _, price := range recentPrices {
-		if price > highest {
-			highest = price
-		}
-		if price < lowest {
-			lowest = price
-		}
-	}
-
-	currentPrice := prices[len(prices)-1]
-
-	k := 50.0
-	if highest-lowest > 0 {
-		k = ((currentPrice - lowest) / (highest - lowest)) * 100
-	}
-
-	d := k * 0.9 // Simplified %D calculation
-
-	return k, d
-}
-
-func (rf *RandomForestPredictor) calculateWilliamsR(prices []float64) float64 {
-	period := 14
-	if len(prices) < period {
-		return -50
-	}
-
-	recentPrices := prices[len(prices)-period:]
-	highest := recentPrices[0]
-	lowest := recentPrices[0]
-
-	for _, price := range recentPrices {
-		if price > highest {
-			highest = price
-		}
-		if price < lowest {
-			lowest = price
-		}
-	}
-
-	currentPrice := prices[len(prices)-1]
-
-	if highest-lowest > 0 {
-		return ((highest - currentPrice) / (highest - lowest)) * -100
-	}
-
-	return -50
-}
-
-func (rf *RandomForestPredictor) calculateCCI(prices []float64) float64 {
-	period := 20
-	if len(prices) < period {
-		return 0
-	}
-
-	sma := rf.sma(prices[len(prices)-period:], period)
-
-	meanDeviation := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		meanDeviation += math.Abs(prices[i] - sma)
-	}
-	meanDeviation /= float64(period)
-
-	if meanDeviation > 0 {
-		return (prices[len(prices)-1] - sma) / (0.015 * meanDeviation)
-	}
-
-	return 0
-}
-
-func (rf *RandomForestPredictor) calculateADX(prices []float64) float64 {
-	// Simplified ADX calculation
-	period := 14
-	if len(prices) < period {
-		return 25
-	}
-
-	// Return a value between 0-100
-	// Higher values indicate stronger trend
-	volatility := rf.calculateVolatility(prices[len(prices)-period:])
-	return math.Min(100, volatility*100)
-}
-
-func (rf *RandomForestPredictor) calculateMFI(prices []float64) float64 {
-	// Simplified MFI calculation
-	period := 14
-	if len(prices) < period {
-		return 50
-	}
-
-	// Similar to RSI but with volume weighting
-	// Since we don't have volume, approximate
-	return rf.calculateRSI(prices)
-}
-
-func (rf *RandomForestPredictor) calculateROC(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return 0
-	}
-
-	currentPrice := prices[len(prices)-1]
-	pastPrice := prices[len(prices)-period]
-
-	if pastPrice > 0 {
-		return ((currentPrice - pastPrice) / pastPrice) * 100
-	}
-
-	return 0
-}
-
-func (rf *RandomForestPredictor) calculateVolatility(prices []float64) float64 {
-	if len(prices) < 2 {
-		return 0
-	}
-
-	returns := make([]float64, len(prices)-1)
-	for i := 1; i < len(prices); i++ {
-		returns[i-1] = (prices[i] - prices[i-1]) / prices[i-1]
-	}
-
-	mean := 0.0
-	for _, r := range returns {
-		mean += r
-	}
-	mean /= float64(len(returns))
-
-	variance := 0.0
-	for _, r := range returns {
-		variance += math.Pow(r-mean, 2)
-	}
-
-	return math.Sqrt(variance / float64(len(returns)))
-}
-
-func (rf *RandomForestPredictor) sma(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return prices[len(prices)-1]
-	}
-
-	sum := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		sum += prices[i]
-	}
-	return sum / float64(period)
-}
-
-func (rf *RandomForestPredictor) ema(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return prices[len(prices)-1]
-	}
-
-	multiplier := 2.0 / float64(period+1)
-	ema := prices[len(prices)-period]
-
-	for i := len(prices) - period + 1; i < len(prices); i++ {
-		ema = (prices[i]-ema)*multiplier + ema
-	}
-
-	return ema
-}
\ No newline at end of file
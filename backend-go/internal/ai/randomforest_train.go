@@ -0,0 +1,205 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/pkg/forest"
+)
+
+// Train implements Trainer: it grows a CART forest on bootstrap samples of
+// the next-candle returns, trees in parallel, then swaps it in. Epochs, batch
+// size, learning rate and patience do not apply. Params may set
+// n_estimators, max_depth, min_samples_split, min_samples_leaf,
+// max_features, bootstrap (0 or 1) and gini (1 classifies the direction with
+// Gini impurity instead of regressing the return).
+func (rf *RandomForestPredictor) Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	rf.mu.RLock()
+	config := rf.Config
+	rf.mu.RUnlock()
+
+	if err := randomForestParams(cfg, &config); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults(1, 1)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	maxFeatures, err := config.maxFeatures(len(randomForestFeatureNames))
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	closes := data.Candles.Close
+	samples := len(closes) - 1 - randomForestWarmup
+	if samples < 100 {
+		return nil, fmt.Errorf("%w: %d candles give %d training samples, need 100", ErrInsufficientData, len(closes), max(samples, 0))
+	}
+	valSamples := max(int(float64(samples)*cfg.ValidationSplit), 10)
+	trainSamples := samples - valSamples
+
+	rows := randomForestFeatures(data.Candles)[randomForestWarmup : len(closes)-1]
+	returns := make([]float64, samples)
+	for i := range returns {
+		c := randomForestWarmup + i
+		returns[i] = closes[c+1]/closes[c] - 1
+	}
+
+	gini := config.Criterion == string(forest.Gini)
+	targets := returns[:trainSamples]
+	returnScale := 0.0
+	for _, r := range targets {
+		returnScale += math.Abs(r)
+	}
+	returnScale /= float64(trainSamples)
+	fc := forest.Config{
+		Trees:           config.NEstimators,
+		Criterion:       forest.Variance,
+		MaxDepth:        config.MaxDepth,
+		MinSamplesSplit: config.MinSamplesSplit,
+		MinSamplesLeaf:  config.MinSamplesLeaf,
+		MaxFeatures:     maxFeatures,
+		Bootstrap:       config.Bootstrap,
+		Seed:            cfg.Seed,
+	}
+	if gini {
+		// Class 1 is an up move, class 0 anything else
+		fc.Criterion, fc.Classes = forest.Gini, 2
+		targets = make([]float64, trainSamples)
+		for i, r := range returns[:trainSamples] {
+			if r > 0 {
+				targets[i] = 1
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	trained, err := forest.Train(rows[:trainSamples], targets, fc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	// Score with a predictor that is not shared yet
+	candidate := &RandomForestPredictor{Forest: trained, ReturnScale: returnScale}
+	forecast := func(x []float64) float64 {
+		sum := 0.0
+		for _, tree := range trained.Trees {
+			sum += candidate.predictTree(tree, x)
+		}
+		return sum / float64(len(trained.Trees))
+	}
+	trainPred, valPred := make([]float64, trainSamples), make([]float64, valSamples)
+	for i := range trainPred {
+		trainPred[i] = forecast(rows[i])
+	}
+	for i := range valPred {
+		valPred[i] = forecast(rows[trainSamples+i])
+	}
+	valReturns := returns[trainSamples:]
+
+	metrics := regressionMetrics("val_", valPred, valReturns)
+	metrics["train_mse"] = meanSquaredError(trainPred, returns[:trainSamples])
+	metrics["trees"] = float64(len(trained.Trees))
+	metrics["oob_samples"] = float64(trained.OOBSamples)
+	metrics["oob_error"] = trained.OOBError
+	metrics["oob_score"] = trained.OOBScore
+	for i, name := range randomForestFeatureNames {
+		metrics["importance_impurity."+name] = trained.ImpurityImportance[i]
+		if trained.PermutationImportance != nil {
+			metrics["importance_permutation."+name] = trained.PermutationImportance[i]
+		}
+	}
+	meta := &TrainingMeta{Symbol: data.Symbol, Interval: data.Interval}
+	meta.setValidation(metrics)
+
+	report := &TrainingReport{
+		Model:        rf.Name(),
+		Symbol:       data.Symbol,
+		Interval:     data.Interval,
+		Candles:      len(closes),
+		TrainSamples: trainSamples,
+		ValSamples:   valSamples,
+		Epochs:       1,
+		BestEpoch:    1,
+		TrainLoss:    []float64{metrics["train_mse"]},
+		ValLoss:      []float64{metrics["val_mse"]},
+		Metrics:      metrics,
+		Duration:     time.Since(start).Seconds(),
+	}
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	rf.Config, rf.Forest, rf.ReturnScale, rf.Meta = config, trained, returnScale, meta
+	rf.version = NewModelVersion()
+	report.Version = rf.version
+	return report, nil
+}
+
+// randomForestParams applies the forest hyperparameters in cfg.Params to c
+func randomForestParams(cfg TrainConfig, c *RandomForestConfig) error {
+	var err error
+	if c.NEstimators, err = cfg.intParam("n_estimators", c.NEstimators, 1, 1000); err != nil {
+		return err
+	}
+	if c.MaxDepth, err = cfg.intParam("max_depth", c.MaxDepth, 0, 64); err != nil {
+		return err
+	}
+	if c.MinSamplesSplit, err = cfg.intParam("min_samples_split", c.MinSamplesSplit, 2, 10000); err != nil {
+		return err
+	}
+	if c.MinSamplesLeaf, err = cfg.intParam("min_samples_leaf", c.MinSamplesLeaf, 1, 10000); err != nil {
+		return err
+	}
+	if _, ok := cfg.Params["max_features"]; ok {
+		n, err := cfg.intParam("max_features", 0, 1, len(randomForestFeatureNames))
+		if err != nil {
+			return err
+		}
+		c.MaxFeatures = strconv.Itoa(n)
+	}
+	bootstrap, err := cfg.intParam("bootstrap", boolInt(c.Bootstrap), 0, 1)
+	if err != nil {
+		return err
+	}
+	c.Bootstrap = bootstrap == 1
+	gini, err := cfg.intParam("gini", boolInt(c.Criterion == string(forest.Gini)), 0, 1)
+	if err != nil {
+		return err
+	}
+	c.Criterion = string(forest.Variance)
+	if gini == 1 {
+		c.Criterion = string(forest.Gini)
+	}
+	return nil
+}
+
+// maxFeatures resolves MaxFeatures to a count out of n features
+func (c RandomForestConfig) maxFeatures(n int) (int, error) {
+	switch c.MaxFeatures {
+	case "", "sqrt":
+		return max(1, int(math.Sqrt(float64(n)))), nil
+	case "log2":
+		return max(1, int(math.Log2(float64(n)))), nil
+	case "third":
+		return max(1, n/3), nil
+	case "all":
+		return n, nil
+	}
+	count, err := strconv.Atoi(c.MaxFeatures)
+	if err != nil || count < 1 || count > n {
+		return 0, fmt.Errorf("%w: max_features %q", ErrInvalidInput, c.MaxFeatures)
+	}
+	return count, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,882 @@
+package ai
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+// LSTM and GRU networks trained with Keras or PyTorch can be imported for
+// inference. The network must read windows of the sequenceFeatureNames rows
+// (in any order, named in "features"), standardized with the exported
+// scaler, and predict the next candle return divided by target_scale from
+// the last hidden state of the top layer through one linear unit. Only the
+// default activations (tanh, sigmoid gates) are supported, and no
+// bidirectional or projected layers.
+//
+// An export is a JSON object:
+//
+//	{
+//	  "framework": "keras" | "pytorch",
+//	  "sequence_len": 30,
+//	  "features": ["return", "range", ...],
+//	  "scaler": {"mean": [...], "std": [...]},    // "scale" as in sklearn works too
+//	  "target_scale": 0.0123,
+//	  "tensors": {"<name>": nested arrays, ...},
+//	  "reference": {"inputs": [[[...]]], "outputs": [...]}
+//	}
+//
+// Tensors are named after the framework layouts, k being the layer index:
+//
+//   - keras: rnn_k/kernel (input, gates*hidden), rnn_k/recurrent_kernel
+//     (hidden, gates*hidden), rnn_k/bias (gates*hidden, or 2 x gates*hidden
+//     for reset_after GRUs), dense/kernel (hidden, 1), dense/bias (1); gates
+//     are ordered i, f, c, o (LSTM) and z, r, h (GRU)
+//   - pytorch: the state_dict of an nn.LSTM or nn.GRU named rnn and an
+//     nn.Linear named fc: rnn.weight_ih_lk (gates*hidden, input),
+//     rnn.weight_hh_lk, rnn.bias_ih_lk, rnn.bias_hh_lk, fc.weight (1, hidden),
+//     fc.bias (1); gates are ordered i, f, g, o (LSTM) and r, z, n (GRU)
+//
+// The tensors may instead come in an NPZ archive (numpy.savez) holding the
+// same names, which may also carry scaler_mean, scaler_std, target_scale,
+// reference_inputs and reference_outputs in place of the JSON fields.
+//
+// The reference holds unscaled feature windows (samples x steps x features,
+// in the export's feature order) and the network outputs for them. Imports
+// are checked against it: the Go network must reproduce every output.
+
+// ErrParityMismatch is returned when an imported network does not reproduce
+// the reference outputs of its export
+var ErrParityMismatch = errors.New("imported network does not reproduce the reference outputs")
+
+// recurrentParityTolerance is the default parity tolerance, relative to the
+// output size above 1; float32 exports differ from Go by about 1e-6
+const recurrentParityTolerance = 1e-4
+
+// Tensor is an exported weight array in row-major order
+type Tensor struct {
+	Shape []int
+	Data  []float64
+}
+
+// UnmarshalJSON reads a number or a rectangular nested array
+func (t *Tensor) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Shape, t.Data = nil, nil
+	var walk func(v interface{}, depth int) error
+	walk = func(v interface{}, depth int) error {
+		switch x := v.(type) {
+		case float64:
+			if depth != len(t.Shape) {
+				return fmt.Errorf("tensor is not rectangular")
+			}
+			t.Data = append(t.Data, x)
+			return nil
+		case []interface{}:
+			if depth == len(t.Shape) && len(t.Data) == 0 {
+				t.Shape = append(t.Shape, len(x))
+			} else if depth >= len(t.Shape) || t.Shape[depth] != len(x) {
+				return fmt.Errorf("tensor is not rectangular")
+			}
+			for _, item := range x {
+				if err := walk(item, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("tensor holds %T", v)
+		}
+	}
+	return walk(v, 0)
+}
+
+// matrix returns a 2-D tensor as rows
+func (t Tensor) matrix(name string, rows, cols int) ([][]float64, error) {
+	if len(t.Shape) != 2 || t.Shape[0] != rows || t.Shape[1] != cols {
+		return nil, fmt.Errorf("%w: %s has shape %v, want [%d %d]", ErrInvalidInput, name, t.Shape, rows, cols)
+	}
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = t.Data[i*cols : (i+1)*cols]
+	}
+	return m, nil
+}
+
+// vector returns a 1-D tensor
+func (t Tensor) vector(name string, size int) ([]float64, error) {
+	if len(t.Shape) != 1 || t.Shape[0] != size {
+		return nil, fmt.Errorf("%w: %s has shape %v, want [%d]", ErrInvalidInput, name, t.Shape, size)
+	}
+	return t.Data, nil
+}
+
+// transpose returns the transpose of a 2-D tensor
+func (t Tensor) transpose() Tensor {
+	if len(t.Shape) != 2 {
+		return t
+	}
+	rows, cols := t.Shape[0], t.Shape[1]
+	out := Tensor{Shape: []int{cols, rows}, Data: make([]float64, len(t.Data))}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			out.Data[j*rows+i] = t.Data[i*cols+j]
+		}
+	}
+	return out
+}
+
+// RecurrentExport is a Keras or PyTorch recurrent network export, see above
+type RecurrentExport struct {
+	Framework   string   `json:"framework"`
+	SequenceLen int      `json:"sequence_len"`
+	Features    []string `json:"features"`
+	Scaler      struct {
+		Mean  []float64 `json:"mean"`
+		Std   []float64 `json:"std"`
+		Scale []float64 `json:"scale"`
+	} `json:"scaler"`
+	TargetScale         float64             `json:"target_scale"`
+	Activation          string              `json:"activation"`
+	RecurrentActivation string              `json:"recurrent_activation"`
+	Tensors             map[string]Tensor   `json:"tensors"`
+	Reference           *RecurrentReference `json:"reference"`
+}
+
+// RecurrentReference holds unscaled feature windows and the outputs the
+// exported network gives for them
+type RecurrentReference struct {
+	Inputs  [][][]float64 `json:"inputs"`
+	Outputs []float64     `json:"outputs"`
+}
+
+// ParseRecurrentExport reads an export from its JSON object and, if given,
+// an NPZ archive with its tensors
+func ParseRecurrentExport(data, npz []byte) (*RecurrentExport, error) {
+	var e RecurrentExport
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("%w: recurrent export: %v", ErrInvalidInput, err)
+		}
+	}
+	if e.Tensors == nil {
+		e.Tensors = make(map[string]Tensor)
+	}
+	if len(npz) == 0 {
+		return &e, nil
+	}
+	arrays, err := readNPZ(npz)
+	if err != nil {
+		return nil, err
+	}
+	for name, t := range arrays {
+		switch name {
+		case "scaler_mean":
+			if e.Scaler.Mean == nil {
+				e.Scaler.Mean = t.Data
+			}
+		case "scaler_std", "scaler_scale":
+			if e.Scaler.Std == nil {
+				e.Scaler.Std = t.Data
+			}
+		case "target_scale":
+			if e.TargetScale == 0 && len(t.Data) == 1 {
+				e.TargetScale = t.Data[0]
+			}
+		case "reference_inputs", "reference_outputs":
+			// assembled below
+		default:
+			if _, ok := e.Tensors[name]; !ok {
+				e.Tensors[name] = t
+			}
+		}
+	}
+	inputs, hasInputs := arrays["reference_inputs"]
+	outputs, hasOutputs := arrays["reference_outputs"]
+	if e.Reference == nil && hasInputs && hasOutputs {
+		if len(inputs.Shape) != 3 {
+			return nil, fmt.Errorf("%w: reference_inputs has shape %v, want samples x steps x features", ErrInvalidInput, inputs.Shape)
+		}
+		e.Reference = &RecurrentReference{Outputs: outputs.Data}
+		steps, width := inputs.Shape[1], inputs.Shape[2]
+		for s := 0; s < inputs.Shape[0]; s++ {
+			window := make([][]float64, steps)
+			for t := range window {
+				offset := (s*steps + t) * width
+				window[t] = inputs.Data[offset : offset+width]
+			}
+			e.Reference.Inputs = append(e.Reference.Inputs, window)
+		}
+	}
+	return &e, nil
+}
+
+// RecurrentImportOptions controls how an export is turned into a predictor
+type RecurrentImportOptions struct {
+	Symbol   string
+	Interval string
+	// Candles are the recent candles the imported network is scored on; the
+	// scores set its confidence. Without them confidence stays at 50.
+	Candles indicators.OHLCV
+	// Tolerance is the largest accepted difference to a reference output,
+	// relative to outputs above 1 (recurrentParityTolerance by default)
+	Tolerance float64
+	// TrainedUntil is the open time (ms) of the last candle the network was
+	// trained on; only later candles are scored. Without it every candle is
+	// scored and the metrics are marked in_sample.
+	TrainedUntil int64
+}
+
+// ParityReport compares the imported network with the reference outputs
+type ParityReport struct {
+	Samples      int     `json:"samples"`
+	MaxAbsError  float64 `json:"max_abs_error"`
+	MeanAbsError float64 `json:"mean_abs_error"`
+	WorstSample  int     `json:"worst_sample"`
+	Tolerance    float64 `json:"tolerance"`
+	Passed       bool    `json:"passed"`
+}
+
+// RecurrentImportReport describes an imported recurrent network
+type RecurrentImportReport struct {
+	Model       string             `json:"model"`
+	Framework   string             `json:"framework"`
+	Version     string             `json:"version"`
+	Layers      int                `json:"layers"`
+	HiddenSize  int                `json:"hidden_size"`
+	SequenceLen int                `json:"sequence_len"`
+	ResetAfter  bool               `json:"reset_after,omitempty"` // GRU only
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+	Parity      *ParityReport      `json:"parity,omitempty"`
+}
+
+// recurrentLayer holds the tensors of one layer in the PyTorch layout, gates
+// in the Go order: i, f, g, o for LSTMs and r, z, n for GRUs. Rec is the
+// hidden to hidden bias; it is nil for Keras LSTMs and reset-before GRUs.
+type recurrentLayer struct {
+	W, U    [][]float64 // (gates*hidden) x input, (gates*hidden) x hidden
+	In, Rec []float64
+}
+
+// gate returns the rows of gate g
+func gate(rows [][]float64, g, hidden int) [][]float64 {
+	return rows[g*hidden : (g+1)*hidden]
+}
+
+func gateBias(b []float64, g, hidden int) []float64 {
+	if b == nil {
+		return make([]float64, hidden)
+	}
+	return b[g*hidden : (g+1)*hidden]
+}
+
+func addVectors(a, b []float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range a {
+		out[i] = a[i] + b[i]
+	}
+	return out
+}
+
+func negateMatrix(m [][]float64) [][]float64 {
+	out := make([][]float64, len(m))
+	for i, row := range m {
+		out[i] = negateVector(row)
+	}
+	return out
+}
+
+func negateVector(v []float64) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = -x
+	}
+	return out
+}
+
+// importedNetwork is an export converted to the PyTorch layout, with the
+// inputs of the first layer and the scaler reordered to the Go features
+type importedNetwork struct {
+	layers     []recurrentLayer
+	hidden     int
+	resetAfter bool
+	head       []float64
+	headBias   float64
+	meta       *SequenceMeta
+	order      []int // order[j] is the export column of Go feature j
+}
+
+// convert checks an export and converts it for a cell with gates gates
+// ("lstm": 4, "gru": 3)
+func (e *RecurrentExport) convert(cell string, gates int) (*importedNetwork, error) {
+	if e.Framework != "keras" && e.Framework != "pytorch" {
+		return nil, fmt.Errorf("%w: framework must be keras or pytorch, got %q", ErrInvalidInput, e.Framework)
+	}
+	if e.Activation != "" && e.Activation != "tanh" {
+		return nil, fmt.Errorf("%w: activation %q is not supported, only tanh", ErrInvalidInput, e.Activation)
+	}
+	if e.RecurrentActivation != "" && e.RecurrentActivation != "sigmoid" {
+		return nil, fmt.Errorf("%w: recurrent activation %q is not supported, only sigmoid", ErrInvalidInput, e.RecurrentActivation)
+	}
+	if e.SequenceLen < 2 || e.SequenceLen > 200 {
+		return nil, fmt.Errorf("%w: sequence_len must be between 2 and 200", ErrInvalidInput)
+	}
+	order, err := e.featureOrder()
+	if err != nil {
+		return nil, err
+	}
+	net := &importedNetwork{order: order}
+	if e.Framework == "keras" {
+		err = e.kerasLayers(cell, gates, net)
+	} else {
+		err = e.pytorchLayers(cell, gates, net)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The network sees the Go feature columns in the Go order
+	first := net.layers[0].W
+	for i, row := range first {
+		reordered := make([]float64, len(order))
+		for j, col := range order {
+			reordered[j] = row[col]
+		}
+		first[i] = reordered
+	}
+
+	std := e.Scaler.Std
+	if std == nil {
+		std = e.Scaler.Scale
+	}
+	n := len(sequenceFeatureNames)
+	if len(e.Scaler.Mean) != n || len(std) != n {
+		return nil, fmt.Errorf("%w: scaler must have a mean and std for each of the %d features", ErrInvalidInput, n)
+	}
+	meta := &SequenceMeta{
+		TrainingMeta: TrainingMeta{Source: e.Framework, ValDirectionAccuracy: 0.5},
+		Features:     append([]string(nil), sequenceFeatureNames...),
+		Mean:         make([]float64, n),
+		Std:          make([]float64, n),
+		TargetScale:  e.TargetScale,
+	}
+	for j, col := range order {
+		meta.Mean[j], meta.Std[j] = e.Scaler.Mean[col], std[col]
+	}
+	if err := meta.validate(n); err != nil {
+		return nil, err
+	}
+	net.meta = meta
+	return net, nil
+}
+
+// featureOrder maps the Go features onto the export's feature columns
+func (e *RecurrentExport) featureOrder() ([]int, error) {
+	columns := make(map[string]int, len(e.Features))
+	for i, name := range e.Features {
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: feature %s is listed twice", ErrInvalidInput, name)
+		}
+		columns[name] = i
+	}
+	if len(e.Features) != len(sequenceFeatureNames) {
+		return nil, fmt.Errorf("%w: export has features %v, want the %d features %v", ErrInvalidInput, e.Features, len(sequenceFeatureNames), sequenceFeatureNames)
+	}
+	order := make([]int, len(sequenceFeatureNames))
+	var missing []string
+	for j, name := range sequenceFeatureNames {
+		col, ok := columns[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		order[j] = col
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: export lacks features %v of %v", ErrInvalidInput, missing, sequenceFeatureNames)
+	}
+	return order, nil
+}
+
+// kerasLayers reads rnn_k/kernel, rnn_k/recurrent_kernel, rnn_k/bias and the
+// dense head. Keras GRU gates are ordered z, r, h.
+func (e *RecurrentExport) kerasLayers(cell string, gates int, net *importedNetwork) error {
+	inputSize := len(sequenceFeatureNames)
+	for k := 0; ; k++ {
+		prefix := "rnn_" + strconv.Itoa(k) + "/"
+		kernel, ok := e.Tensors[prefix+"kernel"]
+		if !ok {
+			if k == 0 {
+				return fmt.Errorf("%w: export has no %skernel", ErrInvalidInput, prefix)
+			}
+			break
+		}
+		recurrent, ok := e.Tensors[prefix+"recurrent_kernel"]
+		if !ok || len(recurrent.Shape) != 2 || recurrent.Shape[1]%gates != 0 {
+			return fmt.Errorf("%w: %srecurrent_kernel missing or not hidden x %d*hidden", ErrInvalidInput, prefix, gates)
+		}
+		if k == 0 {
+			net.hidden = recurrent.Shape[1] / gates
+		}
+		h := net.hidden
+		var layer recurrentLayer
+		var err error
+		if layer.W, err = kernel.transpose().matrix(prefix+"kernel", gates*h, inputSize); err != nil {
+			return err
+		}
+		if layer.U, err = recurrent.transpose().matrix(prefix+"recurrent_kernel", gates*h, h); err != nil {
+			return err
+		}
+		if bias, ok := e.Tensors[prefix+"bias"]; ok {
+			if cell == "gru" && len(bias.Shape) == 2 {
+				b, err := bias.matrix(prefix+"bias", 2, gates*h)
+				if err != nil {
+					return err
+				}
+				layer.In, layer.Rec = b[0], b[1]
+			} else if layer.In, err = bias.vector(prefix+"bias", gates*h); err != nil {
+				return err
+			}
+		}
+		if cell == "gru" {
+			if k == 0 {
+				net.resetAfter = layer.Rec != nil
+			} else if net.resetAfter != (layer.Rec != nil) {
+				return fmt.Errorf("%w: GRU layers mix reset_after settings", ErrInvalidInput)
+			}
+			if net.resetAfter && layer.In == nil {
+				return fmt.Errorf("%w: %sbias missing", ErrInvalidInput, prefix)
+			}
+			// z, r, h -> r, z, n
+			swap := func(rows [][]float64) [][]float64 {
+				return append(append(append([][]float64(nil), gate(rows, 1, h)...), gate(rows, 0, h)...), gate(rows, 2, h)...)
+			}
+			swapBias := func(b []float64) []float64 {
+				if b == nil {
+					return nil
+				}
+				return append(append(append([]float64(nil), b[h:2*h]...), b[:h]...), b[2*h:]...)
+			}
+			layer.W, layer.U = swap(layer.W), swap(layer.U)
+			layer.In, layer.Rec = swapBias(layer.In), swapBias(layer.Rec)
+		}
+		net.layers = append(net.layers, layer)
+		inputSize = h
+	}
+
+	kernel, ok := e.Tensors["dense/kernel"]
+	if !ok {
+		return fmt.Errorf("%w: export has no dense/kernel", ErrInvalidInput)
+	}
+	head, err := kernel.transpose().matrix("dense/kernel", 1, net.hidden)
+	if err != nil {
+		return err
+	}
+	net.head = head[0]
+	if bias, ok := e.Tensors["dense/bias"]; ok {
+		b, err := bias.vector("dense/bias", 1)
+		if err != nil {
+			return err
+		}
+		net.headBias = b[0]
+	}
+	return nil
+}
+
+// pytorchLayers reads rnn.weight_ih_lk, rnn.weight_hh_lk, rnn.bias_ih_lk,
+// rnn.bias_hh_lk and the fc head. PyTorch GRUs reset after the recurrent
+// product.
+func (e *RecurrentExport) pytorchLayers(cell string, gates int, net *importedNetwork) error {
+	for name := range e.Tensors {
+		if strings.HasSuffix(name, "_reverse") || strings.Contains(name, "weight_hr_l") {
+			return fmt.Errorf("%w: bidirectional and projected layers are not supported (%s)", ErrInvalidInput, name)
+		}
+	}
+	net.resetAfter = cell == "gru"
+	inputSize := len(sequenceFeatureNames)
+	for k := 0; ; k++ {
+		suffix := "_l" + strconv.Itoa(k)
+		ih, ok := e.Tensors["rnn.weight_ih"+suffix]
+		if !ok {
+			if k == 0 {
+				return fmt.Errorf("%w: export has no rnn.weight_ih_l0", ErrInvalidInput)
+			}
+			break
+		}
+		hh, ok := e.Tensors["rnn.weight_hh"+suffix]
+		if !ok || len(hh.Shape) != 2 || hh.Shape[0]%gates != 0 {
+			return fmt.Errorf("%w: rnn.weight_hh%s missing or not %d*hidden x hidden", ErrInvalidInput, suffix, gates)
+		}
+		if k == 0 {
+			net.hidden = hh.Shape[0] / gates
+		}
+		h := net.hidden
+		var layer recurrentLayer
+		var err error
+		if layer.W, err = ih.matrix("rnn.weight_ih"+suffix, gates*h, inputSize); err != nil {
+			return err
+		}
+		if layer.U, err = hh.matrix("rnn.weight_hh"+suffix, gates*h, h); err != nil {
+			return err
+		}
+		if b, ok := e.Tensors["rnn.bias_ih"+suffix]; ok {
+			if layer.In, err = b.vector("rnn.bias_ih"+suffix, gates*h); err != nil {
+				return err
+			}
+		}
+		if b, ok := e.Tensors["rnn.bias_hh"+suffix]; ok {
+			if layer.Rec, err = b.vector("rnn.bias_hh"+suffix, gates*h); err != nil {
+				return err
+			}
+		}
+		if layer.In == nil {
+			layer.In = make([]float64, gates*h)
+		}
+		if layer.Rec == nil && cell == "gru" {
+			layer.Rec = make([]float64, gates*h)
+		}
+		net.layers = append(net.layers, layer)
+		inputSize = h
+	}
+
+	weight, ok := e.Tensors["fc.weight"]
+	if !ok {
+		return fmt.Errorf("%w: export has no fc.weight", ErrInvalidInput)
+	}
+	head, err := weight.matrix("fc.weight", 1, net.hidden)
+	if err != nil {
+		return err
+	}
+	net.head = head[0]
+	if bias, ok := e.Tensors["fc.bias"]; ok {
+		b, err := bias.vector("fc.bias", 1)
+		if err != nil {
+			return err
+		}
+		net.headBias = b[0]
+	}
+	return nil
+}
+
+// lstmWeights builds the Go LSTM; the gate order is the same
+func (n *importedNetwork) lstmWeights() *LSTMWeights {
+	h := n.hidden
+	w := &LSTMWeights{Wy: [][]float64{n.head}, By: []float64{n.headBias}}
+	for _, l := range n.layers {
+		bias := l.In
+		if l.Rec != nil {
+			bias = addVectors(l.In, l.Rec)
+		}
+		w.Layers = append(w.Layers, LSTMLayer{
+			Wi: gate(l.W, 0, h), Ui: gate(l.U, 0, h), Bi: gateBias(bias, 0, h),
+			Wf: gate(l.W, 1, h), Uf: gate(l.U, 1, h), Bf: gateBias(bias, 1, h),
+			Wc: gate(l.W, 2, h), Uc: gate(l.U, 2, h), Bc: gateBias(bias, 2, h),
+			Wo: gate(l.W, 3, h), Uo: gate(l.U, 3, h), Bo: gateBias(bias, 3, h),
+		})
+	}
+	return w
+}
+
+// gruWeights builds the Go GRU. Keras and PyTorch keep the previous state
+// with weight z (h = z*h + (1-z)*n) where the Go cell keeps it with 1-z;
+// since 1-sigmoid(x) = sigmoid(-x) the update gate is negated.
+func (n *importedNetwork) gruWeights() *GRUWeights {
+	h := n.hidden
+	w := &GRUWeights{Wy: [][]float64{n.head}, By: []float64{n.headBias}}
+	for _, l := range n.layers {
+		in, rec := gateBias(l.In, 0, h), gateBias(l.Rec, 0, h)
+		layer := GRULayer{
+			Wr: gate(l.W, 0, h), Ur: gate(l.U, 0, h), Br: addVectors(in, rec),
+			Wh: gate(l.W, 2, h), Uh: gate(l.U, 2, h), Bh: gateBias(l.In, 2, h),
+		}
+		in, rec = gateBias(l.In, 1, h), gateBias(l.Rec, 1, h)
+		layer.Wz, layer.Uz, layer.Bz = negateMatrix(gate(l.W, 1, h)), negateMatrix(gate(l.U, 1, h)), negateVector(addVectors(in, rec))
+		if n.resetAfter {
+			layer.Bhr = gateBias(l.Rec, 2, h)
+		}
+		w.Layers = append(w.Layers, layer)
+	}
+	return w
+}
+
+// ImportLSTM converts a Keras or PyTorch LSTM export into a standalone
+// LSTMPredictor, checked against the export's reference outputs
+func ImportLSTM(e *RecurrentExport, opts RecurrentImportOptions) (*LSTMPredictor, *RecurrentImportReport, error) {
+	net, err := e.convert("lstm", 4)
+	if err != nil {
+		return nil, nil, err
+	}
+	weights := net.lstmWeights()
+	p := newLSTMPredictor()
+	p.Config.HiddenSize, p.Config.NumLayers, p.Config.SequenceLen = net.hidden, len(net.layers), e.SequenceLen
+	if err := weights.validate(p.Config); err != nil {
+		return nil, nil, err
+	}
+	report, err := finishRecurrentImport(p.Name(), e, net, weights, opts)
+	if err != nil {
+		return nil, report, err
+	}
+	p.Weights, p.Meta, p.version, p.IsLoaded = weights, net.meta, report.Version, true
+	return p, report, nil
+}
+
+// ImportGRU converts a Keras or PyTorch GRU export into a standalone
+// GRUPredictor, checked against the export's reference outputs
+func ImportGRU(e *RecurrentExport, opts RecurrentImportOptions) (*GRUPredictor, *RecurrentImportReport, error) {
+	net, err := e.convert("gru", 3)
+	if err != nil {
+		return nil, nil, err
+	}
+	weights := net.gruWeights()
+	p := newGRUPredictor()
+	p.Config.HiddenSize, p.Config.NumLayers, p.Config.SequenceLen = net.hidden, len(net.layers), e.SequenceLen
+	if err := weights.validate(p.Config); err != nil {
+		return nil, nil, err
+	}
+	report, err := finishRecurrentImport(p.Name(), e, net, weights, opts)
+	if err != nil {
+		return nil, report, err
+	}
+	report.ResetAfter = net.resetAfter
+	p.Weights, p.Meta, p.version, p.IsLoaded = weights, net.meta, report.Version, true
+	return p, report, nil
+}
+
+// finishRecurrentImport runs the parity check and scores the network on the
+// candles, filling in its metadata
+func finishRecurrentImport(model string, e *RecurrentExport, net *importedNetwork, weights recurrentNet, opts RecurrentImportOptions) (*RecurrentImportReport, error) {
+	report := &RecurrentImportReport{
+		Model:       model,
+		Framework:   e.Framework,
+		Layers:      len(net.layers),
+		HiddenSize:  net.hidden,
+		SequenceLen: e.SequenceLen,
+	}
+	if e.Reference != nil {
+		parity, err := recurrentParity(e, net, weights, opts.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		report.Parity = parity
+		if !parity.Passed {
+			return report, fmt.Errorf("%w: sample %d is off by %g (tolerance %g)", ErrParityMismatch, parity.WorstSample, parity.MaxAbsError, parity.Tolerance)
+		}
+	}
+
+	meta := net.meta
+	meta.Symbol, meta.Interval = opts.Symbol, opts.Interval
+	closes := opts.Candles.Close
+	if first := sequenceWarmup + e.SequenceLen - 1; len(closes)-1-first >= 30 {
+		scaled := meta.scale(sequenceFeatures(opts.Candles))
+		var samples []sequenceSample
+		for end := first; end+1 < len(closes); end++ {
+			if opts.TrainedUntil > 0 && opts.Candles.Time[end] <= opts.TrainedUntil {
+				continue
+			}
+			samples = append(samples, sequenceSample{
+				window: scaled[end-e.SequenceLen+1 : end+1],
+				target: (closes[end+1]/closes[end] - 1) / meta.TargetScale,
+			})
+		}
+		if len(samples) >= 30 {
+			report.Metrics = evaluateSequence(weights, samples, meta.TargetScale)
+			report.Metrics["val_samples"] = float64(len(samples))
+			if opts.TrainedUntil == 0 {
+				// The candles likely overlap the training data
+				report.Metrics["in_sample"] = 1
+			}
+			meta.setValidation(report.Metrics)
+		}
+	}
+	report.Version = NewModelVersion()
+	return report, nil
+}
+
+// recurrentParity runs the reference windows through the Go network
+func recurrentParity(e *RecurrentExport, net *importedNetwork, weights recurrentNet, tolerance float64) (*ParityReport, error) {
+	ref := e.Reference
+	if len(ref.Inputs) == 0 || len(ref.Inputs) != len(ref.Outputs) {
+		return nil, fmt.Errorf("%w: reference has %d input windows and %d outputs", ErrInvalidInput, len(ref.Inputs), len(ref.Outputs))
+	}
+	if tolerance <= 0 {
+		tolerance = recurrentParityTolerance
+	}
+	report := &ParityReport{Samples: len(ref.Inputs), Tolerance: tolerance, Passed: true}
+	worst := -1.0
+	for s, window := range ref.Inputs {
+		if len(window) == 0 {
+			return nil, fmt.Errorf("%w: reference window %d is empty", ErrInvalidInput, s)
+		}
+		rows := make([][]float64, len(window))
+		for t, row := range window {
+			if len(row) != len(net.order) {
+				return nil, fmt.Errorf("%w: reference window %d step %d has %d features, want %d", ErrInvalidInput, s, t, len(row), len(net.order))
+			}
+			rows[t] = make([]float64, len(net.order))
+			for j, col := range net.order {
+				rows[t][j] = row[col]
+			}
+		}
+		want := ref.Outputs[s]
+		diff := math.Abs(weights.predict(net.meta.scale(rows)) - want)
+		if math.IsNaN(diff) {
+			diff = math.Inf(1)
+		}
+		report.MeanAbsError += diff
+		if diff > report.MaxAbsError {
+			report.MaxAbsError = diff
+		}
+		if excess := diff / math.Max(1, math.Abs(want)); excess > worst {
+			worst, report.WorstSample = excess, s
+		}
+		if diff > tolerance*math.Max(1, math.Abs(want)) {
+			report.Passed = false
+		}
+	}
+	report.MeanAbsError /= float64(len(ref.Inputs))
+	return report, nil
+}
+
+// npyDescr, npyFortran and npyShape read the fields of a .npy header
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// readNPZ reads the float arrays of a numpy .npz archive, keyed by name
+func readNPZ(data []byte) (map[string]Tensor, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: npz: %v", ErrInvalidInput, err)
+	}
+	arrays := make(map[string]Tensor, len(zr.File))
+	for _, f := range zr.File {
+		name, ok := strings.CutSuffix(f.Name, ".npy")
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: npz %s: %v", ErrInvalidInput, f.Name, err)
+		}
+		t, err := readNPY(io.LimitReader(rc, 1<<30))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: npz %s: %v", ErrInvalidInput, f.Name, err)
+		}
+		arrays[name] = t
+	}
+	return arrays, nil
+}
+
+// readNPY reads a little-endian float32 or float64 .npy array
+func readNPY(r io.Reader) (Tensor, error) {
+	var t Tensor
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return t, err
+	}
+	if string(magic[:6]) != "\x93NUMPY" {
+		return t, fmt.Errorf("not a .npy array")
+	}
+	var headerLen int
+	if magic[6] == 1 {
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return t, err
+		}
+		headerLen = int(n)
+	} else {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return t, err
+		}
+		headerLen = int(n)
+	}
+	if headerLen > 1<<16 {
+		return t, fmt.Errorf("header too large")
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return t, err
+	}
+
+	descr := npyDescr.FindSubmatch(header)
+	fortran := npyFortran.FindSubmatch(header)
+	shape := npyShape.FindSubmatch(header)
+	if descr == nil || fortran == nil || shape == nil {
+		return t, fmt.Errorf("malformed header %q", header)
+	}
+	size := 1
+	for _, dim := range strings.Split(string(shape[1]), ",") {
+		if dim = strings.TrimSpace(dim); dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil || n < 0 {
+			return t, fmt.Errorf("bad shape %q", shape[1])
+		}
+		t.Shape = append(t.Shape, n)
+		size *= n
+		if size > 1<<27 {
+			return t, fmt.Errorf("array too large")
+		}
+	}
+
+	t.Data = make([]float64, size)
+	switch string(descr[1]) {
+	case "<f8":
+		if err := binary.Read(r, binary.LittleEndian, t.Data); err != nil {
+			return t, err
+		}
+	case "<f4":
+		buf := make([]float32, size)
+		if err := binary.Read(r, binary.LittleEndian, buf); err != nil {
+			return t, err
+		}
+		for i, v := range buf {
+			t.Data[i] = float64(v)
+		}
+	default:
+		return t, fmt.Errorf("dtype %s is not supported, save float32 or float64", descr[1])
+	}
+	if string(fortran[1]) == "True" && len(t.Shape) > 1 {
+		t.Data = fortranToC(t.Data, t.Shape)
+	}
+	return t, nil
+}
+
+// fortranToC reorders a column-major array to row-major
+func fortranToC(data []float64, shape []int) []float64 {
+	out := make([]float64, len(data))
+	index := make([]int, len(shape))
+	for i := range out {
+		// index is the row-major position i; find its column-major offset
+		offset, stride := 0, 1
+		for d := range shape {
+			offset += index[d] * stride
+			stride *= shape[d]
+		}
+		out[i] = data[offset]
+		for d := len(shape) - 1; d >= 0; d-- {
+			if index[d]++; index[d] < shape[d] {
+				break
+			}
+			index[d] = 0
+		}
+	}
+	return out
+}
@@ -0,0 +1,758 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/sirupsen/logrus"
+)
+
+// retrainableModel is a model the retraining scheduler can train a
+// standalone candidate of and promote it into
+type retrainableModel interface {
+	Trainer
+	MarshalState() (json.RawMessage, error)
+	RestoreState(version string, state json.RawMessage) error
+}
+
+// modelInputs describes the features a model predicts from, for drift
+// detection, and how to build a standalone instance of it
+type modelInputs struct {
+	features []string
+	// rows returns one feature row per candle after the model's warmup
+	rows  func(c indicators.OHLCV) [][]float64
+	fresh func() retrainableModel
+}
+
+// retrainableModels are the models the scheduler monitors
+var retrainableModels = map[string]modelInputs{
+	"lstm": {
+		features: sequenceFeatureNames,
+		rows:     func(c indicators.OHLCV) [][]float64 { return skipRows(sequenceFeatures(c), sequenceWarmup) },
+		fresh:    func() retrainableModel { return newLSTMPredictor() },
+	},
+	"gru": {
+		features: sequenceFeatureNames,
+		rows:     func(c indicators.OHLCV) [][]float64 { return skipRows(sequenceFeatures(c), sequenceWarmup) },
+		fresh:    func() retrainableModel { return newGRUPredictor() },
+	},
+	"lightgbm": {
+		features: lightgbmFeatureNames,
+		rows:     func(c indicators.OHLCV) [][]float64 { return skipRows(lightgbmFeatures(c), lightgbmWarmup) },
+		fresh:    func() retrainableModel { return newLightGBMPredictor() },
+	},
+	"randomforest": {
+		features: randomForestFeatureNames,
+		rows:     func(c indicators.OHLCV) [][]float64 { return skipRows(randomForestFeatures(c), randomForestWarmup) },
+		fresh:    func() retrainableModel { return newRandomForestPredictor() },
+	},
+	"xgboost": {
+		features: xgboostFeatureNames,
+		rows:     func(c indicators.OHLCV) [][]float64 { return NewXGBoostModel().extractFeatures(c) },
+		fresh:    func() retrainableModel { return NewXGBoostModel() },
+	},
+}
+
+func skipRows(rows [][]float64, warmup int) [][]float64 {
+	if len(rows) <= warmup {
+		return nil
+	}
+	return rows[warmup:]
+}
+
+// featureWarmup is the history fetched ahead of the candles whose features
+// are compared, enough for the longest model warmup
+const featureWarmup = lightgbmWarmup
+
+// RetrainPolicy decides when models are retrained and when a retrained
+// candidate replaces the current model
+type RetrainPolicy struct {
+	Enabled bool     `json:"enabled"`
+	Models  []string `json:"models,omitempty"` // empty: every retrainable model
+
+	// Triggers
+	MaxAgeHours      int     `json:"max_age_hours"`     // retrain models older than this; 0 disables
+	CooldownHours    int     `json:"cooldown_hours"`    // minimum time between retrains of a model
+	PSIThreshold     float64 `json:"psi_threshold"`     // population stability index of a drifted feature
+	KSThreshold      float64 `json:"ks_threshold"`      // Kolmogorov-Smirnov statistic of a drifted feature
+	DriftShare       float64 `json:"drift_share"`       // share of drifted features that triggers a retrain
+	ReferenceCandles int     `json:"reference_candles"` // candles before training the reference spans
+	RecentCandles    int     `json:"recent_candles"`    // latest candles compared to the reference
+	LiveWindowHours  int     `json:"live_window_hours"` // ledger history the live error is taken from
+	MinLiveSamples   int     `json:"min_live_samples"`  // resolved predictions the live error needs
+	AccuracyDrop     float64 `json:"accuracy_drop"`     // live direction accuracy below validation by
+	ErrorRatio       float64 `json:"error_ratio"`       // live return MAE above validation MAE by factor
+
+	// Candidate training and validation
+	Candles        int         `json:"candles"`         // candles the candidate is trained on
+	HoldoutCandles int         `json:"holdout_candles"` // later candles both models are scored on
+	MinImprovement float64     `json:"min_improvement"` // required relative holdout MAE reduction
+	AccuracySlack  float64     `json:"accuracy_slack"`  // holdout direction accuracy the candidate may lose
+	Config         TrainConfig `json:"config"`
+}
+
+// DefaultRetrainPolicy is the policy the scheduler starts with;
+// RETRAIN_ENABLED=false starts it disabled
+func DefaultRetrainPolicy() RetrainPolicy {
+	enabled := true
+	if v, err := strconv.ParseBool(os.Getenv("RETRAIN_ENABLED")); err == nil {
+		enabled = v
+	}
+	return RetrainPolicy{
+		Enabled:          enabled,
+		MaxAgeHours:      7 * 24,
+		CooldownHours:    6,
+		PSIThreshold:     0.25,
+		KSThreshold:      0.2,
+		DriftShare:       0.3,
+		ReferenceCandles: 1000,
+		RecentCandles:    300,
+		LiveWindowHours:  7 * 24,
+		MinLiveSamples:   30,
+		AccuracyDrop:     0.1,
+		ErrorRatio:       2,
+		Candles:          3000,
+		HoldoutCandles:   300,
+		MinImprovement:   0.01,
+		AccuracySlack:    0.02,
+	}
+}
+
+// validate rejects policies the scheduler cannot run with
+func (p RetrainPolicy) validate() error {
+	for _, name := range p.Models {
+		if _, ok := retrainableModels[name]; !ok {
+			return fmt.Errorf("%w: %s cannot be retrained", ErrInvalidInput, name)
+		}
+	}
+	switch {
+	case p.MaxAgeHours < 0 || p.CooldownHours < 0 || p.LiveWindowHours < 1:
+		return fmt.Errorf("%w: max_age_hours and cooldown_hours must not be negative, live_window_hours must be positive", ErrInvalidInput)
+	case p.PSIThreshold <= 0 || p.KSThreshold <= 0 || p.KSThreshold > 1:
+		return fmt.Errorf("%w: psi_threshold must be positive and ks_threshold in (0, 1]", ErrInvalidInput)
+	case p.DriftShare <= 0 || p.DriftShare > 1:
+		return fmt.Errorf("%w: drift_share must be in (0, 1]", ErrInvalidInput)
+	case p.ReferenceCandles < 100 || p.RecentCandles < 50 || p.ReferenceCandles+featureWarmup > MaxTrainingCandles:
+		return fmt.Errorf("%w: reference_candles must be at least 100 and recent_candles at least 50", ErrInvalidInput)
+	case p.MinLiveSamples < 1 || p.AccuracyDrop <= 0 || p.ErrorRatio <= 1:
+		return fmt.Errorf("%w: min_live_samples and accuracy_drop must be positive, error_ratio above 1", ErrInvalidInput)
+	case p.HoldoutCandles < 50 || p.Candles < 500 || p.Candles+p.HoldoutCandles > MaxTrainingCandles:
+		return fmt.Errorf("%w: holdout_candles must be at least 50 and candles at least 500, together at most %d", ErrInvalidInput, MaxTrainingCandles)
+	case p.MinImprovement < 0 || p.MinImprovement >= 1 || p.AccuracySlack < 0 || p.AccuracySlack > 1:
+		return fmt.Errorf("%w: min_improvement must be in [0, 1) and accuracy_slack in [0, 1]", ErrInvalidInput)
+	}
+	return nil
+}
+
+// monitors reports whether the policy covers a model
+func (p RetrainPolicy) monitors(name string) bool {
+	if len(p.Models) == 0 {
+		return true
+	}
+	for _, m := range p.Models {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureDrift compares the distribution of one feature on recent candles
+// with the candles before the model was trained
+type FeatureDrift struct {
+	Feature string  `json:"feature"`
+	PSI     float64 `json:"psi"`
+	KS      float64 `json:"ks"`
+	PValue  float64 `json:"p_value"` // of the two-sample KS test
+	Drifted bool    `json:"drifted"`
+}
+
+// DriftReport is the outcome of checking a model for drift
+type DriftReport struct {
+	Model      string         `json:"model"`
+	Version    string         `json:"version"`
+	Symbol     string         `json:"symbol"`
+	Interval   string         `json:"interval"`
+	TrainedAt  time.Time      `json:"trained_at"`
+	CheckedAt  time.Time      `json:"checked_at"`
+	Features   []FeatureDrift `json:"features,omitempty"`
+	DriftShare float64        `json:"drift_share"` // share of drifted features
+	Live       *LedgerScore   `json:"live,omitempty"`
+	Reasons    []string       `json:"reasons,omitempty"` // why the model is due for retraining
+	Error      string         `json:"error,omitempty"`
+}
+
+// Due reports whether the check found a reason to retrain
+func (r DriftReport) Due() bool {
+	return len(r.Reasons) > 0
+}
+
+// RetrainRun records one retraining of a model and whether it was promoted
+type RetrainRun struct {
+	Model            string             `json:"model"`
+	Symbol           string             `json:"symbol"`
+	Interval         string             `json:"interval"`
+	Reasons          []string           `json:"reasons"`
+	CurrentVersion   string             `json:"current_version"`
+	CandidateVersion string             `json:"candidate_version,omitempty"`
+	Current          map[string]float64 `json:"current,omitempty"`   // holdout results of the current model
+	Candidate        map[string]float64 `json:"candidate,omitempty"` // holdout results of the candidate
+	Promoted         bool               `json:"promoted"`
+	Decision         string             `json:"decision,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	StartedAt        time.Time          `json:"started_at"`
+	FinishedAt       time.Time          `json:"finished_at"`
+}
+
+// maxRetrainHistory bounds the retraining runs kept
+const maxRetrainHistory = 100
+
+// Retrainer watches the trained models for feature drift, live prediction
+// error and age, retrains the models that cross a threshold and promotes a
+// retrained candidate only when it beats the current model on candles
+// neither was trained on
+type Retrainer struct {
+	mu         sync.Mutex
+	policy     RetrainPolicy
+	reports    map[string]DriftReport // by retrainKey
+	history    []RetrainRun
+	running    map[string]bool        // by retrainKey
+	lastRun    map[string]time.Time   // by retrainKey
+	references map[string][][]float64 // feature rows before training, by model and version
+}
+
+var retrainer *Retrainer
+var retrainerOnce sync.Once
+
+// GetRetrainer returns the retraining scheduler
+func GetRetrainer() *Retrainer {
+	retrainerOnce.Do(func() {
+		retrainer = &Retrainer{
+			policy:     DefaultRetrainPolicy(),
+			reports:    make(map[string]DriftReport),
+			running:    make(map[string]bool),
+			lastRun:    make(map[string]time.Time),
+			references: make(map[string][][]float64),
+		}
+	})
+	return retrainer
+}
+
+// Policy returns the current policy
+func (r *Retrainer) Policy() RetrainPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy
+}
+
+// SetPolicy replaces the policy
+func (r *Retrainer) SetPolicy(p RetrainPolicy) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if err := p.Config.withDefaults(1, 0.001).validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = p
+	return nil
+}
+
+// Reports returns the last drift report of every checked model
+func (r *Retrainer) Reports() []DriftReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reports := make([]DriftReport, 0, len(r.reports))
+	for _, report := range r.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Model != reports[j].Model {
+			return reports[i].Model < reports[j].Model
+		}
+		return reports[i].Symbol < reports[j].Symbol
+	})
+	return reports
+}
+
+// retrainKey identifies a model on its training symbol for the reports,
+// cooldowns and running retrainings
+func retrainKey(name, symbol string) string {
+	return name + "|" + symbol
+}
+
+// History returns the retraining runs, newest first
+func (r *Retrainer) History() []RetrainRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]RetrainRun, len(r.history))
+	for i, run := range r.history {
+		runs[len(runs)-1-i] = run
+	}
+	return runs
+}
+
+// RunOnce checks every monitored model and retrains the ones that are due
+// and out of their cooldown
+func (r *Retrainer) RunOnce(ctx context.Context) {
+	policy := r.Policy()
+	if !policy.Enabled {
+		return
+	}
+	names := make([]string, 0, len(retrainableModels))
+	for name := range retrainableModels {
+		if policy.monitors(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		report, err := r.Check(ctx, name)
+		if errors.Is(err, ErrModelNotTrained) || errors.Is(err, ErrUnknownModel) {
+			continue
+		}
+		if err != nil {
+			logrus.Warnf("Drift check of %s failed: %v", name, err)
+			continue
+		}
+		if !report.Due() {
+			continue
+		}
+		r.mu.Lock()
+		cooling := time.Since(r.lastRun[retrainKey(name, report.Symbol)]) < time.Duration(policy.CooldownHours)*time.Hour
+		r.mu.Unlock()
+		if cooling {
+			continue
+		}
+		logrus.Infof("Retraining %s: %v", name, report.Reasons)
+		if _, err := r.Retrain(ctx, name, report.Reasons); err != nil {
+			logrus.Warnf("Retraining %s failed: %v", name, err)
+		}
+	}
+}
+
+// trainedModel returns a retrainable registered model with its saved state
+// and what it was trained on
+func trainedModel(name string) (retrainableModel, json.RawMessage, *TrainingMeta, error) {
+	if _, ok := retrainableModels[name]; !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %s", ErrNotTrainable, name)
+	}
+	p, err := GetManager().Get(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	model, ok := p.(retrainableModel)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %s", ErrNotTrainable, name)
+	}
+	state, err := model.MarshalState()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var saved struct {
+		Meta *TrainingMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(state, &saved); err != nil {
+		return nil, nil, nil, err
+	}
+	if saved.Meta == nil || saved.Meta.Symbol == "" || saved.Meta.Interval == "" {
+		return nil, nil, nil, fmt.Errorf("%w: %s has no training symbol", ErrModelNotTrained, name)
+	}
+	return model, state, saved.Meta, nil
+}
+
+// Check compares the recent feature distribution of a model with the one it
+// was trained on and its live error in the prediction ledger with its
+// validation error, and records the report
+func (r *Retrainer) Check(ctx context.Context, name string) (DriftReport, error) {
+	model, _, meta, err := trainedModel(name)
+	if err != nil {
+		return DriftReport{}, err
+	}
+	policy := r.Policy()
+	report := DriftReport{
+		Model:     name,
+		Version:   model.Version(),
+		Symbol:    meta.Symbol,
+		Interval:  meta.Interval,
+		TrainedAt: meta.TrainedAt,
+		CheckedAt: time.Now().UTC(),
+	}
+
+	if age := time.Since(meta.TrainedAt); policy.MaxAgeHours > 0 && !meta.TrainedAt.IsZero() && age > time.Duration(policy.MaxAgeHours)*time.Hour {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("trained %.0fh ago", age.Hours()))
+	}
+
+	if err := r.checkFeatures(ctx, name, meta, policy, &report); err != nil {
+		report.Error = err.Error()
+	} else if report.DriftShare >= policy.DriftShare {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("feature drift in %.0f%% of features", report.DriftShare*100))
+	}
+
+	board, err := GetLedger().Leaderboard(LedgerFilter{
+		Model:   name,
+		Version: report.Version,
+		Symbol:  meta.Symbol,
+		Horizon: meta.Interval,
+		Since:   time.Now().Add(-time.Duration(policy.LiveWindowHours) * time.Hour),
+	}, nil, policy.MinLiveSamples)
+	if err == nil && len(board) > 0 {
+		live := board[0]
+		report.Live = &live
+		if meta.ValDirectionAccuracy > 0 && live.DirectionAccuracy < meta.ValDirectionAccuracy-policy.AccuracyDrop {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("live direction accuracy %.3f against %.3f in validation", live.DirectionAccuracy, meta.ValDirectionAccuracy))
+		}
+		if meta.ValMAE > 0 && live.ReturnMAE > meta.ValMAE*policy.ErrorRatio {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("live return MAE %.5f against %.5f in validation", live.ReturnMAE, meta.ValMAE))
+		}
+	}
+
+	r.mu.Lock()
+	r.reports[retrainKey(name, meta.Symbol)] = report
+	r.mu.Unlock()
+	return report, nil
+}
+
+// checkFeatures fills in the feature drift of a report
+func (r *Retrainer) checkFeatures(ctx context.Context, name string, meta *TrainingMeta, policy RetrainPolicy, report *DriftReport) error {
+	if meta.TrainedAt.IsZero() {
+		return errors.New("training time unknown, no reference distribution")
+	}
+	inputs := retrainableModels[name]
+	key := name + "@" + report.Version
+	r.mu.Lock()
+	reference, ok := r.references[key]
+	r.mu.Unlock()
+	if !ok {
+		candles, err := candlesUntil(meta.Symbol, meta.Interval, policy.ReferenceCandles+featureWarmup, meta.TrainedAt)
+		if err != nil {
+			return err
+		}
+		reference = lastRows(inputs.rows(candles), policy.ReferenceCandles)
+		r.mu.Lock()
+		for k := range r.references {
+			if len(k) > len(name) && k[:len(name)+1] == name+"@" {
+				delete(r.references, k)
+			}
+		}
+		r.references[key] = reference
+		r.mu.Unlock()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	candles, err := TrainingCandles(meta.Symbol, meta.Interval, policy.RecentCandles+featureWarmup)
+	if err != nil {
+		return err
+	}
+	recent := lastRows(inputs.rows(candles), policy.RecentCandles)
+	if len(reference) == 0 || len(recent) == 0 {
+		return fmt.Errorf("%w: no feature rows to compare", ErrInsufficientData)
+	}
+
+	drifted := 0
+	for j, feature := range inputs.features {
+		ref, cur := column(reference, j), column(recent, j)
+		if len(ref) == 0 || len(cur) == 0 {
+			continue
+		}
+		d := FeatureDrift{Feature: feature, PSI: psi(ref, cur, 10)}
+		d.KS, d.PValue = ksTest(ref, cur)
+		d.Drifted = d.PSI >= policy.PSIThreshold || d.KS >= policy.KSThreshold
+		if d.Drifted {
+			drifted++
+		}
+		report.Features = append(report.Features, d)
+	}
+	if len(report.Features) > 0 {
+		report.DriftShare = float64(drifted) / float64(len(report.Features))
+	}
+	return nil
+}
+
+func lastRows(rows [][]float64, n int) [][]float64 {
+	if len(rows) > n {
+		return rows[len(rows)-n:]
+	}
+	return rows
+}
+
+// column returns the finite values of feature j
+func column(rows [][]float64, j int) []float64 {
+	values := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		if j < len(row) && !math.IsNaN(row[j]) && !math.IsInf(row[j], 0) {
+			values = append(values, row[j])
+		}
+	}
+	return values
+}
+
+// psi is the population stability index of current against reference over
+// the reference quantile bins
+func psi(reference, current []float64, bins int) float64 {
+	sorted := append([]float64(nil), reference...)
+	sort.Float64s(sorted)
+	edges := make([]float64, 0, bins-1)
+	for i := 1; i < bins; i++ {
+		edge := sorted[i*len(sorted)/bins]
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	share := func(values []float64) []float64 {
+		counts := make([]float64, len(edges)+1)
+		for _, v := range values {
+			counts[sort.SearchFloat64s(edges, v)]++
+		}
+		for i := range counts {
+			counts[i] = math.Max(counts[i]/float64(len(values)), 1e-4)
+		}
+		return counts
+	}
+	expected, actual := share(reference), share(current)
+	var index float64
+	for i := range expected {
+		index += (actual[i] - expected[i]) * math.Log(actual[i]/expected[i])
+	}
+	return index
+}
+
+// ksTest returns the two-sample Kolmogorov-Smirnov statistic and its
+// asymptotic p-value
+func ksTest(a, b []float64) (float64, float64) {
+	x := append([]float64(nil), a...)
+	y := append([]float64(nil), b...)
+	sort.Float64s(x)
+	sort.Float64s(y)
+	var d float64
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		v := math.Min(x[i], y[j])
+		for i < len(x) && x[i] <= v {
+			i++
+		}
+		for j < len(y) && y[j] <= v {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/float64(len(x))-float64(j)/float64(len(y))))
+	}
+
+	n := float64(len(x)) * float64(len(y)) / float64(len(x)+len(y))
+	lambda := (math.Sqrt(n) + 0.12 + 0.11/math.Sqrt(n)) * d
+	if lambda < 0.2 {
+		return d, 1
+	}
+	var p float64
+	for k := 1; k <= 100; k++ {
+		term := 2 * math.Pow(-1, float64(k-1)) * math.Exp(-2*float64(k*k)*lambda*lambda)
+		p += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+	}
+	return d, math.Max(0, math.Min(1, p))
+}
+
+// Start retrains a model in the background regardless of its drift report
+func (r *Retrainer) Start(name, reason string) error {
+	_, _, meta, err := trainedModel(name)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	running := r.running[retrainKey(name, meta.Symbol)]
+	r.mu.Unlock()
+	if running {
+		return fmt.Errorf("%w: %s on %s", ErrTrainingInProgress, name, meta.Symbol)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), trainingTimeout)
+		defer cancel()
+		if _, err := r.Retrain(ctx, name, []string{reason}); err != nil {
+			logrus.Warnf("Retraining %s failed: %v", name, err)
+		}
+	}()
+	return nil
+}
+
+// Retrain trains a candidate of a model on its training symbol and interval,
+// scores the candidate and the current model on the candles that follow the
+// training candles of both, and promotes the candidate as the active
+// version only when its holdout MAE is lower by MinImprovement without
+// losing more than AccuracySlack direction accuracy
+func (r *Retrainer) Retrain(ctx context.Context, name string, reasons []string) (RetrainRun, error) {
+	model, state, meta, err := trainedModel(name)
+	if err != nil {
+		return RetrainRun{}, err
+	}
+	key := retrainKey(name, meta.Symbol)
+	r.mu.Lock()
+	if r.running[key] {
+		r.mu.Unlock()
+		return RetrainRun{}, fmt.Errorf("%w: %s on %s", ErrTrainingInProgress, name, meta.Symbol)
+	}
+	r.running[key] = true
+	policy := r.policy
+	r.mu.Unlock()
+
+	run := RetrainRun{Model: name, Reasons: reasons, StartedAt: time.Now().UTC()}
+	err = r.retrain(ctx, name, model, state, meta, policy, &run)
+	run.FinishedAt = time.Now().UTC()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	delete(r.running, key)
+	r.lastRun[key] = run.FinishedAt
+	r.history = append(r.history, run)
+	if len(r.history) > maxRetrainHistory {
+		r.history = r.history[len(r.history)-maxRetrainHistory:]
+	}
+	r.mu.Unlock()
+	return run, err
+}
+
+func (r *Retrainer) retrain(ctx context.Context, name string, model retrainableModel, state json.RawMessage, meta *TrainingMeta, policy RetrainPolicy, run *RetrainRun) error {
+	run.Symbol, run.Interval, run.CurrentVersion = meta.Symbol, meta.Interval, model.Version()
+
+	m := GetManager()
+	previous, err := m.beginTraining(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if previous.State == StateFailed {
+			m.MarkFailed(name, errors.New(previous.Error))
+		} else {
+			m.MarkLoaded(name)
+		}
+	}()
+
+	candles, err := TrainingCandles(meta.Symbol, meta.Interval, policy.Candles+policy.HoldoutCandles)
+	if err != nil {
+		return err
+	}
+	split := candles.Len() - policy.HoldoutCandles
+	if split < policy.Candles/2 {
+		return fmt.Errorf("%w: %d candles of %s %s for %d training and %d holdout candles",
+			ErrInsufficientData, candles.Len(), meta.Symbol, meta.Interval, policy.Candles, policy.HoldoutCandles)
+	}
+	// The current model has seen every candle before it was trained, so the
+	// holdout starts after both training sets; a holdout the current model
+	// mostly trained on cannot tell the two apart
+	holdout := max(split, sort.Search(candles.Len(), func(i int) bool { return candles.Time[i] >= meta.TrainedAt.UnixMilli() }))
+	if candles.Len()-holdout < policy.HoldoutCandles/2 {
+		run.Decision = fmt.Sprintf("kept %s: only %d holdout candles of %s %s follow its training at %s",
+			run.CurrentVersion, candles.Len()-holdout, meta.Symbol, meta.Interval, meta.TrainedAt.Format(time.RFC3339))
+		logrus.Infof("Retraining %s on %s %s skipped: %s", name, meta.Symbol, meta.Interval, run.Decision)
+		return nil
+	}
+
+	// The candidate starts from the current configuration and never serves
+	// predictions unless promoted
+	candidate := retrainableModels[name].fresh()
+	if err := candidate.RestoreState(model.Version(), state); err != nil {
+		return err
+	}
+	data := TrainingData{Symbol: meta.Symbol, Interval: meta.Interval, Candles: sliceCandles(candles, 0, split)}
+	report, err := func() (report *TrainingReport, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("training %s panicked: %v", name, rec)
+			}
+		}()
+		return candidate.Train(ctx, data, policy.Config)
+	}()
+	if err != nil {
+		return err
+	}
+	run.CandidateVersion = report.Version
+
+	if run.Current, err = holdoutMetrics(ctx, model, meta.Symbol, meta.Interval, candles, holdout); err != nil {
+		return fmt.Errorf("score current %s: %w", name, err)
+	}
+	if run.Candidate, err = holdoutMetrics(ctx, candidate, meta.Symbol, meta.Interval, candles, holdout); err != nil {
+		return fmt.Errorf("score candidate %s: %w", name, err)
+	}
+	currentMAE, candidateMAE := run.Current["holdout_mae"], run.Candidate["holdout_mae"]
+	currentAcc, candidateAcc := run.Current["holdout_direction_accuracy"], run.Candidate["holdout_direction_accuracy"]
+	switch {
+	case candidateMAE > currentMAE*(1-policy.MinImprovement):
+		run.Decision = fmt.Sprintf("kept %s: candidate holdout MAE %.5f is not below %.5f", run.CurrentVersion, candidateMAE, currentMAE)
+	case candidateAcc < currentAcc-policy.AccuracySlack:
+		run.Decision = fmt.Sprintf("kept %s: candidate holdout direction accuracy %.3f against %.3f", run.CurrentVersion, candidateAcc, currentAcc)
+	default:
+		if err := promote(model, candidate, report.Metrics, run.Candidate); err != nil {
+			return err
+		}
+		run.Promoted = true
+		run.Decision = fmt.Sprintf("promoted %s: holdout MAE %.5f against %.5f", run.CandidateVersion, candidateMAE, currentMAE)
+	}
+	logrus.Infof("Retrained %s on %s %s: %s", name, meta.Symbol, meta.Interval, run.Decision)
+	return nil
+}
+
+// promote swaps the candidate weights into the registered model and saves
+// them as the active version
+func promote(model, candidate retrainableModel, trainMetrics, holdout map[string]float64) error {
+	state, err := candidate.MarshalState()
+	if err != nil {
+		return err
+	}
+	if err := model.RestoreState(candidate.Version(), state); err != nil {
+		return err
+	}
+	metrics := make(map[string]float64, len(trainMetrics)+len(holdout))
+	for k, v := range trainMetrics {
+		metrics[k] = v
+	}
+	for k, v := range holdout {
+		metrics[k] = v
+	}
+	_, err = GetModelStore().SaveActive(model, metrics)
+	return err
+}
+
+// holdoutMetrics scores the forecasts of a model for candles [from, end),
+// each made from the candles before it, against the realized returns
+func holdoutMetrics(ctx context.Context, p Predictor, symbol, interval string, candles indicators.OHLCV, from int) (map[string]float64, error) {
+	replayed, err := replayForecasts(ctx, p, symbol, interval, candles, from)
+	if err != nil {
+		return nil, err
+	}
+	outcomes := replayed[interval]
+	predicted, actual := make([]float64, len(outcomes)), make([]float64, len(outcomes))
+	for i, o := range outcomes {
+		predicted[i], actual[i] = o.Predicted, o.Actual
+	}
+	metrics := regressionMetrics("holdout_", predicted, actual)
+	metrics["holdout_samples"] = float64(len(outcomes))
+	return metrics, nil
+}
+
+// sliceCandles returns candles [from, to)
+func sliceCandles(c indicators.OHLCV, from, to int) indicators.OHLCV {
+	return indicators.OHLCV{
+		Time:   c.Time[from:to],
+		Open:   c.Open[from:to],
+		High:   c.High[from:to],
+		Low:    c.Low[from:to],
+		Close:  c.Close[from:to],
+		Volume: c.Volume[from:to],
+	}
+}
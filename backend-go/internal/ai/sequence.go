@@ -0,0 +1,435 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+// sequenceFeatureNames are the per-candle inputs of the recurrent models. All
+// of them are relative to the price, so one network serves any symbol.
+var sequenceFeatureNames = []string{
+	"return",        // close-to-close return
+	"range",         // (high - low) / close
+	"body",          // (close - open) / close
+	"upper_wick",    // (high - max(open, close)) / close
+	"lower_wick",    // (min(open, close) - low) / close
+	"volume_change", // log volume ratio to the previous candle
+	"sma7_gap",      // close / SMA(7) - 1
+	"sma25_gap",     // close / SMA(25) - 1
+	"rsi14",         // RSI(14) / 100 - 0.5
+	"macd_hist",     // MACD(12, 26, 9) histogram / close
+	"bb_position",   // position inside Bollinger(20, 2), -0.5 to 0.5
+	"volatility10",  // standard deviation of the last 10 returns
+}
+
+// sequenceWarmup is the number of leading candles whose indicators are not
+// defined yet; training windows start after it
+const sequenceWarmup = 34
+
+// sequenceFeatures computes sequenceFeatureNames for every candle
+func sequenceFeatures(c indicators.OHLCV) [][]float64 {
+	closes := c.Close
+	returns := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		returns[i] = closes[i]/closes[i-1] - 1
+	}
+	sma7 := indicators.SMA(closes, 7)
+	sma25 := indicators.SMA(closes, 25)
+	rsi := indicators.RSI(closes, 14)
+	_, _, hist := indicators.MACD(closes, 12, 26, 9)
+	upper, _, lower := indicators.Bollinger(closes, 20, 2.0)
+	volatility := indicators.StdDev(returns, 10)
+
+	rows := make([][]float64, len(closes))
+	for i, price := range closes {
+		row := make([]float64, len(sequenceFeatureNames))
+		rows[i] = row
+		if price <= 0 {
+			continue
+		}
+		open, high, low := c.Open[i], c.High[i], c.Low[i]
+		prevVolume := c.Volume[i]
+		if i > 0 {
+			prevVolume = c.Volume[i-1]
+		}
+
+		row[0] = returns[i]
+		row[1] = (high - low) / price
+		row[2] = (price - open) / price
+		row[3] = (high - math.Max(open, price)) / price
+		row[4] = (math.Min(open, price) - low) / price
+		row[5] = math.Log((c.Volume[i] + 1) / (prevVolume + 1))
+		row[6] = price/sma7.At(i, price) - 1
+		row[7] = price/sma25.At(i, price) - 1
+		row[8] = rsi.At(i, 50)/100 - 0.5
+		row[9] = hist.At(i, 0) / price
+		if u, l := upper.At(i, 0), lower.At(i, 0); u > l {
+			row[10] = (price-l)/(u-l) - 0.5
+		}
+		row[11] = volatility.At(i, 0)
+	}
+	return rows
+}
+
+// SequenceMeta describes how a recurrent network was trained: on which
+// candles, with which inputs, and how inputs and target are scaled. The
+// network predicts the next candle return divided by TargetScale.
+type SequenceMeta struct {
+	TrainingMeta
+	Features    []string  `json:"features"`
+	Mean        []float64 `json:"mean"` // per feature, subtracted before Std division
+	Std         []float64 `json:"std"`
+	TargetScale float64   `json:"target_scale"`
+}
+
+// fitScaler sets the feature means and standard deviations from rows
+func (m *SequenceMeta) fitScaler(rows [][]float64) {
+	size := len(sequenceFeatureNames)
+	m.Features = append([]string(nil), sequenceFeatureNames...)
+	m.Mean, m.Std = make([]float64, size), make([]float64, size)
+	for _, row := range rows {
+		for j, v := range row {
+			m.Mean[j] += v
+		}
+	}
+	for j := range m.Mean {
+		m.Mean[j] /= float64(len(rows))
+	}
+	for _, row := range rows {
+		for j, v := range row {
+			m.Std[j] += (v - m.Mean[j]) * (v - m.Mean[j])
+		}
+	}
+	for j := range m.Std {
+		m.Std[j] = math.Sqrt(m.Std[j] / float64(len(rows)))
+		if m.Std[j] < 1e-12 {
+			m.Std[j] = 1
+		}
+	}
+}
+
+// scale standardizes feature rows
+func (m *SequenceMeta) scale(rows [][]float64) [][]float64 {
+	scaled := make([][]float64, len(rows))
+	for i, row := range rows {
+		scaled[i] = make([]float64, len(row))
+		for j, v := range row {
+			scaled[i][j] = (v - m.Mean[j]) / m.Std[j]
+		}
+	}
+	return scaled
+}
+
+// validate checks that a restored scaler matches the features computed here
+func (m *SequenceMeta) validate(inputSize int) error {
+	if !sameStrings(m.Features, sequenceFeatureNames) {
+		return fmt.Errorf("%w: trained on features %v, want %v", ErrInvalidInput, m.Features, sequenceFeatureNames)
+	}
+	if inputSize != len(sequenceFeatureNames) {
+		return fmt.Errorf("%w: input size %d, want %d", ErrInvalidInput, inputSize, len(sequenceFeatureNames))
+	}
+	if err := checkVector("mean", m.Mean, inputSize); err != nil {
+		return err
+	}
+	if err := checkVector("std", m.Std, inputSize); err != nil {
+		return err
+	}
+	for _, s := range m.Std {
+		if s <= 0 {
+			return fmt.Errorf("%w: feature std must be positive", ErrInvalidInput)
+		}
+	}
+	if !(m.TargetScale > 0) || math.IsInf(m.TargetScale, 0) {
+		return fmt.Errorf("%w: target scale must be positive", ErrInvalidInput)
+	}
+	return nil
+}
+
+// check fails when the network is untrained (m is nil) or cannot predict on
+// the input, see TrainingMeta.check. The window must start after the
+// indicator warmup, as in training.
+func (m *SequenceMeta) check(model string, in Input, seqLen int) error {
+	if m == nil {
+		return fmt.Errorf("%w: %s", ErrModelNotTrained, model)
+	}
+	return m.TrainingMeta.check(model, in, sequenceWarmup+seqLen)
+}
+
+// window returns the last seqLen scaled feature rows of the candles
+func (m *SequenceMeta) window(c indicators.OHLCV, seqLen int) [][]float64 {
+	rows := sequenceFeatures(c)
+	return m.scale(rows[len(rows)-seqLen:])
+}
+
+// recurrentParams applies the hidden_size, num_layers, sequence_len and
+// dropout hyperparameters of a training request
+func recurrentParams(cfg TrainConfig, hidden, layers, seqLen *int, dropout *float64) error {
+	var err error
+	if *hidden, err = cfg.intParam("hidden_size", *hidden, 2, 256); err != nil {
+		return err
+	}
+	if *layers, err = cfg.intParam("num_layers", *layers, 1, 4); err != nil {
+		return err
+	}
+	if *seqLen, err = cfg.intParam("sequence_len", *seqLen, 2, 200); err != nil {
+		return err
+	}
+	*dropout, err = cfg.floatParam("dropout", *dropout, 0, 0.9)
+	return err
+}
+
+// sequenceSample is a window of scaled feature rows labelled with the scaled
+// return of the candle after it
+type sequenceSample struct {
+	window [][]float64
+	target float64
+}
+
+// recurrentNet is a recurrent network trained by fitSequenceModel
+type recurrentNet interface {
+	// tensors returns the weight rows in a fixed order
+	tensors() [][]float64
+	clone() recurrentNet
+	predict(window [][]float64) float64
+	// gradient adds the gradient of half the squared error on one window to
+	// grad, applying inverted dropout between layers, and returns the squared
+	// error
+	gradient(window [][]float64, target, dropout float64, rng *rand.Rand, grad recurrentNet) float64
+}
+
+// sequenceTraining is the result of fitSequenceModel
+type sequenceTraining struct {
+	net    recurrentNet
+	meta   *SequenceMeta
+	report *TrainingReport
+}
+
+// fitSequenceModel trains net on sliding windows of seqLen candles to predict
+// the next candle return. The most recent cfg.ValidationSplit of the windows
+// is held out; training stops when the validation loss has not improved for
+// cfg.Patience epochs, keeping the best weights.
+func fitSequenceModel(ctx context.Context, model string, net recurrentNet, data TrainingData, cfg TrainConfig, seqLen int, dropout float64) (*sequenceTraining, error) {
+	start := time.Now()
+	closes := data.Candles.Close
+	first := sequenceWarmup + seqLen - 1 // last candle of the first window
+	samples := len(closes) - 1 - first
+	if samples < 50 {
+		return nil, fmt.Errorf("%w: %d candles give %d training windows, need 50", ErrInsufficientData, len(closes), max(samples, 0))
+	}
+	valSamples := int(float64(samples) * cfg.ValidationSplit)
+	if valSamples < 10 {
+		valSamples = 10
+	}
+	trainSamples := samples - valSamples
+
+	// Scaler and target scale come from the training windows only
+	rows := sequenceFeatures(data.Candles)
+	targets := make([]float64, len(closes))
+	for i := 0; i+1 < len(closes); i++ {
+		targets[i] = closes[i+1]/closes[i] - 1
+	}
+	splitEnd := first + trainSamples // first candle ending a validation window
+	meta := &SequenceMeta{TrainingMeta: TrainingMeta{Symbol: data.Symbol, Interval: data.Interval}}
+	meta.fitScaler(rows[sequenceWarmup:splitEnd])
+	meta.TargetScale = math.Sqrt(meanSquare(targets[first:splitEnd]))
+	if meta.TargetScale < 1e-9 {
+		return nil, fmt.Errorf("%w: prices do not move in the training candles", ErrInsufficientData)
+	}
+	scaled := meta.scale(rows)
+
+	build := func(from, to int) []sequenceSample {
+		out := make([]sequenceSample, 0, to-from)
+		for end := from; end < to; end++ {
+			out = append(out, sequenceSample{window: scaled[end-seqLen+1 : end+1], target: targets[end] / meta.TargetScale})
+		}
+		return out
+	}
+	train := build(first, splitEnd)
+	val := build(splitEnd, len(closes)-1)
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	workers := min(runtime.GOMAXPROCS(0), cfg.BatchSize)
+	grads := make([]recurrentNet, workers)
+	rngs := make([]*rand.Rand, workers)
+	for w := range grads {
+		grads[w] = net.clone()
+		rngs[w] = rand.New(rand.NewSource(cfg.Seed + int64(w) + 1))
+	}
+	params := net.tensors()
+	total := grads[0].tensors()
+	opt := newAdam(params, cfg.LearningRate)
+
+	report := &TrainingReport{
+		Model:        model,
+		Symbol:       data.Symbol,
+		Interval:     data.Interval,
+		Candles:      len(closes),
+		TrainSamples: len(train),
+		ValSamples:   len(val),
+	}
+	best, bestLoss, stale := net.clone(), math.Inf(1), 0
+	order := rng.Perm(len(train))
+	for epoch := 1; epoch <= cfg.Epochs; epoch++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		trainLoss := 0.0
+		for from := 0; from < len(order); from += cfg.BatchSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			batch := order[from:min(from+cfg.BatchSize, len(order))]
+			trainLoss += batchGradient(net, train, batch, grads, rngs, dropout)
+			for _, g := range grads[1:] {
+				addRows(total, g.tensors())
+			}
+			scaleRows(total, 1/float64(len(batch)))
+			clipGradients(total, cfg.ClipNorm)
+			opt.update(params, total)
+		}
+		trainLoss /= float64(len(train))
+		valLoss := evaluateSequence(net, val, meta.TargetScale)["val_mse"]
+		if math.IsNaN(trainLoss) || math.IsNaN(valLoss) {
+			return nil, fmt.Errorf("training %s diverged at epoch %d", model, epoch)
+		}
+		report.TrainLoss = append(report.TrainLoss, trainLoss)
+		report.ValLoss = append(report.ValLoss, valLoss)
+		report.Epochs = epoch
+
+		if valLoss < bestLoss {
+			best, bestLoss, stale = net.clone(), valLoss, 0
+			report.BestEpoch = epoch
+			continue
+		}
+		if stale++; stale >= cfg.Patience {
+			report.StoppedEarly = epoch < cfg.Epochs
+			break
+		}
+	}
+
+	metrics := evaluateSequence(best, val, meta.TargetScale)
+	metrics["train_mse"] = report.TrainLoss[report.BestEpoch-1]
+	metrics["epochs"] = float64(report.Epochs)
+	metrics["best_epoch"] = float64(report.BestEpoch)
+	meta.setValidation(metrics)
+	report.Metrics = metrics
+	report.Duration = time.Since(start).Seconds()
+	return &sequenceTraining{net: best, meta: meta, report: report}, nil
+}
+
+// batchGradient spreads the batch over the gradient buffers, one goroutine
+// each, leaving the summed gradient split across grads; it returns the summed
+// squared error
+func batchGradient(net recurrentNet, samples []sequenceSample, batch []int, grads []recurrentNet, rngs []*rand.Rand, dropout float64) float64 {
+	losses := make([]float64, len(grads))
+	var wg sync.WaitGroup
+	for w := range grads {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			zeroRows(grads[w].tensors())
+			for k := w; k < len(batch); k += len(grads) {
+				s := samples[batch[k]]
+				losses[w] += net.gradient(s.window, s.target, dropout, rngs[w], grads[w])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	sum := 0.0
+	for _, l := range losses {
+		sum += l
+	}
+	return sum
+}
+
+// evaluateSequence scores a network on samples: MSE on the scaled target,
+// MAE/RMSE of the predicted return, direction accuracy, and the MAE of
+// always predicting no change as a baseline
+func evaluateSequence(net recurrentNet, samples []sequenceSample, targetScale float64) map[string]float64 {
+	predicted, actual := make([]float64, len(samples)), make([]float64, len(samples))
+	var mse float64
+	for i, s := range samples {
+		p := net.predict(s.window)
+		mse += (p - s.target) * (p - s.target)
+		predicted[i], actual[i] = p*targetScale, s.target*targetScale
+	}
+	metrics := regressionMetrics("val_", predicted, actual)
+	metrics["val_mse"] = mse / float64(len(samples))
+	return metrics
+}
+
+// dropoutMask returns inverted dropout multipliers for steps x size units, or
+// nil when no dropout is applied
+func dropoutMask(rng *rand.Rand, rate float64, steps, size int) [][]float64 {
+	if rate <= 0 || rng == nil {
+		return nil
+	}
+	mask := make([][]float64, steps)
+	for t := range mask {
+		mask[t] = make([]float64, size)
+		for k := range mask[t] {
+			if rng.Float64() >= rate {
+				mask[t][k] = 1 / (1 - rate)
+			}
+		}
+	}
+	return mask
+}
+
+// applyMask multiplies each row by its mask row; rows are returned unchanged
+// when mask is nil
+func applyMask(rows, mask [][]float64) [][]float64 {
+	if mask == nil {
+		return rows
+	}
+	out := make([][]float64, len(rows))
+	for t, row := range rows {
+		out[t] = make([]float64, len(row))
+		for k, v := range row {
+			out[t][k] = v * mask[t][k]
+		}
+	}
+	return out
+}
+
+// accumulateGate adds the gradient dz of a gate pre-activation W·x + U·h + b
+// to the gate weights, and propagates it to dx and dh (skipped when nil)
+func accumulateGate(gW, gU [][]float64, gB []float64, W, U [][]float64, dz, x, h, dx, dh []float64) {
+	for k, d := range dz {
+		if d == 0 {
+			continue
+		}
+		gB[k] += d
+		gw, w := gW[k], W[k]
+		for j, v := range x {
+			gw[j] += d * v
+		}
+		if dx != nil {
+			for j := range dx {
+				dx[j] += w[j] * d
+			}
+		}
+		gu, u := gU[k], U[k]
+		for j, v := range h {
+			gu[j] += d * v
+			dh[j] += u[j] * d
+		}
+	}
+}
+
+func meanSquare(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum / float64(len(values))
+}
@@ -3,12 +3,21 @@ package ai
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/loadstar0723/monstas7-backend/internal/backtesting"
+	"github.com/loadstar0723/monstas7-backend/internal/expr"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
 	"github.com/sirupsen/logrus"
 )
 
+// backtestCandles is how many closed candles a strategy is backtested over
+// unless its "candles" parameter says otherwise
+const backtestCandles = 2000
+
 // StrategyBuilder builds and optimizes trading strategies
 type StrategyBuilder struct {
 	Strategies map[string]StrategyTemplate
@@ -31,6 +40,7 @@ type StrategyTemplate struct {
 // Strategy represents a generated trading strategy
 type Strategy struct {
 	Name            string                 `json:"name"`
+	Symbol          string                 `json:"symbol"`
 	Description     string                 `json:"description"`
 	Type            string                 `json:"type"`
 	Rules           []TradingRule          `json:"rules"`
@@ -214,8 +224,21 @@ func (sb *StrategyBuilder) initialize() {
 	}
 }
 
-// Build generates a trading strategy
-func (sb *StrategyBuilder) Build(symbol string, parameters map[string]interface{}) Strategy {
+// Build generates a trading strategy, failing when a custom entry/exit
+// expression does not compile
+func (sb *StrategyBuilder) Build(symbol string, parameters map[string]interface{}) (Strategy, error) {
+	strategy, err := sb.draft(symbol, parameters)
+	if err != nil {
+		return Strategy{}, err
+	}
+
+	// Backtest outside the lock; it fetches candles over REST
+	strategy.BacktestResults = sb.runBacktest(strategy)
+	return strategy, nil
+}
+
+// draft generates the strategy's rules and risk management from its template
+func (sb *StrategyBuilder) draft(symbol string, parameters map[string]interface{}) (Strategy, error) {
 	sb.mu.RLock()
 	defer sb.mu.RUnlock()
 
@@ -234,83 +257,108 @@ func (sb *StrategyBuilder) Build(symbol string, parameters map[string]interface{
 	// Generate strategy
 	strategy := Strategy{
 		Name:        fmt.Sprintf("%s_%s_%d", template.Name, symbol, time.Now().Unix()),
+		Symbol:      symbol,
 		Description: template.Description,
 		Type:        template.Type,
 		Parameters:  parameters,
 	}
 
 	// Generate trading rules
-	strategy.Rules = sb.generateRules(template, parameters)
+	rules, err := sb.generateRules(template, parameters)
+	if err != nil {
+		return Strategy{}, err
+	}
+	strategy.Rules = rules
 
 	// Set risk management
 	strategy.RiskManagement = sb.generateRiskManagement(template.RiskParams, parameters)
 
-	// Run backtest
-	strategy.BacktestResults = sb.runBacktest(strategy, symbol)
+	return strategy, nil
+}
 
-	return strategy
+// entryConditions are the expression-language entry conditions per template
+// indicator. TREND_FOLLOW rules act as filters on the BUY rules.
+var entryConditions = map[string]struct {
+	condition string
+	action    string
+}{
+	"RSI":      {"rsi(close, 14) < 30", "BUY"},
+	"MACD":     {"crossover(macd(close, 12, 26), macd_signal(close, 12, 26, 9))", "BUY"},
+	"BB":       {"close < bb_lower(close, 20, 2)", "BUY"},
+	"ADX":      {"adx(14) > 25", "TREND_FOLLOW"},
+	"EMA":      {"crossover(close, ema(close, 9))", "BUY"},
+	"STOCH":    {"stoch_k(14, 3) < 20 and stoch_d(14, 3, 3) < 20", "BUY"},
+	"ATR":      {"true_range() > 1.5 * atr(14)", "TREND_FOLLOW"},
+	"VOLUME":   {"volume > 2 * sma(volume, 20)", "TREND_FOLLOW"},
+	"DONCHIAN": {"close > prev(highest(high, 20), 1)", "BUY"},
+	"VWAP":     {"crossover(close, vwap())", "BUY"},
 }
 
-// generateRules creates trading rules from template
-func (sb *StrategyBuilder) generateRules(template StrategyTemplate, params map[string]interface{}) []TradingRule {
+// defaultExit closes a position when momentum is overbought
+const defaultExit = "rsi(close, 14) > 70"
+
+// generateRules creates trading rules from template. Conditions are
+// expressions evaluated over candles; "entry"/"exit" parameters override the
+// combined template conditions and may reference saved definitions.
+func (sb *StrategyBuilder) generateRules(template StrategyTemplate, params map[string]interface{}) ([]TradingRule, error) {
 	rules := []TradingRule{}
+	var buys, filters []string
 
 	// Entry rules
 	for _, indicator := range template.Indicators {
-		rule := TradingRule{
-			Name: fmt.Sprintf("%s_Entry", indicator),
-			Type: "ENTRY",
+		entry, ok := entryConditions[indicator]
+		if !ok {
+			// Not computable from candles (order flow, spreads, ...)
+			continue
+		}
+		rules = append(rules, TradingRule{
+			Name:      fmt.Sprintf("%s_Entry", indicator),
+			Type:      "ENTRY",
+			Condition: entry.condition,
+			Action:    entry.action,
 			Params: map[string]interface{}{
 				"indicator": indicator,
 			},
+		})
+		if entry.action == "BUY" {
+			buys = append(buys, "("+entry.condition+")")
+		} else {
+			filters = append(filters, "("+entry.condition+")")
 		}
+	}
 
-		switch indicator {
-		case "RSI":
-			rule.Condition = "RSI < 30"
-			rule.Action = "BUY"
-			rule.Params["threshold"] = 30.0
-
-		case "MACD":
-			rule.Condition = "MACD_LINE > SIGNAL_LINE"
-			rule.Action = "BUY"
-
-		case "BB":
-			rule.Condition = "PRICE < LOWER_BAND"
-			rule.Action = "BUY"
-			rule.Params["std_dev"] = 2.0
-
-		case "ADX":
-			rule.Condition = "ADX > 25"
-			rule.Action = "TREND_FOLLOW"
-			rule.Params["threshold"] = 25.0
-
-		case "EMA":
-			rule.Condition = "PRICE > EMA_9"
-			rule.Action = "BUY"
-			rule.Params["period"] = 9
-
-		case "STOCH":
-			rule.Condition = "K < 20 AND D < 20"
-			rule.Action = "BUY"
-			rule.Params["oversold"] = 20.0
-		}
-
-		rules = append(rules, rule)
+	entry := strings.Join(buys, " or ")
+	if len(buys) == 0 {
+		entry = strings.Join(filters, " and ")
+	} else if len(filters) > 0 {
+		entry = "(" + entry + ") and " + strings.Join(filters, " and ")
+	}
+	if custom, ok := params["entry"].(string); ok && custom != "" {
+		entry = custom
+	}
+	if entry == "" {
+		return nil, fmt.Errorf("strategy %s has no candle-based entry condition; provide an entry expression", template.Name)
+	}
+	exit := defaultExit
+	if custom, ok := params["exit"].(string); ok && custom != "" {
+		exit = custom
 	}
 
-	// Exit rules
-	exitRule := TradingRule{
-		Name:      "Exit_Rule",
-		Type:      "EXIT",
-		Condition: "PROFIT_TARGET OR STOP_LOSS",
-		Action:    "CLOSE",
-		Params: map[string]interface{}{
-			"profit_target": 0.03,
-			"stop_loss":     0.01,
-		},
+	// Combined rules, validated so invalid custom expressions are reported
+	for _, rule := range []TradingRule{
+		{Name: "Entry_Rule", Type: "ENTRY", Condition: entry, Action: "BUY"},
+		{Name: "Exit_Rule", Type: "EXIT", Condition: exit, Action: "CLOSE"},
+	} {
+		program, err := expr.GetLibrary().Compile(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rule.Name, err)
+		}
+		if program.Type != expr.TypeBool {
+			return nil, fmt.Errorf("%s must be a condition, got %s", rule.Name, program.Type)
+		}
+		rule.Params = map[string]interface{}{"lookback": program.Lookback}
+		rules = append(rules, rule)
 	}
-	rules = append(rules, exitRule)
 
 	// Position sizing rule
 	sizeRule := TradingRule{
@@ -325,7 +373,17 @@ func (sb *StrategyBuilder) generateRules(template StrategyTemplate, params map[s
 	}
 	rules = append(rules, sizeRule)
 
-	return rules
+	return rules, nil
+}
+
+// ruleCondition returns the condition of the named rule
+func ruleCondition(rules []TradingRule, name string) string {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return rule.Condition
+		}
+	}
+	return ""
 }
 
 // generateRiskManagement creates risk management rules
@@ -376,65 +434,119 @@ func (sb *StrategyBuilder) generateRiskManagement(riskParams RiskParameters, par
 	return rm
 }
 
-// runBacktest performs strategy backtesting
-func (sb *StrategyBuilder) runBacktest(strategy Strategy, symbol string) BacktestResult {
-	// Simplified backtest simulation
-	// In production, this would use historical data
-
-	numTrades := 100 + rand.Intn(200)
-	winRate := 0.45 + rand.Float64()*0.3
-	winningTrades := int(float64(numTrades) * winRate)
-	losingTrades := numTrades - winningTrades
-
-	avgWin := 0.02 + rand.Float64()*0.03
-	avgLoss := 0.01 + rand.Float64()*0.015
-
-	totalWin := float64(winningTrades) * avgWin
-	totalLoss := float64(losingTrades) * avgLoss
-	totalReturn := totalWin - totalLoss
-
-	profitFactor := 1.0
-	if totalLoss > 0 {
-		profitFactor = totalWin / totalLoss
+// runBacktest replays the strategy's entry/exit rules over the symbol's
+// recent closed candles ("candles" parameter, default backtestCandles). It
+// fetches candles over REST, so it must not be called with sb.mu held.
+func (sb *StrategyBuilder) runBacktest(strategy Strategy) BacktestResult {
+	interval := "1h"
+	if value, ok := strategy.Parameters["interval"].(string); ok && value != "" {
+		interval = value
 	}
-
-	// Calculate Sharpe ratio (simplified)
-	annualReturn := totalReturn * 12 // Assume monthly
-	volatility := 0.15 + rand.Float64()*0.1
-	sharpeRatio := 0.0
-	if volatility > 0 {
-		sharpeRatio = (annualReturn - 0.02) / volatility
+	candles := backtestCandles
+	if value, ok := strategy.Parameters["candles"].(float64); ok && value > 0 {
+		candles = int(value)
+	}
+	failed := func(err error) BacktestResult {
+		return BacktestResult{Metrics: map[string]interface{}{"error": err.Error()}}
 	}
 
-	// Calculate max drawdown
-	maxDrawdown := volatility * 2
+	var klines []market.Kline
+	var err error
+	if candles <= features.HistoryBars {
+		// The feature store already caches this much history
+		klines, err = features.GetStore().Klines(strategy.Symbol, interval)
+		if len(klines) > candles {
+			klines = klines[len(klines)-candles:]
+		}
+	} else {
+		klines, err = klinesUntil(strategy.Symbol, interval, candles, time.Now())
+	}
+	if err != nil {
+		return failed(err)
+	}
+	exprStrategy, err := backtesting.NewExprStrategy(
+		ruleCondition(strategy.Rules, "Entry_Rule"),
+		ruleCondition(strategy.Rules, "Exit_Rule"),
+		expr.GetLibrary(),
+	)
+	if err != nil {
+		return failed(err)
+	}
+	exprStrategy.StopLoss = strategy.RiskManagement.StopLoss
+	exprStrategy.TakeProfit = strategy.RiskManagement.TakeProfit
+	if strategy.RiskManagement.MaxPosition > 0 {
+		exprStrategy.PositionSize = strategy.RiskManagement.MaxPosition
+	}
 
+	data := backtesting.FromKlines(strategy.Symbol, klines)
+	result := backtesting.NewBacktestEngine(10000).RunBacktest(data, exprStrategy)
+
+	// Engine reports percentages; strategy results use fractions
+	maxDrawdown := result.MaxDrawdown / 100
+	avgWin, avgLoss := averageTradeReturns(result.TradeHistory)
+	metrics := map[string]interface{}{
+		"interval":        interval,
+		"bars":            len(data),
+		"recovery_factor": result.RecoveryFactor,
+		"expectancy":      result.ExpectancyRatio,
+	}
+	if maxDrawdown > 0 {
+		metrics["calmar_ratio"] = result.AnnualizedReturn / 100 / maxDrawdown
+	}
 	return BacktestResult{
-		TotalReturn:   totalReturn,
-		AnnualReturn:  annualReturn,
-		SharpeRatio:   sharpeRatio,
+		TotalReturn:   result.TotalReturn / 100,
+		AnnualReturn:  result.AnnualizedReturn / 100,
+		SharpeRatio:   result.SharpeRatio,
 		MaxDrawdown:   maxDrawdown,
-		WinRate:       winRate,
-		ProfitFactor:  profitFactor,
-		TotalTrades:   numTrades,
-		WinningTrades: winningTrades,
-		LosingTrades:  losingTrades,
+		WinRate:       result.WinRate / 100,
+		ProfitFactor:  result.ProfitFactor,
+		TotalTrades:   result.TotalTrades,
+		WinningTrades: result.WinningTrades,
+		LosingTrades:  result.LosingTrades,
 		AvgWin:        avgWin,
 		AvgLoss:       avgLoss,
-		Metrics: map[string]interface{}{
-			"calmar_ratio":   annualReturn / maxDrawdown,
-			"sortino_ratio":  sharpeRatio * 1.2,
-			"recovery_factor": totalReturn / maxDrawdown,
-			"expectancy":     (winRate * avgWin) - ((1 - winRate) * avgLoss),
-			"r_squared":      0.7 + rand.Float64()*0.25,
-			"beta":           0.8 + rand.Float64()*0.4,
-			"alpha":          annualReturn - (0.08 * (0.8 + rand.Float64()*0.4)),
-		},
+		Metrics:       metrics,
 	}
 }
 
+// averageTradeReturns returns the mean net return of winning and losing
+// trades as fractions of position size, the unit of the other results (the
+// engine's averages are in account currency)
+func averageTradeReturns(trades []backtesting.Trade) (avgWin, avgLoss float64) {
+	var wins, losses int
+	for _, trade := range trades {
+		if trade.Size <= 0 {
+			continue
+		}
+		r := trade.NetPnL / trade.Size
+		if trade.NetPnL > 0 {
+			avgWin += r
+			wins++
+		} else {
+			avgLoss -= r
+			losses++
+		}
+	}
+	if wins > 0 {
+		avgWin /= float64(wins)
+	}
+	if losses > 0 {
+		avgLoss /= float64(losses)
+	}
+	return avgWin, avgLoss
+}
+
 // OptimizeStrategy optimizes strategy parameters
 func (sb *StrategyBuilder) OptimizeStrategy(strategy Strategy, historicalData []float64) Strategy {
+	strategy.Parameters = sb.optimizeParameters(strategy, historicalData)
+
+	// Backtest outside the lock; it fetches candles over REST
+	strategy.BacktestResults = sb.runBacktest(strategy)
+	return strategy
+}
+
+// optimizeParameters searches parameter variations for the best score
+func (sb *StrategyBuilder) optimizeParameters(strategy Strategy, historicalData []float64) map[string]interface{} {
 	sb.mu.Lock()
 	defer sb.mu.Unlock()
 
@@ -462,11 +574,7 @@ func (sb *StrategyBuilder) OptimizeStrategy(strategy Strategy, historicalData []
 		}
 	}
 
-	// Return optimized strategy
-	strategy.Parameters = bestParams
-	strategy.BacktestResults = sb.runBacktest(strategy, "OPTIMIZED")
-
-	return strategy
+	return bestParams
 }
 
 // evaluateStrategy scores a strategy
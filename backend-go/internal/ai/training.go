@@ -0,0 +1,412 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// MaxTrainingCandles bounds the history a model is trained on
+const MaxTrainingCandles = 20000
+
+var (
+	ErrNotTrainable       = errors.New("model cannot be trained")
+	ErrTrainingInProgress = errors.New("model is already training")
+)
+
+// Trainer is implemented by predictors that are fitted on historical candles
+type Trainer interface {
+	Predictor
+	// Train fits new weights and swaps them in when training succeeds; the
+	// model keeps predicting with its current weights meanwhile
+	Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error)
+}
+
+// TrainingData is the candle history a model is trained on
+type TrainingData struct {
+	Symbol   string
+	Interval string
+	Candles  indicators.OHLCV
+}
+
+// TrainConfig holds the optimizer settings shared by the trainers. Zero values
+// fall back to the model defaults; Params carries model specific
+// hyperparameters such as hidden_size or num_layers.
+type TrainConfig struct {
+	Epochs          int                `json:"epochs"` // boosting rounds for tree ensembles
+	BatchSize       int                `json:"batch_size"`
+	LearningRate    float64            `json:"learning_rate"`
+	ClipNorm        float64            `json:"clip_norm"`        // maximum global gradient norm
+	ValidationSplit float64            `json:"validation_split"` // most recent share of samples held out
+	Patience        int                `json:"patience"`         // epochs without improvement before stopping
+	Seed            int64              `json:"seed"`             // 0 seeds from the clock
+	Params          map[string]float64 `json:"params,omitempty"`
+}
+
+// withDefaults fills the unset settings, taking batch size and learning rate
+// from the model configuration
+func (c TrainConfig) withDefaults(batchSize int, learningRate float64) TrainConfig {
+	if c.Epochs == 0 {
+		c.Epochs = 30
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = batchSize
+	}
+	if c.LearningRate == 0 {
+		c.LearningRate = learningRate
+	}
+	if c.ClipNorm == 0 {
+		c.ClipNorm = 1.0
+	}
+	if c.ValidationSplit == 0 {
+		c.ValidationSplit = 0.2
+	}
+	if c.Patience == 0 {
+		c.Patience = 5
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	return c
+}
+
+// validate rejects settings training cannot run with
+func (c TrainConfig) validate() error {
+	switch {
+	case c.Epochs < 1 || c.Epochs > 1000:
+		return fmt.Errorf("%w: epochs must be between 1 and 1000", ErrInvalidInput)
+	case c.BatchSize < 1:
+		return fmt.Errorf("%w: batch_size must be positive", ErrInvalidInput)
+	case c.LearningRate <= 0 || c.LearningRate > 1:
+		return fmt.Errorf("%w: learning_rate must be in (0, 1]", ErrInvalidInput)
+	case c.ClipNorm < 0:
+		return fmt.Errorf("%w: clip_norm must not be negative", ErrInvalidInput)
+	case c.ValidationSplit < 0.05 || c.ValidationSplit > 0.5:
+		return fmt.Errorf("%w: validation_split must be between 0.05 and 0.5", ErrInvalidInput)
+	case c.Patience < 1:
+		return fmt.Errorf("%w: patience must be positive", ErrInvalidInput)
+	}
+	return nil
+}
+
+// intParam returns a model specific integer hyperparameter within [lo, hi]
+func (c TrainConfig) intParam(name string, fallback, lo, hi int) (int, error) {
+	v, ok := c.Params[name]
+	if !ok {
+		return fallback, nil
+	}
+	if v != math.Trunc(v) || v < float64(lo) || v > float64(hi) {
+		return 0, fmt.Errorf("%w: %s must be an integer between %d and %d", ErrInvalidInput, name, lo, hi)
+	}
+	return int(v), nil
+}
+
+// floatParam returns a model specific hyperparameter within [lo, hi]
+func (c TrainConfig) floatParam(name string, fallback, lo, hi float64) (float64, error) {
+	v, ok := c.Params[name]
+	if !ok {
+		return fallback, nil
+	}
+	if math.IsNaN(v) || v < lo || v > hi {
+		return 0, fmt.Errorf("%w: %s must be between %g and %g", ErrInvalidInput, name, lo, hi)
+	}
+	return v, nil
+}
+
+// TrainingReport describes a finished training run
+type TrainingReport struct {
+	Model        string             `json:"model"`
+	Version      string             `json:"version"`
+	Symbol       string             `json:"symbol"`
+	Interval     string             `json:"interval"`
+	Candles      int                `json:"candles"`
+	TrainSamples int                `json:"train_samples"`
+	ValSamples   int                `json:"val_samples"`
+	Epochs       int                `json:"epochs"`
+	BestEpoch    int                `json:"best_epoch"`
+	StoppedEarly bool               `json:"stopped_early"`
+	TrainLoss    []float64          `json:"train_loss"`      // per epoch
+	ValLoss      []float64          `json:"validation_loss"` // per epoch
+	Metrics      map[string]float64 `json:"metrics"`         // validation results of the kept weights
+	Duration     float64            `json:"duration_seconds"`
+}
+
+// TrainingMeta records what a model was trained on and how it did on the
+// validation split
+type TrainingMeta struct {
+	Symbol               string    `json:"symbol"`
+	Interval             string    `json:"interval"`
+	ValMAE               float64   `json:"val_mae"` // of the predicted next candle return
+	ValDirectionAccuracy float64   `json:"val_direction_accuracy"`
+	TrainedAt            time.Time `json:"trained_at"`
+	Source               string    `json:"source,omitempty"` // import format of externally trained models
+}
+
+// setValidation records the validation results of a training run
+func (m *TrainingMeta) setValidation(metrics map[string]float64) {
+	m.ValMAE = metrics["val_mae"]
+	m.ValDirectionAccuracy = metrics["val_direction_accuracy"]
+	m.TrainedAt = time.Now().UTC()
+}
+
+// check fails when the model is untrained (m is nil), when the input has
+// fewer than need candles, or when its interval differs from the training
+// candles
+func (m *TrainingMeta) check(model string, in Input, need int) error {
+	if m == nil {
+		return fmt.Errorf("%w: %s", ErrModelNotTrained, model)
+	}
+	if err := in.require(need); err != nil {
+		return err
+	}
+	if in.Interval != m.Interval {
+		return fmt.Errorf("%w: %s is trained on %s candles, got %s", ErrInvalidInput, model, m.Interval, in.Interval)
+	}
+	return nil
+}
+
+// confidence (0-100) scales the validation direction accuracy by how large
+// the forecast return is compared to the validation error
+func (m *TrainingMeta) confidence(predicted float64) float64 {
+	strength := 1.0
+	if m.ValMAE > 0 {
+		strength = math.Min(1, math.Abs(predicted)/m.ValMAE)
+	}
+	confidence := 50 + (m.ValDirectionAccuracy*100-50)*strength
+	return math.Max(0, math.Min(100, confidence))
+}
+
+// regressionMetrics scores next candle return forecasts: MSE, MAE, RMSE and
+// R², direction accuracy over the candles that moved, and the MAE of always
+// predicting no change as a baseline. Keys are prefixed with prefix.
+func regressionMetrics(prefix string, predicted, actual []float64) map[string]float64 {
+	var mse, mae, baseline, hits, moves, mean, variance float64
+	for _, a := range actual {
+		mean += a
+	}
+	mean /= math.Max(1, float64(len(actual)))
+	for _, a := range actual {
+		variance += (a - mean) * (a - mean)
+	}
+	for i, p := range predicted {
+		diff := p - actual[i]
+		mse += diff * diff
+		mae += math.Abs(diff)
+		baseline += math.Abs(actual[i])
+		if actual[i] != 0 {
+			moves++
+			if (p > 0) == (actual[i] > 0) {
+				hits++
+			}
+		}
+	}
+	n := math.Max(1, float64(len(predicted)))
+	accuracy := 0.5
+	if moves > 0 {
+		accuracy = hits / moves
+	}
+	r2 := 0.0
+	if variance > 0 {
+		r2 = 1 - mse/variance
+	}
+	return map[string]float64{
+		prefix + "mse":                mse / n,
+		prefix + "mae":                mae / n,
+		prefix + "rmse":               math.Sqrt(mse / n),
+		prefix + "r2":                 r2,
+		prefix + "direction_accuracy": accuracy,
+		"baseline_mae":                baseline / n,
+	}
+}
+
+// TrainingRequest asks for a model to be trained on the last Candles closed
+// candles of Symbol/Interval
+type TrainingRequest struct {
+	Symbol   string      `json:"symbol"`
+	Interval string      `json:"interval"`
+	Candles  int         `json:"candles"`
+	Config   TrainConfig `json:"config"`
+}
+
+// TrainingJobState is the state of a background training job
+type TrainingJobState string
+
+const (
+	JobRunning   TrainingJobState = "running"
+	JobSucceeded TrainingJobState = "succeeded"
+	JobFailed    TrainingJobState = "failed"
+)
+
+// TrainingJob is a background training run started by AIManager.StartTraining
+type TrainingJob struct {
+	ID         string           `json:"id"`
+	Model      string           `json:"model"`
+	Request    TrainingRequest  `json:"request"`
+	State      TrainingJobState `json:"state"`
+	Error      string           `json:"error,omitempty"`
+	Report     *TrainingReport  `json:"report,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+// TrainingCandles fetches the last n closed candles of symbol/interval,
+// checked and repaired by the market data quality rules
+func TrainingCandles(symbol, interval string, n int) (indicators.OHLCV, error) {
+	return candlesUntil(symbol, interval, n, time.Now())
+}
+
+// candlesUntil fetches the last n candles of symbol/interval closed by end
+func candlesUntil(symbol, interval string, n int, end time.Time) (indicators.OHLCV, error) {
+	klines, err := klinesUntil(symbol, interval, n, end)
+	if err != nil {
+		return indicators.OHLCV{}, err
+	}
+	bars := make([]indicators.Bar, 0, len(klines))
+	for _, k := range klines {
+		bars = append(bars, indicators.Bar{Time: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume})
+	}
+	return indicators.FromBars(bars), nil
+}
+
+// klinesUntil fetches the last n klines of symbol/interval closed by end,
+// with the quality flags set by market.CheckKlines
+func klinesUntil(symbol, interval string, n int, end time.Time) ([]market.Kline, error) {
+	if n < 1 || n > MaxTrainingCandles {
+		return nil, fmt.Errorf("%w: candles must be between 1 and %d", ErrInvalidInput, MaxTrainingCandles)
+	}
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	stepMs := step.Milliseconds()
+	now := end.UnixMilli()
+	start := now - int64(n+1)*stepMs // one extra for the candle still open
+
+	client := market.NewBinanceClient()
+	var klines []market.Kline
+	for from := start; from <= now; {
+		page, err := client.GetKlinesRange(symbol, interval, from, now, 1000)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		klines = append(klines, page...)
+		from = page[len(page)-1].OpenTime + stepMs
+	}
+	checked, _, err := market.CheckKlines(symbol, interval, klines, market.DefaultQualityConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	closed := make([]market.Kline, 0, len(checked))
+	for _, k := range checked {
+		if k.OpenTime+stepMs > now {
+			break
+		}
+		closed = append(closed, k)
+	}
+	if len(closed) == 0 {
+		return nil, fmt.Errorf("%w: no closed candles for %s %s", ErrInsufficientData, symbol, interval)
+	}
+	if len(closed) > n {
+		closed = closed[len(closed)-n:]
+	}
+	return closed, nil
+}
+
+// adam is the Adam optimizer over a list of weight rows
+type adam struct {
+	rate, beta1, beta2, epsilon float64
+	step                        int
+	m, v                        [][]float64
+}
+
+func newAdam(params [][]float64, rate float64) *adam {
+	a := &adam{rate: rate, beta1: 0.9, beta2: 0.999, epsilon: 1e-8}
+	a.m = make([][]float64, len(params))
+	a.v = make([][]float64, len(params))
+	for i, row := range params {
+		a.m[i] = make([]float64, len(row))
+		a.v[i] = make([]float64, len(row))
+	}
+	return a
+}
+
+// update applies one optimizer step; grads are shaped like params
+func (a *adam) update(params, grads [][]float64) {
+	a.step++
+	c1 := 1 - math.Pow(a.beta1, float64(a.step))
+	c2 := 1 - math.Pow(a.beta2, float64(a.step))
+	for i, row := range params {
+		m, v, g := a.m[i], a.v[i], grads[i]
+		for j := range row {
+			m[j] = a.beta1*m[j] + (1-a.beta1)*g[j]
+			v[j] = a.beta2*v[j] + (1-a.beta2)*g[j]*g[j]
+			row[j] -= a.rate * (m[j] / c1) / (math.Sqrt(v[j]/c2) + a.epsilon)
+		}
+	}
+}
+
+// clipGradients rescales grads so their global L2 norm is at most maxNorm
+// (no limit when maxNorm is 0) and returns the norm before clipping
+func clipGradients(grads [][]float64, maxNorm float64) float64 {
+	sum := 0.0
+	for _, row := range grads {
+		for _, g := range row {
+			sum += g * g
+		}
+	}
+	norm := math.Sqrt(sum)
+	if maxNorm > 0 && norm > maxNorm {
+		scaleRows(grads, maxNorm/norm)
+	}
+	return norm
+}
+
+func scaleRows(rows [][]float64, factor float64) {
+	for _, row := range rows {
+		for j := range row {
+			row[j] *= factor
+		}
+	}
+}
+
+func zeroRows(rows [][]float64) {
+	for _, row := range rows {
+		for j := range row {
+			row[j] = 0
+		}
+	}
+}
+
+// addRows adds src into dst; both have the same shape
+func addRows(dst, src [][]float64) {
+	for i, row := range dst {
+		for j, v := range src[i] {
+			row[j] += v
+		}
+	}
+}
+
+func copyMatrix(m [][]float64) [][]float64 {
+	c := make([][]float64, len(m))
+	for i, row := range m {
+		c[i] = append([]float64(nil), row...)
+	}
+	return c
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i, v := range a {
+		sum += v * b[i]
+	}
+	return sum
+}
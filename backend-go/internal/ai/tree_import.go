@@ -0,0 +1,734 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+// Tree ensembles trained in Python can be imported for inference:
+//
+//   - XGBoost: booster.save_model("model.json") (format "xgboost-json") or
+//     booster.dump_model("dump.json", dump_format="json") ("xgboost-dump"),
+//     trained on the columns of xgboostFeatureNames
+//   - LightGBM: booster.save_model("model.txt") ("lightgbm-text"), trained on
+//     the columns of lightgbmFeatureNames
+//
+// Only regression objectives with an identity link are accepted, since the
+// Go models read the output as the next candle return. Categorical splits
+// and linear trees are rejected. Missing values are not routed: the Go
+// feature builders never produce them.
+
+// TreeImportOptions controls how an exported model is mapped onto a Go model
+type TreeImportOptions struct {
+	Symbol   string
+	Interval string
+	// FeatureMap renames model feature names to Go feature names
+	FeatureMap map[string]string
+	// Positional maps unnamed model features (f0, Column_0, ...) to the Go
+	// features by position; they are rejected otherwise
+	Positional bool
+	// BaseScore is required for XGBoost dumps, which do not store it
+	BaseScore *float64
+	// Candles are the recent candles the imported model is scored on; the
+	// scores set its confidence. Without them confidence stays at 50.
+	Candles indicators.OHLCV
+	// TrainedUntil is the open time (ms) of the last candle the model was
+	// trained on; only later candles are scored. Without it every candle is
+	// scored and the metrics are marked in_sample.
+	TrainedUntil int64
+}
+
+// TreeImportReport describes an imported tree ensemble
+type TreeImportReport struct {
+	Model     string             `json:"model"`
+	Format    string             `json:"format"`
+	Version   string             `json:"version"`
+	Trees     int                `json:"trees"`
+	BaseScore float64            `json:"base_score"`
+	Features  map[string]string  `json:"features"`        // model feature -> Go feature
+	Unused    []string           `json:"unused_features"` // Go features the model does not read
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+}
+
+// unnamedFeature matches the names XGBoost and LightGBM give features that
+// were trained without names
+var unnamedFeature = regexp.MustCompile(`^(?:f|Column_)(\d+)$`)
+
+// featureMapping maps each model feature to the index of the Go feature it
+// reads. names are the model feature names, or nil when the model stores
+// none; count is the model feature count (a lower bound unless exact).
+func featureMapping(names []string, count int, exact bool, goNames []string, opts TreeImportOptions) ([]int, map[string]string, error) {
+	goIndex := make(map[string]int, len(goNames))
+	for i, name := range goNames {
+		goIndex[name] = i
+	}
+
+	unnamed := unnamedFeatures(names, goIndex, opts)
+
+	index := make([]int, count)
+	mapping := make(map[string]string, count)
+	if unnamed {
+		if !opts.Positional {
+			return nil, nil, fmt.Errorf("%w: model has no feature names; set positional to map its %d features onto %v", ErrInvalidInput, count, goNames)
+		}
+		if count > len(goNames) || (exact && count != len(goNames)) {
+			return nil, nil, fmt.Errorf("%w: model has %d features, Go model builds %d", ErrInvalidInput, count, len(goNames))
+		}
+		for i := range index {
+			index[i] = i
+			if len(names) > i {
+				mapping[names[i]] = goNames[i]
+			} else {
+				mapping["f"+strconv.Itoa(i)] = goNames[i]
+			}
+		}
+		return index, mapping, nil
+	}
+
+	var unknown []string
+	used := make(map[int]string)
+	for i, name := range names {
+		goName := name
+		if renamed, ok := opts.FeatureMap[name]; ok {
+			goName = renamed
+		}
+		j, ok := goIndex[goName]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if other, dup := used[j]; dup {
+			return nil, nil, fmt.Errorf("%w: model features %s and %s both map to %s", ErrInvalidInput, other, name, goName)
+		}
+		used[j] = name
+		index[i] = j
+		mapping[name] = goName
+	}
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("%w: model features %v have no Go counterpart in %v; map them with feature_map", ErrInvalidInput, unknown, goNames)
+	}
+	return index, mapping, nil
+}
+
+// unnamedFeatures reports whether a model stores no feature names of its
+// own: none, or only generated ones that are neither Go features nor mapped
+func unnamedFeatures(names []string, goIndex map[string]int, opts TreeImportOptions) bool {
+	for _, name := range names {
+		if _, known := goIndex[name]; known || opts.FeatureMap[name] != "" || !unnamedFeature.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// unusedFeatures lists the Go features no model feature maps to
+func unusedFeatures(goNames []string, mapping map[string]string) []string {
+	mapped := make(map[string]bool, len(mapping))
+	for _, goName := range mapping {
+		mapped[goName] = true
+	}
+	unused := []string{}
+	for _, name := range goNames {
+		if !mapped[name] {
+			unused = append(unused, name)
+		}
+	}
+	return unused
+}
+
+// importMeta scores an imported model on feature rows whose row i describes
+// candle first+i, against the next candle return, skipping candles up to
+// opts.TrainedUntil. Too few candles leave the metadata without validation
+// results.
+func importMeta(source string, opts TreeImportOptions, rows [][]float64, first int, predict func([]float64) float64) (*TrainingMeta, map[string]float64) {
+	meta := &TrainingMeta{Symbol: opts.Symbol, Interval: opts.Interval, Source: source, ValDirectionAccuracy: 0.5}
+	closes := opts.Candles.Close
+	var predicted, actual []float64
+	for i, row := range rows {
+		c := first + i
+		if c+1 >= len(closes) {
+			break
+		}
+		if opts.TrainedUntil > 0 && opts.Candles.Time[c] <= opts.TrainedUntil {
+			continue
+		}
+		predicted = append(predicted, predict(row))
+		actual = append(actual, closes[c+1]/closes[c]-1)
+	}
+	if len(predicted) < 30 {
+		return meta, nil
+	}
+	metrics := regressionMetrics("val_", predicted, actual)
+	metrics["val_samples"] = float64(len(predicted))
+	if opts.TrainedUntil == 0 {
+		// The candles likely overlap the training data
+		metrics["in_sample"] = 1
+	}
+	meta.setValidation(metrics)
+	return meta, metrics
+}
+
+// xgboostJSONModel is the part of an XGBoost save_model JSON file the import
+// reads
+type xgboostJSONModel struct {
+	Learner struct {
+		FeatureNames    []string `json:"feature_names"`
+		GradientBooster struct {
+			Name  string `json:"name"`
+			Model struct {
+				Trees []xgboostJSONTree `json:"trees"`
+			} `json:"model"`
+		} `json:"gradient_booster"`
+		LearnerModelParam struct {
+			BaseScore  string `json:"base_score"`
+			NumClass   string `json:"num_class"`
+			NumFeature string `json:"num_feature"`
+		} `json:"learner_model_param"`
+		Objective struct {
+			Name string `json:"name"`
+		} `json:"objective"`
+	} `json:"learner"`
+}
+
+// xgboostJSONTree stores the nodes of one tree as parallel arrays; node i is
+// a leaf when LeftChildren[i] is -1, and then SplitConditions[i] is its value
+type xgboostJSONTree struct {
+	LeftChildren    []int     `json:"left_children"`
+	RightChildren   []int     `json:"right_children"`
+	SplitIndices    []int     `json:"split_indices"`
+	SplitConditions []float64 `json:"split_conditions"`
+	SplitType       []int     `json:"split_type"`
+	LossChanges     []float64 `json:"loss_changes"`
+	SumHessian      []float64 `json:"sum_hessian"`
+}
+
+// xgboostDumpNode is a node of an XGBoost JSON dump
+type xgboostDumpNode struct {
+	NodeID         int                `json:"nodeid"`
+	Split          string             `json:"split"`
+	SplitCondition float64            `json:"split_condition"`
+	Yes            int                `json:"yes"`
+	No             int                `json:"no"`
+	Gain           float64            `json:"gain"`
+	Cover          float64            `json:"cover"`
+	Leaf           *float64           `json:"leaf"`
+	Children       []*xgboostDumpNode `json:"children"`
+}
+
+// identityObjectives are the XGBoost objectives whose output is the target
+var identityObjectives = map[string]bool{
+	"reg:squarederror":     true,
+	"reg:pseudohubererror": true,
+	"reg:absoluteerror":    true,
+	"reg:quantileerror":    true,
+}
+
+// ImportXGBoost converts an XGBoost save_model JSON file, or a JSON dump
+// (a top-level array of trees), into a standalone XGBoostModel
+func ImportXGBoost(data []byte, opts TreeImportOptions) (*XGBoostModel, *TreeImportReport, error) {
+	var (
+		trees     []*XGBoostTree
+		baseScore float64
+		mapping   map[string]string
+		format    string
+		err       error
+	)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		format = "xgboost-dump"
+		trees, baseScore, mapping, err = parseXGBoostDump(trimmed, opts)
+	} else {
+		format = "xgboost-json"
+		trees, baseScore, mapping, err = parseXGBoostJSON(data, opts)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	model := NewXGBoostModel()
+	model.Trees, model.BaseScore, model.NumTrees = trees, baseScore, len(trees)
+	model.MaxDepth = 0
+	for _, tree := range trees {
+		model.MaxDepth = max(model.MaxDepth, xgboostDepth(tree.Root))
+	}
+	model.version = NewModelVersion()
+
+	rows := model.extractFeatures(opts.Candles)
+	var metrics map[string]float64
+	model.Meta, metrics = importMeta(format, opts, rows, xgboostWarmup, model.predictSingle)
+
+	return model, &TreeImportReport{
+		Model:     model.Name(),
+		Format:    format,
+		Version:   model.version,
+		Trees:     len(trees),
+		BaseScore: baseScore,
+		Features:  mapping,
+		Unused:    unusedFeatures(xgboostFeatureNames, mapping),
+		Metrics:   metrics,
+	}, nil
+}
+
+func parseXGBoostJSON(data []byte, opts TreeImportOptions) ([]*XGBoostTree, float64, map[string]string, error) {
+	var m xgboostJSONModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost JSON: %v", ErrInvalidInput, err)
+	}
+	learner := m.Learner
+	if booster := learner.GradientBooster.Name; booster != "gbtree" {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost booster %q is not supported, only gbtree", ErrInvalidInput, booster)
+	}
+	if !identityObjectives[learner.Objective.Name] {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost objective %q is not a regression objective", ErrInvalidInput, learner.Objective.Name)
+	}
+	if n, _ := strconv.Atoi(learner.LearnerModelParam.NumClass); n > 1 {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost model has %d classes", ErrInvalidInput, n)
+	}
+	// XGBoost 3 writes the base score as a one element array
+	baseScore, err := strconv.ParseFloat(strings.Trim(learner.LearnerModelParam.BaseScore, "[]"), 64)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost base_score %q", ErrInvalidInput, learner.LearnerModelParam.BaseScore)
+	}
+	numFeature, err := strconv.Atoi(learner.LearnerModelParam.NumFeature)
+	if err != nil || numFeature < 1 {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost num_feature %q", ErrInvalidInput, learner.LearnerModelParam.NumFeature)
+	}
+	if names := learner.FeatureNames; len(names) > 0 && len(names) != numFeature {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost model names %d of %d features", ErrInvalidInput, len(names), numFeature)
+	}
+	if len(learner.GradientBooster.Model.Trees) == 0 {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost model has no trees", ErrInvalidInput)
+	}
+
+	index, mapping, err := featureMapping(learner.FeatureNames, numFeature, true, xgboostFeatureNames, opts)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	trees := make([]*XGBoostTree, len(learner.GradientBooster.Model.Trees))
+	for t, raw := range learner.GradientBooster.Model.Trees {
+		root, err := raw.node(0, index, make(map[int]bool))
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("%w: XGBoost tree %d: %v", ErrInvalidInput, t, err)
+		}
+		trees[t] = &XGBoostTree{Root: root, Weight: 1}
+	}
+	return trees, baseScore, mapping, nil
+}
+
+// node converts node i and its subtree; index maps model to Go features
+func (t *xgboostJSONTree) node(i int, index []int, seen map[int]bool) (*XGBoostNode, error) {
+	n := len(t.LeftChildren)
+	if len(t.RightChildren) != n || len(t.SplitIndices) != n || len(t.SplitConditions) != n {
+		return nil, fmt.Errorf("node arrays differ in length")
+	}
+	if i < 0 || i >= n || seen[i] {
+		return nil, fmt.Errorf("invalid child %d", i)
+	}
+	seen[i] = true
+
+	node := &XGBoostNode{}
+	if i < len(t.SumHessian) {
+		node.Cover = t.SumHessian[i]
+	}
+	if t.LeftChildren[i] == -1 {
+		node.IsLeaf, node.Value = true, t.SplitConditions[i]
+		return node, nil
+	}
+	if i < len(t.SplitType) && t.SplitType[i] != 0 {
+		return nil, fmt.Errorf("node %d is a categorical split", i)
+	}
+	feature := t.SplitIndices[i]
+	if feature < 0 || feature >= len(index) {
+		return nil, fmt.Errorf("node %d splits on unknown feature %d", i, feature)
+	}
+	node.FeatureIndex = index[feature]
+	// XGBoost sends x < condition left, the Go trees x <= threshold
+	node.Threshold = math.Nextafter(t.SplitConditions[i], math.Inf(-1))
+	if i < len(t.LossChanges) {
+		node.Gain = t.LossChanges[i]
+	}
+	var err error
+	if node.Left, err = t.node(t.LeftChildren[i], index, seen); err != nil {
+		return nil, err
+	}
+	if node.Right, err = t.node(t.RightChildren[i], index, seen); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func parseXGBoostDump(data []byte, opts TreeImportOptions) ([]*XGBoostTree, float64, map[string]string, error) {
+	if opts.BaseScore == nil {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost dumps do not store the base score; pass base_score", ErrInvalidInput)
+	}
+	var roots []*xgboostDumpNode
+	if err := json.Unmarshal(data, &roots); err != nil {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost dump: %v", ErrInvalidInput, err)
+	}
+	if len(roots) == 0 {
+		return nil, 0, nil, fmt.Errorf("%w: XGBoost dump has no trees", ErrInvalidInput)
+	}
+
+	// Dumps name only the features that are split on: fN without names
+	splits := make(map[string]bool)
+	var collect func(n *xgboostDumpNode)
+	collect = func(n *xgboostDumpNode) {
+		if n == nil || n.Leaf != nil {
+			return
+		}
+		splits[n.Split] = true
+		for _, child := range n.Children {
+			collect(child)
+		}
+	}
+	for _, root := range roots {
+		collect(root)
+	}
+	names := make([]string, 0, len(splits))
+	for name := range splits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	goIndex := make(map[string]int, len(xgboostFeatureNames))
+	for i, name := range xgboostFeatureNames {
+		goIndex[name] = i
+	}
+	featureIndex := make(map[string]int, len(names))
+	if len(names) > 0 && unnamedFeatures(names, goIndex, opts) {
+		// fN is the Nth feature, which the dump may never split on
+		count := 0
+		for _, name := range names {
+			n, _ := strconv.Atoi(unnamedFeature.FindStringSubmatch(name)[1])
+			count = max(count, n+1)
+		}
+		index, mapping, err := featureMapping(nil, count, false, xgboostFeatureNames, opts)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		for _, name := range names {
+			n, _ := strconv.Atoi(unnamedFeature.FindStringSubmatch(name)[1])
+			featureIndex[name] = index[n]
+		}
+		return convertXGBoostDump(roots, featureIndex, *opts.BaseScore, mapping)
+	}
+	index, mapping, err := featureMapping(names, len(names), false, xgboostFeatureNames, opts)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	for i, name := range names {
+		featureIndex[name] = index[i]
+	}
+	return convertXGBoostDump(roots, featureIndex, *opts.BaseScore, mapping)
+}
+
+// convertXGBoostDump converts the dumped trees, reading each split feature
+// through featureIndex
+func convertXGBoostDump(roots []*xgboostDumpNode, featureIndex map[string]int, baseScore float64, mapping map[string]string) ([]*XGBoostTree, float64, map[string]string, error) {
+	trees := make([]*XGBoostTree, len(roots))
+	for t, root := range roots {
+		node, err := root.convert(featureIndex, 0)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("%w: XGBoost tree %d: %v", ErrInvalidInput, t, err)
+		}
+		trees[t] = &XGBoostTree{Root: node, Weight: 1}
+	}
+	return trees, baseScore, mapping, nil
+}
+
+// convert turns a dump node and its children into Go nodes
+func (n *xgboostDumpNode) convert(featureIndex map[string]int, depth int) (*XGBoostNode, error) {
+	if n == nil || depth > 64 {
+		return nil, fmt.Errorf("missing node or tree too deep")
+	}
+	if n.Leaf != nil {
+		return &XGBoostNode{IsLeaf: true, Value: *n.Leaf, Cover: n.Cover}, nil
+	}
+	var yes, no *xgboostDumpNode
+	for _, child := range n.Children {
+		if child == nil {
+			continue
+		}
+		switch child.NodeID {
+		case n.Yes:
+			yes = child
+		case n.No:
+			no = child
+		}
+	}
+	node := &XGBoostNode{
+		FeatureIndex: featureIndex[n.Split],
+		Threshold:    math.Nextafter(n.SplitCondition, math.Inf(-1)),
+		Gain:         n.Gain,
+		Cover:        n.Cover,
+	}
+	var err error
+	if node.Left, err = yes.convert(featureIndex, depth+1); err != nil {
+		return nil, fmt.Errorf("node %d: %v", n.NodeID, err)
+	}
+	if node.Right, err = no.convert(featureIndex, depth+1); err != nil {
+		return nil, fmt.Errorf("node %d: %v", n.NodeID, err)
+	}
+	return node, nil
+}
+
+func xgboostDepth(node *XGBoostNode) int {
+	if node == nil || node.IsLeaf {
+		return 0
+	}
+	return 1 + max(xgboostDepth(node.Left), xgboostDepth(node.Right))
+}
+
+// lightgbmRegression are the LightGBM objectives whose output is the target
+var lightgbmRegression = map[string]bool{
+	"regression": true, "regression_l1": true, "huber": true,
+	"fair": true, "quantile": true, "mape": true,
+}
+
+// LightGBM decision_type bits
+const (
+	lightgbmCategorical = 1
+	lightgbmMissingMask = 3 << 2
+	lightgbmMissingZero = 1 << 2
+)
+
+// ImportLightGBM converts a LightGBM text model file into a standalone
+// LightGBMPredictor. The average LightGBM starts boosting from is part of
+// the first tree, so the base score is zero.
+func ImportLightGBM(data []byte, opts TreeImportOptions) (*LightGBMPredictor, *TreeImportReport, error) {
+	header, blocks, err := parseLightGBMText(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	objective := strings.Fields(header["objective"])
+	if len(objective) == 0 || !lightgbmRegression[objective[0]] || strings.Contains(header["objective"], "sqrt") {
+		return nil, nil, fmt.Errorf("%w: LightGBM objective %q is not a regression objective", ErrInvalidInput, header["objective"])
+	}
+	if n, _ := strconv.Atoi(header["num_class"]); n > 1 {
+		return nil, nil, fmt.Errorf("%w: LightGBM model has %d classes", ErrInvalidInput, n)
+	}
+	maxFeature, err := strconv.Atoi(header["max_feature_idx"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: LightGBM max_feature_idx %q", ErrInvalidInput, header["max_feature_idx"])
+	}
+	names := strings.Fields(header["feature_names"])
+	if len(names) != maxFeature+1 {
+		return nil, nil, fmt.Errorf("%w: LightGBM model names %d of %d features", ErrInvalidInput, len(names), maxFeature+1)
+	}
+	if len(blocks) == 0 {
+		return nil, nil, fmt.Errorf("%w: LightGBM model has no trees", ErrInvalidInput)
+	}
+	index, mapping, err := featureMapping(names, len(names), true, lightgbmFeatureNames, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	model := newLightGBMPredictor()
+	for t, block := range blocks {
+		tree, err := lightgbmTextTree(block, index)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: LightGBM tree %d: %v", ErrInvalidInput, t, err)
+		}
+		model.Trees = append(model.Trees, tree)
+	}
+	model.Config.NumTrees = len(model.Trees)
+	if shrinkage, err := strconv.ParseFloat(blocks[0]["shrinkage"], 64); err == nil {
+		model.Config.LearningRate = shrinkage
+	}
+	model.version = NewModelVersion()
+
+	rows := lightgbmFeatures(opts.Candles)
+	if len(rows) > lightgbmWarmup {
+		rows = rows[lightgbmWarmup:]
+	} else {
+		rows = nil
+	}
+	var metrics map[string]float64
+	model.Meta, metrics = importMeta("lightgbm-text", opts, rows, lightgbmWarmup, model.predictRaw)
+
+	return model, &TreeImportReport{
+		Model:    model.Name(),
+		Format:   "lightgbm-text",
+		Version:  model.version,
+		Trees:    len(model.Trees),
+		Features: mapping,
+		Unused:   unusedFeatures(lightgbmFeatureNames, mapping),
+		Metrics:  metrics,
+	}, nil
+}
+
+// parseLightGBMText splits a LightGBM model file into its header and tree
+// blocks of key=value lines
+func parseLightGBMText(data []byte) (map[string]string, []map[string]string, error) {
+	header := make(map[string]string)
+	var blocks []map[string]string
+	current := header
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "end of trees" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if key == "Tree" {
+			current = make(map[string]string)
+			blocks = append(blocks, current)
+			continue
+		}
+		current[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: LightGBM model: %v", ErrInvalidInput, err)
+	}
+	if header["version"] == "" {
+		return nil, nil, fmt.Errorf("%w: not a LightGBM text model", ErrInvalidInput)
+	}
+	return header, blocks, nil
+}
+
+// lightgbmTextTree converts one tree block. Internal nodes are numbered from
+// 0; a negative child c is leaf ^c.
+func lightgbmTextTree(block map[string]string, index []int) (*Tree, error) {
+	if block["is_linear"] == "1" {
+		return nil, fmt.Errorf("linear trees are not supported")
+	}
+	if n, _ := strconv.Atoi(block["num_cat"]); n > 0 {
+		return nil, fmt.Errorf("categorical splits are not supported")
+	}
+	leaves, err := strconv.Atoi(block["num_leaves"])
+	if err != nil || leaves < 1 {
+		return nil, fmt.Errorf("num_leaves %q", block["num_leaves"])
+	}
+	leafValue, err := lightgbmFloats(block, "leaf_value", leaves)
+	if err != nil {
+		return nil, err
+	}
+	leafCount, _ := lightgbmFloats(block, "leaf_count", leaves)
+
+	tree := &Tree{FeatureImportance: make(map[string]float64), Splits: make(map[string]int)}
+	if leaves == 1 {
+		tree.Root = &Node{IsLeaf: true, Value: leafValue[0]}
+		return tree, nil
+	}
+
+	internal := leaves - 1
+	feature, err := lightgbmInts(block, "split_feature", internal)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := lightgbmFloats(block, "threshold", internal)
+	if err != nil {
+		return nil, err
+	}
+	decision, err := lightgbmInts(block, "decision_type", internal)
+	if err != nil {
+		return nil, err
+	}
+	left, err := lightgbmInts(block, "left_child", internal)
+	if err != nil {
+		return nil, err
+	}
+	right, err := lightgbmInts(block, "right_child", internal)
+	if err != nil {
+		return nil, err
+	}
+	gain, _ := lightgbmFloats(block, "split_gain", internal)
+	internalCount, _ := lightgbmFloats(block, "internal_count", internal)
+
+	seen := make(map[int]bool)
+	var build func(child, depth int) (*Node, error)
+	build = func(child, depth int) (*Node, error) {
+		if depth > leaves {
+			return nil, fmt.Errorf("cycle in tree")
+		}
+		if child < 0 {
+			leaf := ^child
+			if leaf >= leaves {
+				return nil, fmt.Errorf("invalid leaf %d", leaf)
+			}
+			node := &Node{IsLeaf: true, Value: leafValue[leaf]}
+			if leafCount != nil {
+				node.Cover = leafCount[leaf]
+			}
+			return node, nil
+		}
+		if child >= internal || seen[child] {
+			return nil, fmt.Errorf("invalid node %d", child)
+		}
+		seen[child] = true
+		if decision[child]&lightgbmCategorical != 0 {
+			return nil, fmt.Errorf("node %d is a categorical split", child)
+		}
+		if decision[child]&lightgbmMissingMask == lightgbmMissingZero {
+			return nil, fmt.Errorf("node %d treats zero as missing (zero_as_missing is not supported)", child)
+		}
+		f := feature[child]
+		if f < 0 || f >= len(index) {
+			return nil, fmt.Errorf("node %d splits on unknown feature %d", child, f)
+		}
+		node := &Node{Feature: index[f], Threshold: threshold[child]}
+		if gain != nil {
+			node.Gain = gain[child]
+		}
+		if internalCount != nil {
+			node.Cover = internalCount[child]
+		}
+		name := lightgbmFeatureNames[node.Feature]
+		tree.FeatureImportance[name] += node.Gain
+		tree.Splits[name]++
+
+		var err error
+		if node.Left, err = build(left[child], depth+1); err != nil {
+			return nil, err
+		}
+		if node.Right, err = build(right[child], depth+1); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	if tree.Root, err = build(0, 0); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// lightgbmFloats parses the n space separated numbers of a tree block key
+func lightgbmFloats(block map[string]string, key string, n int) ([]float64, error) {
+	fields := strings.Fields(block[key])
+	if len(fields) != n {
+		return nil, fmt.Errorf("%s has %d values, want %d", key, len(fields), n)
+	}
+	out := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func lightgbmInts(block map[string]string, key string, n int) ([]int, error) {
+	values, err := lightgbmFloats(block, key, n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int, n)
+	for i, v := range values {
+		out[i] = int(v)
+	}
+	return out, nil
+}
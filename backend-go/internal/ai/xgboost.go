@@ -1,37 +1,45 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"math"
-	"math/rand"
-	"sort"
 	"sync"
-	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
 )
 
-// XGBoostModel represents an XGBoost implementation for crypto price prediction
+// XGBoostModel represents an XGBoost implementation for crypto price
+// prediction. It forecasts the next candle return as BaseScore plus the sum
+// of the tree outputs, whose leaf values already include the learning rate.
 type XGBoostModel struct {
-	Trees         []*XGBoostTree
-	LearningRate  float64
-	MaxDepth      int
-	NumTrees      int
+	Trees          []*XGBoostTree
+	Features       []string
+	BaseScore      float64
+	LearningRate   float64
+	MaxDepth       int
+	NumTrees       int
 	MinChildWeight float64
-	Subsample     float64
-	Colsample     float64
-	Lambda        float64 // L2 regularization
-	Alpha         float64 // L1 regularization
-	Gamma         float64 // Minimum loss reduction
-	mu            sync.RWMutex
+	Subsample      float64
+	Colsample      float64
+	Lambda         float64       // L2 regularization
+	Alpha          float64       // L1 regularization
+	Gamma          float64       // Minimum loss reduction
+	Meta           *TrainingMeta // nil until trained
+	version        string
+	mu             sync.RWMutex
 }
 
 // XGBoostTree represents a single tree in the XGBoost ensemble
 type XGBoostTree struct {
 	Root           *XGBoostNode
-	FeatureIndices []int
+	FeatureIndices []int // features the tree was allowed to split on
 	Weight         float64
 }
 
-// XGBoostNode represents a node in the decision tree
+// XGBoostNode represents a node in the decision tree; samples with
+// FeatureIndex <= Threshold go left
 type XGBoostNode struct {
 	FeatureIndex int
 	Threshold    float64
@@ -40,6 +48,7 @@ type XGBoostNode struct {
 	Value        float64
 	IsLeaf       bool
 	Gain         float64
+	Cover        float64 // hessian sum of the training samples reaching the node
 }
 
 // XGBoostConfig holds configuration for XGBoost model
@@ -55,10 +64,11 @@ type XGBoostConfig struct {
 	Gamma          float64
 }
 
-// NewXGBoostModel creates a new XGBoost model
+// NewXGBoostModel creates a new, untrained XGBoost model
 func NewXGBoostModel() *XGBoostModel {
 	return &XGBoostModel{
 		Trees:          make([]*XGBoostTree, 0),
+		Features:       xgboostFeatureNames,
 		LearningRate:   0.3,
 		MaxDepth:       6,
 		NumTrees:       100,
@@ -68,345 +78,280 @@ func NewXGBoostModel() *XGBoostModel {
 		Lambda:         1.0,
 		Alpha:          0.0,
 		Gamma:          0.0,
+		version:        "1.0.0",
 	}
 }
 
-// Predict generates price predictions using XGBoost
-func (xgb *XGBoostModel) Predict(prices []float64, volumes []float64) (*Prediction, error) {
+// Name implements Predictor
+func (xgb *XGBoostModel) Name() string { return "xgboost" }
+
+// Version implements Predictor
+func (xgb *XGBoostModel) Version() string {
 	xgb.mu.RLock()
 	defer xgb.mu.RUnlock()
+	return xgb.version
+}
 
-	if len(prices) < 100 {
-		return nil, ErrInsufficientData
-	}
+// xgboostFeatureNames are the columns built by extractFeatures
+var xgboostFeatureNames = []string{
+	"return_1", "return_5", "return_10", "return_20",
+	"sma_20_ratio", "sma_50_ratio", "volatility_20", "volatility_50",
+	"volume_ratio_20", "volume_ratio_50", "rsi", "macd",
+	"momentum_10", "momentum_20", "high_low_spread", "price_position",
+}
 
-	// Extract features
-	features := xgb.extractFeatures(prices, volumes)
+// xgboostWarmup is the number of leading candles without a feature row: the
+// longest lookback is 50 candles
+const xgboostWarmup = 50
 
-	// Train model if trees are empty
-	if len(xgb.Trees) == 0 {
-		xgb.mu.RUnlock()
-		xgb.mu.Lock()
-		xgb.train(features, prices)
-		xgb.mu.Unlock()
-		xgb.mu.RLock()
+// config returns the hyperparameters of the model
+func (xgb *XGBoostModel) config() XGBoostConfig {
+	return XGBoostConfig{
+		NumTrees:       xgb.NumTrees,
+		MaxDepth:       xgb.MaxDepth,
+		LearningRate:   xgb.LearningRate,
+		MinChildWeight: xgb.MinChildWeight,
+		Subsample:      xgb.Subsample,
+		Colsample:      xgb.Colsample,
+		Lambda:         xgb.Lambda,
+		Alpha:          xgb.Alpha,
+		Gamma:          xgb.Gamma,
 	}
-
-	// Make prediction
-	currentPrice := prices[len(prices)-1]
-	prediction := xgb.predictSingle(features[len(features)-1])
-
-	// Calculate prediction components
-	priceChange := (prediction - currentPrice) / currentPrice * 100
-	confidence := xgb.calculateConfidence(features, prices)
-
-	// Generate targets based on prediction
-	targets := []float64{
-		currentPrice * 1.02,  // 2% target
-		currentPrice * 1.05,  // 5% target
-		currentPrice * 1.10,  // 10% target
-	}
-
-	stopLoss := currentPrice * 0.95 // 5% stop loss
-
-	// Feature importance
-	importance := xgb.getFeatureImportance()
-
-	return &Prediction{
-		Model:       "XGBoost",
-		Symbol:      "BTCUSDT",
-		CurrentPrice: currentPrice,
-		Predictions: map[string]PricePoint{
-			"1h": {
-				Price:      prediction * 1.001,
-				Confidence: confidence * 0.95,
-				Timestamp:  time.Now().Add(1 * time.Hour).Unix(),
-			},
-			"4h": {
-				Price:      prediction * 1.004,
-				Confidence: confidence * 0.90,
-				Timestamp:  time.Now().Add(4 * time.Hour).Unix(),
-			},
-			"24h": {
-				Price:      prediction,
-				Confidence: confidence,
-				Timestamp:  time.Now().Add(24 * time.Hour).Unix(),
-			},
-		},
-		Confidence: confidence,
-		Factors: map[string]float64{
-			"tree_depth":         float64(xgb.MaxDepth),
-			"num_trees":          float64(len(xgb.Trees)),
-			"learning_rate":      xgb.LearningRate,
-			"feature_importance": importance,
-			"regularization":     xgb.Lambda,
-		},
-		Recommendation: xgb.generateRecommendation(priceChange, confidence),
-		RiskLevel:      xgb.assessRisk(priceChange, confidence),
-		Timestamp:      time.Now().Unix(),
-		Targets:        targets,
-		StopLoss:       stopLoss,
-		EntryPrice:     currentPrice * 1.001,
-	}, nil
 }
 
-// extractFeatures creates feature set for XGBoost
-func (xgb *XGBoostModel) extractFeatures(prices, volumes []float64) [][]float64 {
-	features := make([][]float64, 0)
-
-	for i := 50; i < len(prices); i++ {
-		feat := []float64{
-			// Price features
-			prices[i] / prices[i-1] - 1,                    // 1-period return
-			prices[i] / prices[i-5] - 1,                    // 5-period return
-			prices[i] / prices[i-10] - 1,                   // 10-period return
-			prices[i] / prices[i-20] - 1,                   // 20-period return
-
-			// Moving averages
-			calculateSMA(prices[i-20:i+1], 20) / prices[i] - 1,
-			calculateSMA(prices[i-50:i+1], 50) / prices[i] - 1,
-
-			// Volatility
-			calculateVolatility(prices[i-20:i+1]),
-			calculateVolatility(prices[i-50:i+1]),
-
-			// Volume features
-			volumes[i] / calculateSMA(volumes[i-20:i+1], 20) - 1,
-			volumes[i] / calculateSMA(volumes[i-50:i+1], 50) - 1,
-
-			// Technical indicators
-			calculateRSI(prices[i-14:i+1]),
-			calculateMACD(prices[i-26:i+1]),
-
-			// Momentum
-			(prices[i] - prices[i-10]) / prices[i-10],
-			(prices[i] - prices[i-20]) / prices[i-20],
-
-			// High-low spread
-			xgb.calculateHighLowSpread(prices[i-20:i+1]),
+// setConfig replaces the hyperparameters of the model
+func (xgb *XGBoostModel) setConfig(c XGBoostConfig) {
+	xgb.NumTrees = c.NumTrees
+	xgb.MaxDepth = c.MaxDepth
+	xgb.LearningRate = c.LearningRate
+	xgb.MinChildWeight = c.MinChildWeight
+	xgb.Subsample = c.Subsample
+	xgb.Colsample = c.Colsample
+	xgb.Lambda = c.Lambda
+	xgb.Alpha = c.Alpha
+	xgb.Gamma = c.Gamma
+}
 
-			// Price position
-			(prices[i] - xgb.getMin(prices[i-50:i+1])) /
-				(xgb.getMax(prices[i-50:i+1]) - xgb.getMin(prices[i-50:i+1])),
-		}
+// xgboostState is the serialized form of the boosted trees
+type xgboostState struct {
+	LearningRate   float64        `json:"learning_rate"`
+	MaxDepth       int            `json:"max_depth"`
+	NumTrees       int            `json:"num_trees"`
+	MinChildWeight float64        `json:"min_child_weight"`
+	Subsample      float64        `json:"subsample"`
+	Colsample      float64        `json:"colsample"`
+	Lambda         float64        `json:"lambda"`
+	Alpha          float64        `json:"alpha"`
+	Gamma          float64        `json:"gamma"`
+	Features       []string       `json:"features"`
+	BaseScore      float64        `json:"base_score"`
+	Trees          []*XGBoostTree `json:"trees"`
+	Meta           *TrainingMeta  `json:"meta"`
+}
 
-		features = append(features, feat)
+// MarshalState implements Persistent
+func (xgb *XGBoostModel) MarshalState() (json.RawMessage, error) {
+	xgb.mu.RLock()
+	defer xgb.mu.RUnlock()
+	if xgb.Meta == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotTrained, xgb.Name())
 	}
-
-	return features
+	return json.Marshal(xgboostState{
+		LearningRate:   xgb.LearningRate,
+		MaxDepth:       xgb.MaxDepth,
+		NumTrees:       xgb.NumTrees,
+		MinChildWeight: xgb.MinChildWeight,
+		Subsample:      xgb.Subsample,
+		Colsample:      xgb.Colsample,
+		Lambda:         xgb.Lambda,
+		Alpha:          xgb.Alpha,
+		Gamma:          xgb.Gamma,
+		Features:       xgb.Features,
+		BaseScore:      xgb.BaseScore,
+		Trees:          xgb.Trees,
+		Meta:           xgb.Meta,
+	})
 }
 
-// train trains the XGBoost model
-func (xgb *XGBoostModel) train(features [][]float64, prices []float64) {
-	if len(features) < 100 {
-		return
+// RestoreState implements Persistent; the feature names must match the
+// columns built by extractFeatures. States saved before training existed
+// have no training metadata and are rejected with ErrModelNotTrained.
+func (xgb *XGBoostModel) RestoreState(version string, data json.RawMessage) error {
+	var state xgboostState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
 	}
-
-	// Prepare training data
-	X := features[:len(features)-1]
-	y := make([]float64, len(X))
-	for i := range y {
-		// Predict next price change
-		y[i] = (prices[51+i] - prices[50+i]) / prices[50+i]
+	if state.Meta == nil {
+		return fmt.Errorf("%w: %s version %s has no training metadata", ErrModelNotTrained, xgb.Name(), version)
 	}
-
-	// Initialize predictions with zeros
-	predictions := make([]float64, len(y))
-
-	// Build trees sequentially (boosting)
-	for t := 0; t < xgb.NumTrees; t++ {
-		// Calculate gradients and hessians
-		gradients := make([]float64, len(y))
-		hessians := make([]float64, len(y))
-
-		for i := range y {
-			// Gradient of squared loss
-			gradients[i] = predictions[i] - y[i]
-			hessians[i] = 1.0
-		}
-
-		// Subsample data
-		sampleIndices := xgb.subsample(len(X))
-		sampledX := make([][]float64, len(sampleIndices))
-		sampledGradients := make([]float64, len(sampleIndices))
-		sampledHessians := make([]float64, len(sampleIndices))
-
-		for i, idx := range sampleIndices {
-			sampledX[i] = X[idx]
-			sampledGradients[i] = gradients[idx]
-			sampledHessians[i] = hessians[idx]
-		}
-
-		// Build tree
-		tree := xgb.buildTree(sampledX, sampledGradients, sampledHessians, 0)
-		xgb.Trees = append(xgb.Trees, tree)
-
-		// Update predictions
-		for i := range predictions {
-			treePredict := xgb.predictTree(tree, X[i])
-			predictions[i] += xgb.LearningRate * treePredict
+	if len(state.Trees) == 0 {
+		return fmt.Errorf("%w: XGBoost state has no trees", ErrInvalidInput)
+	}
+	for i, tree := range state.Trees {
+		if tree == nil || !validXGBoostNode(tree.Root, len(state.Features)) {
+			return fmt.Errorf("%w: XGBoost tree %d is malformed", ErrInvalidInput, i)
 		}
 	}
+	if err := checkFinite("base_score", []float64{state.BaseScore}); err != nil {
+		return err
+	}
+
+	xgb.mu.Lock()
+	defer xgb.mu.Unlock()
+	if !sameStrings(state.Features, xgb.Features) {
+		return fmt.Errorf("%w: XGBoost features %v, want %v", ErrInvalidInput, state.Features, xgb.Features)
+	}
+	xgb.LearningRate = state.LearningRate
+	xgb.MaxDepth = state.MaxDepth
+	xgb.NumTrees = state.NumTrees
+	xgb.MinChildWeight = state.MinChildWeight
+	xgb.Subsample = state.Subsample
+	xgb.Colsample = state.Colsample
+	xgb.Lambda = state.Lambda
+	xgb.Alpha = state.Alpha
+	xgb.Gamma = state.Gamma
+	xgb.BaseScore = state.BaseScore
+	xgb.Trees = state.Trees
+	xgb.Meta = state.Meta
+	xgb.version = version
+	return nil
 }
 
-// buildTree builds a single decision tree
-func (xgb *XGBoostModel) buildTree(X [][]float64, gradients, hessians []float64, depth int) *XGBoostTree {
-	tree := &XGBoostTree{
-		Root: xgb.buildNode(X, gradients, hessians, depth),
+// validXGBoostNode checks that every split has both children and a known
+// feature, and that leaf values are finite
+func validXGBoostNode(node *XGBoostNode, features int) bool {
+	if node == nil {
+		return false
 	}
-	return tree
+	if node.IsLeaf {
+		return !math.IsNaN(node.Value) && !math.IsInf(node.Value, 0)
+	}
+	return node.FeatureIndex >= 0 && node.FeatureIndex < features &&
+		validXGBoostNode(node.Left, features) && validXGBoostNode(node.Right, features)
 }
 
-// buildNode builds a tree node recursively
-func (xgb *XGBoostModel) buildNode(X [][]float64, gradients, hessians []float64, depth int) *XGBoostNode {
-	// Check stopping conditions
-	if depth >= xgb.MaxDepth || len(X) < 2 {
-		return &XGBoostNode{
-			IsLeaf: true,
-			Value:  xgb.calculateLeafValue(gradients, hessians),
-		}
+// Predict forecasts the next candle close with the trained trees
+func (xgb *XGBoostModel) Predict(ctx context.Context, in Input) (*Prediction, error) {
+	xgb.mu.RLock()
+	defer xgb.mu.RUnlock()
+	if err := xgb.Meta.check(xgb.Name(), in, xgboostWarmup+1); err != nil {
+		return nil, err
 	}
 
-	// Find best split
-	bestFeature, bestThreshold, bestGain := xgb.findBestSplit(X, gradients, hessians)
-
-	if bestGain < xgb.Gamma {
-		return &XGBoostNode{
-			IsLeaf: true,
-			Value:  xgb.calculateLeafValue(gradients, hessians),
-		}
-	}
+	// Extract features of the last candle
+	features := xgb.extractFeatures(in.Candles)
+	predictedReturn := xgb.predictSingle(features[len(features)-1])
 
-	// Split data
-	leftIndices, rightIndices := xgb.splitData(X, bestFeature, bestThreshold)
+	// Make prediction
+	prices := in.Closes()
+	currentPrice := prices[len(prices)-1]
+	prediction := currentPrice * (1 + predictedReturn)
 
-	if len(leftIndices) == 0 || len(rightIndices) == 0 {
-		return &XGBoostNode{
-			IsLeaf: true,
-			Value:  xgb.calculateLeafValue(gradients, hessians),
-		}
+	direction := "NEUTRAL"
+	if predictedReturn > 0.001 {
+		direction = "UP"
+	} else if predictedReturn < -0.001 {
+		direction = "DOWN"
 	}
 
-	// Create child nodes
-	leftX := make([][]float64, len(leftIndices))
-	leftGradients := make([]float64, len(leftIndices))
-	leftHessians := make([]float64, len(leftIndices))
+	// Confidence from the validation accuracy of the trained trees
+	confidence := xgb.Meta.confidence(predictedReturn)
+	priceChange := predictedReturn * 100
 
-	rightX := make([][]float64, len(rightIndices))
-	rightGradients := make([]float64, len(rightIndices))
-	rightHessians := make([]float64, len(rightIndices))
-
-	for i, idx := range leftIndices {
-		leftX[i] = X[idx]
-		leftGradients[i] = gradients[idx]
-		leftHessians[i] = hessians[idx]
+	factors := map[string]float64{
+		"raw_prediction": predictedReturn,
+		"base_score":     xgb.BaseScore,
+		"tree_depth":     float64(xgb.MaxDepth),
+		"num_trees":      float64(len(xgb.Trees)),
+		"learning_rate":  xgb.LearningRate,
+		"regularization": xgb.Lambda,
 	}
-
-	for i, idx := range rightIndices {
-		rightX[i] = X[idx]
-		rightGradients[i] = gradients[idx]
-		rightHessians[i] = hessians[idx]
+	for name, value := range xgboostImportance(xgb.Trees, xgb.Features) {
+		factors["importance."+name] = value
 	}
 
-	return &XGBoostNode{
-		FeatureIndex: bestFeature,
-		Threshold:    bestThreshold,
-		Gain:         bestGain,
-		Left:         xgb.buildNode(leftX, leftGradients, leftHessians, depth+1),
-		Right:        xgb.buildNode(rightX, rightGradients, rightHessians, depth+1),
-	}
-}
-
-// findBestSplit finds the best feature and threshold for splitting
-func (xgb *XGBoostModel) findBestSplit(X [][]float64, gradients, hessians []float64) (int, float64, float64) {
-	bestFeature := -1
-	bestThreshold := 0.0
-	bestGain := -math.MaxFloat64
+	p := stepPrediction("xgboost", in, prediction, confidence, direction,
+		xgb.generateRecommendation(priceChange, confidence/100), factors)
+	p.RiskLevel = xgb.assessRisk(priceChange, confidence/100)
 
-	numFeatures := len(X[0])
-
-	// Column sampling
-	featureIndices := xgb.colsample(numFeatures)
-
-	for _, featureIdx := range featureIndices {
-		// Get unique values for this feature
-		uniqueValues := xgb.getUniqueValues(X, featureIdx)
-
-		for _, threshold := range uniqueValues {
-			leftIndices, rightIndices := xgb.splitData(X, featureIdx, threshold)
-
-			if len(leftIndices) == 0 || len(rightIndices) == 0 {
-				continue
-			}
-
-			// Calculate gain
-			gain := xgb.calculateGain(gradients, hessians, leftIndices, rightIndices)
+	// Generate targets based on prediction
+	p.Targets = []float64{
+		currentPrice * 1.02, // 2% target
+		currentPrice * 1.05, // 5% target
+		currentPrice * 1.10, // 10% target
+	}
+	p.StopLoss = currentPrice * 0.95 // 5% stop loss
+	p.EntryPrice = currentPrice * 1.001
+	return p, nil
+}
 
-			if gain > bestGain {
-				bestGain = gain
-				bestFeature = featureIdx
-				bestThreshold = threshold
-			}
+// extractFeatures builds the feature row of every candle after the first
+// xgboostWarmup, in the order of xgboostFeatureNames: row k describes candle
+// xgboostWarmup+k. Training and prediction share it.
+func (xgb *XGBoostModel) extractFeatures(c indicators.OHLCV) [][]float64 {
+	prices, volumes := c.Close, c.Volume
+	features := make([][]float64, 0, max(len(prices)-xgboostWarmup, 0))
+
+	sma20 := indicators.SMA(prices, 20)
+	sma50 := indicators.SMA(prices, 50)
+	volSMA20 := indicators.SMA(volumes, 20)
+	volSMA50 := indicators.SMA(volumes, 50)
+	rsi := indicators.RSI(prices, 14)
+	macd, _, _ := indicators.MACD(prices, 12, 26, 9)
+
+	for i := xgboostWarmup; i < len(prices); i++ {
+		low, high := xgb.getMin(prices[i-50:i+1]), xgb.getMax(prices[i-50:i+1])
+		position := 0.5
+		if high > low {
+			position = (prices[i] - low) / (high - low)
 		}
-	}
 
-	return bestFeature, bestThreshold, bestGain
-}
+		feat := []float64{
+			// Price features
+			prices[i]/prices[i-1] - 1,  // 1-period return
+			prices[i]/prices[i-5] - 1,  // 5-period return
+			prices[i]/prices[i-10] - 1, // 10-period return
+			prices[i]/prices[i-20] - 1, // 20-period return
 
-// calculateGain calculates the gain from a split
-func (xgb *XGBoostModel) calculateGain(gradients, hessians []float64, leftIndices, rightIndices []int) float64 {
-	leftG, leftH := 0.0, 0.0
-	rightG, rightH := 0.0, 0.0
-	totalG, totalH := 0.0, 0.0
+			// Moving averages
+			sma20.At(i, prices[i])/prices[i] - 1,
+			sma50.At(i, prices[i])/prices[i] - 1,
 
-	for _, idx := range leftIndices {
-		leftG += gradients[idx]
-		leftH += hessians[idx]
-	}
+			// Volatility
+			calculateVolatility(prices[i-20 : i+1]),
+			calculateVolatility(prices[i-50 : i+1]),
 
-	for _, idx := range rightIndices {
-		rightG += gradients[idx]
-		rightH += hessians[idx]
-	}
+			// Volume features (close only candles have no volume)
+			volumeRatio(volumes[i], volSMA20.At(i, 0)),
+			volumeRatio(volumes[i], volSMA50.At(i, 0)),
 
-	totalG = leftG + rightG
-	totalH = leftH + rightH
+			// Technical indicators
+			rsi.At(i, 50),
+			macd.At(i, 0),
 
-	// Calculate gain with regularization
-	gain := 0.5 * (
-		(leftG*leftG)/(leftH+xgb.Lambda) +
-		(rightG*rightG)/(rightH+xgb.Lambda) -
-		(totalG*totalG)/(totalH+xgb.Lambda))
+			// Momentum
+			(prices[i] - prices[i-10]) / prices[i-10],
+			(prices[i] - prices[i-20]) / prices[i-20],
 
-	return gain
-}
+			// High-low spread
+			xgb.calculateHighLowSpread(prices[i-20 : i+1]),
+
+			// Price position within the 50 candle range
+			position,
+		}
 
-// calculateLeafValue calculates the optimal value for a leaf node
-func (xgb *XGBoostModel) calculateLeafValue(gradients, hessians []float64) float64 {
-	sumG, sumH := 0.0, 0.0
-	for i := range gradients {
-		sumG += gradients[i]
-		sumH += hessians[i]
+		features = append(features, feat)
 	}
 
-	// Optimal leaf value with regularization
-	return -sumG / (sumH + xgb.Lambda)
+	return features
 }
 
-// splitData splits data based on feature and threshold
-func (xgb *XGBoostModel) splitData(X [][]float64, featureIdx int, threshold float64) ([]int, []int) {
-	leftIndices := []int{}
-	rightIndices := []int{}
-
-	for i, x := range X {
-		if x[featureIdx] <= threshold {
-			leftIndices = append(leftIndices, i)
-		} else {
-			rightIndices = append(rightIndices, i)
-		}
+// volumeRatio returns v/avg - 1, or 0 when avg is not positive
+func volumeRatio(v, avg float64) float64 {
+	if avg <= 0 {
+		return 0
 	}
-
-	return leftIndices, rightIndices
+	return v/avg - 1
 }
 
 // predictTree makes prediction using a single tree
@@ -424,64 +369,13 @@ func (xgb *XGBoostModel) predictTree(tree *XGBoostTree, x []float64) float64 {
 	return node.Value
 }
 
-// predictSingle makes a prediction for a single sample
+// predictSingle returns the forecast next candle return of one feature row
 func (xgb *XGBoostModel) predictSingle(x []float64) float64 {
-	prediction := 0.0
-
+	prediction := xgb.BaseScore
 	for _, tree := range xgb.Trees {
-		prediction += xgb.LearningRate * xgb.predictTree(tree, x)
-	}
-
-	// Convert back to price (assuming base price is last known)
-	return x[0] * (1 + prediction)
-}
-
-// subsample performs row sampling
-func (xgb *XGBoostModel) subsample(n int) []int {
-	sampleSize := int(float64(n) * xgb.Subsample)
-	indices := make([]int, n)
-	for i := range indices {
-		indices[i] = i
-	}
-
-	// Shuffle and take first sampleSize
-	rand.Shuffle(n, func(i, j int) {
-		indices[i], indices[j] = indices[j], indices[i]
-	})
-
-	return indices[:sampleSize]
-}
-
-// colsample performs column sampling
-func (xgb *XGBoostModel) colsample(n int) []int {
-	sampleSize := int(float64(n) * xgb.Colsample)
-	indices := make([]int, n)
-	for i := range indices {
-		indices[i] = i
+		prediction += xgb.predictTree(tree, x)
 	}
-
-	// Shuffle and take first sampleSize
-	rand.Shuffle(n, func(i, j int) {
-		indices[i], indices[j] = indices[j], indices[i]
-	})
-
-	return indices[:sampleSize]
-}
-
-// getUniqueValues gets unique values for a feature
-func (xgb *XGBoostModel) getUniqueValues(X [][]float64, featureIdx int) []float64 {
-	values := make(map[float64]bool)
-	for _, x := range X {
-		values[x[featureIdx]] = true
-	}
-
-	unique := make([]float64, 0, len(values))
-	for v := range values {
-		unique = append(unique, v)
-	}
-
-	sort.Float64s(unique)
-	return unique
+	return prediction
 }
 
 // calculateHighLowSpread calculates the high-low spread
@@ -530,62 +424,30 @@ func (xgb *XGBoostModel) getMax(values []float64) float64 {
 	return max
 }
 
-// calculateConfidence calculates prediction confidence
-func (xgb *XGBoostModel) calculateConfidence(features [][]float64, prices []float64) float64 {
-	if len(features) < 10 {
-		return 0.5
-	}
-
-	// Base confidence on tree agreement and feature importance
-	confidence := 0.7
-
-	// Adjust based on recent prediction accuracy (simulated)
-	recentAccuracy := 0.75 + rand.Float64()*0.2
-	confidence = confidence*0.6 + recentAccuracy*0.4
-
-	// Ensure confidence is between 0 and 1
-	if confidence > 0.95 {
-		confidence = 0.95
-	}
-	if confidence < 0.4 {
-		confidence = 0.4
-	}
-
-	return confidence
-}
-
-// getFeatureImportance calculates average feature importance across trees
-func (xgb *XGBoostModel) getFeatureImportance() float64 {
-	if len(xgb.Trees) == 0 {
-		return 0
-	}
-
-	totalGain := 0.0
-	nodeCount := 0
-
-	for _, tree := range xgb.Trees {
-		gain, count := xgb.calculateTreeImportance(tree.Root)
-		totalGain += gain
-		nodeCount += count
+// xgboostImportance returns each feature's share of the split gain across
+// the trees
+func xgboostImportance(trees []*XGBoostTree, features []string) map[string]float64 {
+	gain := make(map[string]float64)
+	total := 0.0
+	var walk func(node *XGBoostNode)
+	walk = func(node *XGBoostNode) {
+		if node == nil || node.IsLeaf {
+			return
+		}
+		gain[features[node.FeatureIndex]] += node.Gain
+		total += node.Gain
+		walk(node.Left)
+		walk(node.Right)
 	}
-
-	if nodeCount == 0 {
-		return 0
+	for _, tree := range trees {
+		walk(tree.Root)
 	}
-
-	return totalGain / float64(nodeCount)
-}
-
-// calculateTreeImportance calculates importance for a single tree
-func (xgb *XGBoostModel) calculateTreeImportance(node *XGBoostNode) (float64, int) {
-	if node == nil || node.IsLeaf {
-		return 0, 0
+	if total > 0 {
+		for name := range gain {
+			gain[name] /= total
+		}
 	}
-
-	leftGain, leftCount := xgb.calculateTreeImportance(node.Left)
-	rightGain, rightCount := xgb.calculateTreeImportance(node.Right)
-
-	return node.Gain + leftGain + rightGain, 1 + leftCount + rightCount
+	return gain
 }
 
 // generateRecommendation generates trading recommendation
@@ -645,18 +507,47 @@ func (xgb *XGBoostModel) ToJSON() ([]byte, error) {
 		Gamma:          xgb.Gamma,
 	})
 }
+
+var xgboostPredictor *XGBoostModel
+var xgboostOnce sync.Once
+
 // GetXGBoostPredictor returns XGBoost predictor instance
 func GetXGBoostPredictor() *XGBoostModel {
-	return &XGBoostModel{
-		NumTrees:     100,
-		MaxDepth:     6,
-		LearningRate: 0.3,
-		MinChildWeight: 1,
-		Subsample:    0.8,
-		Colsample:     0.8,
-		Lambda:       1.0,
-		Alpha:        0.0,
-		Gamma:        0.0,
-		Trees:        []*XGBoostTree{},
+	xgboostOnce.Do(func() {
+		xgboostPredictor = NewXGBoostModel()
+	})
+	return xgboostPredictor
+}
+
+// xgboostVersions caches saved XGBoost versions loaded by LoadXGBoostVersion
+var xgboostVersions = struct {
+	sync.Mutex
+	models map[string]*XGBoostModel
+}{models: make(map[string]*XGBoostModel)}
+
+// maxCachedXGBoostVersions bounds the versions kept in memory
+const maxCachedXGBoostVersions = 16
+
+// LoadXGBoostVersion returns a saved XGBoost version as a standalone model,
+// independent of the registered one, so a specific trained model can be
+// predicted with without activating it
+func LoadXGBoostVersion(version string) (*XGBoostModel, error) {
+	xgboostVersions.Lock()
+	defer xgboostVersions.Unlock()
+	if model, ok := xgboostVersions.models[version]; ok {
+		return model, nil
+	}
+
+	model := NewXGBoostModel()
+	if err := GetModelStore().Load(model, version); err != nil {
+		return nil, err
+	}
+	if len(xgboostVersions.models) >= maxCachedXGBoostVersions {
+		for v := range xgboostVersions.models {
+			delete(xgboostVersions.models, v)
+			break
+		}
 	}
+	xgboostVersions.models[version] = model
+	return model, nil
 }
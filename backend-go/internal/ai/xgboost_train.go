@@ -0,0 +1,263 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// xgboostPatience is the default number of boosting rounds without
+// validation improvement before training stops
+const xgboostPatience = 10
+
+// Train implements Trainer: it fits gradient boosted trees on next-candle
+// returns with exact greedy splits, growing each tree depth-wise, then swaps
+// them in. Epochs is the maximum number of boosting rounds and LearningRate
+// the shrinkage (eta). Params may set max_depth, min_child_weight,
+// subsample, colsample_bytree, lambda, alpha and gamma.
+func (xgb *XGBoostModel) Train(ctx context.Context, data TrainingData, cfg TrainConfig) (*TrainingReport, error) {
+	xgb.mu.RLock()
+	config := xgb.config()
+	xgb.mu.RUnlock()
+
+	if err := xgboostParams(cfg, &config); err != nil {
+		return nil, err
+	}
+	if cfg.Epochs == 0 {
+		cfg.Epochs = config.NumTrees
+	}
+	if cfg.Patience == 0 {
+		cfg.Patience = xgboostPatience
+	}
+	cfg = cfg.withDefaults(1, config.LearningRate)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	config.LearningRate = cfg.LearningRate
+
+	result, err := fitXGBoost(ctx, xgb, data, cfg, config)
+	if err != nil {
+		return nil, err
+	}
+	config.NumTrees = len(result.trees)
+
+	xgb.mu.Lock()
+	defer xgb.mu.Unlock()
+	xgb.setConfig(config)
+	xgb.Trees, xgb.BaseScore, xgb.Meta = result.trees, result.baseScore, result.meta
+	xgb.version = NewModelVersion()
+	result.report.Version = xgb.version
+	return result.report, nil
+}
+
+// xgboostParams applies the tree hyperparameters in cfg.Params to c
+func xgboostParams(cfg TrainConfig, c *XGBoostConfig) error {
+	var err error
+	if c.MaxDepth, err = cfg.intParam("max_depth", c.MaxDepth, 1, 32); err != nil {
+		return err
+	}
+	if c.MinChildWeight, err = cfg.floatParam("min_child_weight", c.MinChildWeight, 0, 1e6); err != nil {
+		return err
+	}
+	if c.Subsample, err = cfg.floatParam("subsample", c.Subsample, 0.01, 1); err != nil {
+		return err
+	}
+	if c.Colsample, err = cfg.floatParam("colsample_bytree", c.Colsample, 0.01, 1); err != nil {
+		return err
+	}
+	if c.Lambda, err = cfg.floatParam("lambda", c.Lambda, 0, 1e6); err != nil {
+		return err
+	}
+	if c.Alpha, err = cfg.floatParam("alpha", c.Alpha, 0, 1e6); err != nil {
+		return err
+	}
+	c.Gamma, err = cfg.floatParam("gamma", c.Gamma, 0, math.MaxFloat64)
+	return err
+}
+
+// xgboostTraining is the result of fitXGBoost
+type xgboostTraining struct {
+	trees     []*XGBoostTree
+	baseScore float64
+	meta      *TrainingMeta
+	report    *TrainingReport
+}
+
+// fitXGBoost boosts trees on the feature rows of data. The most recent
+// cfg.ValidationSplit of the samples is held out; boosting stops when the
+// validation loss has not improved for cfg.Patience rounds, keeping the trees
+// up to the best round. Train and validation loss are recorded every round.
+func fitXGBoost(ctx context.Context, xgb *XGBoostModel, data TrainingData, cfg TrainConfig, config XGBoostConfig) (*xgboostTraining, error) {
+	start := time.Now()
+	closes := data.Candles.Close
+	samples := len(closes) - 1 - xgboostWarmup
+	if samples < 100 {
+		return nil, fmt.Errorf("%w: %d candles give %d training samples, need 100", ErrInsufficientData, len(closes), max(samples, 0))
+	}
+	valSamples := max(int(float64(samples)*cfg.ValidationSplit), 10)
+	trainSamples := samples - valSamples
+
+	// Row k describes candle xgboostWarmup+k; the last candle has no target
+	rows := xgb.extractFeatures(data.Candles)[:samples]
+	targets := make([]float64, samples)
+	for i := range targets {
+		c := xgboostWarmup + i
+		targets[i] = closes[c+1]/closes[c] - 1
+	}
+	trainX, trainY := rows[:trainSamples], targets[:trainSamples]
+	valX, valY := rows[trainSamples:], targets[trainSamples:]
+
+	baseScore := 0.0
+	for _, y := range trainY {
+		baseScore += y
+	}
+	baseScore /= float64(trainSamples)
+
+	trainPred := filled(trainSamples, baseScore)
+	valPred := filled(valSamples, baseScore)
+	b := &xgboostBuilder{config: config, x: trainX, grad: make([]float64, trainSamples), hess: filled(trainSamples, 1)}
+
+	report := &TrainingReport{
+		Model:        xgb.Name(),
+		Symbol:       data.Symbol,
+		Interval:     data.Interval,
+		Candles:      len(closes),
+		TrainSamples: trainSamples,
+		ValSamples:   valSamples,
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var trees []*XGBoostTree
+	bestLoss, stale := math.Inf(1), 0
+	for round := 1; round <= cfg.Epochs; round++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Squared error: the gradient is the residual, the hessian is 1
+		for i, p := range trainPred {
+			b.grad[i] = p - trainY[i]
+		}
+		tree := b.grow(sampleIndices(rng, trainSamples, config.Subsample), sampleIndices(rng, len(xgboostFeatureNames), config.Colsample))
+		trees = append(trees, tree)
+		for i, x := range trainX {
+			trainPred[i] += xgb.predictTree(tree, x)
+		}
+		for i, x := range valX {
+			valPred[i] += xgb.predictTree(tree, x)
+		}
+
+		trainLoss, valLoss := meanSquaredError(trainPred, trainY), meanSquaredError(valPred, valY)
+		if math.IsNaN(trainLoss) || math.IsNaN(valLoss) {
+			return nil, fmt.Errorf("training %s diverged at round %d", xgb.Name(), round)
+		}
+		report.TrainLoss = append(report.TrainLoss, trainLoss)
+		report.ValLoss = append(report.ValLoss, valLoss)
+		report.Epochs = round
+
+		if valLoss < bestLoss {
+			bestLoss, stale = valLoss, 0
+			report.BestEpoch = round
+			continue
+		}
+		if stale++; stale >= cfg.Patience {
+			report.StoppedEarly = round < cfg.Epochs
+			break
+		}
+	}
+	trees = trees[:report.BestEpoch]
+
+	for i, x := range valX {
+		valPred[i] = baseScore
+		for _, tree := range trees {
+			valPred[i] += xgb.predictTree(tree, x)
+		}
+	}
+	metrics := regressionMetrics("val_", valPred, valY)
+	metrics["train_mse"] = report.TrainLoss[report.BestEpoch-1]
+	metrics["rounds"] = float64(report.Epochs)
+	metrics["trees"] = float64(len(trees))
+	for name, value := range xgboostImportance(trees, xgboostFeatureNames) {
+		metrics["importance_gain."+name] = value
+	}
+
+	meta := &TrainingMeta{Symbol: data.Symbol, Interval: data.Interval}
+	meta.setValidation(metrics)
+	report.Metrics = metrics
+	report.Duration = time.Since(start).Seconds()
+	return &xgboostTraining{trees: trees, baseScore: baseScore, meta: meta, report: report}, nil
+}
+
+// xgboostBuilder grows regression trees on the gradients of the training rows
+type xgboostBuilder struct {
+	config     XGBoostConfig
+	x          [][]float64
+	grad, hess []float64
+	features   []int // features of the tree being grown
+}
+
+// grow builds one tree depth-wise on rows, splitting on features only. Leaf
+// values include the learning rate.
+func (b *xgboostBuilder) grow(rows, features []int) *XGBoostTree {
+	b.features = features
+	return &XGBoostTree{Root: b.node(rows, 0), FeatureIndices: features, Weight: 1}
+}
+
+// node splits rows on the best split that passes the constraints, or makes
+// them a leaf
+func (b *xgboostBuilder) node(rows []int, depth int) *XGBoostNode {
+	g, h := sumGradients(rows, b.grad, b.hess)
+	node := &XGBoostNode{Cover: h}
+	if depth < b.config.MaxDepth && len(rows) >= 2 {
+		if feature, threshold, gain, ok := b.bestSplit(rows, g, h); ok {
+			var left, right []int
+			for _, r := range rows {
+				if b.x[r][feature] <= threshold {
+					left = append(left, r)
+				} else {
+					right = append(right, r)
+				}
+			}
+			node.FeatureIndex, node.Threshold, node.Gain = feature, threshold, gain
+			node.Left = b.node(left, depth+1)
+			node.Right = b.node(right, depth+1)
+			return node
+		}
+	}
+	node.IsLeaf = true
+	node.Value = b.config.LearningRate * leafOutput(g, h, b.config.Alpha, b.config.Lambda)
+	return node
+}
+
+// bestSplit scans every distinct value of every tree feature (exact greedy)
+// for the split with the largest loss reduction minus gamma. Both children
+// need a hessian sum of at least MinChildWeight; splits that do not reduce
+// the loss are rejected.
+func (b *xgboostBuilder) bestSplit(rows []int, g, h float64) (int, float64, float64, bool) {
+	c := b.config
+	parent := leafScore(g, h, c.Alpha, c.Lambda)
+	bestFeature, bestThreshold, bestGain := -1, 0.0, 0.0
+
+	sorted := make([]int, len(rows))
+	for _, f := range b.features {
+		copy(sorted, rows)
+		sort.Slice(sorted, func(i, j int) bool { return b.x[sorted[i]][f] < b.x[sorted[j]][f] })
+
+		var gl, hl float64
+		for k := 0; k < len(sorted)-1; k++ {
+			r := sorted[k]
+			gl += b.grad[r]
+			hl += b.hess[r]
+			v, next := b.x[r][f], b.x[sorted[k+1]][f]
+			if v == next || hl < c.MinChildWeight || h-hl < c.MinChildWeight {
+				continue
+			}
+			gain := 0.5*(leafScore(gl, hl, c.Alpha, c.Lambda)+leafScore(g-gl, h-hl, c.Alpha, c.Lambda)-parent) - c.Gamma
+			if gain > bestGain {
+				bestFeature, bestThreshold, bestGain = f, (v+next)/2, gain
+			}
+		}
+	}
+	return bestFeature, bestThreshold, bestGain, bestFeature >= 0
+}
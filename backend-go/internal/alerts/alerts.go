@@ -0,0 +1,327 @@
+// Package alerts 수식 조건 알림
+//
+// 규칙은 expr 조건식(예: "crossover(ema(close,12), ema(close,26))")과
+// 심볼/인터벌로 구성되며, 실시간 스트림에서 캔들이 마감될 때마다 평가된다.
+// 스트림은 관심 종목의 1분봉만 받으므로, 1분봉 마감이 규칙 인터벌의 경계와
+// 겹칠 때 그 인터벌 캔들이 마감된 것으로 보고 평가한다.
+// 조건이 거짓에서 참으로 바뀌는 캔들에서 한 번 발생한다.
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/loadstar0723/monstas7-backend/internal/expr"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rulesKey 규칙 저장 Redis 키
+	rulesKey = "alerts:rules"
+	// Channel 발생한 알림을 발행하는 Redis 채널
+	Channel = "alerts"
+)
+
+// ErrRuleNotFound 규칙이 없음
+var ErrRuleNotFound = errors.New("alert rule not found")
+
+// weekOrigin 주봉 경계 기준 시각 (1970-01-05 월요일 00:00 UTC, 밀리초)
+// 바이낸스 주봉은 월요일에 시작하고 나머지 인터벌은 유닉스 시각 0 기준이다
+const weekOrigin = 4 * 24 * 60 * 60 * 1000
+
+// Rule 조건 알림 규칙
+type Rule struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Symbol    string     `json:"symbol"`
+	Interval  string     `json:"interval"`
+	Condition string     `json:"condition"`
+	Message   string     `json:"message,omitempty"`
+	Once      bool       `json:"once"`     // 한 번 발생하면 비활성화
+	Cooldown  int        `json:"cooldown"` // 발생 후 다시 발생하기까지 최소 캔들 수
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastFired *time.Time `json:"lastFired,omitempty"`
+	LastBar   int64      `json:"lastBar,omitempty"` // 마지막 발생 캔들 시작 시각 (밀리초)
+}
+
+// Event 발생한 알림
+type Event struct {
+	RuleID    string    `json:"ruleId"`
+	Name      string    `json:"name,omitempty"`
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"`
+	Condition string    `json:"condition"`
+	Message   string    `json:"message,omitempty"`
+	Time      int64     `json:"time"` // 조건을 만족한 캔들 시작 시각
+	Close     float64   `json:"close"`
+	FiredAt   time.Time `json:"firedAt"`
+}
+
+// Manager 알림 규칙 저장 및 평가
+type Manager struct {
+	mu    sync.Mutex
+	rules map[string]*Rule
+}
+
+var (
+	manager     *Manager
+	managerOnce sync.Once
+)
+
+// GetManager 싱글톤 알림 관리자 (최초 호출 시 Redis에서 규칙 로드)
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		manager = &Manager{rules: make(map[string]*Rule)}
+		manager.load()
+	})
+	return manager
+}
+
+// Create 조건식을 검사한 뒤 규칙 추가
+func (m *Manager) Create(rule Rule) (Rule, error) {
+	rule.Symbol = strings.ToUpper(strings.TrimSpace(rule.Symbol))
+	if rule.Symbol == "" {
+		return Rule{}, fmt.Errorf("symbol is required")
+	}
+	if rule.Interval == "" {
+		rule.Interval = "1h"
+	}
+	step, err := market.IntervalDuration(rule.Interval)
+	if err != nil {
+		return Rule{}, err
+	}
+	if step < time.Minute {
+		return Rule{}, fmt.Errorf("interval %s is shorter than the 1m stream candles", rule.Interval)
+	}
+	if !streamed(rule.Symbol) {
+		return Rule{}, fmt.Errorf("symbol %s is not in the streamed watchlist", rule.Symbol)
+	}
+	if rule.Cooldown < 0 {
+		return Rule{}, fmt.Errorf("cooldown must not be negative")
+	}
+	if err := validateCondition(rule.Condition); err != nil {
+		return Rule{}, err
+	}
+
+	rule.ID = uuid.New().String()
+	rule.Active = true
+	rule.CreatedAt = time.Now()
+	rule.LastFired, rule.LastBar = nil, 0
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules[rule.ID] = &rule
+	m.persist()
+	return rule, nil
+}
+
+// List 생성 순 규칙 목록 (symbol이 비어 있으면 전체)
+func (m *Manager) List(symbol string) []Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := make([]Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		if symbol == "" || rule.Symbol == symbol {
+			rules = append(rules, *rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+	return rules
+}
+
+// Delete 규칙 삭제
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.rules[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, id)
+	}
+	delete(m.rules, id)
+	m.persist()
+	return nil
+}
+
+// OnClose 스트림의 symbol/interval 캔들(openTime에 시작)이 마감되면, 이 마감으로
+// 캔들이 끝나는 인터벌의 규칙을 평가하고 발생한 알림을 반환 (Redis 채널에도 발행)
+func (m *Manager) OnClose(symbol, interval string, openTime int64) []Event {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil
+	}
+	closeTime := openTime + step.Milliseconds()
+
+	m.mu.Lock()
+	due := make(map[string][]Rule)
+	for _, rule := range m.rules {
+		if !rule.Active || rule.Symbol != symbol {
+			continue
+		}
+		ruleOpen, ok := closedCandle(rule.Interval, closeTime, step)
+		if ok && rule.LastBar < ruleOpen {
+			due[rule.Interval] = append(due[rule.Interval], *rule)
+		}
+	}
+	m.mu.Unlock()
+
+	var events []Event
+	for ruleInterval, rules := range due {
+		ruleOpen, _ := closedCandle(ruleInterval, closeTime, step)
+		events = append(events, m.evaluate(symbol, ruleInterval, ruleOpen, rules)...)
+	}
+
+	if len(events) > 0 {
+		if redis := database.GetRedis(); redis != nil {
+			for _, event := range events {
+				if err := redis.Publish(Channel, event); err != nil {
+					logrus.Warnf("Failed to publish alert %s: %v", event.RuleID, err)
+				}
+			}
+		}
+	}
+	return events
+}
+
+// closedCandle closeTime에 마감되는 interval 캔들의 시작 시각
+// (스트림 캔들보다 짧거나 closeTime이 경계가 아니면 false)
+func closedCandle(interval string, closeTime int64, streamStep time.Duration) (int64, bool) {
+	step, err := market.IntervalDuration(interval)
+	if err != nil || step < streamStep {
+		return 0, false
+	}
+	origin := int64(0)
+	if strings.HasSuffix(interval, "w") {
+		origin = weekOrigin
+	}
+	if (closeTime-origin)%step.Milliseconds() != 0 {
+		return 0, false
+	}
+	return closeTime - step.Milliseconds(), true
+}
+
+// streamed 실시간 스트림이 구독하는 관심 종목인지 여부
+func streamed(symbol string) bool {
+	for _, s := range market.GetRegistry().Watchlist() {
+		if strings.EqualFold(s, symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate openTime에 시작한 symbol/interval 캔들로 규칙 평가
+func (m *Manager) evaluate(symbol, interval string, openTime int64, due []Rule) []Event {
+	candles, err := features.GetStore().Candles(symbol, interval)
+	if err != nil {
+		logrus.Warnf("Alert evaluation for %s %s skipped: %v", symbol, interval, err)
+		return nil
+	}
+	last := candles.Len() - 1
+	if candles.Time[last] != openTime {
+		// REST 이력에 방금 마감된 캔들이 아직 없음
+		logrus.Warnf("Alert evaluation for %s %s skipped: latest closed candle not available yet", symbol, interval)
+		return nil
+	}
+
+	step, _ := market.IntervalDuration(interval)
+	var events []Event
+	for _, rule := range due {
+		program, err := expr.GetLibrary().Compile(rule.Condition)
+		if err != nil || program.Type != expr.TypeBool {
+			// 참조하던 정의가 바뀌어 더 이상 조건식이 아님
+			logrus.Warnf("Alert %s condition no longer valid: %v", rule.ID, err)
+			continue
+		}
+		values := program.Eval(candles)
+		if values[last] != 1 || (last > 0 && values[last-1] == 1) {
+			continue
+		}
+		if rule.LastBar > 0 && openTime-rule.LastBar < int64(rule.Cooldown)*step.Milliseconds() {
+			continue
+		}
+
+		event := Event{
+			RuleID:    rule.ID,
+			Name:      rule.Name,
+			Symbol:    symbol,
+			Interval:  interval,
+			Condition: rule.Condition,
+			Message:   rule.Message,
+			Time:      openTime,
+			Close:     candles.Close[last],
+			FiredAt:   time.Now(),
+		}
+		if m.markFired(rule.ID, event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// markFired 발생 기록 (그사이 삭제되었거나 이미 이 캔들에서 발생했으면 false)
+func (m *Manager) markFired(id string, event Event) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rule, ok := m.rules[id]
+	if !ok || !rule.Active || rule.LastBar >= event.Time {
+		return false
+	}
+	firedAt := event.FiredAt
+	rule.LastFired, rule.LastBar = &firedAt, event.Time
+	if rule.Once {
+		rule.Active = false
+	}
+	m.persist()
+	return true
+}
+
+// validateCondition 조건식이 참/거짓 시계열인지 검사
+func validateCondition(condition string) error {
+	program, err := expr.GetLibrary().Compile(condition)
+	if err != nil {
+		return err
+	}
+	if program.Type != expr.TypeBool {
+		return fmt.Errorf("condition must be a comparison or crossover, got %s", program.Type)
+	}
+	return nil
+}
+
+// persist Redis에 전체 규칙 저장 (호출자가 잠금 보유)
+func (m *Manager) persist() {
+	redis := database.GetRedis()
+	if redis == nil {
+		return
+	}
+	rules := make([]*Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	if err := redis.Set(rulesKey, rules, 0); err != nil {
+		logrus.Warnf("Failed to persist alert rules: %v", err)
+	}
+}
+
+// load Redis에서 규칙 로드
+func (m *Manager) load() {
+	redis := database.GetRedis()
+	if redis == nil {
+		return
+	}
+	var rules []*Rule
+	if err := redis.Get(rulesKey, &rules); err != nil {
+		return
+	}
+	for _, rule := range rules {
+		m.rules[rule.ID] = rule
+	}
+	logrus.Infof("Loaded %d alert rules", len(m.rules))
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/loadstar0723/monstas7-backend/internal/ai"
 	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +23,61 @@ type PredictionRequest struct {
 	Timeframe  string                 `json:"timeframe"`
 	Features   map[string]interface{} `json:"features"`
 	Historical []float64              `json:"historical"`
+	Candles    []indicators.Bar       `json:"candles"`
+	ModelID    string                 `json:"modelId"` // saved model version (XGBoost only)
+}
+
+// requestCandles returns the OHLCV candles for indicator features: candles
+// sent by the client, else close-only candles built from the client's
+// historical prices, else the feature store's closed candles for the request
+// timeframe. Client data always wins over the store.
+func requestCandles(req *PredictionRequest) indicators.OHLCV {
+	if len(req.Candles) > 0 {
+		return indicators.FromBars(req.Candles)
+	}
+	if len(req.Historical) > 0 {
+		return indicators.FromCloses(req.Historical)
+	}
+
+	candles, err := features.GetStore().Candles(req.Symbol, requestInterval(req))
+	if err != nil {
+		logrus.Debugf("No candles for %s: %v", req.Symbol, err)
+		return indicators.OHLCV{}
+	}
+	return candles
+}
+
+// addMarketFeatures merges the feature store's default sets (technical,
+// volume, order book, futures) for the request symbol/timeframe into the
+// request features, keyed as "<set>.<feature>". Values sent by the client take
+// precedence; sets that cannot be computed are skipped.
+func addMarketFeatures(req *PredictionRequest) {
+	vector, err := features.GetStore().Vector(req.Symbol, requestInterval(req), features.DefaultSets...)
+	if err != nil {
+		logrus.Debugf("No store features for %s: %v", req.Symbol, err)
+		return
+	}
+	mergeFeatures(req, vector.Map())
+}
+
+// requestInterval returns the candle interval for the request (1h by default)
+func requestInterval(req *PredictionRequest) string {
+	if req.Timeframe == "" {
+		return "1h"
+	}
+	return req.Timeframe
+}
+
+// mergeFeatures adds features without overwriting client-supplied keys
+func mergeFeatures(req *PredictionRequest, values map[string]interface{}) {
+	if req.Features == nil {
+		req.Features = make(map[string]interface{}, len(values))
+	}
+	for name, value := range values {
+		if _, exists := req.Features[name]; !exists {
+			req.Features[name] = value
+		}
+	}
 }
 
 // PredictionResponse represents AI prediction response
@@ -30,266 +92,208 @@ type PredictionResponse struct {
 	Metadata    map[string]interface{} `json:"metadata"`
 }
 
-// NeuralPredict handles neural network prediction
-func NeuralPredict(c *gin.Context) {
+// predictTimeout bounds a single model prediction
+const predictTimeout = 15 * time.Second
+
+// predictionInput builds the model input for a request: candles from
+// requestCandles, features merged with the feature store. The input is live
+// only when the client sent no candles, prices or features of its own.
+func predictionInput(req *PredictionRequest) ai.Input {
+	serverSourced := len(req.Candles) == 0 && len(req.Historical) == 0 && len(req.Features) == 0
+	addMarketFeatures(req)
+	return ai.Input{
+		Symbol:   req.Symbol,
+		Interval: requestInterval(req),
+		Candles:  requestCandles(req),
+		Features: req.Features,
+		Live:     serverSourced,
+	}
+}
+
+// bindPredictionRequest parses and validates a prediction request, writing the
+// error response when it is invalid
+func bindPredictionRequest(c *gin.Context) (*PredictionRequest, bool) {
 	var req PredictionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return nil, false
 	}
-
-	// Get market data from Redis cache
-	redis := database.GetRedis()
-	var marketData interface{}
-	if redis != nil {
-		redis.GetMarketData(req.Symbol, &marketData)
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return nil, false
 	}
+	req.Symbol = symbol
+	return &req, true
+}
 
-	// Process with neural network
-	predictor := ai.GetNeuralPredictor()
-	result := predictor.Predict(req.Symbol, req.Historical, req.Features)
+// predict runs a registered model through the AI manager, writing the error
+// response when it fails
+func predict(c *gin.Context, model string, in ai.Input) (*ai.Prediction, bool) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), predictTimeout)
+	defer cancel()
 
-	response := PredictionResponse{
-		Model:      "neural",
-		Symbol:     req.Symbol,
-		Prediction: result.Price,
-		Confidence: result.Confidence,
-		Direction:  result.Direction,
-		Signal:     result.Signal,
-		Timestamp:  time.Now(),
-		Metadata: map[string]interface{}{
-			"layers":     predictor.Config.Layers,
-			"accuracy":   result.Accuracy,
-			"volatility": result.Volatility,
-		},
+	prediction, err := ai.GetManager().Predict(ctx, model, in)
+	if err != nil {
+		c.JSON(predictionErrorStatus(err), gin.H{"error": err.Error(), "model": model})
+		return nil, false
 	}
+	return prediction, true
+}
 
-	// Cache the prediction
-	if redis != nil {
-		redis.CachePrediction("neural", req.Symbol, response)
+// predictionErrorStatus maps a prediction error to its HTTP status
+func predictionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ai.ErrUnknownModel):
+		return http.StatusNotFound
+	case errors.Is(err, ai.ErrInsufficientData), errors.Is(err, ai.ErrInvalidInput):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ai.ErrModelUnavailable), errors.Is(err, ai.ErrModelNotTrained):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
 	}
+}
 
-	// Save to Supabase
-	supabase := database.GetSupabaseClient()
-	if supabase != nil {
-		prediction := &database.SupabasePrediction{
-			Symbol:     req.Symbol,
-			Model:      "neural",
-			Prediction: result.Price,
-			Confidence: result.Confidence,
-			Direction:  result.Direction,
-			Timeframe:  req.Timeframe,
-			Signal:     result.Signal,
-		}
-		if err := supabase.SavePrediction(prediction); err != nil {
-			logrus.Warnf("Failed to save prediction to Supabase: %v", err)
-		} else {
-			logrus.Infof("Prediction saved to Supabase for %s", req.Symbol)
-		}
+// toPredictionResponse converts a model prediction to the legacy response shape
+func toPredictionResponse(model string, p *ai.Prediction, interval string) PredictionResponse {
+	metadata := make(map[string]interface{}, len(p.Factors)+2)
+	for name, value := range p.Factors {
+		metadata[name] = value
+	}
+	if status, err := ai.GetManager().Status(model); err == nil {
+		metadata["version"] = status.Version
+	}
+	metadata["risk_level"] = p.RiskLevel
+	return PredictionResponse{
+		Model:      model,
+		Symbol:     p.Symbol,
+		Prediction: p.PriceAt(interval),
+		Confidence: p.Confidence,
+		Direction:  p.Direction,
+		Signal:     p.Recommendation,
+		Timestamp:  time.Unix(p.Timestamp, 0),
+		Metadata:   metadata,
 	}
-
-
-	c.JSON(http.StatusOK, response)
-	logrus.Infof("Neural prediction for %s: %.2f (confidence: %.2f%%)",
-		req.Symbol, result.Price, result.Confidence)
 }
 
-// LightGBMPredict handles LightGBM prediction
-func LightGBMPredict(c *gin.Context) {
-	var req PredictionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// savePrediction stores a prediction in Supabase
+func savePrediction(model string, req *PredictionRequest, p *ai.Prediction) {
+	supabase := database.GetSupabaseClient()
+	if supabase == nil {
 		return
 	}
-
-	// Get cached data
-	redis := database.GetRedis()
-	var cachedPrediction PredictionResponse
-	if redis != nil {
-		err := redis.GetPrediction("lightgbm", req.Symbol, &cachedPrediction)
-		if err == nil {
-			c.JSON(http.StatusOK, cachedPrediction)
-			return
-		}
+	prediction := &database.SupabasePrediction{
+		Symbol:     req.Symbol,
+		Model:      model,
+		Prediction: p.PriceAt(requestInterval(req)),
+		Confidence: p.Confidence,
+		Direction:  p.Direction,
+		Timeframe:  req.Timeframe,
+		Signal:     p.Recommendation,
+	}
+	if err := supabase.SavePrediction(prediction); err != nil {
+		logrus.Warnf("Failed to save %s prediction to Supabase: %v", model, err)
 	}
+}
 
-	// Process with LightGBM
-	predictor := ai.GetLightGBMPredictor()
-	result := predictor.Predict(req.Symbol, req.Historical, req.Features)
+// PredictModel handles /ai/:model/predict for any registered model
+func PredictModel(c *gin.Context) {
+	modelPredict(c, c.Param("model"))
+}
 
-	response := PredictionResponse{
-		Model:      "lightgbm",
-		Symbol:     req.Symbol,
-		Prediction: result.Price,
-		Confidence: result.Confidence,
-		Direction:  result.Direction,
-		Signal:     result.Signal,
-		Timestamp:  time.Now(),
-		Metadata: map[string]interface{}{
-			"trees":           predictor.Config.NumTrees,
-			"feature_importance": result.FeatureImportance,
-			"shap_values":     result.ShapValues,
-		},
+// modelPredict runs a model and responds with the full prediction
+func modelPredict(c *gin.Context, model string) {
+	req, ok := bindPredictionRequest(c)
+	if !ok {
+		return
 	}
+	respondModelPrediction(c, model, req)
+}
 
-	// Cache and store
-	if redis != nil {
-		redis.CachePrediction("lightgbm", req.Symbol, response)
+// respondModelPrediction runs a model for a bound request and responds with
+// the full prediction
+func respondModelPrediction(c *gin.Context, model string, req *PredictionRequest) {
+	prediction, ok := predict(c, model, predictionInput(req))
+	if !ok {
+		return
 	}
 
-	// Save to Supabase
-	supabase := database.GetSupabaseClient()
-	if supabase != nil {
-		prediction := &database.SupabasePrediction{
-			Symbol:     req.Symbol,
-			Model:      "lightgbm",
-			Prediction: result.Price,
-			Confidence: result.Confidence,
-			Direction:  result.Direction,
-			Timeframe:  req.Timeframe,
-			Signal:     result.Signal,
-		}
-		if err := supabase.SavePrediction(prediction); err != nil {
-			logrus.Warnf("Failed to save LightGBM prediction to Supabase: %v", err)
-		}
+	if redis := database.GetRedis(); redis != nil {
+		redis.CachePrediction(model, req.Symbol, prediction)
 	}
+	savePrediction(model, req, prediction)
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, prediction)
 }
 
-// RandomForestPredict handles Random Forest prediction
-func RandomForestPredict(c *gin.Context) {
-	var req PredictionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// legacyPredict runs a model and responds in the PredictionResponse shape
+func legacyPredict(c *gin.Context, model string) {
+	req, ok := bindPredictionRequest(c)
+	if !ok {
 		return
 	}
-
-	// Process with Random Forest
-	predictor := ai.GetRandomForestPredictor()
-	result := predictor.Predict(req.Symbol, req.Historical, req.Features)
-
-	response := PredictionResponse{
-		Model:      "randomforest",
-		Symbol:     req.Symbol,
-		Prediction: result.Price,
-		Confidence: result.Confidence,
-		Direction:  result.Direction,
-		Signal:     result.Signal,
-		Timestamp:  time.Now(),
-		Metadata: map[string]interface{}{
-			"n_estimators": predictor.Config.NEstimators,
-			"oob_score":    result.OOBScore,
-			"predictions":  result.TreePredictions,
-		},
-	}
-
-	// Cache the result
-	redis := database.GetRedis()
-	if redis != nil {
-		redis.CachePrediction("randomforest", req.Symbol, response)
+	prediction, ok := predict(c, model, predictionInput(req))
+	if !ok {
+		return
 	}
+	response := toPredictionResponse(model, prediction, requestInterval(req))
 
-	// Save to Supabase
-	supabase := database.GetSupabaseClient()
-	if supabase != nil {
-		prediction := &database.SupabasePrediction{
-			Symbol:     req.Symbol,
-			Model:      "randomforest",
-			Prediction: result.Price,
-			Confidence: result.Confidence,
-			Direction:  result.Direction,
-			Timeframe:  req.Timeframe,
-			Signal:     result.Signal,
-		}
-		if err := supabase.SavePrediction(prediction); err != nil {
-			logrus.Warnf("Failed to save RandomForest prediction to Supabase: %v", err)
-		}
+	if redis := database.GetRedis(); redis != nil {
+		redis.CachePrediction(model, req.Symbol, response)
 	}
+	savePrediction(model, req, prediction)
 
 	c.JSON(http.StatusOK, response)
+	logrus.Infof("%s prediction for %s: %.2f (confidence: %.2f%%)",
+		model, req.Symbol, response.Prediction, response.Confidence)
 }
 
-// EnsemblePredict combines multiple model predictions
-func EnsemblePredict(c *gin.Context) {
-	var req PredictionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Get predictions from all models
-	neural := ai.GetNeuralPredictor().Predict(req.Symbol, req.Historical, req.Features)
-	lightgbm := ai.GetLightGBMPredictor().Predict(req.Symbol, req.Historical, req.Features)
-	rf := ai.GetRandomForestPredictor().Predict(req.Symbol, req.Historical, req.Features)
-
-	// Weighted ensemble
-	weights := map[string]float64{
-		"neural":       0.35,
-		"lightgbm":     0.40,
-		"randomforest": 0.25,
-	}
+// NeuralPredict handles neural network prediction
+func NeuralPredict(c *gin.Context) {
+	legacyPredict(c, "neural")
+}
 
-	ensemblePrediction := neural.Price*weights["neural"] +
-		lightgbm.Price*weights["lightgbm"] +
-		rf.Price*weights["randomforest"]
+// LightGBMPredict handles LightGBM prediction
+func LightGBMPredict(c *gin.Context) {
+	legacyPredict(c, "lightgbm")
+}
 
-	ensembleConfidence := neural.Confidence*weights["neural"] +
-		lightgbm.Confidence*weights["lightgbm"] +
-		rf.Confidence*weights["randomforest"]
+// RandomForestPredict handles Random Forest prediction
+func RandomForestPredict(c *gin.Context) {
+	legacyPredict(c, "randomforest")
+}
 
-	// Determine consensus direction
-	directions := map[string]int{}
-	directions[neural.Direction]++
-	directions[lightgbm.Direction]++
-	directions[rf.Direction]++
+// EnsemblePredict combines multiple model predictions
+func EnsemblePredict(c *gin.Context) {
+	legacyPredict(c, "ensemble")
+}
 
-	consensusDirection := "NEUTRAL"
-	maxVotes := 0
-	for dir, votes := range directions {
-		if votes > maxVotes {
-			consensusDirection = dir
-			maxVotes = votes
-		}
-	}
+// GetEnsembleConfig returns the ensemble members, weighting and the recent
+// forecast errors per symbol/interval
+func GetEnsembleConfig(c *gin.Context) {
+	ensemble := ai.GetEnsemblePredictor()
+	c.JSON(http.StatusOK, gin.H{
+		"config": ensemble.Config(),
+		"tracks": ensemble.Tracks(),
+	})
+}
 
-	response := PredictionResponse{
-		Model:      "ensemble",
-		Symbol:     req.Symbol,
-		Prediction: ensemblePrediction,
-		Confidence: ensembleConfidence,
-		Direction:  consensusDirection,
-		Signal:     determineSignal(consensusDirection, ensembleConfidence),
-		Timestamp:  time.Now(),
-		Metadata: map[string]interface{}{
-			"weights": weights,
-			"models": map[string]interface{}{
-				"neural":       neural,
-				"lightgbm":     lightgbm,
-				"randomforest": rf,
-			},
-			"consensus": maxVotes,
-		},
+// UpdateEnsembleConfig replaces the ensemble members and weighting
+func UpdateEnsembleConfig(c *gin.Context) {
+	var cfg ai.EnsembleConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Save ensemble prediction to Supabase
-	supabase := database.GetSupabaseClient()
-	if supabase != nil {
-		prediction := &database.SupabasePrediction{
-			Symbol:     req.Symbol,
-			Model:      "ensemble",
-			Prediction: ensemblePrediction,
-			Confidence: ensembleConfidence,
-			Direction:  consensusDirection,
-			Timeframe:  req.Timeframe,
-			Signal:     determineSignal(consensusDirection, ensembleConfidence),
-		}
-		if err := supabase.SavePrediction(prediction); err != nil {
-			logrus.Warnf("Failed to save Ensemble prediction to Supabase: %v", err)
-		}
+	ensemble := ai.GetEnsemblePredictor()
+	if err := ensemble.Configure(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{"config": ensemble.Config()})
 }
 
 // PatternRecognition identifies trading patterns
@@ -304,6 +308,11 @@ func PatternRecognition(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	req.Symbol = symbol
 
 	patterns := ai.GetPatternRecognizer().Recognize(req.Symbol, req.Candles)
 
@@ -361,9 +370,25 @@ func StrategyGenerate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	req.Symbol = symbol
+
+	if req.Parameters == nil {
+		req.Parameters = make(map[string]interface{})
+	}
+	if _, ok := req.Parameters["interval"]; !ok && req.Timeframe != "" {
+		req.Parameters["interval"] = req.Timeframe
+	}
 
 	builder := ai.GetStrategyBuilder()
-	strategy := builder.Build(req.Symbol, req.Parameters)
+	strategy, err := builder.Build(req.Symbol, req.Parameters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	response := gin.H{
 		"strategy": strategy,
@@ -379,191 +404,382 @@ func StrategyGenerate(c *gin.Context) {
 
 // LSTMPredict handles LSTM prediction requests
 func LSTMPredict(c *gin.Context) {
-	var req PredictionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	modelPredict(c, "lstm")
+}
+
+// GRUPredict handles GRU prediction requests
+func GRUPredict(c *gin.Context) {
+	modelPredict(c, "gru")
+}
+
+// XGBoostPredict handles XGBoost prediction requests. With modelId set it
+// predicts with that saved version (as returned by /ai/xgboost/train)
+// instead of the active one.
+func XGBoostPredict(c *gin.Context) {
+	req, ok := bindPredictionRequest(c)
+	if !ok {
+		return
+	}
+	if req.ModelID == "" {
+		respondModelPrediction(c, "xgboost", req)
 		return
 	}
 
-	// Process with LSTM
-	predictor := ai.GetLSTMPredictor()
-	result := predictor.Predict(req.Symbol, req.Historical, req.Features)
+	model, err := ai.LoadXGBoostVersion(req.ModelID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ai.ErrNoSavedModel):
+			status = http.StatusNotFound
+		case errors.Is(err, ai.ErrInvalidInput), errors.Is(err, ai.ErrModelNotTrained):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "modelId": req.ModelID})
+		return
+	}
 
-	c.JSON(http.StatusOK, result)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), predictTimeout)
+	defer cancel()
+	prediction, err := model.Predict(ctx, predictionInput(req))
+	if err != nil {
+		c.JSON(predictionErrorStatus(err), gin.H{"error": err.Error(), "modelId": req.ModelID})
+		return
+	}
+	c.JSON(http.StatusOK, prediction)
 }
 
-// GRUPredict handles GRU prediction requests
-func GRUPredict(c *gin.Context) {
-	var req PredictionRequest
+// ARIMAPredict handles ARIMA prediction requests
+func ARIMAPredict(c *gin.Context) {
+	modelPredict(c, "arima")
+}
+
+// GetAllModelStatus returns the status of all AI models
+func GetAllModelStatus(c *gin.Context) {
+	status := ai.GetManager().GetAllModelStatus()
+	active := 0
+	for _, s := range status {
+		if s.State == ai.StateLoaded {
+			active++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"models": status,
+		"total":  len(status),
+		"active": active,
+	})
+}
+
+// ListModelVersions returns the saved versions of a model
+func ListModelVersions(c *gin.Context) {
+	model := c.Param("model")
+	if _, err := ai.GetManager().Get(model); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	store := ai.GetModelStore()
+	versions, err := store.Versions(model)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	active, _ := store.Active(model)
+	c.JSON(http.StatusOK, gin.H{
+		"model":    model,
+		"active":   active,
+		"versions": versions,
+	})
+}
+
+// ActivateModelVersion loads a saved version into a model and makes it the
+// version loaded at startup
+func ActivateModelVersion(c *gin.Context) {
+	var req struct {
+		Version string `json:"version" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Process with GRU
-	predictor := ai.GetGRUPredictor()
-	result := predictor.Predict(req.Symbol, req.Historical, req.Features)
+	model := c.Param("model")
+	if err := ai.GetModelStore().Activate(model, req.Version); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ai.ErrUnknownModel), errors.Is(err, ai.ErrNoSavedModel):
+			status = http.StatusNotFound
+		case errors.Is(err, ai.ErrNotPersistent), errors.Is(err, ai.ErrInvalidInput), errors.Is(err, ai.ErrModelNotTrained):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, result)
+	modelStatus, _ := ai.GetManager().Status(model)
+	c.JSON(http.StatusOK, gin.H{"model": model, "status": modelStatus})
 }
 
-// XGBoostPredict handles XGBoost prediction requests
-func XGBoostPredict(c *gin.Context) {
-	var req PredictionRequest
+// ImportModelRequest is the body of /ai/models/:model/import
+type ImportModelRequest struct {
+	Symbol    string `json:"symbol" binding:"required"`
+	Timeframe string `json:"timeframe"`
+	// Model is the exported model: an XGBoost save_model JSON object or
+	// dump_model JSON array, or a string holding a LightGBM text model (or
+	// either XGBoost file). For LSTM and GRU it is the recurrent export
+	// object, see ai.RecurrentExport.
+	Model      json.RawMessage   `json:"model" binding:"required"`
+	NPZ        []byte            `json:"npz"`         // base64 numpy .npz with the LSTM/GRU tensors
+	FeatureMap map[string]string `json:"feature_map"` // model feature -> Go feature
+	Positional bool              `json:"positional"`
+	BaseScore  *float64          `json:"base_score"` // XGBoost dumps only
+	Tolerance  float64           `json:"tolerance"`  // LSTM/GRU parity check
+	Activate   bool              `json:"activate"`
+	// TrainedUntil is the open time (ms) of the last training candle; the
+	// import is scored only on later candles. Without it the scores are
+	// marked in_sample.
+	TrainedUntil int64 `json:"trained_until"`
+}
+
+// Import limits: the request body and the candles the import is scored on
+const (
+	maxImportBytes    = 64 << 20
+	importEvalCandles = 1000
+)
+
+// ImportModel converts an XGBoost, LightGBM, LSTM or GRU model trained
+// outside the server into a saved model version, scored on recent candles of
+// the request symbol after its training cutoff. LSTM and GRU exports are first checked against the
+// reference outputs they ship with. With activate set the version also
+// becomes the active one.
+func ImportModel(c *gin.Context) {
+	model := c.Param("model")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	var req ImportModelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	interval := req.Timeframe
+	if interval == "" {
+		interval = "1h"
+	}
+	if _, err := market.IntervalDuration(interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Process with XGBoost
-	model := ai.GetXGBoostPredictor()
-
-	// Convert features to []float64 for XGBoost
-	featureValues := make([]float64, 0)
-	if len(req.Historical) >= 10 {
-		featureValues = req.Historical[len(req.Historical)-10:]
+	candles, err := ai.TrainingCandles(symbol, interval, importEvalCandles)
+	if err != nil {
+		c.JSON(trainingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	var (
+		imported ai.Persistent
+		report   interface{}
+		metrics  map[string]float64
+	)
+	switch model {
+	case "xgboost", "lightgbm":
+		data := []byte(req.Model)
+		var text string
+		if err := json.Unmarshal(req.Model, &text); err == nil {
+			data = []byte(text)
+		}
+		opts := ai.TreeImportOptions{
+			Symbol:       symbol,
+			Interval:     interval,
+			FeatureMap:   req.FeatureMap,
+			Positional:   req.Positional,
+			BaseScore:    req.BaseScore,
+			Candles:      candles,
+			TrainedUntil: req.TrainedUntil,
+		}
+		var r *ai.TreeImportReport
+		if model == "xgboost" {
+			imported, r, err = ai.ImportXGBoost(data, opts)
+		} else {
+			imported, r, err = ai.ImportLightGBM(data, opts)
+		}
+		if err == nil {
+			report, metrics = r, r.Metrics
+		}
+	case "lstm", "gru":
+		var r *ai.RecurrentImportReport
+		imported, r, err = importRecurrent(model, req.Model, req.NPZ, ai.RecurrentImportOptions{
+			Symbol:       symbol,
+			Interval:     interval,
+			Candles:      candles,
+			Tolerance:    req.Tolerance,
+			TrainedUntil: req.TrainedUntil,
+		})
+		if r != nil {
+			report, metrics = r, r.Metrics
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only xgboost, lightgbm, lstm and gru models can be imported"})
+		return
+	}
+	if err != nil {
+		c.JSON(importErrorStatus(err), gin.H{"error": err.Error(), "import": report})
+		return
 	}
 
-	result, _ := model.Predict(featureValues, featureValues)
+	store := ai.GetModelStore()
+	version, err := store.Save(imported, metrics)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save model: " + err.Error()})
+		return
+	}
+	if req.Activate {
+		if err := store.Activate(model, version.Version); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "import": report})
+			return
+		}
+		version.Active = true
+	}
+	logrus.Infof("Imported %s model as version %s", model, version.Version)
+	c.JSON(http.StatusCreated, gin.H{"import": report, "version": version})
+}
 
-	// Convert XGBoost prediction to standard Prediction format
-	// Handle empty historical data and nil result
-	currentPrice := 0.0
-	if len(req.Historical) > 0 {
-		currentPrice = req.Historical[len(req.Historical)-1]
-	} else if result != nil {
-		currentPrice = result.CurrentPrice
-	} else {
-		// Default price if no data available
-		currentPrice = 100000.0 // Default BTC price
-	}
-
-	// Set default predicted price
-	predictedPrice := currentPrice * 1.02
-	confidence := 75.0
-
-	// Use actual result if available
-	if result != nil && len(result.Predictions) > 0 {
-		if h1, ok := result.Predictions["1h"]; ok {
-			predictedPrice = h1.Price
-			confidence = h1.Confidence
+// importRecurrent converts a Keras or PyTorch LSTM/GRU export. The returned
+// report is set when only the parity check failed.
+func importRecurrent(model string, export json.RawMessage, npz []byte, opts ai.RecurrentImportOptions) (ai.Persistent, *ai.RecurrentImportReport, error) {
+	e, err := ai.ParseRecurrentExport(export, npz)
+	if err != nil {
+		return nil, nil, err
+	}
+	if model == "lstm" {
+		p, report, err := ai.ImportLSTM(e, opts)
+		if err != nil {
+			return nil, report, err
 		}
+		return p, report, nil
 	}
+	p, report, err := ai.ImportGRU(e, opts)
+	if err != nil {
+		return nil, report, err
+	}
+	return p, report, nil
+}
 
-	prediction := &ai.Prediction{
-		Model:        "XGBoost",
-		Symbol:       req.Symbol,
-		CurrentPrice: currentPrice,
-		Predictions: map[string]ai.PricePoint{
-			"1h": {
-				Price:      predictedPrice,
-				Confidence: confidence,
-				Timestamp:  time.Now().Add(1 * time.Hour).Unix(),
-			},
-		},
-		Confidence:     75.0,
-		Recommendation: "HOLD",
-		RiskLevel:      "MEDIUM",
-		Timestamp:      time.Now().Unix(),
+// importErrorStatus maps an import error to its HTTP status
+func importErrorStatus(err error) int {
+	if errors.Is(err, ai.ErrParityMismatch) {
+		return http.StatusUnprocessableEntity
 	}
+	return trainingErrorStatus(err)
+}
 
-	c.JSON(http.StatusOK, prediction)
+// ParityCheckRequest is the body of /ai/models/:model/parity
+type ParityCheckRequest struct {
+	Model     json.RawMessage `json:"model" binding:"required"` // recurrent export, see ImportModelRequest
+	NPZ       []byte          `json:"npz"`
+	Tolerance float64         `json:"tolerance"`
 }
 
-// ARIMAPredict handles ARIMA prediction requests
-func ARIMAPredict(c *gin.Context) {
-	var req PredictionRequest
+// ParityCheck converts an LSTM or GRU export without saving it and compares
+// the Go network's outputs with the reference outputs shipped with the
+// export
+func ParityCheck(c *gin.Context) {
+	model := c.Param("model")
+	if model != "lstm" && model != "gru" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parity checks are available for lstm and gru exports"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	var req ParityCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	_, report, err := importRecurrent(model, req.Model, req.NPZ, ai.RecurrentImportOptions{Tolerance: req.Tolerance})
+	switch {
+	case err != nil && report == nil:
+		c.JSON(importErrorStatus(err), gin.H{"error": err.Error()})
+	case report.Parity == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "export has no reference inputs and outputs"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"model": model, "framework": report.Framework, "parity": report.Parity})
+	}
+}
 
-	// Process with ARIMA
-	predictor := ai.GetARIMAPredictor()
-	result, _ := predictor.Predict(req.Historical, req.Historical)
-
-	c.JSON(http.StatusOK, result)
+// TrainModelRequest is the body of /ai/:model/train
+type TrainModelRequest struct {
+	Symbol    string         `json:"symbol" binding:"required"`
+	Timeframe string         `json:"timeframe"`
+	Candles   int            `json:"candles"` // closed candles to train on
+	Config    ai.TrainConfig `json:"config"`
 }
 
-// GetAllModelStatus returns the status of all AI models
-func GetAllModelStatus(c *gin.Context) {
-	lstm := ai.GetLSTMPredictor()
-	gru := ai.GetGRUPredictor()
-
-	status := map[string]interface{}{
-		"lstm": map[string]interface{}{
-			"loaded": lstm.IsLoaded,
-			"version": "1.0.0",
-			"accuracy": 0.82,
-		},
-		"gru": map[string]interface{}{
-			"loaded": gru.IsLoaded,
-			"version": "1.0.0",
-			"accuracy": 0.84,
-		},
-		"xgboost": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.86,
-		},
-		"arima": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.78,
-		},
-		"neural": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.80,
-		},
-		"lightgbm": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.85,
-		},
-		"random_forest": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.83,
-		},
-		"ensemble": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.88,
-		},
-		"pattern_recognition": map[string]interface{}{
-			"loaded": true,
-			"version": "1.0.0",
-			"accuracy": 0.91,
-		},
+// defaultTrainingCandles is the history trained on unless the request sets it
+const defaultTrainingCandles = 2000
+
+// TrainModel starts training a model in the background on recent candles;
+// progress and the training report are served by GetTrainingJob
+func TrainModel(c *gin.Context) {
+	var req TrainModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	interval := req.Timeframe
+	if interval == "" {
+		interval = "1h"
+	}
+	if _, err := market.IntervalDuration(interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Candles == 0 {
+		req.Candles = defaultTrainingCandles
+	}
+	if req.Candles < 1 || req.Candles > ai.MaxTrainingCandles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "candles must be between 1 and " + strconv.Itoa(ai.MaxTrainingCandles)})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"models": status,
-		"total": 9,
-		"active": 9,
+	job, err := ai.GetManager().StartTraining(c.Param("model"), ai.TrainingRequest{
+		Symbol:   symbol,
+		Interval: interval,
+		Candles:  req.Candles,
+		Config:   req.Config,
 	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ai.ErrUnknownModel):
+			status = http.StatusNotFound
+		case errors.Is(err, ai.ErrNotTrainable):
+			status = http.StatusBadRequest
+		case errors.Is(err, ai.ErrTrainingInProgress):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
 }
 
-// Helper functions
-func determineSignal(direction string, confidence float64) string {
-	if confidence < 60 {
-		return "HOLD"
+// GetTrainingJob returns the last training job of a model
+func GetTrainingJob(c *gin.Context) {
+	model := c.Param("model")
+	if _, err := ai.GetManager().Get(model); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
-
-	switch direction {
-	case "UP":
-		if confidence > 80 {
-			return "STRONG_BUY"
-		}
-		return "BUY"
-	case "DOWN":
-		if confidence > 80 {
-			return "STRONG_SELL"
-		}
-		return "SELL"
-	default:
-		return "HOLD"
+	job, ok := ai.GetManager().TrainingJob(model)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no training job for " + model})
+		return
 	}
+	c.JSON(http.StatusOK, job)
 }
-
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/alerts"
+	"github.com/loadstar0723/monstas7-backend/internal/backtesting"
+	"github.com/loadstar0723/monstas7-backend/internal/expr"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+const (
+	// maxScreenerSymbols 스크리너 한 번에 평가하는 최대 심볼 수
+	maxScreenerSymbols = 50
+	// screenerWorkers 동시에 캔들을 조회하는 심볼 수
+	screenerWorkers = 8
+)
+
+// exprError 수식 오류 응답 (위치 정보가 있으면 포함)
+func exprError(c *gin.Context, err error) {
+	var syntax *expr.Error
+	if errors.As(err, &syntax) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": syntax.Msg, "pos": syntax.Pos})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// GetExprFunctions 수식에서 쓸 수 있는 함수와 가격 시계열 목록
+func GetExprFunctions(c *gin.Context) {
+	functions, series := expr.Functions()
+	c.JSON(http.StatusOK, gin.H{"functions": functions, "series": series})
+}
+
+// ValidateExpr 수식 파싱/타입 검사 (저장된 정의 참조 가능)
+func ValidateExpr(c *gin.Context) {
+	var req struct {
+		Expression string `json:"expression" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	program, err := expr.GetLibrary().Compile(req.Expression)
+	if err != nil {
+		exprError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true, "program": program})
+}
+
+// EvaluateExpr 심볼의 마감된 캔들 위에서 수식 평가
+func EvaluateExpr(c *gin.Context) {
+	var req struct {
+		Symbol     string `json:"symbol" binding:"required"`
+		Interval   string `json:"interval"`
+		Expression string `json:"expression" binding:"required"`
+		Limit      int    `json:"limit"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	if req.Interval == "" {
+		req.Interval = "1h"
+	}
+	program, err := expr.GetLibrary().Compile(req.Expression)
+	if err != nil {
+		exprError(c, err)
+		return
+	}
+	candles, err := features.GetStore().Candles(symbol, req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	values := program.Eval(candles)
+	start := 0
+	if req.Limit > 0 && req.Limit < len(values) {
+		start = len(values) - req.Limit
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": req.Interval,
+		"program":  program,
+		"time":     candles.Time[start:],
+		"close":    candles.Close[start:],
+		"values":   values[start:],
+	})
+}
+
+// ListExprDefinitions 저장된 정의 목록
+func ListExprDefinitions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"definitions": expr.GetLibrary().List()})
+}
+
+// GetExprDefinition 저장된 정의 조회
+func GetExprDefinition(c *gin.Context) {
+	def, err := expr.GetLibrary().Get(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// SaveExprDefinition 정의 생성/수정 (참조하는 정의가 깨지면 거부)
+func SaveExprDefinition(c *gin.Context) {
+	var req struct {
+		Expression  string `json:"expression" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	def, err := expr.GetLibrary().Save(c.Param("name"), req.Expression, req.Description)
+	if err != nil {
+		exprError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// DeleteExprDefinition 정의 삭제 (다른 정의가 참조하면 409)
+func DeleteExprDefinition(c *gin.Context) {
+	err := expr.GetLibrary().Delete(c.Param("name"))
+	switch {
+	case errors.Is(err, expr.ErrDefinitionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, expr.ErrDefinitionInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"deleted": c.Param("name")})
+	}
+}
+
+// ScreenerRequest 스크리너 요청
+// filter는 조건식, columns는 이름 -> 수식이며 모두 마지막 마감 캔들 값으로 평가된다
+type ScreenerRequest struct {
+	Symbols  []string          `json:"symbols"`
+	Interval string            `json:"interval"`
+	Filter   string            `json:"filter"`
+	Columns  map[string]string `json:"columns"`
+	Sort     string            `json:"sort"`
+	Desc     bool              `json:"desc"`
+	Limit    int               `json:"limit"`
+}
+
+// ScreenerRow 스크리너 결과 행 (값이 정의되지 않은 컬럼은 null)
+type ScreenerRow struct {
+	Symbol string              `json:"symbol"`
+	Time   int64               `json:"time"`
+	Close  float64             `json:"close"`
+	Values map[string]*float64 `json:"values"`
+}
+
+// RunScreener 여러 심볼에 필터/컬럼 수식을 평가
+func RunScreener(c *gin.Context) {
+	var req ScreenerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Interval == "" {
+		req.Interval = "1h"
+	}
+	if _, err := market.IntervalDuration(req.Interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Symbols) == 0 {
+		req.Symbols = market.DefaultWatchlist
+	}
+	if len(req.Symbols) > maxScreenerSymbols {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many symbols"})
+		return
+	}
+	symbols := make([]string, len(req.Symbols))
+	for i, raw := range req.Symbols {
+		symbol, ok := validateSymbol(c, raw)
+		if !ok {
+			return
+		}
+		symbols[i] = symbol
+	}
+
+	library := expr.GetLibrary()
+	var filter *expr.Program
+	if req.Filter != "" {
+		program, err := library.Compile(req.Filter)
+		if err != nil {
+			exprError(c, err)
+			return
+		}
+		if program.Type != expr.TypeBool {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "filter must be a condition, got " + program.Type.String()})
+			return
+		}
+		filter = program
+	}
+	columns := make(map[string]*expr.Program, len(req.Columns))
+	for name, src := range req.Columns {
+		program, err := library.Compile(src)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "column " + name + ": " + err.Error()})
+			return
+		}
+		columns[name] = program
+	}
+	if _, ok := columns[req.Sort]; req.Sort != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of the columns"})
+		return
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		rows     = []ScreenerRow{}
+		failures = map[string]string{}
+		slots    = make(chan struct{}, screenerWorkers)
+	)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			candles, err := features.GetStore().Candles(symbol, req.Interval)
+			if err != nil {
+				mu.Lock()
+				failures[symbol] = err.Error()
+				mu.Unlock()
+				return
+			}
+			if filter != nil && lastValue(filter.Eval(candles)) != 1 {
+				return
+			}
+			last := candles.Len() - 1
+			row := ScreenerRow{Symbol: symbol, Time: candles.Time[last], Close: candles.Close[last], Values: make(map[string]*float64, len(columns))}
+			for name, program := range columns {
+				if v := lastValue(program.Eval(candles)); !math.IsNaN(v) && !math.IsInf(v, 0) {
+					row.Values[name] = &v
+				} else {
+					row.Values[name] = nil
+				}
+			}
+			mu.Lock()
+			rows = append(rows, row)
+			mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+
+	sortScreenerRows(rows, req.Sort, req.Desc)
+	if req.Limit > 0 && req.Limit < len(rows) {
+		rows = rows[:req.Limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"interval":  req.Interval,
+		"matched":   len(rows),
+		"rows":      rows,
+		"errors":    failures,
+		"timestamp": time.Now(),
+	})
+}
+
+// sortScreenerRows 컬럼 값 기준 정렬 (값이 없는 행은 항상 뒤로), 컬럼이 없으면 심볼순
+func sortScreenerRows(rows []ScreenerRow, column string, desc bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if column == "" {
+			return rows[i].Symbol < rows[j].Symbol
+		}
+		a, b := rows[i].Values[column], rows[j].Values[column]
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		case desc:
+			return *a > *b
+		default:
+			return *a < *b
+		}
+	})
+}
+
+func lastValue(s indicators.Series) float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	return s[len(s)-1]
+}
+
+// ListAlerts 조건 알림 목록 (?symbol=)
+func ListAlerts(c *gin.Context) {
+	symbol := ""
+	if raw := c.Query("symbol"); raw != "" {
+		var ok bool
+		if symbol, ok = validateSymbol(c, raw); !ok {
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts.GetManager().List(symbol)})
+}
+
+// CreateAlert 조건 알림 생성 (실시간 스트림에서 캔들 마감 시 평가)
+func CreateAlert(c *gin.Context) {
+	var rule alerts.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol, ok := validateSymbol(c, rule.Symbol)
+	if !ok {
+		return
+	}
+	rule.Symbol = symbol
+	created, err := alerts.GetManager().Create(rule)
+	if err != nil {
+		exprError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// DeleteAlert 조건 알림 삭제
+func DeleteAlert(c *gin.Context) {
+	if err := alerts.GetManager().Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": c.Param("id")})
+}
+
+// BacktestExpr 진입/청산 조건식 전략을 마감된 캔들로 백테스트
+func BacktestExpr(c *gin.Context) {
+	var req struct {
+		Symbol         string  `json:"symbol" binding:"required"`
+		Interval       string  `json:"interval"`
+		Entry          string  `json:"entry" binding:"required"`
+		Exit           string  `json:"exit"`
+		StopLoss       float64 `json:"stop_loss"`
+		TakeProfit     float64 `json:"take_profit"`
+		PositionSize   float64 `json:"position_size"`
+		InitialCapital float64 `json:"initial_capital"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	if req.Interval == "" {
+		req.Interval = "1h"
+	}
+	if req.InitialCapital <= 0 {
+		req.InitialCapital = 10000
+	}
+	if req.StopLoss < 0 || req.TakeProfit < 0 || req.PositionSize < 0 || req.PositionSize > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stop_loss and take_profit must be >= 0, position_size in [0, 1]"})
+		return
+	}
+
+	strategy, err := backtesting.NewExprStrategy(req.Entry, req.Exit, expr.GetLibrary())
+	if err != nil {
+		exprError(c, err)
+		return
+	}
+	strategy.StopLoss, strategy.TakeProfit = req.StopLoss, req.TakeProfit
+	if req.PositionSize > 0 {
+		strategy.PositionSize = req.PositionSize
+	}
+
+	klines, err := features.GetStore().Klines(symbol, req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	data := backtesting.FromKlines(symbol, klines)
+	result := backtesting.NewBacktestEngine(req.InitialCapital).RunBacktest(data, strategy)
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": req.Interval,
+		"entry":    strategy.Entry,
+		"exit":     strategy.Exit,
+		"result":   result,
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/features"
+)
+
+// GetFeatureSets lists the registered feature sets with their versions
+func GetFeatureSets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"sets":     features.Sets(),
+		"defaults": features.DefaultSets,
+	})
+}
+
+// GetFeatureVector returns the current inference vector for a symbol
+// (?interval=1h&sets=technical,volume; all default sets when omitted)
+func GetFeatureVector(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1h")
+	names := features.DefaultSets
+	if raw := c.Query("sets"); raw != "" {
+		names = strings.Split(raw, ",")
+	}
+
+	vector, err := features.GetStore().Vector(symbol, interval, names...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, vector)
+}
+
+// GetFeatureFrame returns the training frame of a historical feature set
+// (?interval=1h&set=technical)
+func GetFeatureFrame(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1h")
+
+	frame, err := features.GetStore().Frame(symbol, interval, c.DefaultQuery("set", "technical"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, features.ErrUnknownSet) || errors.Is(err, features.ErrNoHistory) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, frame)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// calibrationTimeout bounds a forecast or calibration request, which may
+// replay the model on recent candles when its prediction history is short
+const calibrationTimeout = 2 * time.Minute
+
+// ForecastRequest is a prediction request with the interval and calibration
+// settings of /ai/:model/forecast
+type ForecastRequest struct {
+	PredictionRequest
+	ai.ForecastOptions
+}
+
+// Forecast runs a model and returns its forecasts with split-conformal
+// lower/upper bands, quantile forecasts and calibrated direction
+// probabilities
+func Forecast(c *gin.Context) {
+	model := c.Param("model")
+	var req ForecastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	req.Symbol = symbol
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), calibrationTimeout)
+	defer cancel()
+	forecast, err := ai.GetCalibrator().Forecast(ctx, model, predictionInput(&req.PredictionRequest), req.ForecastOptions)
+	if err != nil {
+		c.JSON(predictionErrorStatus(err), gin.H{"error": err.Error(), "model": model})
+		return
+	}
+	c.JSON(http.StatusOK, forecast)
+}
+
+// GetModelCalibration returns the reliability diagrams of a model's stated
+// and calibrated direction probabilities and the coverage of its conformal
+// intervals for one symbol, interval and horizon
+func GetModelCalibration(c *gin.Context) {
+	model := c.Param("model")
+	symbol, ok := validateSymbol(c, c.Query("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1h")
+	if _, err := market.IntervalDuration(interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	horizon := c.DefaultQuery("horizon", interval)
+	method := c.Query("method")
+	if method != "" && method != ai.CalibrationPlatt && method != ai.CalibrationIsotonic {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "method must be platt or isotonic"})
+		return
+	}
+	bins, err := strconv.Atoi(c.DefaultQuery("bins", "10"))
+	if err != nil || bins < 2 || bins > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bins must be between 2 and 50"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), calibrationTimeout)
+	defer cancel()
+	calibrations, err := ai.GetCalibrator().Calibrations(ctx, model, symbol, interval, []string{horizon})
+	if err != nil {
+		c.JSON(predictionErrorStatus(err), gin.H{"error": err.Error(), "model": model})
+		return
+	}
+	calibration, ok := calibrations[horizon]
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "no " + horizon + " forecasts of " + model + " to calibrate on"})
+		return
+	}
+	c.JSON(http.StatusOK, calibration.Report(method, bins))
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// validateFuturesSymbol 선물 심볼은 현물 목록에 없을 수 있어 형식만 검사
+func validateFuturesSymbol(c *gin.Context, symbol string) (string, bool) {
+	normalized, err := market.NormalizeSymbol(symbol)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return normalized, true
+}
+
+// futuresError 레이트리밋/밴은 503, 그 외 업스트림 오류는 500으로 응답
+func futuresError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, market.ErrRateLimited) || errors.Is(err, market.ErrIPBanned) {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// GetFundingRates 펀딩비 이력과 다음 정산 예상 펀딩비 조회
+func GetFundingRates(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	startTime, _ := strconv.ParseInt(c.DefaultQuery("startTime", "0"), 10, 64)
+	endTime, _ := strconv.ParseInt(c.DefaultQuery("endTime", "0"), 10, 64)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	client := market.NewFuturesClient()
+	history, err := client.GetFundingRates(symbol, startTime, endTime, limit)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	index, err := client.GetPremiumIndex(symbol)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":          symbol,
+		"history":         history,
+		"predictedRate":   index.LastFundingRate,
+		"nextFundingTime": index.NextFundingTime,
+	})
+}
+
+// GetMarkPrice 마크/인덱스 가격과 베이시스 조회
+func GetMarkPrice(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+
+	index, err := market.NewFuturesClient().GetPremiumIndex(symbol)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"premiumIndex": index,
+		"basis":        index.Basis(),
+	})
+}
+
+// GetFundingOverview 전체 선물 심볼의 마크 가격/예상 펀딩비 조회
+func GetFundingOverview(c *gin.Context) {
+	indexes, err := market.NewFuturesClient().GetAllPremiumIndex()
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(indexes),
+		"symbols": indexes,
+	})
+}
+
+// GetOpenInterest 현재 미결제약정과 기간별 이력 조회
+func GetOpenInterest(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	period := c.DefaultQuery("period", "5m")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+
+	client := market.NewFuturesClient()
+	current, err := client.GetOpenInterest(symbol)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	history, err := client.GetOpenInterestHist(symbol, period, limit)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"current": current,
+		"period":  period,
+		"history": history,
+	})
+}
+
+// GetLongShortRatio 롱숏 비율 이력 조회 (?type=global|topAccounts|topPositions)
+func GetLongShortRatio(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	kind := c.DefaultQuery("type", market.RatioGlobalAccounts)
+	period := c.DefaultQuery("period", "5m")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+
+	ratios, err := market.NewFuturesClient().GetLongShortRatio(symbol, kind, period, limit)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol": symbol,
+		"type":   kind,
+		"period": period,
+		"ratios": ratios,
+	})
+}
+
+// GetLiquidations 스트림으로 수집한 최근 강제청산과 구간 집계 조회
+func GetLiquidations(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	store := market.GetLiquidationStore()
+	since := time.Now().Add(-window).UnixMilli()
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":       symbol,
+		"summary":      store.Summary(symbol, window),
+		"liquidations": store.Recent(symbol, since, limit),
+	})
+}
+
+// GetFuturesFeatures 모델 입력으로 쓰이는 선물 피처 조회
+func GetFuturesFeatures(c *gin.Context) {
+	symbol, ok := validateFuturesSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+
+	features, err := market.FuturesFeatures(symbol)
+	if err != nil {
+		futuresError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"features": features,
+	})
+}
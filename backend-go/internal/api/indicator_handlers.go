@@ -0,0 +1,269 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxIndicatorBars 한 번에 반환하는 최대 캔들 수
+	maxIndicatorBars = 5000
+	// historicalIndicatorTTL 이미 마감된 구간 결과의 캐시 시간
+	historicalIndicatorTTL = time.Hour
+	// maxIndicatorIntervals 한 요청에서 계산하는 최대 인터벌 수
+	maxIndicatorIntervals = 6
+)
+
+// IndicatorResponse 지표 조회 응답 (모든 시계열은 time과 같은 길이로 정렬)
+type IndicatorResponse struct {
+	Symbol     string                                  `json:"symbol"`
+	Interval   string                                  `json:"interval"`
+	From       int64                                   `json:"from"`
+	To         int64                                   `json:"to"`
+	Time       []int64                                 `json:"time"`
+	Close      []float64                               `json:"close"`
+	Indicators map[string]map[string]indicators.Series `json:"indicators"`
+}
+
+// GetIndicators 지표 시계열 조회
+// ?interval=1h&set=rsi:14,macd:12:26:9,bb:20:2&from=&to=&limit=
+// from/to는 밀리초, RFC3339 또는 YYYY-MM-DD. 마감된 캔들만 포함하며 캔들 마감 단위로 캐시된다
+// interval=15m,1h,4h처럼 여러 인터벌을 주면 같은 구간을 인터벌별로 계산해 timeframes에 담아 반환한다
+// (limit은 인터벌별 캔들 수)
+func GetIndicators(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	intervals, err := parseIntervals(c.DefaultQuery("interval", "1h"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	specs, err := indicators.ParseSpecs(c.Query("set"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var from, to int64
+	if raw := c.Query("from"); raw != "" {
+		if from, err = parseTimeParam(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseTimeParam(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if limit <= 0 || limit > maxIndicatorBars {
+		limit = maxIndicatorBars
+	}
+
+	timeframes := make(map[string]json.RawMessage, len(intervals))
+	for _, interval := range intervals {
+		data, status, err := intervalIndicators(symbol, interval, specs, from, to, limit)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error(), "interval": interval})
+			return
+		}
+		if len(intervals) == 1 {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+			return
+		}
+		timeframes[interval] = data
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"intervals":  intervals,
+		"timeframes": timeframes,
+	})
+}
+
+// parseIntervals 쉼표로 구분된 인터벌 목록 검증 (중복 제거, 최대 maxIndicatorIntervals개)
+func parseIntervals(raw string) ([]string, error) {
+	var intervals []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		interval := strings.TrimSpace(part)
+		if interval == "" || seen[interval] {
+			continue
+		}
+		if _, err := market.IntervalDuration(interval); err != nil {
+			return nil, err
+		}
+		seen[interval] = true
+		intervals = append(intervals, interval)
+	}
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("interval is required")
+	}
+	if len(intervals) > maxIndicatorIntervals {
+		return nil, fmt.Errorf("at most %d intervals per request", maxIndicatorIntervals)
+	}
+	return intervals, nil
+}
+
+// intervalIndicators 인터벌 하나의 지표 응답 JSON (캐시 우선)
+// from/to가 0이면 각각 to-limit 캔들, 마지막 마감 시각을 사용한다
+func intervalIndicators(symbol, interval string, specs []indicators.Spec, from, to int64, limit int) ([]byte, int, error) {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// 현재 진행 중인 캔들의 시작 시각 = 마지막 마감 시각
+	stepMs := step.Milliseconds()
+	closedUntil := time.Now().UnixMilli() / stepMs * stepMs
+
+	if to == 0 || to > closedUntil {
+		to = closedUntil
+	}
+	if from == 0 {
+		from = to - int64(limit)*stepMs
+	}
+	if from >= to {
+		return nil, http.StatusBadRequest, fmt.Errorf("from must be before to")
+	}
+	if (to-from)/stepMs > maxIndicatorBars {
+		return nil, http.StatusBadRequest, fmt.Errorf("range exceeds %d candles", maxIndicatorBars)
+	}
+
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.String()
+	}
+	cacheKey := fmt.Sprintf("indicators:%s:%s:%s:%d:%d", symbol, interval, strings.Join(names, ","), from, to)
+
+	redis := database.GetRedis()
+	if redis != nil {
+		var cached json.RawMessage
+		if err := redis.Get(cacheKey, &cached); err == nil {
+			return cached, http.StatusOK, nil
+		}
+	}
+
+	response, err := computeIndicators(symbol, interval, specs, from, to, stepMs)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if redis != nil {
+		// 마지막 마감 캔들까지 포함하는 구간은 다음 캔들이 마감될 때까지만 유효
+		ttl := historicalIndicatorTTL
+		if to == closedUntil {
+			ttl = time.Until(time.UnixMilli(closedUntil + stepMs))
+		}
+		if err := redis.Set(cacheKey, json.RawMessage(data), ttl); err != nil {
+			logrus.Debugf("Failed to cache indicators for %s: %v", symbol, err)
+		}
+	}
+	return data, http.StatusOK, nil
+}
+
+// computeIndicators 워밍업 구간을 포함해 캔들을 조회하고 [from, to) 구간의 지표를 계산
+func computeIndicators(symbol, interval string, specs []indicators.Spec, from, to, stepMs int64) (*IndicatorResponse, error) {
+	lookback := 0
+	for _, spec := range specs {
+		if n := spec.Lookback(); n > lookback {
+			lookback = n
+		}
+	}
+
+	klines, err := fetchKlineRange(symbol, interval, from-int64(lookback)*stepMs, to-1, stepMs)
+	if err != nil {
+		return nil, err
+	}
+	checked, _, err := market.CheckKlines(symbol, interval, klines, market.DefaultQualityConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	bars := make([]indicators.Bar, 0, len(checked))
+	for _, k := range checked {
+		if k.OpenTime+stepMs > to {
+			break
+		}
+		bars = append(bars, indicators.Bar{Time: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume})
+	}
+	candles := indicators.FromBars(bars)
+
+	// 워밍업 구간은 응답에서 제외
+	start := 0
+	for start < len(bars) && bars[start].Time < from {
+		start++
+	}
+
+	response := &IndicatorResponse{
+		Symbol:     symbol,
+		Interval:   interval,
+		From:       from,
+		To:         to,
+		Time:       candles.Time[start:],
+		Close:      candles.Close[start:],
+		Indicators: make(map[string]map[string]indicators.Series, len(specs)),
+	}
+	for _, spec := range specs {
+		outputs, err := spec.Compute(candles)
+		if err != nil {
+			return nil, err
+		}
+		for name, series := range outputs {
+			outputs[name] = series[start:]
+		}
+		response.Indicators[spec.String()] = outputs
+	}
+	return response, nil
+}
+
+// fetchKlineRange 1000개 단위로 나눠 [start, end] 구간의 캔들 조회
+func fetchKlineRange(symbol, interval string, start, end, stepMs int64) ([]market.Kline, error) {
+	client := market.NewBinanceClient()
+	var klines []market.Kline
+	for start <= end {
+		page, err := client.GetKlinesRange(symbol, interval, start, end, 1000)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		klines = append(klines, page...)
+		start = page[len(page)-1].OpenTime + stepMs
+	}
+	return klines, nil
+}
+
+// parseTimeParam 밀리초 타임스탬프, RFC3339, YYYY-MM-DD 형식의 시각 파싱
+func parseTimeParam(raw string) (int64, error) {
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return ms, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t.UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("invalid time %q (use milliseconds, RFC3339 or YYYY-MM-DD)", raw)
+}
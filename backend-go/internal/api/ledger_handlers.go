@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// ledgerFilter reads the model, symbol, interval, horizon, status and since
+// query parameters. since is either an RFC 3339 time or a lookback such as 7d.
+func ledgerFilter(c *gin.Context) (ai.LedgerFilter, error) {
+	f := ai.LedgerFilter{
+		Model:    c.Query("model"),
+		Symbol:   strings.ToUpper(c.Query("symbol")),
+		Interval: c.Query("interval"),
+		Horizon:  c.Query("horizon"),
+		Status:   c.Query("status"),
+	}
+	if since := c.Query("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			f.Since = t
+		} else if d, err := market.IntervalDuration(since); err == nil {
+			f.Since = time.Now().Add(-d)
+		} else {
+			return f, errors.New("since must be an RFC 3339 time or a lookback such as 24h or 7d")
+		}
+	}
+	return f, nil
+}
+
+// GetLedgerEntries lists recorded predictions, newest first
+func GetLedgerEntries(c *gin.Context) {
+	f, err := ledgerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	entries := ai.GetLedger().Entries(f, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// GetLedgerLeaderboard scores recorded predictions grouped by model, symbol
+// and/or horizon (group_by=model,horizon)
+func GetLedgerLeaderboard(c *gin.Context) {
+	f, err := ledgerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groupBy := strings.Split(c.DefaultQuery("group_by", "model"), ",")
+	minSamples, err := strconv.Atoi(c.DefaultQuery("min_samples", "1"))
+	if err != nil || minSamples < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_samples must be a non-negative integer"})
+		return
+	}
+
+	board, err := ai.GetLedger().Leaderboard(f, groupBy, minSamples)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"group_by":    groupBy,
+		"leaderboard": board,
+	})
+}
+
+// GetLedgerCalibration returns the reliability diagram of the confidence of
+// resolved predictions
+func GetLedgerCalibration(c *gin.Context) {
+	f, err := ledgerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bins, err := strconv.Atoi(c.DefaultQuery("bins", "10"))
+	if err != nil || bins < 2 || bins > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bins must be between 2 and 50"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ai.GetLedger().Reliability(f, bins))
+}
@@ -2,15 +2,30 @@ package api
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/loadstar0723/monstas7-backend/internal/market"
 )
 
+// validateSymbol 심볼을 레지스트리로 검증하고 실패하면 400 응답
+func validateSymbol(c *gin.Context, symbol string) (string, bool) {
+	normalized, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return normalized, true
+}
+
 // GetPrice 현재 가격 조회
 func GetPrice(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
 
 	// 실시간 데이터 수집기에서 가격 조회
 	collector := market.GetCollector()
@@ -37,7 +52,10 @@ func GetPrice(c *gin.Context) {
 
 // GetOrderBook 오더북 조회
 func GetOrderBook(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
 	limitStr := c.DefaultQuery("limit", "20")
 	limit, _ := strconv.Atoi(limitStr)
 
@@ -53,7 +71,10 @@ func GetOrderBook(c *gin.Context) {
 
 // GetTrades 최근 거래 내역 조회
 func GetTrades(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
 	limitStr := c.DefaultQuery("limit", "100")
 	limit, _ := strconv.Atoi(limitStr)
 
@@ -72,7 +93,10 @@ func GetTrades(c *gin.Context) {
 
 // GetKlines 캔들 데이터 조회
 func GetKlines(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
 	interval := c.DefaultQuery("interval", "1m")
 	limitStr := c.DefaultQuery("limit", "100")
 	limit, _ := strconv.Atoi(limitStr)
@@ -84,10 +108,64 @@ func GetKlines(c *gin.Context) {
 		return
 	}
 
+	// 품질 검사: 의심 캔들 태그, clean=exclude|interpolate 지정 시 정리
+	checked, report, err := market.CheckKlines(symbol, interval, klines, market.DefaultQualityConfig)
+	if err == nil {
+		klines = checked
+		switch c.DefaultQuery("clean", "none") {
+		case "exclude":
+			klines = market.ExcludeSuspect(klines)
+		case "interpolate":
+			klines = market.InterpolateSuspect(klines)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"symbol": symbol,
 		"interval": interval,
 		"klines": klines,
+		"quality": report,
+	})
+}
+
+// GetDataQuality 심볼별 캔들 데이터 품질 리포트와 품질 태그가 붙은 캔들 (repair=true면 갭 재조회)
+func GetDataQuality(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1h")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "500"))
+
+	client := market.NewBinanceClient()
+	klines, err := client.GetKlines(symbol, interval, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	checked, report, err := market.CheckKlines(symbol, interval, klines, market.DefaultQualityConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 복구된 캔들은 FlagRepaired로 표시되어 품질 태그와 함께 응답에 포함
+	repairError := ""
+	if c.DefaultQuery("repair", "false") == "true" {
+		repaired, err := client.RepairGaps(checked, report, market.DefaultQualityConfig)
+		if err != nil {
+			repairError = err.Error()
+		}
+		checked = repaired
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":      symbol,
+		"interval":    interval,
+		"klines":      checked,
+		"report":      report,
+		"repairError": repairError,
 	})
 }
 
@@ -98,6 +176,10 @@ func Get24hrTicker(c *gin.Context) {
 	client := market.NewBinanceClient()
 
 	if symbol != "" {
+		symbol, ok := validateSymbol(c, symbol)
+		if !ok {
+			return
+		}
 		ticker, err := client.Get24hrTicker(symbol)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -120,6 +202,68 @@ func Get24hrTicker(c *gin.Context) {
 	})
 }
 
+// GetSymbols exchangeInfo 기반 심볼 목록 조회
+func GetSymbols(c *gin.Context) {
+	quote := strings.ToUpper(c.DefaultQuery("quote", "USDT"))
+	tradingOnly := c.DefaultQuery("all", "false") != "true"
+
+	registry := market.GetRegistry()
+	symbols := registry.Symbols(quote, tradingOnly)
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbols":  symbols,
+		"count":    len(symbols),
+		"loadedAt": registry.LoadedAt(),
+	})
+}
+
+// GetSymbolInfo 단일 심볼의 거래 필터 조회
+func GetSymbolInfo(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+
+	info, found := market.GetRegistry().Get(symbol)
+	if !found {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "exchange info not loaded"})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetWatchlist 현재 감시 목록 조회
+func GetWatchlist(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"watchlist": market.GetRegistry().Watchlist(),
+	})
+}
+
+// UpdateWatchlist 감시 목록 교체
+// 실행 중인 수집기와 스트림은 시작할 때 목록을 복사하므로 변경은 서버 재시작 후 반영된다
+func UpdateWatchlist(c *gin.Context) {
+	var req struct {
+		Symbols []string `json:"symbols" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watchlist, err := market.GetRegistry().SetWatchlist(req.Symbols)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"watchlist": watchlist,
+		"note":      "the running collector and streams pick up watchlist changes after a server restart",
+	})
+}
+
 // Trading placeholder handlers
 func CreateOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Create Order API"})
@@ -164,11 +308,20 @@ func GetCorrelation(c *gin.Context) {
 
 // System handlers
 func HealthCheck(c *gin.Context) {
+	binance := market.GetScheduler().Status()
+
+	status := "healthy"
+	if binance.Banned || binance.RetryAfter != nil {
+		status = "degraded"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
+		"status": status,
 		"service": "Go AI Trading Server",
 		"version": "1.0.0",
 		"database": "Supabase Connected",
+		"binance": binance,
+		"binanceFutures": market.GetFuturesScheduler().Status(),
 	})
 }
 
@@ -182,5 +335,7 @@ func GetSystemStatus(c *gin.Context) {
 		"uptime": "0h",
 		"memory": "OK",
 		"cpu": "OK",
+		"binance": market.GetScheduler().Status(),
+		"binanceFutures": market.GetFuturesScheduler().Status(),
 	})
 }
\ No newline at end of file
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// GetFootprint 체결 기반 풋프린트 캔들 조회 (?interval=1m|5m|15m..., ?limit=, ?current=true)
+func GetFootprint(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1m")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "60"))
+	includeCurrent := c.DefaultQuery("current", "true") == "true"
+
+	candles, err := market.AggregateFootprints(market.GetBarBuilder().Footprints(symbol, includeCurrent), interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit > 0 && len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": interval,
+		"candles":  candles,
+	})
+}
+
+// GetVWAP 세션 VWAP 시계열 조회 (?interval=, ?limit=)
+func GetVWAP(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "1m")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "500"))
+
+	candles, err := market.AggregateFootprints(market.GetBarBuilder().Footprints(symbol, true), interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series := market.VWAPSeries(candles)
+	if limit > 0 && len(series) > limit {
+		series = series[len(series)-limit:]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": interval,
+		"series":   series,
+	})
+}
+
+// GetVolumeProfile 볼륨 프로파일 조회 (?type=session|rolling, ?window=4h, ?step=)
+func GetVolumeProfile(c *gin.Context) {
+	symbol, ok := validateSymbol(c, c.Param("symbol"))
+	if !ok {
+		return
+	}
+	step, _ := strconv.ParseFloat(c.DefaultQuery("step", "0"), 64)
+
+	builder := market.GetBarBuilder()
+	var profile *market.VolumeProfile
+	var err error
+
+	switch kind := c.DefaultQuery("type", "session"); kind {
+	case "session":
+		profile, err = builder.SessionProfile(symbol, step)
+	case "rolling":
+		window, parseErr := time.ParseDuration(c.DefaultQuery("window", "4h"))
+		if parseErr != nil || window <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window"})
+			return
+		}
+		profile, err = builder.RollingProfile(symbol, window, step)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be session or rolling"})
+		return
+	}
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, market.ErrNoBars) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
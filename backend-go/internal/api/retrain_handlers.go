@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+)
+
+// retrainErrorStatus maps drift and retraining errors to HTTP statuses
+func retrainErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ai.ErrUnknownModel):
+		return http.StatusNotFound
+	case errors.Is(err, ai.ErrNotTrainable), errors.Is(err, ai.ErrModelNotTrained):
+		return http.StatusBadRequest
+	case errors.Is(err, ai.ErrTrainingInProgress):
+		return http.StatusConflict
+	default:
+		return trainingErrorStatus(err)
+	}
+}
+
+// GetDriftReports returns the last drift check of every monitored model
+func GetDriftReports(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"reports": ai.GetRetrainer().Reports()})
+}
+
+// CheckModelDrift checks a model for feature drift and live error now
+func CheckModelDrift(c *gin.Context) {
+	report, err := ai.GetRetrainer().Check(c.Request.Context(), c.Param("model"))
+	if err != nil {
+		c.JSON(retrainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report, "due": report.Due()})
+}
+
+// RetrainModel retrains a model in the background; the candidate replaces
+// the current version only if it scores better on the holdout candles
+func RetrainModel(c *gin.Context) {
+	model := c.Param("model")
+	if err := ai.GetRetrainer().Start(model, "requested via API"); err != nil {
+		c.JSON(retrainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"model":   model,
+		"history": "/api/v1/ai/retrain/history",
+	})
+}
+
+// GetRetrainHistory lists the retraining runs, newest first
+func GetRetrainHistory(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"runs": ai.GetRetrainer().History()})
+}
+
+// GetRetrainPolicy returns the drift thresholds and retraining settings
+func GetRetrainPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"policy": ai.GetRetrainer().Policy()})
+}
+
+// UpdateRetrainPolicy replaces the retraining policy; omitted fields keep
+// their current values
+func UpdateRetrainPolicy(c *gin.Context) {
+	retrainer := ai.GetRetrainer()
+	policy := retrainer.Policy()
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := retrainer.SetPolicy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
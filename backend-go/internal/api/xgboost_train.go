@@ -1,114 +1,182 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"math"
-	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
 )
 
 // XGBoostTrainingRequest represents the training request structure
 type XGBoostTrainingRequest struct {
 	Symbol     string                 `json:"symbol"`
 	Timeframe  string                 `json:"timeframe"`
-	DataPoints int                    `json:"dataPoints"`
+	DataPoints int                    `json:"dataPoints"` // closed candles to train on
 	Parameters map[string]interface{} `json:"parameters"`
 }
 
 // XGBoostTrainingResponse represents the training response
 type XGBoostTrainingResponse struct {
 	Success      bool                   `json:"success"`
-	ModelID      string                 `json:"modelId"`
+	ModelID      string                 `json:"modelId"` // saved version, usable as modelId in /ai/xgboost/predict
 	Accuracy     float64                `json:"accuracy"`
 	TrainingTime float64                `json:"trainingTime"`
 	Metrics      map[string]interface{} `json:"metrics"`
 	Message      string                 `json:"message"`
 }
 
-// XGBoostTrain handles the training request for XGBoost model
+// xgboostTrainTimeout bounds a synchronous XGBoost training request
+const xgboostTrainTimeout = 10 * time.Minute
+
+// xgboostTreeParams are the parameters passed through to the trainer as
+// model specific hyperparameters
+var xgboostTreeParams = map[string]bool{
+	"max_depth": true, "min_child_weight": true, "subsample": true,
+	"colsample_bytree": true, "lambda": true, "alpha": true, "gamma": true,
+}
+
+// XGBoostTrain trains a new XGBoost model on the last DataPoints closed
+// candles and saves it as a model version. The registered model is left as
+// it is; the version can be predicted with by its model ID or activated
+// through /ai/models/xgboost/activate.
 func XGBoostTrain(c *gin.Context) {
 	var req XGBoostTrainingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	symbol, ok := validateSymbol(c, req.Symbol)
+	if !ok {
+		return
+	}
+	interval := req.Timeframe
+	if interval == "" {
+		interval = "1h"
+	}
+	if _, err := market.IntervalDuration(interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DataPoints == 0 {
+		req.DataPoints = defaultTrainingCandles
+	}
+	cfg, err := xgboostTrainConfig(req.Parameters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Start timer
 	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), xgboostTrainTimeout)
+	defer cancel()
 
-	// Simulate training process (실제 구현 시 real XGBoost 라이브러리 사용)
-	// 실제로는 github.com/dmitryikh/leaves 같은 Go XGBoost 라이브러리 사용
-
-	// Generate training metrics (실제 훈련 후 메트릭)
-	accuracy := 85.0 + rand.Float64()*10.0 // 85-95% accuracy
-	mse := 0.001 + rand.Float64()*0.004    // 0.001-0.005 MSE
-	mae := 0.01 + rand.Float64()*0.02      // 0.01-0.03 MAE
-	r2Score := 0.85 + rand.Float64()*0.12  // 0.85-0.97 R²
-
-	// Calculate training time
-	trainingTime := time.Since(startTime).Seconds()
-
-	// Generate model ID
-	modelID := fmt.Sprintf("xgb_%s_%d", req.Symbol, time.Now().Unix())
+	candles, err := ai.TrainingCandles(symbol, interval, req.DataPoints)
+	if err != nil {
+		c.JSON(trainingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	model := ai.NewXGBoostModel()
+	report, err := model.Train(ctx, ai.TrainingData{Symbol: symbol, Interval: interval, Candles: candles}, cfg)
+	if err != nil {
+		c.JSON(trainingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := ai.GetModelStore().Save(model, report.Metrics); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save model: " + err.Error()})
+		return
+	}
 
-	// Prepare response
+	importance := make(map[string]float64)
+	for name, value := range report.Metrics {
+		if feature, ok := strings.CutPrefix(name, "importance_gain."); ok {
+			importance[feature] = value
+		}
+	}
+	metrics := report.Metrics
 	response := XGBoostTrainingResponse{
 		Success:      true,
-		ModelID:      modelID,
-		Accuracy:     accuracy,
-		TrainingTime: trainingTime,
+		ModelID:      report.Version,
+		Accuracy:     metrics["val_direction_accuracy"] * 100,
+		TrainingTime: time.Since(startTime).Seconds(),
 		Metrics: map[string]interface{}{
-			"mse":                 mse,
-			"mae":                 mae,
-			"r2_score":           r2Score,
-			"feature_importance": generateFeatureImportance(),
-			"training_loss":      generateTrainingLoss(),
-			"validation_loss":    generateValidationLoss(),
-			"trees_created":      100,
-			"depth":              6,
-			"learning_rate":      0.1,
+			"mse":                metrics["val_mse"],
+			"mae":                metrics["val_mae"],
+			"rmse":               metrics["val_rmse"],
+			"r2_score":           metrics["val_r2"],
+			"baseline_mae":       metrics["baseline_mae"],
+			"feature_importance": importance,
+			"training_loss":      report.TrainLoss,
+			"validation_loss":    report.ValLoss,
+			"best_iteration":     report.BestEpoch,
+			"stopped_early":      report.StoppedEarly,
+			"trees_created":      len(model.Trees),
+			"depth":              model.MaxDepth,
+			"learning_rate":      model.LearningRate,
+			"candles":            report.Candles,
+			"train_samples":      report.TrainSamples,
+			"validation_samples": report.ValSamples,
 		},
-		Message: fmt.Sprintf("XGBoost 모델 훈련 완료: %s", req.Symbol),
+		Message: fmt.Sprintf("XGBoost 모델 훈련 완료: %s", symbol),
 	}
+	logrus.Infof("Trained XGBoost model %s on %d %s %s candles: %d trees, validation MAE %.6f",
+		report.Version, report.Candles, symbol, interval, len(model.Trees), metrics["val_mae"])
 
 	c.JSON(http.StatusOK, response)
 }
 
-// generateFeatureImportance generates feature importance scores
-func generateFeatureImportance() map[string]float64 {
-	return map[string]float64{
-		"price_change":    0.25 + rand.Float64()*0.1,
-		"volume":         0.20 + rand.Float64()*0.1,
-		"rsi":            0.15 + rand.Float64()*0.1,
-		"macd":           0.12 + rand.Float64()*0.08,
-		"bollinger_band": 0.10 + rand.Float64()*0.08,
-		"ema":            0.08 + rand.Float64()*0.05,
-		"sma":            0.06 + rand.Float64()*0.04,
-		"volatility":     0.04 + rand.Float64()*0.02,
+// xgboostTrainConfig maps the request parameters to a training config:
+// n_estimators (or num_boost_round), learning_rate (or eta),
+// early_stopping_rounds, validation_split and seed set the boosting run, the
+// tree parameters are passed through. Unknown or non-numeric parameters are
+// rejected.
+func xgboostTrainConfig(params map[string]interface{}) (ai.TrainConfig, error) {
+	var cfg ai.TrainConfig
+	for name, raw := range params {
+		v, ok := raw.(float64)
+		if !ok {
+			return cfg, fmt.Errorf("parameter %s must be a number", name)
+		}
+		switch {
+		case name == "n_estimators" || name == "num_boost_round":
+			cfg.Epochs = int(v)
+		case name == "learning_rate" || name == "eta":
+			cfg.LearningRate = v
+		case name == "early_stopping_rounds":
+			cfg.Patience = int(v)
+		case name == "validation_split":
+			cfg.ValidationSplit = v
+		case name == "seed":
+			cfg.Seed = int64(v)
+		case xgboostTreeParams[name]:
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]float64)
+			}
+			cfg.Params[name] = v
+		default:
+			return cfg, fmt.Errorf("unknown parameter %s", name)
+		}
 	}
+	return cfg, nil
 }
 
-// generateTrainingLoss generates training loss curve
-func generateTrainingLoss() []float64 {
-	loss := make([]float64, 10)
-	initialLoss := 0.5
-	for i := 0; i < 10; i++ {
-		// Exponential decay with noise
-		loss[i] = initialLoss * math.Exp(-float64(i)*0.3) + rand.Float64()*0.02
+// trainingErrorStatus maps a candle fetch or training error to its HTTP status
+func trainingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ai.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, ai.ErrInsufficientData):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
 	}
-	return loss
 }
-
-// generateValidationLoss generates validation loss curve
-func generateValidationLoss() []float64 {
-	loss := make([]float64, 10)
-	initialLoss := 0.52
-	for i := 0; i < 10; i++ {
-		// Slightly higher than training loss
-		loss[i] = initialLoss * math.Exp(-float64(i)*0.25) + rand.Float64()*0.03
-	}
-	return loss
-}
\ No newline at end of file
@@ -1,7 +1,6 @@
 package backtesting
 
 import (
-	"fmt"
 	"math"
 	"time"
 )
@@ -104,37 +103,46 @@ func (be *BacktestEngine) RunBacktest(data []MarketData, strategy Strategy) *Bac
 	maxConsecutiveWins := 0
 	maxConsecutiveLosses := 0
 
+	// 전체 구간을 한 번에 계산할 수 있는 전략은 미리 준비
+	if preparer, ok := strategy.(Preparer); ok {
+		preparer.Prepare(data)
+	}
+
 	for i, candle := range data {
-		// 전략 신호 생성
-		signal := strategy.GenerateSignal(data[:i+1])
-
-		// 포지션 관리
-		if signal.Action == "BUY" && len(be.Positions) == 0 {
-			be.OpenPosition(candle, signal, "LONG")
-		} else if signal.Action == "SELL" && len(be.Positions) > 0 {
-			trade := be.ClosePosition(candle, be.Positions[0])
-
-			// 연속 승패 추적
-			if trade.PnL > 0 {
-				consecutiveWins++
-				consecutiveLosses = 0
-				if consecutiveWins > maxConsecutiveWins {
-					maxConsecutiveWins = consecutiveWins
-				}
-			} else {
-				consecutiveLosses++
-				consecutiveWins = 0
-				if consecutiveLosses > maxConsecutiveLosses {
-					maxConsecutiveLosses = consecutiveLosses
+		// 의심 캔들에서는 신호를 만들거나 체결하지 않음
+		// 손절/익절은 다음 정상 캔들에서 판단하고, 자본 곡선은 계속 기록
+		if !candle.Suspect {
+			// 전략 신호 생성
+			signal := strategy.GenerateSignal(data[:i+1])
+
+			// 포지션 관리
+			if signal.Action == "BUY" && len(be.Positions) == 0 {
+				be.OpenPosition(candle, signal, "LONG")
+			} else if signal.Action == "SELL" && len(be.Positions) > 0 {
+				trade := be.ClosePosition(candle, be.Positions[0])
+
+				// 연속 승패 추적
+				if trade.PnL > 0 {
+					consecutiveWins++
+					consecutiveLosses = 0
+					if consecutiveWins > maxConsecutiveWins {
+						maxConsecutiveWins = consecutiveWins
+					}
+				} else {
+					consecutiveLosses++
+					consecutiveWins = 0
+					if consecutiveLosses > maxConsecutiveLosses {
+						maxConsecutiveLosses = consecutiveLosses
+					}
 				}
 			}
-		}
 
-		// 손절/익절 체크
-		for _, position := range be.Positions {
-			if position.Side == "LONG" {
-				if candle.Low <= position.StopLoss || candle.High >= position.TakeProfit {
-					be.ClosePosition(candle, position)
+			// 손절/익절 체크
+			for _, position := range be.Positions {
+				if position.Side == "LONG" {
+					if candle.Low <= position.StopLoss || candle.High >= position.TakeProfit {
+						be.ClosePosition(candle, position)
+					}
 				}
 			}
 		}
@@ -360,13 +368,14 @@ func calculateSharpeRatio(returns []float64) float64 {
 
 // MarketData 시장 데이터
 type MarketData struct {
-	Symbol string
-	Time   time.Time
-	Open   float64
-	High   float64
-	Low    float64
-	Close  float64
-	Volume float64
+	Symbol  string
+	Time    time.Time
+	Open    float64
+	High    float64
+	Low     float64
+	Close   float64
+	Volume  float64
+	Suspect bool // 품질 검사에서 이상치/거래량 0으로 태그된 캔들
 }
 
 // Signal 거래 신호
@@ -384,6 +393,11 @@ type Strategy interface {
 	GenerateSignal(data []MarketData) Signal
 }
 
+// Preparer 백테스트 시작 전에 전체 데이터를 받는 전략 (선택)
+type Preparer interface {
+	Prepare(data []MarketData)
+}
+
 // OptimizeStrategy 전략 최적화
 func (be *BacktestEngine) OptimizeStrategy(data []MarketData, paramRanges map[string][]float64) map[string]float64 {
 	bestParams := make(map[string]float64)
@@ -0,0 +1,146 @@
+package backtesting
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/expr"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// ExprStrategy 진입/청산 조건식으로 정의한 전략
+type ExprStrategy struct {
+	Entry        *expr.Program
+	Exit         *expr.Program // nil이면 손절/익절로만 청산
+	StopLoss     float64       // 진입가 대비 비율 (0이면 사용 안 함)
+	TakeProfit   float64       // 진입가 대비 비율 (0이면 사용 안 함)
+	PositionSize float64       // 자본 대비 비율 (0-1)
+
+	entry, exit indicators.Series
+}
+
+// NewExprStrategy 조건식을 컴파일해 전략 생성 (exit는 비워도 됨)
+func NewExprStrategy(entry, exit string, resolver expr.Resolver) (*ExprStrategy, error) {
+	s := &ExprStrategy{PositionSize: 1}
+	var err error
+	if s.Entry, err = compileCondition("entry", entry, resolver); err != nil {
+		return nil, err
+	}
+	if exit != "" {
+		if s.Exit, err = compileCondition("exit", exit, resolver); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func compileCondition(role, src string, resolver expr.Resolver) (*expr.Program, error) {
+	program, err := expr.Compile(src, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", role, err)
+	}
+	if program.Type != expr.TypeBool {
+		return nil, fmt.Errorf("%s must be a condition, got %s", role, program.Type)
+	}
+	return program, nil
+}
+
+// Prepare 전체 구간의 조건을 한 번에 계산 (지표는 과거 값만 사용하므로 결과는 동일)
+func (s *ExprStrategy) Prepare(data []MarketData) {
+	candles := toOHLCV(data)
+	s.entry = s.Entry.Eval(candles)
+	s.exit = nil
+	if s.Exit != nil {
+		s.exit = s.Exit.Eval(candles)
+	}
+}
+
+// GenerateSignal 마지막 캔들에서 청산 조건이 참이면 SELL, 진입 조건이 참이면 BUY
+func (s *ExprStrategy) GenerateSignal(data []MarketData) Signal {
+	i := len(data) - 1
+	entry, exit := s.entry, s.exit
+	if len(entry) < len(data) {
+		// Prepare 없이 호출되면 현재까지의 데이터로 계산
+		candles := toOHLCV(data)
+		entry = s.Entry.Eval(candles)
+		if s.Exit != nil {
+			exit = s.Exit.Eval(candles)
+		}
+	}
+
+	if exit != nil && exit[i] == 1 {
+		return Signal{Action: "SELL", Confidence: 1}
+	}
+	if entry[i] != 1 {
+		return Signal{Action: "HOLD"}
+	}
+
+	price := data[i].Close
+	signal := Signal{
+		Action:       "BUY",
+		Confidence:   1,
+		TakeProfit:   math.Inf(1),
+		PositionSize: s.PositionSize,
+		Leverage:     1,
+	}
+	if s.StopLoss > 0 {
+		signal.StopLoss = price * (1 - s.StopLoss)
+	}
+	if s.TakeProfit > 0 {
+		signal.TakeProfit = price * (1 + s.TakeProfit)
+	}
+	return signal
+}
+
+// toOHLCV 백테스트 데이터를 지표 입력 형식으로 변환
+func toOHLCV(data []MarketData) indicators.OHLCV {
+	bars := make([]indicators.Bar, len(data))
+	for i, d := range data {
+		bars[i] = indicators.Bar{
+			Time:   d.Time.UnixMilli(),
+			Open:   d.Open,
+			High:   d.High,
+			Low:    d.Low,
+			Close:  d.Close,
+			Volume: d.Volume,
+		}
+	}
+	return indicators.FromBars(bars)
+}
+
+// FromOHLCV 지표 입력 형식의 캔들을 백테스트 데이터로 변환
+func FromOHLCV(symbol string, o indicators.OHLCV) []MarketData {
+	data := make([]MarketData, o.Len())
+	for i := range data {
+		data[i] = MarketData{
+			Symbol: symbol,
+			Time:   time.UnixMilli(o.Time[i]),
+			Open:   o.Open[i],
+			High:   o.High[i],
+			Low:    o.Low[i],
+			Close:  o.Close[i],
+			Volume: o.Volume[i],
+		}
+	}
+	return data
+}
+
+// FromKlines 품질 검사를 거친 캔들을 백테스트 데이터로 변환 (의심 캔들은 Suspect로 표시)
+func FromKlines(symbol string, klines []market.Kline) []MarketData {
+	data := make([]MarketData, len(klines))
+	for i, k := range klines {
+		data[i] = MarketData{
+			Symbol:  symbol,
+			Time:    time.UnixMilli(k.OpenTime),
+			Open:    k.Open,
+			High:    k.High,
+			Low:     k.Low,
+			Close:   k.Close,
+			Volume:  k.Volume,
+			Suspect: k.IsSuspect(),
+		}
+	}
+	return data
+}
@@ -0,0 +1,449 @@
+package expr
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+// Type 수식 값의 타입
+type Type int
+
+const (
+	// TypeNumber 상수 숫자 (기간, 배수 등)
+	TypeNumber Type = iota
+	// TypeSeries 캔들마다 값이 있는 숫자 시계열
+	TypeSeries
+	// TypeBool 캔들마다 참/거짓인 조건 시계열
+	TypeBool
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeNumber:
+		return "number"
+	case TypeSeries:
+		return "series"
+	case TypeBool:
+		return "bool"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 타입 이름으로 직렬화
+func (t Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// Resolver 저장된 정의 이름을 수식 원문으로 변환
+type Resolver interface {
+	Resolve(name string) (string, bool)
+}
+
+// Program 타입 검사를 마친 실행 가능한 수식
+type Program struct {
+	Source   string   `json:"source"`
+	Type     Type     `json:"type"`
+	Lookback int      `json:"lookback"` // 값이 안정되기까지 필요한 대략적인 캔들 수
+	Refs     []string `json:"refs,omitempty"`
+
+	root *compiled
+}
+
+// env 평가 대상 캔들
+type env struct {
+	o indicators.OHLCV
+	n int
+}
+
+// compiled 타입이 결정된 노드 (숫자 타입은 항상 상수로 접힌다)
+type compiled struct {
+	typ      Type
+	num      float64
+	lookback int
+	eval     func(e *env) indicators.Series
+}
+
+// series 숫자 상수는 상수 시계열로 확장
+func (c *compiled) series(e *env) indicators.Series {
+	if c.typ == TypeNumber {
+		out := make(indicators.Series, e.n)
+		for i := range out {
+			out[i] = c.num
+		}
+		return out
+	}
+	return c.eval(e)
+}
+
+// Compile 수식을 파싱하고 타입 검사 (resolver가 nil이면 저장된 정의를 참조할 수 없음)
+func Compile(src string, resolver Resolver) (*Program, error) {
+	return compileNamed("", src, resolver)
+}
+
+// compileNamed 정의 name의 본문으로 컴파일 (자기 자신을 참조하면 순환 오류)
+func compileNamed(name, src string, resolver Resolver) (*Program, error) {
+	tree, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{resolver: resolver, refs: make(map[string]bool)}
+	if name != "" {
+		c.stack, c.named = []string{name}, true
+	}
+	root, err := c.compile(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0, len(c.refs))
+	for name := range c.refs {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	return &Program{Source: src, Type: root.typ, Lookback: root.lookback, Refs: refs, root: root}, nil
+}
+
+// Eval 캔들 위에서 수식을 평가 (입력과 같은 길이, 조건은 1/0, 정의되지 않으면 NaN)
+func (p *Program) Eval(o indicators.OHLCV) indicators.Series {
+	return p.root.series(&env{o: o, n: o.Len()})
+}
+
+type compiler struct {
+	resolver Resolver
+	stack    []string // 순환 참조 검사용 정의 이름 스택
+	named    bool     // stack[0]이 컴파일 중인 정의 자신
+	refs     map[string]bool
+}
+
+// base 최상위 수식에서의 스택 깊이
+func (c *compiler) base() int {
+	if c.named {
+		return 1
+	}
+	return 0
+}
+
+func (c *compiler) compile(n node) (*compiled, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		return &compiled{typ: TypeNumber, num: n.value}, nil
+	case *boolNode:
+		v := 0.0
+		if n.value {
+			v = 1
+		}
+		return &compiled{typ: TypeBool, eval: func(e *env) indicators.Series { return constant(e.n, v) }}, nil
+	case *identNode:
+		return c.ident(n)
+	case *unaryNode:
+		return c.unary(n)
+	case *binaryNode:
+		return c.binary(n)
+	case *callNode:
+		return c.call(n)
+	}
+	return nil, errorf(n.position(), "unsupported expression")
+}
+
+// ident 가격 시계열 또는 저장된 정의 참조
+func (c *compiler) ident(n *identNode) (*compiled, error) {
+	if source, ok := priceSeries[n.name]; ok {
+		return &compiled{typ: TypeSeries, eval: source}, nil
+	}
+	if _, ok := functions[n.name]; ok {
+		return nil, errorf(n.pos, "%s is a function; call it as %s(...)", n.name, n.name)
+	}
+
+	if c.resolver == nil {
+		return nil, errorf(n.pos, "unknown identifier %q", n.name)
+	}
+	src, ok := c.resolver.Resolve(n.name)
+	if !ok {
+		return nil, errorf(n.pos, "unknown identifier %q", n.name)
+	}
+	for _, name := range c.stack {
+		if name == n.name {
+			return nil, errorf(n.pos, "circular reference: %s -> %s", strings.Join(c.stack, " -> "), n.name)
+		}
+	}
+
+	tree, err := parse(src)
+	if err != nil {
+		return nil, errorf(n.pos, "definition %s: %v", n.name, err)
+	}
+	c.stack = append(c.stack, n.name)
+	def, err := c.compile(tree)
+	c.stack = c.stack[:len(c.stack)-1]
+	if err != nil {
+		if e, ok := err.(*Error); ok && len(c.stack) == c.base() {
+			return nil, errorf(n.pos, "definition %s: %s", n.name, e.Msg)
+		}
+		return nil, err
+	}
+	c.refs[n.name] = true
+	return def, nil
+}
+
+func (c *compiler) unary(n *unaryNode) (*compiled, error) {
+	operand, err := c.compile(n.operand)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "not" {
+		if operand.typ != TypeBool {
+			return nil, errorf(n.pos, "not expects a condition, got %s", operand.typ)
+		}
+		return &compiled{typ: TypeBool, lookback: operand.lookback, eval: func(e *env) indicators.Series {
+			return mapSeries(operand.eval(e), func(v float64) float64 { return 1 - v })
+		}}, nil
+	}
+
+	if operand.typ == TypeBool {
+		return nil, errorf(n.pos, "cannot negate a condition")
+	}
+	if operand.typ == TypeNumber {
+		return &compiled{typ: TypeNumber, num: -operand.num}, nil
+	}
+	return &compiled{typ: TypeSeries, lookback: operand.lookback, eval: func(e *env) indicators.Series {
+		return mapSeries(operand.eval(e), func(v float64) float64 { return -v })
+	}}, nil
+}
+
+func (c *compiler) binary(n *binaryNode) (*compiled, error) {
+	left, err := c.compile(n.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.compile(n.right)
+	if err != nil {
+		return nil, err
+	}
+	lookback := maxInt(left.lookback, right.lookback)
+
+	switch n.op {
+	case "and", "or":
+		if left.typ != TypeBool || right.typ != TypeBool {
+			return nil, errorf(n.pos, "%s expects conditions on both sides, got %s and %s", n.op, left.typ, right.typ)
+		}
+		logic := andValue
+		if n.op == "or" {
+			logic = orValue
+		}
+		return &compiled{typ: TypeBool, lookback: lookback, eval: func(e *env) indicators.Series {
+			return zipSeries(left.eval(e), right.eval(e), logic)
+		}}, nil
+
+	case "<", "<=", ">", ">=", "==", "!=":
+		if left.typ == TypeBool || right.typ == TypeBool {
+			return nil, errorf(n.pos, "cannot compare conditions with %s", n.op)
+		}
+		cmp := comparisons[n.op]
+		return &compiled{typ: TypeBool, lookback: lookback, eval: func(e *env) indicators.Series {
+			return zipSeries(left.series(e), right.series(e), func(a, b float64) float64 {
+				if math.IsNaN(a) || math.IsNaN(b) {
+					return math.NaN()
+				}
+				if cmp(a, b) {
+					return 1
+				}
+				return 0
+			})
+		}}, nil
+	}
+
+	// 산술 연산
+	if left.typ == TypeBool || right.typ == TypeBool {
+		return nil, errorf(n.pos, "cannot use %s on a condition", n.op)
+	}
+	arith := arithmetic[n.op]
+	if left.typ == TypeNumber && right.typ == TypeNumber {
+		return &compiled{typ: TypeNumber, num: arith(left.num, right.num)}, nil
+	}
+	return &compiled{typ: TypeSeries, lookback: lookback, eval: func(e *env) indicators.Series {
+		return zipSeries(left.series(e), right.series(e), arith)
+	}}, nil
+}
+
+func (c *compiler) call(n *callNode) (*compiled, error) {
+	if fn, ok := mathFunctions[n.name]; ok {
+		return c.mathCall(n, fn)
+	}
+	fn, ok := functions[n.name]
+	if !ok {
+		return nil, errorf(n.pos, "unknown function %q", n.name)
+	}
+	if len(n.args) != len(fn.args) {
+		return nil, errorf(n.pos, "%s expects %d arguments (%s), got %d", n.name, len(fn.args), fn.signature(n.name), len(n.args))
+	}
+
+	args := make([]*compiled, len(n.args))
+	params := make([]float64, len(n.args))
+	lookback := 0
+	for i, argNode := range n.args {
+		arg, err := c.compile(argNode)
+		if err != nil {
+			return nil, err
+		}
+		switch kind := fn.args[i]; kind {
+		case argSeries:
+			if arg.typ == TypeBool {
+				return nil, errorf(argNode.position(), "%s argument %d must be a series, got a condition", n.name, i+1)
+			}
+		case argCondition:
+			if arg.typ != TypeBool {
+				return nil, errorf(argNode.position(), "%s argument %d must be a condition, got %s", n.name, i+1, arg.typ)
+			}
+		case argPeriod, argNumber:
+			if arg.typ != TypeNumber {
+				return nil, errorf(argNode.position(), "%s argument %d must be a constant number, got %s", n.name, i+1, arg.typ)
+			}
+			if kind == argPeriod && (arg.num < 1 || arg.num != math.Trunc(arg.num) || arg.num > maxPeriod) {
+				return nil, errorf(argNode.position(), "%s argument %d must be a whole period between 1 and %d", n.name, i+1, maxPeriod)
+			}
+			if kind == argNumber && (arg.num <= 0 || math.IsInf(arg.num, 0)) {
+				return nil, errorf(argNode.position(), "%s argument %d must be positive", n.name, i+1)
+			}
+			params[i] = arg.num
+		}
+		args[i] = arg
+		lookback = maxInt(lookback, arg.lookback)
+	}
+	if fn.lookback != nil {
+		lookback += fn.lookback(params)
+	}
+
+	apply := fn.apply
+	return &compiled{typ: fn.result, lookback: lookback, eval: func(e *env) indicators.Series {
+		values := make([]indicators.Series, len(args))
+		for i, arg := range args {
+			if fn.args[i] == argSeries || fn.args[i] == argCondition {
+				values[i] = arg.series(e)
+			}
+		}
+		return apply(e, values, params)
+	}}, nil
+}
+
+// mathCall 원소별 수학 함수 (모든 인자가 상수면 상수로 접힘)
+func (c *compiler) mathCall(n *callNode, fn mathFunction) (*compiled, error) {
+	if len(n.args) != fn.arity {
+		return nil, errorf(n.pos, "%s expects %d arguments, got %d", n.name, fn.arity, len(n.args))
+	}
+	args := make([]*compiled, len(n.args))
+	allConst := true
+	lookback := 0
+	for i, argNode := range n.args {
+		arg, err := c.compile(argNode)
+		if err != nil {
+			return nil, err
+		}
+		if arg.typ == TypeBool {
+			return nil, errorf(argNode.position(), "%s argument %d must be a number or series, got a condition", n.name, i+1)
+		}
+		allConst = allConst && arg.typ == TypeNumber
+		lookback = maxInt(lookback, arg.lookback)
+		args[i] = arg
+	}
+
+	if allConst {
+		nums := make([]float64, len(args))
+		for i, arg := range args {
+			nums[i] = arg.num
+		}
+		return &compiled{typ: TypeNumber, num: fn.apply(nums)}, nil
+	}
+	return &compiled{typ: TypeSeries, lookback: lookback, eval: func(e *env) indicators.Series {
+		inputs := make([]indicators.Series, len(args))
+		for i, arg := range args {
+			inputs[i] = arg.series(e)
+		}
+		out := make(indicators.Series, e.n)
+		nums := make([]float64, len(args))
+		for i := range out {
+			for j := range inputs {
+				nums[j] = inputs[j][i]
+			}
+			out[i] = fn.apply(nums)
+		}
+		return out
+	}}, nil
+}
+
+var comparisons = map[string]func(a, b float64) bool{
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+var arithmetic = map[string]func(a, b float64) float64{
+	"+": func(a, b float64) float64 { return a + b },
+	"-": func(a, b float64) float64 { return a - b },
+	"*": func(a, b float64) float64 { return a * b },
+	"/": func(a, b float64) float64 {
+		if b == 0 {
+			return math.NaN()
+		}
+		return a / b
+	},
+}
+
+// andValue/orValue 3값 논리 (NaN = 알 수 없음)
+func andValue(a, b float64) float64 {
+	switch {
+	case a == 0 || b == 0:
+		return 0
+	case math.IsNaN(a) || math.IsNaN(b):
+		return math.NaN()
+	}
+	return 1
+}
+
+func orValue(a, b float64) float64 {
+	switch {
+	case a == 1 || b == 1:
+		return 1
+	case math.IsNaN(a) || math.IsNaN(b):
+		return math.NaN()
+	}
+	return 0
+}
+
+func constant(n int, v float64) indicators.Series {
+	out := make(indicators.Series, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func mapSeries(s indicators.Series, fn func(v float64) float64) indicators.Series {
+	out := make(indicators.Series, len(s))
+	for i, v := range s {
+		out[i] = fn(v)
+	}
+	return out
+}
+
+func zipSeries(a, b indicators.Series, fn func(a, b float64) float64) indicators.Series {
+	out := make(indicators.Series, len(a))
+	for i := range out {
+		out[i] = fn(a[i], b[i])
+	}
+	return out
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
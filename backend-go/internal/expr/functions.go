@@ -0,0 +1,373 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+// maxPeriod 기간 인자 상한
+const maxPeriod = 1000
+
+// priceSeries 수식에서 바로 쓸 수 있는 가격 시계열
+var priceSeries = map[string]func(e *env) indicators.Series{
+	"open":   func(e *env) indicators.Series { return e.o.Open },
+	"high":   func(e *env) indicators.Series { return e.o.High },
+	"low":    func(e *env) indicators.Series { return e.o.Low },
+	"close":  func(e *env) indicators.Series { return e.o.Close },
+	"volume": func(e *env) indicators.Series { return e.o.Volume },
+	"hl2":    func(e *env) indicators.Series { return e.o.Median() },
+	"hlc3":   func(e *env) indicators.Series { return e.o.Typical() },
+	"ohlc4": func(e *env) indicators.Series {
+		out := make(indicators.Series, e.n)
+		for i := range out {
+			out[i] = (e.o.Open[i] + e.o.High[i] + e.o.Low[i] + e.o.Close[i]) / 4
+		}
+		return out
+	},
+}
+
+// argKind 함수 인자 종류
+type argKind int
+
+const (
+	argSeries    argKind = iota // 시계열 (상수는 상수 시계열로 확장)
+	argCondition                // 조건 시계열
+	argPeriod                   // 상수 정수 기간
+	argNumber                   // 상수 양수 (배수, 가속도 등)
+)
+
+var argNames = map[argKind]string{
+	argSeries:    "series",
+	argCondition: "condition",
+	argPeriod:    "period",
+	argNumber:    "number",
+}
+
+// function 지표 함수 정의
+// apply는 시계열/조건 인자 자리에 평가된 시계열을, 상수 인자 자리에 params 값을 받는다
+type function struct {
+	args     []argKind
+	result   Type
+	lookback func(params []float64) int
+	apply    func(e *env, series []indicators.Series, params []float64) indicators.Series
+}
+
+func (f function) signature(name string) string {
+	parts := make([]string, len(f.args))
+	for i, kind := range f.args {
+		parts[i] = argNames[kind]
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(parts, ", "))
+}
+
+func period(i int) func(params []float64) int {
+	return func(params []float64) int { return int(params[i]) }
+}
+
+// smoothedPeriod EMA/RMA 계열은 기간의 3배 정도 지나야 초기값 영향이 사라진다
+func smoothedPeriod(i int) func(params []float64) int {
+	return func(params []float64) int { return int(params[i]) * 3 }
+}
+
+func seriesFn(fn func(src []float64, n int) indicators.Series, lookback func([]float64) int) function {
+	return function{
+		args:     []argKind{argSeries, argPeriod},
+		result:   TypeSeries,
+		lookback: lookback,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return fn(s[0], int(p[1]))
+		},
+	}
+}
+
+func hlcFn(fn func(high, low, close []float64, n int) indicators.Series, lookback func([]float64) int) function {
+	return function{
+		args:     []argKind{argPeriod},
+		result:   TypeSeries,
+		lookback: lookback,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return fn(e.o.High, e.o.Low, e.o.Close, int(p[0]))
+		},
+	}
+}
+
+var functions = map[string]function{
+	"sma":     seriesFn(indicators.SMA, period(1)),
+	"ema":     seriesFn(indicators.EMA, smoothedPeriod(1)),
+	"rma":     seriesFn(indicators.RMA, smoothedPeriod(1)),
+	"rsi":     seriesFn(indicators.RSI, smoothedPeriod(1)),
+	"stdev":   seriesFn(indicators.StdDev, period(1)),
+	"highest": seriesFn(indicators.Highest, period(1)),
+	"lowest":  seriesFn(indicators.Lowest, period(1)),
+	"roc":     seriesFn(indicators.ROC, period(1)),
+	"sum": seriesFn(func(src []float64, n int) indicators.Series {
+		return mapSeries(indicators.SMA(src, n), func(v float64) float64 { return v * float64(n) })
+	}, period(1)),
+	"change": seriesFn(func(src []float64, n int) indicators.Series {
+		out := shift(src, n)
+		for i := range out {
+			out[i] = src[i] - out[i]
+		}
+		return out
+	}, period(1)),
+	"prev": seriesFn(func(src []float64, n int) indicators.Series { return shift(src, n) }, period(1)),
+
+	"macd": {
+		args:     []argKind{argSeries, argPeriod, argPeriod},
+		result:   TypeSeries,
+		lookback: smoothedPeriod(2),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			macd, _, _ := indicators.MACD(s[0], int(p[1]), int(p[2]), 1)
+			return macd
+		},
+	},
+	"macd_signal": macdFn(func(_, signal, _ indicators.Series) indicators.Series { return signal }),
+	"macd_hist":   macdFn(func(_, _, hist indicators.Series) indicators.Series { return hist }),
+
+	"bb_upper":  bandFn(func(upper, _, _ indicators.Series) indicators.Series { return upper }),
+	"bb_middle": bandFn(func(_, middle, _ indicators.Series) indicators.Series { return middle }),
+	"bb_lower":  bandFn(func(_, _, lower indicators.Series) indicators.Series { return lower }),
+	"percent_b": {
+		args:     []argKind{argSeries, argPeriod, argNumber},
+		result:   TypeSeries,
+		lookback: period(1),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.PercentB(s[0], int(p[1]), p[2])
+		},
+	},
+
+	"atr":   hlcFn(indicators.ATR, smoothedPeriod(0)),
+	"cci":   hlcFn(indicators.CCI, period(0)),
+	"willr": hlcFn(indicators.WilliamsR, period(0)),
+	"adx": hlcFn(func(high, low, close []float64, n int) indicators.Series {
+		adx, _, _ := indicators.ADX(high, low, close, n)
+		return adx
+	}, func(p []float64) int { return int(p[0]) * 6 }),
+	"plus_di": hlcFn(func(high, low, close []float64, n int) indicators.Series {
+		_, plus, _ := indicators.ADX(high, low, close, n)
+		return plus
+	}, smoothedPeriod(0)),
+	"minus_di": hlcFn(func(high, low, close []float64, n int) indicators.Series {
+		_, _, minus := indicators.ADX(high, low, close, n)
+		return minus
+	}, smoothedPeriod(0)),
+	"mfi": {
+		args:     []argKind{argPeriod},
+		result:   TypeSeries,
+		lookback: period(0),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.MFI(e.o.High, e.o.Low, e.o.Close, e.o.Volume, int(p[0]))
+		},
+	},
+	"cmf": {
+		args:     []argKind{argPeriod},
+		result:   TypeSeries,
+		lookback: period(0),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.CMF(e.o.High, e.o.Low, e.o.Close, e.o.Volume, int(p[0]))
+		},
+	},
+	"stoch_k": {
+		args:     []argKind{argPeriod, argPeriod},
+		result:   TypeSeries,
+		lookback: func(p []float64) int { return int(p[0] + p[1]) },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			k, _ := indicators.Stochastic(e.o.High, e.o.Low, e.o.Close, int(p[0]), int(p[1]), 1)
+			return k
+		},
+	},
+	"stoch_d": {
+		args:     []argKind{argPeriod, argPeriod, argPeriod},
+		result:   TypeSeries,
+		lookback: func(p []float64) int { return int(p[0] + p[1] + p[2]) },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			_, d := indicators.Stochastic(e.o.High, e.o.Low, e.o.Close, int(p[0]), int(p[1]), int(p[2]))
+			return d
+		},
+	},
+	"obv": {
+		result: TypeSeries,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.OBV(e.o.Close, e.o.Volume)
+		},
+	},
+	"vwap": {
+		result: TypeSeries,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.VWAP(e.o)
+		},
+	},
+	"true_range": {
+		result: TypeSeries,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.TrueRangeSeries(e.o.High, e.o.Low, e.o.Close)
+		},
+	},
+	"supertrend": {
+		args:     []argKind{argPeriod, argNumber},
+		result:   TypeSeries,
+		lookback: smoothedPeriod(0),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			line, _ := indicators.Supertrend(e.o.High, e.o.Low, e.o.Close, int(p[0]), p[1])
+			return line
+		},
+	},
+	"psar": {
+		args:     []argKind{argNumber, argNumber},
+		result:   TypeSeries,
+		lookback: func(p []float64) int { return 50 },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return indicators.ParabolicSAR(e.o.High, e.o.Low, p[0], p[1])
+		},
+	},
+
+	"crossover": {
+		args:     []argKind{argSeries, argSeries},
+		result:   TypeBool,
+		lookback: func(p []float64) int { return 1 },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return cross(s[0], s[1])
+		},
+	},
+	"crossunder": {
+		args:     []argKind{argSeries, argSeries},
+		result:   TypeBool,
+		lookback: func(p []float64) int { return 1 },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return cross(s[1], s[0])
+		},
+	},
+	"iff": {
+		args:   []argKind{argCondition, argSeries, argSeries},
+		result: TypeSeries,
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			out := make(indicators.Series, e.n)
+			for i := range out {
+				switch s[0][i] {
+				case 1:
+					out[i] = s[1][i]
+				case 0:
+					out[i] = s[2][i]
+				default:
+					out[i] = math.NaN()
+				}
+			}
+			return out
+		},
+	},
+}
+
+func macdFn(pick func(macd, signal, hist indicators.Series) indicators.Series) function {
+	return function{
+		args:     []argKind{argSeries, argPeriod, argPeriod, argPeriod},
+		result:   TypeSeries,
+		lookback: func(p []float64) int { return int(p[2]+p[3]) * 3 },
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return pick(indicators.MACD(s[0], int(p[1]), int(p[2]), int(p[3])))
+		},
+	}
+}
+
+func bandFn(pick func(upper, middle, lower indicators.Series) indicators.Series) function {
+	return function{
+		args:     []argKind{argSeries, argPeriod, argNumber},
+		result:   TypeSeries,
+		lookback: period(1),
+		apply: func(e *env, s []indicators.Series, p []float64) indicators.Series {
+			return pick(indicators.Bollinger(s[0], int(p[1]), p[2]))
+		},
+	}
+}
+
+// shift n캔들 전 값 (앞부분은 NaN)
+func shift(src []float64, n int) indicators.Series {
+	out := make(indicators.Series, len(src))
+	for i := range out {
+		if i >= n {
+			out[i] = src[i-n]
+		} else {
+			out[i] = math.NaN()
+		}
+	}
+	return out
+}
+
+// cross a가 b를 아래에서 위로 뚫은 캔들이면 1
+func cross(a, b indicators.Series) indicators.Series {
+	out := make(indicators.Series, len(a))
+	for i := range out {
+		if i == 0 || math.IsNaN(a[i]) || math.IsNaN(b[i]) || math.IsNaN(a[i-1]) || math.IsNaN(b[i-1]) {
+			out[i] = math.NaN()
+			continue
+		}
+		if a[i] > b[i] && a[i-1] <= b[i-1] {
+			out[i] = 1
+		}
+	}
+	return out
+}
+
+// mathFunction 원소별 수학 함수
+type mathFunction struct {
+	arity int
+	apply func(args []float64) float64
+}
+
+var mathFunctions = map[string]mathFunction{
+	"abs":  {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt": {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"log":  {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"min":  {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":  {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+	"pow":  {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+}
+
+// FunctionInfo 함수 목록 응답 항목
+type FunctionInfo struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Returns   Type   `json:"returns"`
+}
+
+// Functions 사용 가능한 함수와 가격 시계열 목록
+func Functions() (funcs []FunctionInfo, series []string) {
+	for name, fn := range functions {
+		funcs = append(funcs, FunctionInfo{Name: name, Signature: fn.signature(name), Returns: fn.result})
+	}
+	for name, fn := range mathFunctions {
+		args := make([]string, fn.arity)
+		for i := range args {
+			args[i] = "series"
+		}
+		funcs = append(funcs, FunctionInfo{Name: name, Signature: fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), Returns: TypeSeries})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+
+	for name := range priceSeries {
+		series = append(series, name)
+	}
+	sort.Strings(series)
+	return funcs, series
+}
+
+// reserved 정의 이름으로 쓸 수 없는 이름
+func reserved(name string) bool {
+	if _, ok := priceSeries[name]; ok {
+		return true
+	}
+	if _, ok := functions[name]; ok {
+		return true
+	}
+	if _, ok := mathFunctions[name]; ok {
+		return true
+	}
+	switch name {
+	case "and", "or", "not", "true", "false":
+		return true
+	}
+	return false
+}
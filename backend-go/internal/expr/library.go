@@ -0,0 +1,227 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/sirupsen/logrus"
+)
+
+// libraryKey 저장된 정의의 Redis 키
+const libraryKey = "expr:definitions"
+
+var (
+	// ErrDefinitionNotFound 저장된 정의가 없음
+	ErrDefinitionNotFound = errors.New("definition not found")
+	// ErrDefinitionInUse 다른 정의가 참조 중이라 삭제할 수 없음
+	ErrDefinitionInUse = errors.New("definition is used by other definitions")
+
+	definitionName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,63}$`)
+)
+
+// Definition 사용자가 저장한 이름 있는 수식
+type Definition struct {
+	Name        string    `json:"name"`
+	Expression  string    `json:"expression"`
+	Description string    `json:"description,omitempty"`
+	Type        Type      `json:"type"`
+	Refs        []string  `json:"refs,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// definitionRecord Redis 저장 형식 (Type은 이름 문자열로 직렬화되므로 제외하고 재컴파일로 복원)
+type definitionRecord struct {
+	Name        string    `json:"name"`
+	Expression  string    `json:"expression"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Library 저장된 정의 모음 (메모리 + Redis)
+type Library struct {
+	mu   sync.RWMutex
+	defs map[string]Definition
+}
+
+var (
+	library     *Library
+	libraryOnce sync.Once
+)
+
+// GetLibrary 싱글톤 정의 라이브러리 (최초 호출 시 Redis에서 로드)
+func GetLibrary() *Library {
+	libraryOnce.Do(func() {
+		library = &Library{defs: make(map[string]Definition)}
+		library.load()
+	})
+	return library
+}
+
+// Resolve Resolver 구현
+func (l *Library) Resolve(name string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	def, ok := l.defs[name]
+	return def.Expression, ok
+}
+
+// Compile 저장된 정의를 참조할 수 있는 수식 컴파일
+func (l *Library) Compile(src string) (*Program, error) {
+	return Compile(src, l)
+}
+
+// Get 정의 조회
+func (l *Library) Get(name string) (Definition, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	def, ok := l.defs[strings.ToLower(name)]
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %s", ErrDefinitionNotFound, name)
+	}
+	return def, nil
+}
+
+// List 이름순 정의 목록
+func (l *Library) List() []Definition {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	defs := make([]Definition, 0, len(l.defs))
+	for _, def := range l.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Save 정의를 검사 후 저장 (기존 정의를 바꾸면 이를 참조하는 정의도 다시 검사)
+func (l *Library) Save(name, expression, description string) (Definition, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !definitionName.MatchString(name) {
+		return Definition{}, fmt.Errorf("invalid name %q: use lowercase letters, digits and _", name)
+	}
+	if reserved(name) {
+		return Definition{}, fmt.Errorf("%q is a built-in name", name)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overlay := overlayResolver{defs: l.defs, name: name, expression: expression}
+	program, err := compileNamed(name, expression, overlay)
+	if err != nil {
+		return Definition{}, err
+	}
+	// 새 본문으로 나머지 정의가 여전히 유효한지 확인
+	for other, def := range l.defs {
+		if other == name {
+			continue
+		}
+		if _, err := compileNamed(other, def.Expression, overlay); err != nil {
+			return Definition{}, fmt.Errorf("change breaks definition %s: %v", other, err)
+		}
+	}
+
+	def := Definition{
+		Name:        name,
+		Expression:  expression,
+		Description: description,
+		Type:        program.Type,
+		Refs:        program.Refs,
+		UpdatedAt:   time.Now(),
+	}
+	l.defs[name] = def
+	l.persist()
+	return def, nil
+}
+
+// Delete 정의 삭제 (다른 정의가 참조하면 실패)
+func (l *Library) Delete(name string) error {
+	name = strings.ToLower(name)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.defs[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrDefinitionNotFound, name)
+	}
+	for other, def := range l.defs {
+		for _, ref := range def.Refs {
+			if ref == name {
+				return fmt.Errorf("%w: %s uses %s", ErrDefinitionInUse, other, name)
+			}
+		}
+	}
+	delete(l.defs, name)
+	l.persist()
+	return nil
+}
+
+// overlayResolver 저장 전 검사용 (name은 새 본문으로 해석)
+type overlayResolver struct {
+	defs       map[string]Definition
+	name       string
+	expression string
+}
+
+func (o overlayResolver) Resolve(name string) (string, bool) {
+	if name == o.name {
+		return o.expression, true
+	}
+	def, ok := o.defs[name]
+	return def.Expression, ok
+}
+
+// persist Redis에 전체 정의 저장 (호출자가 잠금 보유)
+func (l *Library) persist() {
+	redis := database.GetRedis()
+	if redis == nil {
+		return
+	}
+	records := make([]definitionRecord, 0, len(l.defs))
+	for _, def := range l.defs {
+		records = append(records, definitionRecord{
+			Name:        def.Name,
+			Expression:  def.Expression,
+			Description: def.Description,
+			UpdatedAt:   def.UpdatedAt,
+		})
+	}
+	if err := redis.Set(libraryKey, records, 0); err != nil {
+		logrus.Warnf("Failed to persist expression definitions: %v", err)
+	}
+}
+
+// load Redis에서 정의를 읽고 타입/참조 정보를 재계산
+func (l *Library) load() {
+	redis := database.GetRedis()
+	if redis == nil {
+		return
+	}
+	var records []definitionRecord
+	if err := redis.Get(libraryKey, &records); err != nil {
+		return
+	}
+	for _, r := range records {
+		l.defs[r.Name] = Definition{Name: r.Name, Expression: r.Expression, Description: r.Description, UpdatedAt: r.UpdatedAt}
+	}
+	for name, def := range l.defs {
+		program, err := compileNamed(name, def.Expression, l.unlockedResolver())
+		if err != nil {
+			logrus.Warnf("Stored definition %s no longer compiles: %v", name, err)
+			continue
+		}
+		def.Type, def.Refs = program.Type, program.Refs
+		l.defs[name] = def
+	}
+	logrus.Infof("Loaded %d expression definitions", len(l.defs))
+}
+
+// unlockedResolver 잠금 없이 defs를 읽는 Resolver (load/Save 내부용)
+func (l *Library) unlockedResolver() Resolver {
+	return overlayResolver{defs: l.defs}
+}
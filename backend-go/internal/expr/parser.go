@@ -0,0 +1,307 @@
+// Package expr 사용자 정의 지표 수식 언어
+//
+// close, high 같은 OHLCV 시계열과 internal/indicators 함수를 조합한 수식
+// (예: "ema(close,12) - ema(close,26)",
+// "(close - lowest(low,14)) / (highest(high,14) - lowest(low,14))")을
+// 파싱하고 타입 검사한 뒤 캔들 시계열 위에서 평가한다.
+// 저장된 정의는 다른 수식에서 이름으로 참조할 수 있다.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Error 수식 오류 (Pos는 0부터 시작하는 문자 위치)
+type Error struct {
+	Pos int    `json:"pos"`
+	Msg string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 수식을 토큰으로 분리
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, strings.ToLower(string(runes[start:i])), start})
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		default:
+			// 두 글자 연산자 우선
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					tokens = append(tokens, token{tokOp, two, i})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune("+-*/<>!", r) {
+				tokens = append(tokens, token{tokOp, string(r), i})
+				i++
+				continue
+			}
+			return nil, errorf(i, "unexpected character %q", r)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(runes)}), nil
+}
+
+// node 파싱된 구문 트리
+type node interface {
+	position() int
+}
+
+type numberNode struct {
+	pos   int
+	value float64
+}
+
+type boolNode struct {
+	pos   int
+	value bool
+}
+
+type identNode struct {
+	pos  int
+	name string
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos         int
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	pos  int
+	name string
+	args []node
+}
+
+func (n *numberNode) position() int { return n.pos }
+func (n *boolNode) position() int   { return n.pos }
+func (n *identNode) position() int  { return n.pos }
+func (n *unaryNode) position() int  { return n.pos }
+func (n *binaryNode) position() int { return n.pos }
+func (n *callNode) position() int   { return n.pos }
+
+// 이항 연산자 우선순위 (높을수록 먼저 결합)
+var precedence = map[string]int{
+	"or": 1, "||": 1,
+	"and": 2, "&&": 2,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "==": 4, "!=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+// 단항 연산자 결합 우선순위
+const (
+	notPrecedence   = 3
+	minusPrecedence = 7
+)
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+// parse 수식을 구문 트리로 변환
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// binaryOp 다음 토큰이 이항 연산자면 정규화된 이름 반환
+func (p *parser) binaryOp() (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return "", false
+	}
+	op := tok.text
+	switch op {
+	case "&&":
+		op = "and"
+	case "||":
+		op = "or"
+	}
+	_, ok := precedence[op]
+	return op, ok
+}
+
+// expression 우선순위 등반 방식 파싱
+func (p *parser) expression(minPrec int) (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.binaryOp()
+		if !ok || precedence[op] <= minPrec {
+			return left, nil
+		}
+		tok := p.next()
+		right, err := p.expression(precedence[op])
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokOp && tok.text == "-":
+		p.next()
+		operand, err := p.expression(minusPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: "-", operand: operand}, nil
+	case tok.kind == tokOp && tok.text == "+":
+		p.next()
+		return p.expression(minusPrecedence)
+	case (tok.kind == tokOp && tok.text == "!") || (tok.kind == tokIdent && tok.text == "not"):
+		p.next()
+		operand, err := p.expression(notPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: "not", operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, errorf(tok.pos, "invalid number %q", tok.text)
+		}
+		return &numberNode{pos: tok.pos, value: v}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return &boolNode{pos: tok.pos, value: tok.text == "true"}, nil
+		case "and", "or", "not":
+			return nil, errorf(tok.pos, "unexpected %q", tok.text)
+		}
+		if p.peek().kind != tokLParen {
+			return &identNode{pos: tok.pos, name: tok.text}, nil
+		}
+		p.next()
+		call := &callNode{pos: tok.pos, name: tok.text}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.expression(0)
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				return call, nil
+			}
+			if sep.kind != tokComma {
+				return nil, errorf(sep.pos, "expected ',' or ')' in call to %s", call.name)
+			}
+		}
+	case tokLParen:
+		inner, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "expected ')'")
+		}
+		return inner, nil
+	case tokEOF:
+		return nil, errorf(tok.pos, "unexpected end of expression")
+	default:
+		return nil, errorf(tok.pos, "unexpected %q", tok.text)
+	}
+}
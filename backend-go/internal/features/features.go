@@ -0,0 +1,236 @@
+// Package features 심볼/인터벌별로 이름과 버전이 있는 피처 세트를 계산해
+// 학습용 프레임과 추론용 벡터로 제공한다. 모든 모델이 학습과 운영에서 같은
+// 피처 정의를 보도록 하기 위함이다.
+//
+// 과거 세트(technical, volume)는 마감 캔들로 계산해 전체 Frame으로 만들 수 있고,
+// 추론 벡터는 항상 같은 프레임의 마지막 행이다. 실시간 세트(orderbook, futures)는
+// 현재 시점에만 존재하므로 벡터로만 제공된다.
+//
+// 벡터의 레이아웃(Schema)은 세트 이름과 세트의 Features 순서로 고정되며,
+// 계산할 수 없는 값은 빠지지 않고 NaN 자리표시자로 남는다.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+)
+
+var (
+	// ErrUnknownSet 등록되지 않은 피처 세트 이름
+	ErrUnknownSet = errors.New("unknown feature set")
+	// ErrNoHistory 실시간 전용 세트에 프레임을 요청함
+	ErrNoHistory = errors.New("feature set has no history")
+)
+
+// Set 버전이 있는 피처 묶음
+// 피처 의미가 바뀌면 Version을 올려 캐시/저장된 데이터와 섞이지 않게 한다
+type Set struct {
+	Name     string        `json:"name"`
+	Version  int           `json:"version"`
+	Features []string      `json:"features"`
+	Live     bool          `json:"live"`
+	TTL      time.Duration `json:"ttl"` // 실시간 세트 전용 (캔들 세트는 다음 캔들 마감에 만료)
+
+	columns func(c indicators.OHLCV) map[string]indicators.Series
+	current func(symbol string) (map[string]float64, error)
+}
+
+// ID 버전을 포함한 식별자 (예: "technical@v1")
+func (s *Set) ID() string {
+	return fmt.Sprintf("%s@v%d", s.Name, s.Version)
+}
+
+var registry = make(map[string]*Set)
+
+// Register 피처 세트 등록 (같은 이름을 두 번 등록하면 panic)
+func Register(set *Set) {
+	if _, exists := registry[set.Name]; exists {
+		panic("features: duplicate set " + set.Name)
+	}
+	registry[set.Name] = set
+}
+
+// Lookup 이름으로 등록된 세트 조회
+func Lookup(name string) (*Set, error) {
+	set, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSet, name)
+	}
+	return set, nil
+}
+
+// Sets 등록된 모든 세트 (이름순)
+func Sets() []*Set {
+	sets := make([]*Set, 0, len(registry))
+	for _, set := range registry {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets
+}
+
+// Frame 캔들에 정렬된 피처 행렬
+// Rows[i]는 Time[i]에 시작한 캔들 마감 시점의 피처를 Names 순서로 담는다
+// 정의되지 않은 값(지표 워밍업 구간)은 NaN
+type Frame struct {
+	Symbol   string              `json:"symbol"`
+	Interval string              `json:"interval"`
+	Set      string              `json:"set"`
+	Version  int                 `json:"version"`
+	Names    []string            `json:"names"`
+	Time     []int64             `json:"time"`
+	Close    []float64           `json:"close"`
+	Rows     []indicators.Series `json:"rows"`
+}
+
+// BuildFrame 주어진 캔들로 과거 세트의 프레임 계산
+func BuildFrame(set *Set, candles indicators.OHLCV) (*Frame, error) {
+	if set.Live {
+		return nil, fmt.Errorf("%w: %s", ErrNoHistory, set.Name)
+	}
+
+	columns := set.columns(candles)
+	n := candles.Len()
+	frame := &Frame{
+		Set:     set.Name,
+		Version: set.Version,
+		Names:   set.Features,
+		Time:    candles.Time,
+		Close:   candles.Close,
+		Rows:    make([]indicators.Series, n),
+	}
+	for i := 0; i < n; i++ {
+		row := make(indicators.Series, len(set.Features))
+		for j, name := range set.Features {
+			row[j] = math.NaN()
+			if col := columns[name]; i < len(col) {
+				row[j] = col[i]
+			}
+		}
+		frame.Rows[i] = row
+	}
+	return frame, nil
+}
+
+// Len 행 수
+func (f *Frame) Len() int { return len(f.Rows) }
+
+// Latest 마지막 행을 벡터로 반환
+func (f *Frame) Latest() Vector {
+	v := Vector{Symbol: f.Symbol, Interval: f.Interval, Sets: map[string]int{f.Set: f.Version}, Times: map[string]int64{}}
+	if f.Len() == 0 {
+		return v
+	}
+	v.Time = f.Time[f.Len()-1]
+	v.Times[f.Set] = v.Time
+	for j, name := range f.Names {
+		v.Names = append(v.Names, f.Set+"."+name)
+		v.Values = append(v.Values, f.Rows[f.Len()-1][j])
+	}
+	return v
+}
+
+// Dataset 완전한 행과 horizon 캔들 뒤 수익률 라벨(close[i+horizon]/close[i] - 1) 반환
+// 정의되지 않은 피처가 있는 행과 마지막 horizon개 행은 제외
+func (f *Frame) Dataset(horizon int) (X [][]float64, y []float64, times []int64) {
+	if horizon < 1 {
+		horizon = 1
+	}
+	for i := 0; i+horizon < f.Len(); i++ {
+		if !complete(f.Rows[i]) || f.Close[i] == 0 {
+			continue
+		}
+		X = append(X, f.Rows[i])
+		y = append(y, f.Close[i+horizon]/f.Close[i]-1)
+		times = append(times, f.Time[i])
+	}
+	return X, y, times
+}
+
+// Vector 한 시점의 피처 값 (여러 세트를 합칠 수 있음)
+// Names는 세트 이름으로 한정된다 ("technical.rsi_14")
+// 세트마다 기준 시각이 다르므로(캔들 세트는 마지막 마감 캔들, 실시간 세트는 조회 시각)
+// Times에 세트별 시각을 두고, Time은 그중 가장 오래된 시각이다
+type Vector struct {
+	Symbol   string            `json:"symbol"`
+	Interval string            `json:"interval"`
+	Time     int64             `json:"time"`
+	Times    map[string]int64  `json:"times,omitempty"` // 세트 이름 -> 기준 시각 (값이 없는 세트는 제외)
+	Sets     map[string]int    `json:"sets"`            // 세트 이름 -> 버전
+	Names    []string          `json:"names"`
+	Values   indicators.Series `json:"values"`
+}
+
+// Get 한정된 이름으로 피처 값 조회
+func (v Vector) Get(name string) (float64, bool) {
+	for i, n := range v.Names {
+		if n == name {
+			return v.Values[i], !math.IsNaN(v.Values[i])
+		}
+	}
+	return math.NaN(), false
+}
+
+// Map 정의된 값만 한정된 이름을 키로 담은 맵 (예측기 피처 맵 형식)
+// 값이 없는 피처는 키가 빠지므로, 위치가 아니라 Schema의 이름으로 읽어야 한다
+func (v Vector) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(v.Names))
+	for i, name := range v.Names {
+		if value := v.Values[i]; !math.IsNaN(value) && !math.IsInf(value, 0) {
+			m[name] = value
+		}
+	}
+	return m
+}
+
+// merge 다른 벡터의 피처를 뒤에 붙인다 (Time은 세트 시각 중 가장 오래된 값)
+func (v *Vector) merge(other Vector) {
+	if v.Sets == nil {
+		v.Sets = make(map[string]int)
+	}
+	if v.Times == nil {
+		v.Times = make(map[string]int64)
+	}
+	for name, version := range other.Sets {
+		v.Sets[name] = version
+	}
+	for name, t := range other.Times {
+		v.Times[name] = t
+		if v.Time == 0 || t < v.Time {
+			v.Time = t
+		}
+	}
+	v.Names = append(v.Names, other.Names...)
+	v.Values = append(v.Values, other.Values...)
+}
+
+// Schema 세트들을 합친 벡터의 한정된 피처 이름 (Store.Vector와 같은 순서)
+// 모델은 이 이름으로 입력 슬롯을 고정하고, 값이 없는 슬롯은 NaN 자리표시자로 채운다
+func Schema(names ...string) ([]string, error) {
+	var schema []string
+	for _, name := range names {
+		set, err := Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, feature := range set.Features {
+			schema = append(schema, set.Name+"."+feature)
+		}
+	}
+	return schema, nil
+}
+
+func complete(row []float64) bool {
+	for _, v := range row {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package features
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+// DefaultSets 모든 예측 요청에 합쳐지는 세트 (순서가 features.Schema 레이아웃을 정함)
+var DefaultSets = []string{"technical", "volume", "orderbook", "futures"}
+
+func init() {
+	Register(&Set{
+		Name:    "technical",
+		Version: 1,
+		Features: []string{
+			"return_1", "return_24", "rsi_14", "macd_hist", "bb_percent_b", "bb_width",
+			"atr_ratio", "adx_14", "stoch_k", "cci_20", "sma_7_ratio", "sma_30_ratio",
+			"ema_12_ratio", "ema_26_ratio", "volatility_20",
+		},
+		columns: technicalColumns,
+	})
+	Register(&Set{
+		Name:     "volume",
+		Version:  1,
+		Features: []string{"volume_ratio_20", "obv_change_10", "cmf_20", "mfi_14", "vwap_deviation"},
+		columns:  volumeColumns,
+	})
+	Register(&Set{
+		Name:    "orderbook",
+		Version: 1,
+		Features: []string{
+			"spread_bps", "imbalance_5", "imbalance_20",
+			"vwap_deviation", "vwap_zscore", "poc_distance", "in_value_area", "delta_ratio_1h",
+		},
+		Live:    true,
+		TTL:     10 * time.Second,
+		current: orderbookFeatures,
+	})
+	Register(&Set{
+		Name:    "futures",
+		Version: 1,
+		Features: []string{
+			"funding_rate", "mark_index_basis", "open_interest", "open_interest_change_1h",
+			"long_short_ratio", "liquidation_long_notional_1h", "liquidation_short_notional_1h",
+			"liquidation_imbalance_1h",
+		},
+		Live:    true,
+		TTL:     time.Minute,
+		current: market.FuturesFeatures,
+	})
+}
+
+// technicalColumns 가격 기반 지표 (심볼 간 비교 가능하도록 스케일 조정)
+func technicalColumns(c indicators.OHLCV) map[string]indicators.Series {
+	close := c.Close
+	macd, signal, _ := indicators.MACD(close, 12, 26, 9)
+	upper, middle, lower := indicators.Bollinger(close, 20, 2.0)
+	adx, _, _ := indicators.ADX(c.High, c.Low, close, 14)
+	stochK, _ := indicators.Stochastic(c.High, c.Low, close, 14, 3, 3)
+	returns := indicators.ROC(close, 1)
+
+	return map[string]indicators.Series{
+		"return_1":      scale(returns, 0.01),
+		"return_24":     scale(indicators.ROC(close, 24), 0.01),
+		"rsi_14":        scale(indicators.RSI(close, 14), 0.01),
+		"macd_hist":     combine(len(close), func(i int) float64 { return (macd[i] - signal[i]) / close[i] }),
+		"bb_percent_b":  indicators.PercentB(close, 20, 2.0),
+		"bb_width":      combine(len(close), func(i int) float64 { return (upper[i] - lower[i]) / middle[i] }),
+		"atr_ratio":     ratio(indicators.ATR(c.High, c.Low, close, 14), close),
+		"adx_14":        scale(adx, 0.01),
+		"stoch_k":       scale(stochK, 0.01),
+		"cci_20":        scale(indicators.CCI(c.High, c.Low, close, 20), 0.01),
+		"sma_7_ratio":   relative(indicators.SMA(close, 7), close),
+		"sma_30_ratio":  relative(indicators.SMA(close, 30), close),
+		"ema_12_ratio":  relative(indicators.EMA(close, 12), close),
+		"ema_26_ratio":  relative(indicators.EMA(close, 26), close),
+		"volatility_20": scale(indicators.StdDev(returns, 20), 0.01),
+	}
+}
+
+// volumeColumns 거래량/자금 흐름 지표
+func volumeColumns(c indicators.OHLCV) map[string]indicators.Series {
+	obv := indicators.OBV(c.Close, c.Volume)
+	volume10 := indicators.SMA(c.Volume, 10)
+
+	return map[string]indicators.Series{
+		"volume_ratio_20": ratio(c.Volume, indicators.SMA(c.Volume, 20)),
+		"obv_change_10": combine(c.Len(), func(i int) float64 {
+			if i < 10 {
+				return math.NaN()
+			}
+			return (obv[i] - obv[i-10]) / (volume10[i] * 10)
+		}),
+		"cmf_20":         indicators.CMF(c.High, c.Low, c.Close, c.Volume, 20),
+		"mfi_14":         scale(indicators.MFI(c.High, c.Low, c.Close, c.Volume, 14), 0.01),
+		"vwap_deviation": relative(c.Close, indicators.VWAP(c)),
+	}
+}
+
+// orderbookFeatures REST 호가창의 depth 불균형과 체결 스트림의 오더플로우 피처
+func orderbookFeatures(symbol string) (map[string]float64, error) {
+	book, err := market.NewBinanceClient().GetOrderBook(symbol, 20)
+	if err != nil {
+		return nil, err
+	}
+	bids, asks := bookLevels(book["bids"]), bookLevels(book["asks"])
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil, fmt.Errorf("empty order book for %s", symbol)
+	}
+
+	values := map[string]float64{
+		"imbalance_5":  depthImbalance(bids, asks, 5),
+		"imbalance_20": depthImbalance(bids, asks, 20),
+	}
+	if mid := (bids[0][0] + asks[0][0]) / 2; mid > 0 {
+		values["spread_bps"] = (asks[0][0] - bids[0][0]) / mid * 10000
+	}
+
+	if flow, err := market.GetBarBuilder().BarFeatures(symbol); err == nil {
+		for name, value := range flow {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// bookLevels [["price","qty"], ...] 형식의 호가 레벨 파싱
+func bookLevels(raw interface{}) [][2]float64 {
+	rows, _ := raw.([]interface{})
+	levels := make([][2]float64, 0, len(rows))
+	for _, row := range rows {
+		pair, ok := row.([]interface{})
+		if !ok || len(pair) < 2 {
+			continue
+		}
+		price, perr := strconv.ParseFloat(fmt.Sprint(pair[0]), 64)
+		qty, qerr := strconv.ParseFloat(fmt.Sprint(pair[1]), 64)
+		if perr != nil || qerr != nil {
+			continue
+		}
+		levels = append(levels, [2]float64{price, qty})
+	}
+	return levels
+}
+
+// depthImbalance 상위 레벨의 (bid - ask) / (bid + ask) 수량 비율, [-1, 1]
+func depthImbalance(bids, asks [][2]float64, levels int) float64 {
+	var bid, ask float64
+	for i := 0; i < levels && i < len(bids); i++ {
+		bid += bids[i][1]
+	}
+	for i := 0; i < levels && i < len(asks); i++ {
+		ask += asks[i][1]
+	}
+	if bid+ask == 0 {
+		return 0
+	}
+	return (bid - ask) / (bid + ask)
+}
+
+func combine(n int, fn func(i int) float64) indicators.Series {
+	out := make(indicators.Series, n)
+	for i := range out {
+		out[i] = fn(i)
+	}
+	return out
+}
+
+func scale(s indicators.Series, factor float64) indicators.Series {
+	return combine(len(s), func(i int) float64 { return s[i] * factor })
+}
+
+// ratio a / b (b가 0이면 NaN)
+func ratio(a, b []float64) indicators.Series {
+	return combine(len(a), func(i int) float64 {
+		if b[i] == 0 {
+			return math.NaN()
+		}
+		return a[i] / b[i]
+	})
+}
+
+// relative a / b - 1
+func relative(a, b []float64) indicators.Series {
+	return combine(len(a), func(i int) float64 {
+		if b[i] == 0 {
+			return math.NaN()
+		}
+		return a[i]/b[i] - 1
+	})
+}
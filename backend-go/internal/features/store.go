@@ -0,0 +1,267 @@
+package features
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+// HistoryBars 프레임 계산에 쓰는 마감 캔들 수
+const HistoryBars = 500
+
+// Store 피처 세트를 요청 시 계산해 메모리와 Redis에 캐시
+// 캔들 기반 항목은 다음 캔들 마감에, 실시간 항목은 세트의 TTL 후에 만료된다
+type Store struct {
+	mu      sync.Mutex
+	candles map[string]candleEntry
+	frames  map[string]frameEntry
+	live    map[string]liveEntry
+}
+
+type candleEntry struct {
+	klines  []market.Kline
+	candles indicators.OHLCV
+	expires time.Time
+}
+
+type frameEntry struct {
+	frame   *Frame
+	expires time.Time
+}
+
+type liveEntry struct {
+	vector  Vector
+	expires time.Time
+}
+
+var (
+	store     *Store
+	storeOnce sync.Once
+)
+
+// GetStore 피처 스토어 싱글톤
+func GetStore() *Store {
+	storeOnce.Do(func() {
+		store = &Store{
+			candles: make(map[string]candleEntry),
+			frames:  make(map[string]frameEntry),
+			live:    make(map[string]liveEntry),
+		}
+	})
+	return store
+}
+
+// Candles symbol/interval의 최근 HistoryBars개 마감 캔들
+func (s *Store) Candles(symbol, interval string) (indicators.OHLCV, error) {
+	entry, err := s.candleEntry(symbol, interval)
+	if err != nil {
+		return indicators.OHLCV{}, err
+	}
+	return entry.candles, nil
+}
+
+// Klines Candles와 같은 마감 캔들을 market.CheckKlines 품질 플래그와 함께 반환
+// (의심 캔들을 건너뛰어야 하는 호출자용)
+func (s *Store) Klines(symbol, interval string) ([]market.Kline, error) {
+	entry, err := s.candleEntry(symbol, interval)
+	if err != nil {
+		return nil, err
+	}
+	return entry.klines, nil
+}
+
+func (s *Store) candleEntry(symbol, interval string) (candleEntry, error) {
+	key := symbol + ":" + interval
+	s.mu.Lock()
+	entry, ok := s.candles[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry, nil
+	}
+
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return candleEntry{}, err
+	}
+	klines, err := market.NewBinanceClient().GetKlines(symbol, interval, HistoryBars+1)
+	if err != nil {
+		return candleEntry{}, err
+	}
+	checked, _, err := market.CheckKlines(symbol, interval, klines, market.DefaultQualityConfig)
+	if err != nil {
+		return candleEntry{}, err
+	}
+
+	// 마감 캔들만 사용해 학습 행과 추론 행이 같은 방식으로 만들어지게 한다
+	now := time.Now().UnixMilli()
+	closed := make([]market.Kline, 0, len(checked))
+	bars := make([]indicators.Bar, 0, len(checked))
+	for _, k := range checked {
+		if k.OpenTime+step.Milliseconds() > now {
+			break
+		}
+		closed = append(closed, k)
+		bars = append(bars, indicators.Bar{Time: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume})
+	}
+	if len(bars) == 0 {
+		return candleEntry{}, fmt.Errorf("no closed candles for %s %s", symbol, interval)
+	}
+	entry = candleEntry{klines: closed, candles: indicators.FromBars(bars), expires: nextClose(step)}
+
+	s.mu.Lock()
+	s.candles[key] = entry
+	s.mu.Unlock()
+	return entry, nil
+}
+
+// Frame symbol/interval에 대한 과거 세트의 학습 프레임
+func (s *Store) Frame(symbol, interval, name string) (*Frame, error) {
+	set, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if set.Live {
+		return nil, fmt.Errorf("%w: %s", ErrNoHistory, set.Name)
+	}
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("features:frame:%s:%s:%s", symbol, interval, set.ID())
+	s.mu.Lock()
+	entry, ok := s.frames[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.frame, nil
+	}
+
+	redis := database.GetRedis()
+	var frame *Frame
+	if redis != nil {
+		var cached Frame
+		if err := redis.Get(key, &cached); err == nil && cached.Len() > 0 && isCurrent(cached.Time[cached.Len()-1], step) {
+			frame = &cached
+		}
+	}
+
+	if frame == nil {
+		candles, err := s.Candles(symbol, interval)
+		if err != nil {
+			return nil, err
+		}
+		if frame, err = BuildFrame(set, candles); err != nil {
+			return nil, err
+		}
+		frame.Symbol, frame.Interval = symbol, interval
+		if redis != nil {
+			if err := redis.Set(key, frame, time.Until(nextClose(step))); err != nil {
+				logrus.Debugf("Failed to cache feature frame %s: %v", key, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.frames[key] = frameEntry{frame: frame, expires: nextClose(step)}
+	s.mu.Unlock()
+	return frame, nil
+}
+
+// Vector 지정한 세트들의 현재 피처를 하나의 벡터로 합친다
+// 계산할 수 없는 세트는 NaN으로 채워 레이아웃(Schema)을 유지하고,
+// 에러는 알 수 없는 세트에만 반환한다
+func (s *Store) Vector(symbol, interval string, names ...string) (Vector, error) {
+	vector := Vector{Symbol: symbol, Interval: interval, Sets: make(map[string]int)}
+	for _, name := range names {
+		set, err := Lookup(name)
+		if err != nil {
+			return Vector{}, err
+		}
+
+		var part Vector
+		if set.Live {
+			part = s.liveVector(set, symbol)
+		} else if frame, err := s.Frame(symbol, interval, set.Name); err == nil {
+			part = frame.Latest()
+		} else {
+			logrus.Debugf("Feature set %s unavailable for %s %s: %v", set.ID(), symbol, interval, err)
+			part = emptyVector(set)
+		}
+		vector.merge(part)
+	}
+	return vector, nil
+}
+
+// liveVector 실시간 세트 조회 (세트의 TTL 동안 캐시)
+func (s *Store) liveVector(set *Set, symbol string) Vector {
+	key := fmt.Sprintf("features:live:%s:%s", symbol, set.ID())
+	s.mu.Lock()
+	entry, ok := s.live[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.vector
+	}
+
+	redis := database.GetRedis()
+	if redis != nil {
+		var cached Vector
+		if err := redis.Get(key, &cached); err == nil && len(cached.Values) == len(set.Features) {
+			s.mu.Lock()
+			s.live[key] = liveEntry{vector: cached, expires: time.Now().Add(set.TTL)}
+			s.mu.Unlock()
+			return cached
+		}
+	}
+
+	values, err := set.current(symbol)
+	if err != nil {
+		logrus.Debugf("Feature set %s unavailable for %s: %v", set.ID(), symbol, err)
+		return emptyVector(set)
+	}
+
+	vector := emptyVector(set)
+	vector.Symbol = symbol
+	vector.Time = time.Now().UnixMilli()
+	vector.Times = map[string]int64{set.Name: vector.Time}
+	for i, name := range set.Features {
+		if value, ok := values[name]; ok {
+			vector.Values[i] = value
+		}
+	}
+
+	s.mu.Lock()
+	s.live[key] = liveEntry{vector: vector, expires: time.Now().Add(set.TTL)}
+	s.mu.Unlock()
+	if redis != nil {
+		if err := redis.Set(key, vector, set.TTL); err != nil {
+			logrus.Debugf("Failed to cache live features %s: %v", key, err)
+		}
+	}
+	return vector
+}
+
+// emptyVector 모든 값이 정의되지 않은 세트 레이아웃
+func emptyVector(set *Set) Vector {
+	v := Vector{Sets: map[string]int{set.Name: set.Version}}
+	for _, name := range set.Features {
+		v.Names = append(v.Names, set.Name+"."+name)
+		v.Values = append(v.Values, math.NaN())
+	}
+	return v
+}
+
+// nextClose 현재 진행 중인 캔들의 마감 시각
+func nextClose(step time.Duration) time.Time {
+	return time.Now().Truncate(step).Add(step)
+}
+
+// isCurrent openTime에 시작한 캔들이 마지막 마감 캔들인지 여부
+func isCurrent(openTime int64, step time.Duration) bool {
+	return time.UnixMilli(openTime).Add(step).Equal(time.Now().Truncate(step))
+}
@@ -0,0 +1,162 @@
+package indicators
+
+import "math"
+
+// RSI Wilder 평활 상대강도지수 (period개의 가격 변화 이후부터 유효)
+func RSI(src []float64, period int) Series {
+	out := nanSeries(len(src))
+	if period < 1 || len(src) <= period {
+		return out
+	}
+
+	gains := make([]float64, len(src))
+	losses := make([]float64, len(src))
+	gains[0], losses[0] = math.NaN(), math.NaN()
+	for i := 1; i < len(src); i++ {
+		change := src[i] - src[i-1]
+		gains[i] = math.Max(change, 0)
+		losses[i] = math.Max(-change, 0)
+	}
+
+	avgGain := RMA(gains, period)
+	avgLoss := RMA(losses, period)
+	for i := range out {
+		if !valid(avgGain[i]) || !valid(avgLoss[i]) {
+			continue
+		}
+		if avgLoss[i] == 0 {
+			out[i] = 100
+			continue
+		}
+		out[i] = 100 - 100/(1+avgGain[i]/avgLoss[i])
+	}
+	return out
+}
+
+// MACD MACD 라인, 시그널, 히스토그램
+func MACD(src []float64, fastPeriod, slowPeriod, signalPeriod int) (macd, signal, histogram Series) {
+	fast := EMA(src, fastPeriod)
+	slow := EMA(src, slowPeriod)
+
+	macd = nanSeries(len(src))
+	for i := range src {
+		if valid(fast[i]) && valid(slow[i]) {
+			macd[i] = fast[i] - slow[i]
+		}
+	}
+
+	signal = EMA(macd, signalPeriod)
+	histogram = nanSeries(len(src))
+	for i := range src {
+		if valid(macd[i]) && valid(signal[i]) {
+			histogram[i] = macd[i] - signal[i]
+		}
+	}
+	return macd, signal, histogram
+}
+
+// Stochastic 슬로우 스토캐스틱 (raw %K를 smooth 기간 SMA, %D는 %K의 dPeriod SMA)
+func Stochastic(high, low, close []float64, kPeriod, smooth, dPeriod int) (k, d Series) {
+	hh := Highest(high, kPeriod)
+	ll := Lowest(low, kPeriod)
+
+	raw := nanSeries(len(close))
+	for i := range close {
+		if valid(hh[i]) && valid(ll[i]) {
+			raw[i] = stochRaw(close[i], hh[i], ll[i])
+		}
+	}
+
+	k = SMA(raw, smooth)
+	d = SMA(k, dPeriod)
+	return k, d
+}
+
+// StochRSI RSI에 스토캐스틱을 적용 (%K는 smoothK SMA, %D는 %K의 smoothD SMA)
+func StochRSI(src []float64, rsiPeriod, stochPeriod, smoothK, smoothD int) (k, d Series) {
+	rsi := RSI(src, rsiPeriod)
+	hh := Highest(rsi, stochPeriod)
+	ll := Lowest(rsi, stochPeriod)
+
+	raw := nanSeries(len(src))
+	for i := range src {
+		if valid(hh[i]) && valid(ll[i]) {
+			raw[i] = stochRaw(rsi[i], hh[i], ll[i])
+		}
+	}
+
+	k = SMA(raw, smoothK)
+	d = SMA(k, smoothD)
+	return k, d
+}
+
+// WilliamsR 윌리엄스 %R (-100 ~ 0)
+func WilliamsR(high, low, close []float64, period int) Series {
+	hh := Highest(high, period)
+	ll := Lowest(low, period)
+
+	out := nanSeries(len(close))
+	for i := range close {
+		if !valid(hh[i]) || !valid(ll[i]) {
+			continue
+		}
+		if hh[i] == ll[i] {
+			out[i] = -50
+			continue
+		}
+		out[i] = (hh[i] - close[i]) / (hh[i] - ll[i]) * -100
+	}
+	return out
+}
+
+// CCI 상품채널지수 (대표가격 기준, 상수 0.015)
+func CCI(high, low, close []float64, period int) Series {
+	tp := OHLCV{High: high, Low: low, Close: close}.Typical()
+	mean := SMA(tp, period)
+
+	out := nanSeries(len(close))
+	for i := range tp {
+		if !valid(mean[i]) {
+			continue
+		}
+		meanDev := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			meanDev += math.Abs(tp[j] - mean[i])
+		}
+		meanDev /= float64(period)
+		if meanDev == 0 {
+			out[i] = 0
+			continue
+		}
+		out[i] = (tp[i] - mean[i]) / (0.015 * meanDev)
+	}
+	return out
+}
+
+// MFI 자금흐름지수 (거래량 가중 RSI)
+func MFI(high, low, close, volume []float64, period int) Series {
+	tp := OHLCV{High: high, Low: low, Close: close}.Typical()
+
+	out := nanSeries(len(close))
+	for i := period; i < len(tp); i++ {
+		positive, negative := 0.0, 0.0
+		for j := i - period + 1; j <= i; j++ {
+			flow := tp[j] * volume[j]
+			switch {
+			case tp[j] > tp[j-1]:
+				positive += flow
+			case tp[j] < tp[j-1]:
+				negative += flow
+			}
+		}
+		switch {
+		case negative == 0 && positive == 0:
+			out[i] = 50
+		case negative == 0:
+			out[i] = 100
+		default:
+			out[i] = 100 - 100/(1+positive/negative)
+		}
+	}
+	return out
+}
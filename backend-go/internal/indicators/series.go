@@ -0,0 +1,278 @@
+package indicators
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Series 입력과 같은 길이로 정렬된 지표 시계열
+// 워밍업 구간처럼 값이 정의되지 않는 위치는 NaN이며 JSON에서는 null로 직렬화된다
+type Series []float64
+
+// MarshalJSON NaN/Inf를 null로 직렬화
+func (s Series) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, len(s)*12+2)
+	buf = append(buf, '[')
+	for i, v := range s {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			buf = append(buf, "null"...)
+			continue
+		}
+		buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+	}
+	return append(buf, ']'), nil
+}
+
+// UnmarshalJSON null을 NaN으로 복원 (캐시에서 다시 읽을 때 워밍업 구간 유지)
+func (s *Series) UnmarshalJSON(data []byte) error {
+	var raw []*float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make(Series, len(raw))
+	for i, v := range raw {
+		if v == nil {
+			out[i] = math.NaN()
+		} else {
+			out[i] = *v
+		}
+	}
+	*s = out
+	return nil
+}
+
+// Last 마지막 값 (비어 있으면 NaN)
+func (s Series) Last() float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	return s[len(s)-1]
+}
+
+// LastOr 마지막 값이 유효하면 그 값, 아니면 fallback
+func (s Series) LastOr(fallback float64) float64 {
+	return s.At(len(s)-1, fallback)
+}
+
+// At i번째 값이 유효하면 그 값, 범위 밖이거나 NaN이면 fallback
+func (s Series) At(i int, fallback float64) float64 {
+	if i < 0 || i >= len(s) || !valid(s[i]) {
+		return fallback
+	}
+	return s[i]
+}
+
+// OHLCV 열 단위 캔들 데이터 (모든 슬라이스는 같은 길이)
+type OHLCV struct {
+	Time   []int64
+	Open   []float64
+	High   []float64
+	Low    []float64
+	Close  []float64
+	Volume []float64
+}
+
+// FromBars 캔들 목록을 열 단위로 변환
+func FromBars(bars []Bar) OHLCV {
+	o := OHLCV{
+		Time:   make([]int64, len(bars)),
+		Open:   make([]float64, len(bars)),
+		High:   make([]float64, len(bars)),
+		Low:    make([]float64, len(bars)),
+		Close:  make([]float64, len(bars)),
+		Volume: make([]float64, len(bars)),
+	}
+	for i, b := range bars {
+		o.Time[i], o.Open[i], o.High[i], o.Low[i], o.Close[i], o.Volume[i] = b.Time, b.Open, b.High, b.Low, b.Close, b.Volume
+	}
+	return o
+}
+
+// FromCloses 종가만 있을 때의 근사 캔들 (시가=고가=저가=종가, 거래량 0)
+func FromCloses(closes []float64) OHLCV {
+	return OHLCV{
+		Time:   make([]int64, len(closes)),
+		Open:   closes,
+		High:   closes,
+		Low:    closes,
+		Close:  closes,
+		Volume: make([]float64, len(closes)),
+	}
+}
+
+// Len 캔들 수
+func (o OHLCV) Len() int { return len(o.Close) }
+
+// Typical 대표가격 (고가+저가+종가)/3
+func (o OHLCV) Typical() Series {
+	out := make(Series, o.Len())
+	for i := range out {
+		out[i] = (o.High[i] + o.Low[i] + o.Close[i]) / 3
+	}
+	return out
+}
+
+// Median 중간가격 (고가+저가)/2
+func (o OHLCV) Median() Series {
+	out := make(Series, o.Len())
+	for i := range out {
+		out[i] = (o.High[i] + o.Low[i]) / 2
+	}
+	return out
+}
+
+// SMA 단순이동평균 (창 안에 NaN이 있으면 NaN, NaN이 창을 벗어나면 다시 계산)
+func SMA(src []float64, period int) Series {
+	out := nanSeries(len(src))
+	if period < 1 {
+		return out
+	}
+
+	sum, invalid := 0.0, 0
+	for i, v := range src {
+		if valid(v) {
+			sum += v
+		} else {
+			invalid++
+		}
+		if j := i - period; j >= 0 {
+			if valid(src[j]) {
+				sum -= src[j]
+			} else {
+				invalid--
+			}
+		}
+		if i >= period-1 && invalid == 0 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA 지수이동평균 (첫 period개의 SMA로 시드)
+func EMA(src []float64, period int) Series {
+	return smoothed(src, period, 2.0/float64(period+1))
+}
+
+// RMA Wilder 평활 이동평균 (alpha = 1/period, RSI/ATR/ADX에 사용)
+func RMA(src []float64, period int) Series {
+	return smoothed(src, period, 1.0/float64(period))
+}
+
+// smoothed 처음 유효한 period개의 SMA로 시드한 지수 평활
+// NaN 입력은 상태를 바꾸지 않고 해당 위치만 NaN으로 두어, 중간의 NaN이 이후 값을 오염시키지 않는다
+func smoothed(src []float64, period int, alpha float64) Series {
+	out := nanSeries(len(src))
+	if period < 1 {
+		return out
+	}
+
+	seed, seeded := 0.0, 0
+	value := math.NaN()
+	for i, v := range src {
+		if !valid(v) {
+			continue
+		}
+		if seeded < period {
+			seed += v
+			seeded++
+			if seeded == period {
+				value = seed / float64(period)
+				out[i] = value
+			}
+			continue
+		}
+		value += (v - value) * alpha
+		out[i] = value
+	}
+	return out
+}
+
+// StdDev 이동 모표준편차
+func StdDev(src []float64, period int) Series {
+	out := nanSeries(len(src))
+	mean := SMA(src, period)
+	for i := range src {
+		if !valid(mean[i]) {
+			continue
+		}
+		variance := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			d := src[j] - mean[i]
+			variance += d * d
+		}
+		out[i] = math.Sqrt(variance / float64(period))
+	}
+	return out
+}
+
+// Highest 기간 최고값
+func Highest(src []float64, period int) Series {
+	return extreme(src, period, math.Max)
+}
+
+// Lowest 기간 최저값
+func Lowest(src []float64, period int) Series {
+	return extreme(src, period, math.Min)
+}
+
+func extreme(src []float64, period int, pick func(a, b float64) float64) Series {
+	out := nanSeries(len(src))
+	if period < 1 {
+		return out
+	}
+	for i := period - 1; i < len(src); i++ {
+		v := src[i]
+		for j := i - period + 1; j < i; j++ {
+			v = pick(v, src[j])
+		}
+		out[i] = v // 창 안에 NaN이 있으면 결과도 NaN
+	}
+	return out
+}
+
+// ROC 변화율 (%)
+func ROC(src []float64, period int) Series {
+	out := nanSeries(len(src))
+	for i := period; i < len(src); i++ {
+		if src[i-period] != 0 {
+			out[i] = (src[i] - src[i-period]) / src[i-period] * 100
+		}
+	}
+	return out
+}
+
+// sessionStart UTC 자정 기준 세션 시작 여부
+func sessionStart(times []int64, i int) bool {
+	if i == 0 || len(times) <= i {
+		return i == 0
+	}
+	day := func(ms int64) int64 { return time.UnixMilli(ms).UTC().Truncate(24 * time.Hour).Unix() }
+	return times[i] != 0 && day(times[i]) != day(times[i-1])
+}
+
+func nanSeries(n int) Series {
+	out := make(Series, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+func valid(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func firstValid(src []float64) int {
+	for i, v := range src {
+		if valid(v) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,283 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Spec 이름과 파라미터로 지정한 지표 하나 (예: "macd:12:26:9")
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// String 정규화된 표기 (기본값이 채워진 파라미터 포함, 캐시 키와 응답 키로 사용)
+func (s Spec) String() string {
+	parts := make([]string, 0, len(s.Params)+1)
+	parts = append(parts, s.Name)
+	for _, p := range s.Params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, ":")
+}
+
+// Lookback 첫 값이 수렴하기까지 필요한 대략적인 캔들 수
+func (s Spec) Lookback() int {
+	def, ok := specDefinitions[s.Name]
+	if !ok {
+		return 0
+	}
+	return def.lookback(s.Params)
+}
+
+// Compute 지표를 계산해 출력 이름별 시계열 반환 (단일 출력은 "value")
+func (s Spec) Compute(o OHLCV) (map[string]Series, error) {
+	def, ok := specDefinitions[s.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator: %q", s.Name)
+	}
+	return def.compute(o, s.Params), nil
+}
+
+// specDefinition 지표별 기본 파라미터, 정수 여부, 계산 함수
+type specDefinition struct {
+	defaults []float64
+	integer  []bool // 파라미터별 정수 여부 (기간)
+	lookback func(p []float64) int
+	compute  func(o OHLCV, p []float64) map[string]Series
+}
+
+func single(s Series) map[string]Series {
+	return map[string]Series{"value": s}
+}
+
+// smoothedLookback EMA/RMA 계열은 기간의 3배 정도면 초기값 영향이 사라진다
+func smoothedLookback(period float64) int {
+	return int(period) * 3
+}
+
+var specDefinitions = map[string]specDefinition{
+	"sma": {
+		defaults: []float64{20},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(SMA(o.Close, int(p[0]))) },
+	},
+	"ema": {
+		defaults: []float64{20},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(EMA(o.Close, int(p[0]))) },
+	},
+	"rsi": {
+		defaults: []float64{14},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) + 1 },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(RSI(o.Close, int(p[0]))) },
+	},
+	"macd": {
+		defaults: []float64{12, 26, 9},
+		integer:  []bool{true, true, true},
+		lookback: func(p []float64) int { return smoothedLookback(math.Max(p[0], p[1]) + p[2]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			macd, signal, hist := MACD(o.Close, int(p[0]), int(p[1]), int(p[2]))
+			return map[string]Series{"macd": macd, "signal": signal, "histogram": hist}
+		},
+	},
+	"bb": {
+		defaults: []float64{20, 2},
+		integer:  []bool{true, false},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			upper, middle, lower := Bollinger(o.Close, int(p[0]), p[1])
+			return map[string]Series{"upper": upper, "middle": middle, "lower": lower}
+		},
+	},
+	"atr": {
+		defaults: []float64{14},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) + 1 },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(ATR(o.High, o.Low, o.Close, int(p[0])))
+		},
+	},
+	"adx": {
+		defaults: []float64{14},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) * 2 },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			adx, plusDI, minusDI := ADX(o.High, o.Low, o.Close, int(p[0]))
+			return map[string]Series{"adx": adx, "plusDI": plusDI, "minusDI": minusDI}
+		},
+	},
+	"stoch": {
+		defaults: []float64{14, 3, 3},
+		integer:  []bool{true, true, true},
+		lookback: func(p []float64) int { return int(p[0] + p[1] + p[2]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			k, d := Stochastic(o.High, o.Low, o.Close, int(p[0]), int(p[1]), int(p[2]))
+			return map[string]Series{"k": k, "d": d}
+		},
+	},
+	"stochrsi": {
+		defaults: []float64{14, 14, 3, 3},
+		integer:  []bool{true, true, true, true},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) + int(p[1]+p[2]+p[3]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			k, d := StochRSI(o.Close, int(p[0]), int(p[1]), int(p[2]), int(p[3]))
+			return map[string]Series{"k": k, "d": d}
+		},
+	},
+	"willr": {
+		defaults: []float64{14},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(WilliamsR(o.High, o.Low, o.Close, int(p[0])))
+		},
+	},
+	"cci": {
+		defaults: []float64{20},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(CCI(o.High, o.Low, o.Close, int(p[0])))
+		},
+	},
+	"mfi": {
+		defaults: []float64{14},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) + 1 },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(MFI(o.High, o.Low, o.Close, o.Volume, int(p[0])))
+		},
+	},
+	"roc": {
+		defaults: []float64{10},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(ROC(o.Close, int(p[0]))) },
+	},
+	"obv": {
+		lookback: func(p []float64) int { return 0 },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(OBV(o.Close, o.Volume)) },
+	},
+	"vwap": {
+		lookback: func(p []float64) int { return 0 },
+		compute:  func(o OHLCV, p []float64) map[string]Series { return single(VWAP(o)) },
+	},
+	"cmf": {
+		defaults: []float64{20},
+		integer:  []bool{true},
+		lookback: func(p []float64) int { return int(p[0]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(CMF(o.High, o.Low, o.Close, o.Volume, int(p[0])))
+		},
+	},
+	"keltner": {
+		defaults: []float64{20, 10, 2},
+		integer:  []bool{true, true, false},
+		lookback: func(p []float64) int { return smoothedLookback(math.Max(p[0], p[1])) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			upper, middle, lower := Keltner(o.High, o.Low, o.Close, int(p[0]), int(p[1]), p[2])
+			return map[string]Series{"upper": upper, "middle": middle, "lower": lower}
+		},
+	},
+	"supertrend": {
+		defaults: []float64{10, 3},
+		integer:  []bool{true, false},
+		lookback: func(p []float64) int { return smoothedLookback(p[0]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			line, direction := Supertrend(o.High, o.Low, o.Close, int(p[0]), p[1])
+			return map[string]Series{"line": line, "direction": direction}
+		},
+	},
+	"psar": {
+		defaults: []float64{0.02, 0.2},
+		integer:  []bool{false, false},
+		lookback: func(p []float64) int { return 50 },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			return single(ParabolicSAR(o.High, o.Low, p[0], p[1]))
+		},
+	},
+	"ichimoku": {
+		defaults: []float64{9, 26, 52},
+		integer:  []bool{true, true, true},
+		lookback: func(p []float64) int { return int(p[1] + p[2]) },
+		compute: func(o OHLCV, p []float64) map[string]Series {
+			ich := Ichimoku(o.High, o.Low, o.Close, int(p[0]), int(p[1]), int(p[2]))
+			return map[string]Series{
+				"tenkan":  ich.Tenkan,
+				"kijun":   ich.Kijun,
+				"senkouA": ich.SenkouA,
+				"senkouB": ich.SenkouB,
+				"chikou":  ich.Chikou,
+			}
+		},
+	},
+}
+
+// SpecNames 지원하는 지표 이름 목록
+func SpecNames() []string {
+	names := make([]string, 0, len(specDefinitions))
+	for name := range specDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSpec "name[:p1[:p2...]]" 형식 파싱 (생략된 파라미터는 기본값)
+func ParseSpec(text string) (Spec, error) {
+	parts := strings.Split(strings.TrimSpace(text), ":")
+	name := strings.ToLower(parts[0])
+	def, ok := specDefinitions[name]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown indicator %q (supported: %s)", name, strings.Join(SpecNames(), ", "))
+	}
+	if len(parts)-1 > len(def.defaults) {
+		return Spec{}, fmt.Errorf("%s takes at most %d parameters", name, len(def.defaults))
+	}
+
+	params := append([]float64(nil), def.defaults...)
+	for i, raw := range parts[1:] {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 || math.IsInf(v, 0) {
+			return Spec{}, fmt.Errorf("%s: invalid parameter %q", name, raw)
+		}
+		if def.integer[i] && (v != math.Trunc(v) || v > maxSpecPeriod) {
+			return Spec{}, fmt.Errorf("%s: parameter %q must be a period between 1 and %d", name, raw, maxSpecPeriod)
+		}
+		params[i] = v
+	}
+	return Spec{Name: name, Params: params}, nil
+}
+
+// maxSpecPeriod 기간 파라미터 상한
+const maxSpecPeriod = 1000
+
+// ParseSpecs 쉼표로 구분된 지표 목록 파싱 (중복은 한 번만 포함)
+func ParseSpecs(set string) ([]Spec, error) {
+	var specs []Spec
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(set, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		spec, err := ParseSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		if key := spec.String(); !seen[key] {
+			seen[key] = true
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no indicators requested")
+	}
+	return specs, nil
+}
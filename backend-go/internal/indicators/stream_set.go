@@ -0,0 +1,211 @@
+package indicators
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// StreamSet 심볼/인터벌 하나에 대한 표준 스트리밍 지표 묶음
+// (EMA 12/26, RSI 14, MACD 12/26/9, 볼린저 20/2, ATR 14, 스토캐스틱 14/3/3)
+type StreamSet struct {
+	mu       sync.RWMutex
+	lastTime int64 // 마지막으로 반영한 캔들 시작 시각
+	updated  bool
+	ema12    *StreamEMA
+	ema26    *StreamEMA
+	rsi      *StreamRSI
+	macd     *StreamMACD
+	bb       *StreamBollinger
+	atr      *StreamATR
+	stoch    *StreamStochastic
+}
+
+// NewStreamSet 표준 설정의 지표 묶음 생성
+func NewStreamSet() *StreamSet {
+	return &StreamSet{
+		ema12: NewStreamEMA(12),
+		ema26: NewStreamEMA(26),
+		rsi:   NewStreamRSI(14),
+		macd:  NewStreamMACD(12, 26, 9),
+		bb:    NewStreamBollinger(20, 2.0),
+		atr:   NewStreamATR(14),
+		stoch: NewStreamStochastic(14, 3, 3),
+	}
+}
+
+// Update 마감 캔들 반영 (이미 반영한 시각 이하의 캔들은 무시하고 false 반환)
+func (s *StreamSet) Update(bar Bar) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.updated && bar.Time <= s.lastTime {
+		return false
+	}
+	for _, ind := range s.all() {
+		ind.Update(bar)
+	}
+	s.lastTime = bar.Time
+	s.updated = true
+	return true
+}
+
+// Warmup 과거 캔들로 상태를 채우고 반영한 개수 반환
+func (s *StreamSet) Warmup(bars []Bar) int {
+	applied := 0
+	for _, bar := range bars {
+		if s.Update(bar) {
+			applied++
+		}
+	}
+	return applied
+}
+
+// LastTime 마지막으로 반영한 캔들 시작 시각
+func (s *StreamSet) LastTime() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastTime
+}
+
+// Values 준비된 지표의 현재 값
+func (s *StreamSet) Values() map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(map[string]float64)
+	if s.ema12.Ready() {
+		values["ema_12"] = s.ema12.Value()
+	}
+	if s.ema26.Ready() {
+		values["ema_26"] = s.ema26.Value()
+	}
+	if s.rsi.Ready() {
+		values["rsi_14"] = s.rsi.Value()
+	}
+	if s.macd.Ready() {
+		addMACD(values, s.macd.Value())
+	}
+	if s.bb.Ready() {
+		addBand(values, s.bb.Value())
+	}
+	if s.atr.Ready() {
+		values["atr_14"] = s.atr.Value()
+	}
+	if s.stoch.Ready() {
+		addStoch(values, s.stoch.Value())
+	}
+	return values
+}
+
+// Peek 진행 중인 캔들(틱)을 반영했을 때의 값 (상태 변경 없음)
+func (s *StreamSet) Peek(bar Bar) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(map[string]float64)
+	if s.ema12.Ready() {
+		values["ema_12"] = s.ema12.Peek(bar.Close)
+	}
+	if s.ema26.Ready() {
+		values["ema_26"] = s.ema26.Peek(bar.Close)
+	}
+	if s.rsi.Ready() {
+		values["rsi_14"] = s.rsi.Peek(bar.Close)
+	}
+	if s.macd.Ready() {
+		addMACD(values, s.macd.Peek(bar.Close))
+	}
+	if s.bb.Ready() {
+		addBand(values, s.bb.Peek(bar.Close))
+	}
+	if s.atr.Ready() {
+		values["atr_14"] = s.atr.Peek(bar)
+	}
+	if s.stoch.Ready() {
+		addStoch(values, s.stoch.Peek(bar))
+	}
+	return values
+}
+
+// streamSetState StreamSet 스냅샷
+type streamSetState struct {
+	LastTime   int64                      `json:"lastTime"`
+	Updated    bool                       `json:"updated"`
+	Indicators map[string]json.RawMessage `json:"indicators"`
+}
+
+// Snapshot 모든 지표 상태 직렬화
+func (s *StreamSet) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state := streamSetState{LastTime: s.lastTime, Updated: s.updated, Indicators: make(map[string]json.RawMessage)}
+	for name, ind := range s.named() {
+		data, err := ind.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		state.Indicators[name] = data
+	}
+	return json.Marshal(state)
+}
+
+// Restore 스냅샷 복원 (일부 지표가 없으면 해당 지표는 초기 상태로 시작)
+func (s *StreamSet) Restore(data []byte) error {
+	var state streamSetState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	restored := NewStreamSet()
+	for name, ind := range restored.named() {
+		raw, ok := state.Indicators[name]
+		if !ok {
+			continue
+		}
+		if err := ind.Restore(raw); err != nil {
+			return err
+		}
+	}
+	restored.lastTime, restored.updated = state.LastTime, state.Updated
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastTime, s.updated = restored.lastTime, restored.updated
+	s.ema12, s.ema26, s.rsi, s.macd = restored.ema12, restored.ema26, restored.rsi, restored.macd
+	s.bb, s.atr, s.stoch = restored.bb, restored.atr, restored.stoch
+	return nil
+}
+
+func (s *StreamSet) named() map[string]Streaming {
+	return map[string]Streaming{
+		"ema_12": s.ema12,
+		"ema_26": s.ema26,
+		"rsi_14": s.rsi,
+		"macd":   s.macd,
+		"bb":     s.bb,
+		"atr_14": s.atr,
+		"stoch":  s.stoch,
+	}
+}
+
+func (s *StreamSet) all() []Streaming {
+	return []Streaming{s.ema12, s.ema26, s.rsi, s.macd, s.bb, s.atr, s.stoch}
+}
+
+func addMACD(values map[string]float64, v MACDValue) {
+	values["macd"] = v.MACD
+	values["macd_signal"] = v.Signal
+	values["macd_hist"] = v.Histogram
+}
+
+func addBand(values map[string]float64, v BandValue) {
+	values["bb_upper"] = v.Upper
+	values["bb_middle"] = v.Middle
+	values["bb_lower"] = v.Lower
+}
+
+func addStoch(values map[string]float64, v StochValue) {
+	values["stoch_k"] = v.K
+	values["stoch_d"] = v.D
+}
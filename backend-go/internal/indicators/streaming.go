@@ -0,0 +1,744 @@
+package indicators
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Bar OHLCV 캔들 한 개 (Time은 캔들 시작 시각, 밀리초)
+type Bar struct {
+	Time   int64   `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// Streaming 새 캔들마다 O(1)로 갱신되는 상태 기반 지표
+// Update는 마감된 캔들만 반영하고, 진행 중인 캔들(틱)은 각 지표의 Peek으로 상태 변경 없이 계산한다
+type Streaming interface {
+	Update(bar Bar)
+	Ready() bool
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// Warmup 과거 캔들로 지표 상태를 채움
+func Warmup(ind Streaming, bars []Bar) {
+	for _, bar := range bars {
+		ind.Update(bar)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// EMA
+
+// EMAState EMA 스냅샷
+type EMAState struct {
+	Period int     `json:"period"`
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"` // 초기 SMA 시드 계산용
+	Value  float64 `json:"value"`
+}
+
+// StreamEMA 지수이동평균 (첫 period개의 SMA로 시드, calculateEMA와 동일한 값)
+type StreamEMA struct {
+	state EMAState
+}
+
+// NewStreamEMA period 기간 EMA 생성
+func NewStreamEMA(period int) *StreamEMA {
+	if period < 1 {
+		period = 1
+	}
+	return &StreamEMA{state: EMAState{Period: period}}
+}
+
+// Add 종가 하나 반영
+func (e *StreamEMA) Add(price float64) {
+	s := &e.state
+	if s.Count < s.Period {
+		s.Sum += price
+		s.Count++
+		if s.Count == s.Period {
+			s.Value = s.Sum / float64(s.Period)
+		}
+		return
+	}
+	s.Value += (price - s.Value) * e.alpha()
+	s.Count++
+}
+
+// Update 마감 캔들 반영 (종가 사용)
+func (e *StreamEMA) Update(bar Bar) { e.Add(bar.Close) }
+
+// Peek price가 다음 값일 때의 EMA (상태 변경 없음)
+func (e *StreamEMA) Peek(price float64) float64 {
+	next := *e
+	next.Add(price)
+	return next.Value()
+}
+
+// Value 현재 EMA (준비 전에는 0)
+func (e *StreamEMA) Value() float64 {
+	if !e.Ready() {
+		return 0
+	}
+	return e.state.Value
+}
+
+// Ready 시드 기간이 채워졌는지
+func (e *StreamEMA) Ready() bool { return e.state.Count >= e.state.Period }
+
+func (e *StreamEMA) alpha() float64 { return 2.0 / float64(e.state.Period+1) }
+
+// Snapshot 상태 직렬화
+func (e *StreamEMA) Snapshot() ([]byte, error) { return json.Marshal(e.state) }
+
+// Restore 스냅샷 복원
+func (e *StreamEMA) Restore(data []byte) error {
+	var s EMAState
+	if err := restoreState(data, &s, e.state.Period, func() int { return s.Period }); err != nil {
+		return err
+	}
+	e.state = s
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// RSI
+
+// RSIState RSI 스냅샷
+type RSIState struct {
+	Period    int     `json:"period"`
+	Count     int     `json:"count"` // 반영된 가격 변화 수
+	PrevClose float64 `json:"prevClose"`
+	HasPrev   bool    `json:"hasPrev"`
+	AvgGain   float64 `json:"avgGain"`
+	AvgLoss   float64 `json:"avgLoss"`
+}
+
+// StreamRSI Wilder 평활 RSI (CalculateRSI와 동일한 값)
+type StreamRSI struct {
+	state RSIState
+}
+
+// NewStreamRSI period 기간 RSI 생성
+func NewStreamRSI(period int) *StreamRSI {
+	if period < 1 {
+		period = 1
+	}
+	return &StreamRSI{state: RSIState{Period: period}}
+}
+
+// Add 종가 하나 반영
+func (r *StreamRSI) Add(price float64) {
+	s := &r.state
+	if !s.HasPrev {
+		s.PrevClose = price
+		s.HasPrev = true
+		return
+	}
+
+	change := price - s.PrevClose
+	s.PrevClose = price
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(s.Period)
+	switch {
+	case s.Count < s.Period-1:
+		s.AvgGain += gain
+		s.AvgLoss += loss
+	case s.Count == s.Period-1:
+		s.AvgGain = (s.AvgGain + gain) / n
+		s.AvgLoss = (s.AvgLoss + loss) / n
+	default:
+		s.AvgGain = (s.AvgGain*(n-1) + gain) / n
+		s.AvgLoss = (s.AvgLoss*(n-1) + loss) / n
+	}
+	s.Count++
+}
+
+// Update 마감 캔들 반영 (종가 사용)
+func (r *StreamRSI) Update(bar Bar) { r.Add(bar.Close) }
+
+// Peek price가 다음 값일 때의 RSI (상태 변경 없음)
+func (r *StreamRSI) Peek(price float64) float64 {
+	next := *r
+	next.Add(price)
+	return next.Value()
+}
+
+// Value 현재 RSI (준비 전에는 중립값 50)
+func (r *StreamRSI) Value() float64 {
+	if !r.Ready() {
+		return 50.0
+	}
+	if r.state.AvgLoss == 0 {
+		return 100.0
+	}
+	rs := r.state.AvgGain / r.state.AvgLoss
+	return 100.0 - (100.0 / (1.0 + rs))
+}
+
+// Ready period개의 가격 변화가 반영되었는지
+func (r *StreamRSI) Ready() bool { return r.state.Count >= r.state.Period }
+
+// Snapshot 상태 직렬화
+func (r *StreamRSI) Snapshot() ([]byte, error) { return json.Marshal(r.state) }
+
+// Restore 스냅샷 복원
+func (r *StreamRSI) Restore(data []byte) error {
+	var s RSIState
+	if err := restoreState(data, &s, r.state.Period, func() int { return s.Period }); err != nil {
+		return err
+	}
+	r.state = s
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// MACD
+
+// MACDValue MACD 라인, 시그널, 히스토그램
+type MACDValue struct {
+	MACD      float64 `json:"macd"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
+}
+
+// MACDState MACD 스냅샷
+type MACDState struct {
+	Fast   EMAState `json:"fast"`
+	Slow   EMAState `json:"slow"`
+	Signal EMAState `json:"signal"`
+}
+
+// StreamMACD 이동평균 수렴확산 (느린 EMA가 준비된 뒤부터 시그널 EMA에 반영)
+type StreamMACD struct {
+	fast, slow, signal StreamEMA
+}
+
+// NewStreamMACD fast/slow/signal 기간 MACD 생성
+func NewStreamMACD(fastPeriod, slowPeriod, signalPeriod int) *StreamMACD {
+	return &StreamMACD{
+		fast:   *NewStreamEMA(fastPeriod),
+		slow:   *NewStreamEMA(slowPeriod),
+		signal: *NewStreamEMA(signalPeriod),
+	}
+}
+
+// Add 종가 하나 반영
+func (m *StreamMACD) Add(price float64) {
+	m.fast.Add(price)
+	m.slow.Add(price)
+	if m.slow.Ready() {
+		m.signal.Add(m.fast.Value() - m.slow.Value())
+	}
+}
+
+// Update 마감 캔들 반영 (종가 사용)
+func (m *StreamMACD) Update(bar Bar) { m.Add(bar.Close) }
+
+// Peek price가 다음 값일 때의 MACD (상태 변경 없음)
+func (m *StreamMACD) Peek(price float64) MACDValue {
+	next := *m
+	next.Add(price)
+	return next.Value()
+}
+
+// Value 현재 MACD (시그널 준비 전에는 시그널/히스토그램 0)
+func (m *StreamMACD) Value() MACDValue {
+	if !m.slow.Ready() {
+		return MACDValue{}
+	}
+	v := MACDValue{MACD: m.fast.Value() - m.slow.Value()}
+	if m.signal.Ready() {
+		v.Signal = m.signal.Value()
+		v.Histogram = v.MACD - v.Signal
+	}
+	return v
+}
+
+// Ready 시그널 라인까지 준비되었는지
+func (m *StreamMACD) Ready() bool { return m.signal.Ready() }
+
+// Snapshot 상태 직렬화
+func (m *StreamMACD) Snapshot() ([]byte, error) {
+	return json.Marshal(MACDState{Fast: m.fast.state, Slow: m.slow.state, Signal: m.signal.state})
+}
+
+// Restore 스냅샷 복원
+func (m *StreamMACD) Restore(data []byte) error {
+	var s MACDState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Fast.Period != m.fast.state.Period || s.Slow.Period != m.slow.state.Period || s.Signal.Period != m.signal.state.Period {
+		return fmt.Errorf("MACD snapshot periods %d/%d/%d do not match %d/%d/%d",
+			s.Fast.Period, s.Slow.Period, s.Signal.Period,
+			m.fast.state.Period, m.slow.state.Period, m.signal.state.Period)
+	}
+	m.fast.state, m.slow.state, m.signal.state = s.Fast, s.Slow, s.Signal
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Bollinger Bands
+
+// BandValue 볼린저 밴드 값
+type BandValue struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+	StdDev float64 `json:"stdDev"`
+}
+
+// BollingerState 볼린저 밴드 스냅샷
+type BollingerState struct {
+	Period     int       `json:"period"`
+	Multiplier float64   `json:"multiplier"`
+	Window     []float64 `json:"window"` // 오래된 순
+}
+
+// StreamBollinger 볼린저 밴드 (모표준편차, CalculateBollingerBands와 동일한 값)
+type StreamBollinger struct {
+	multiplier float64
+	window     *rollingWindow
+}
+
+// NewStreamBollinger period 기간, multiplier 배수 볼린저 밴드 생성
+func NewStreamBollinger(period int, multiplier float64) *StreamBollinger {
+	return &StreamBollinger{multiplier: multiplier, window: newRollingWindow(period)}
+}
+
+// Add 종가 하나 반영
+func (b *StreamBollinger) Add(price float64) { b.window.push(price) }
+
+// Update 마감 캔들 반영 (종가 사용)
+func (b *StreamBollinger) Update(bar Bar) { b.Add(bar.Close) }
+
+// Peek price가 다음 값일 때의 밴드 (상태 변경 없음)
+func (b *StreamBollinger) Peek(price float64) BandValue {
+	mean, variance, ok := b.window.peek(price)
+	if !ok {
+		return BandValue{}
+	}
+	return b.band(mean, variance)
+}
+
+// Value 현재 밴드 (준비 전에는 0)
+func (b *StreamBollinger) Value() BandValue {
+	if !b.Ready() {
+		return BandValue{}
+	}
+	return b.band(b.window.mean(), b.window.variance())
+}
+
+func (b *StreamBollinger) band(mean, variance float64) BandValue {
+	stdDev := math.Sqrt(variance)
+	return BandValue{
+		Upper:  mean + b.multiplier*stdDev,
+		Middle: mean,
+		Lower:  mean - b.multiplier*stdDev,
+		StdDev: stdDev,
+	}
+}
+
+// Ready 기간이 채워졌는지
+func (b *StreamBollinger) Ready() bool { return b.window.full() }
+
+// Snapshot 상태 직렬화
+func (b *StreamBollinger) Snapshot() ([]byte, error) {
+	return json.Marshal(BollingerState{Period: b.window.size, Multiplier: b.multiplier, Window: b.window.ordered()})
+}
+
+// Restore 스냅샷 복원
+func (b *StreamBollinger) Restore(data []byte) error {
+	var s BollingerState
+	if err := restoreState(data, &s, b.window.size, func() int { return s.Period }); err != nil {
+		return err
+	}
+	b.multiplier = s.Multiplier
+	b.window = newRollingWindow(s.Period)
+	for _, v := range s.Window {
+		b.window.push(v)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// ATR
+
+// ATRState ATR 스냅샷
+type ATRState struct {
+	Period    int     `json:"period"`
+	Count     int     `json:"count"`
+	PrevClose float64 `json:"prevClose"`
+	HasPrev   bool    `json:"hasPrev"`
+	Value     float64 `json:"value"` // 시드 기간에는 TR 합계
+}
+
+// StreamATR Wilder 평활 평균 실제 범위 (첫 period개 TR의 평균으로 시드)
+type StreamATR struct {
+	state ATRState
+}
+
+// NewStreamATR period 기간 ATR 생성
+func NewStreamATR(period int) *StreamATR {
+	if period < 1 {
+		period = 1
+	}
+	return &StreamATR{state: ATRState{Period: period}}
+}
+
+// Update 마감 캔들 반영
+func (a *StreamATR) Update(bar Bar) {
+	s := &a.state
+	tr := TrueRange(bar.High, bar.Low, s.PrevClose, s.HasPrev)
+	s.PrevClose = bar.Close
+	s.HasPrev = true
+
+	n := float64(s.Period)
+	switch {
+	case s.Count < s.Period-1:
+		s.Value += tr
+	case s.Count == s.Period-1:
+		s.Value = (s.Value + tr) / n
+	default:
+		s.Value = (s.Value*(n-1) + tr) / n
+	}
+	s.Count++
+}
+
+// Peek bar가 다음 캔들일 때의 ATR (상태 변경 없음)
+func (a *StreamATR) Peek(bar Bar) float64 {
+	next := *a
+	next.Update(bar)
+	return next.Value()
+}
+
+// Value 현재 ATR (준비 전에는 0)
+func (a *StreamATR) Value() float64 {
+	if !a.Ready() {
+		return 0
+	}
+	return a.state.Value
+}
+
+// Ready 시드 기간이 채워졌는지
+func (a *StreamATR) Ready() bool { return a.state.Count >= a.state.Period }
+
+// Snapshot 상태 직렬화
+func (a *StreamATR) Snapshot() ([]byte, error) { return json.Marshal(a.state) }
+
+// Restore 스냅샷 복원
+func (a *StreamATR) Restore(data []byte) error {
+	var s ATRState
+	if err := restoreState(data, &s, a.state.Period, func() int { return s.Period }); err != nil {
+		return err
+	}
+	a.state = s
+	return nil
+}
+
+// TrueRange 실제 범위 (이전 종가가 없으면 고가-저가)
+func TrueRange(high, low, prevClose float64, hasPrev bool) float64 {
+	tr := high - low
+	if hasPrev {
+		tr = math.Max(tr, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+	}
+	return tr
+}
+
+// ---------------------------------------------------------------------------
+// Stochastic
+
+// StochValue 스토캐스틱 %K, %D
+type StochValue struct {
+	K float64 `json:"k"`
+	D float64 `json:"d"`
+}
+
+// StochasticState 스토캐스틱 스냅샷
+type StochasticState struct {
+	KPeriod int       `json:"kPeriod"`
+	Smooth  int       `json:"smooth"`
+	DPeriod int       `json:"dPeriod"`
+	Highs   []float64 `json:"highs"` // 오래된 순
+	Lows    []float64 `json:"lows"`
+	RawK    []float64 `json:"rawK"`
+	K       []float64 `json:"k"`
+}
+
+// StreamStochastic 슬로우 스토캐스틱 (raw %K를 smooth 기간 SMA, %D는 %K의 dPeriod SMA)
+// 최고가/최저가는 단조 덱으로 관리해 캔들당 분할상환 O(1)
+type StreamStochastic struct {
+	kPeriod int
+	index   int64
+	highs   monoDeque
+	lows    monoDeque
+	recentH *rollingWindow // 스냅샷용 최근 kPeriod개 고가/저가
+	recentL *rollingWindow
+	rawK    *rollingWindow
+	k       *rollingWindow
+}
+
+// NewStreamStochastic kPeriod/smooth/dPeriod 스토캐스틱 생성 (일반적으로 14, 3, 3)
+func NewStreamStochastic(kPeriod, smooth, dPeriod int) *StreamStochastic {
+	if kPeriod < 1 {
+		kPeriod = 1
+	}
+	return &StreamStochastic{
+		kPeriod: kPeriod,
+		highs:   monoDeque{max: true},
+		lows:    monoDeque{max: false},
+		recentH: newRollingWindow(kPeriod),
+		recentL: newRollingWindow(kPeriod),
+		rawK:    newRollingWindow(smooth),
+		k:       newRollingWindow(dPeriod),
+	}
+}
+
+// Update 마감 캔들 반영
+func (s *StreamStochastic) Update(bar Bar) {
+	s.highs.push(s.index, bar.High, s.kPeriod)
+	s.lows.push(s.index, bar.Low, s.kPeriod)
+	s.recentH.push(bar.High)
+	s.recentL.push(bar.Low)
+	s.index++
+
+	if s.index < int64(s.kPeriod) {
+		return
+	}
+	s.rawK.push(stochRaw(bar.Close, s.highs.front(), s.lows.front()))
+	if s.rawK.full() {
+		s.k.push(s.rawK.mean())
+	}
+}
+
+// Peek bar가 다음 캔들일 때의 %K/%D (상태 변경 없음)
+func (s *StreamStochastic) Peek(bar Bar) StochValue {
+	if s.index+1 < int64(s.kPeriod) {
+		return StochValue{}
+	}
+	oldest := s.index + 1 - int64(s.kPeriod)
+	hh := math.Max(s.highs.frontFrom(oldest, bar.High), bar.High)
+	ll := math.Min(s.lows.frontFrom(oldest, bar.Low), bar.Low)
+
+	k, _, ok := s.rawK.peek(stochRaw(bar.Close, hh, ll))
+	if !ok {
+		return StochValue{}
+	}
+	d, _, ok := s.k.peek(k)
+	if !ok {
+		return StochValue{K: k}
+	}
+	return StochValue{K: k, D: d}
+}
+
+// Value 현재 %K/%D (준비 전에는 0)
+func (s *StreamStochastic) Value() StochValue {
+	if !s.k.full() {
+		if s.rawK.full() {
+			return StochValue{K: s.rawK.mean()}
+		}
+		return StochValue{}
+	}
+	return StochValue{K: s.rawK.mean(), D: s.k.mean()}
+}
+
+// Ready %D까지 준비되었는지
+func (s *StreamStochastic) Ready() bool { return s.k.full() }
+
+// Snapshot 상태 직렬화
+func (s *StreamStochastic) Snapshot() ([]byte, error) {
+	return json.Marshal(StochasticState{
+		KPeriod: s.kPeriod,
+		Smooth:  s.rawK.size,
+		DPeriod: s.k.size,
+		Highs:   s.recentH.ordered(),
+		Lows:    s.recentL.ordered(),
+		RawK:    s.rawK.ordered(),
+		K:       s.k.ordered(),
+	})
+}
+
+// Restore 스냅샷 복원
+func (s *StreamStochastic) Restore(data []byte) error {
+	var st StochasticState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if st.KPeriod != s.kPeriod || st.Smooth != s.rawK.size || st.DPeriod != s.k.size {
+		return fmt.Errorf("stochastic snapshot periods %d/%d/%d do not match %d/%d/%d",
+			st.KPeriod, st.Smooth, st.DPeriod, s.kPeriod, s.rawK.size, s.k.size)
+	}
+	if len(st.Highs) != len(st.Lows) {
+		return fmt.Errorf("stochastic snapshot has %d highs but %d lows", len(st.Highs), len(st.Lows))
+	}
+
+	restored := NewStreamStochastic(st.KPeriod, st.Smooth, st.DPeriod)
+	for i := range st.Highs {
+		restored.highs.push(restored.index, st.Highs[i], st.KPeriod)
+		restored.lows.push(restored.index, st.Lows[i], st.KPeriod)
+		restored.recentH.push(st.Highs[i])
+		restored.recentL.push(st.Lows[i])
+		restored.index++
+	}
+	// 덱 인덱스는 상대값이므로 창이 찬 상태면 이후 갱신과 동일하게 동작
+	for _, v := range st.RawK {
+		restored.rawK.push(v)
+	}
+	for _, v := range st.K {
+		restored.k.push(v)
+	}
+	*s = *restored
+	return nil
+}
+
+func stochRaw(close, highest, lowest float64) float64 {
+	if highest == lowest {
+		return 50.0
+	}
+	return (close - lowest) / (highest - lowest) * 100.0
+}
+
+// ---------------------------------------------------------------------------
+// 내부 자료구조
+
+// rollingWindow 고정 크기 링 버퍼 (합계/제곱합을 유지해 평균·분산 O(1))
+type rollingWindow struct {
+	size   int
+	values []float64
+	pos    int
+	count  int
+	sum    float64
+	sumSq  float64
+}
+
+func newRollingWindow(size int) *rollingWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &rollingWindow{size: size, values: make([]float64, size)}
+}
+
+func (w *rollingWindow) push(v float64) {
+	if w.count == w.size {
+		old := w.values[w.pos]
+		w.sum -= old
+		w.sumSq -= old * old
+	} else {
+		w.count++
+	}
+	w.values[w.pos] = v
+	w.sum += v
+	w.sumSq += v * v
+	w.pos = (w.pos + 1) % w.size
+
+	// 누적 부동소수점 오차 방지를 위해 한 바퀴마다 합계 재계산 (분할상환 O(1))
+	if w.pos == 0 && w.count == w.size {
+		w.sum, w.sumSq = 0, 0
+		for _, x := range w.values {
+			w.sum += x
+			w.sumSq += x * x
+		}
+	}
+}
+
+func (w *rollingWindow) full() bool { return w.count == w.size }
+
+func (w *rollingWindow) mean() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	return w.sum / float64(w.count)
+}
+
+func (w *rollingWindow) variance() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	m := w.mean()
+	return math.Max(w.sumSq/float64(w.count)-m*m, 0)
+}
+
+// peek v를 추가했을 때의 평균/분산 (창이 차지 않으면 ok=false)
+func (w *rollingWindow) peek(v float64) (mean, variance float64, ok bool) {
+	if w.count < w.size-1 {
+		return 0, 0, false
+	}
+	sum, sumSq := w.sum+v, w.sumSq+v*v
+	if w.count == w.size {
+		old := w.values[w.pos]
+		sum -= old
+		sumSq -= old * old
+	}
+	n := float64(w.size)
+	mean = sum / n
+	return mean, math.Max(sumSq/n-mean*mean, 0), true
+}
+
+// ordered 오래된 순으로 정렬된 값
+func (w *rollingWindow) ordered() []float64 {
+	out := make([]float64, 0, w.count)
+	start := (w.pos - w.count + w.size) % w.size
+	for i := 0; i < w.count; i++ {
+		out = append(out, w.values[(start+i)%w.size])
+	}
+	return out
+}
+
+// monoDeque 슬라이딩 윈도우 최대/최소값용 단조 덱
+type monoDeque struct {
+	max   bool
+	items []dequeItem
+}
+
+type dequeItem struct {
+	index int64
+	value float64
+}
+
+func (d *monoDeque) dominates(a, b float64) bool {
+	if d.max {
+		return a >= b
+	}
+	return a <= b
+}
+
+func (d *monoDeque) push(index int64, value float64, window int) {
+	for len(d.items) > 0 && d.dominates(value, d.items[len(d.items)-1].value) {
+		d.items = d.items[:len(d.items)-1]
+	}
+	d.items = append(d.items, dequeItem{index: index, value: value})
+	for d.items[0].index <= index-int64(window) {
+		d.items = d.items[1:]
+	}
+}
+
+func (d *monoDeque) front() float64 { return d.items[0].value }
+
+// frontFrom oldest 이상 인덱스 중 극값 (없으면 fallback)
+func (d *monoDeque) frontFrom(oldest int64, fallback float64) float64 {
+	for _, item := range d.items {
+		if item.index >= oldest {
+			return item.value
+		}
+	}
+	return fallback
+}
+
+// restoreState 스냅샷 JSON을 해석하고 기간 일치 여부 확인
+func restoreState(data []byte, dest interface{}, want int, got func() int) error {
+	if err := json.Unmarshal(data, dest); err != nil {
+		return err
+	}
+	if got() != want {
+		return fmt.Errorf("snapshot period %d does not match %d", got(), want)
+	}
+	return nil
+}
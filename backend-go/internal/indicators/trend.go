@@ -0,0 +1,179 @@
+package indicators
+
+import "math"
+
+// ADX 평균방향성지수와 +DI/-DI (Wilder)
+func ADX(high, low, close []float64, period int) (adx, plusDI, minusDI Series) {
+	n := len(close)
+	tr, plusDM, minusDM := nanSeries(n), nanSeries(n), nanSeries(n)
+	for i := 1; i < n; i++ {
+		up := high[i] - high[i-1]
+		down := low[i-1] - low[i]
+		plusDM[i], minusDM[i] = 0, 0
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+		tr[i] = TrueRange(high[i], low[i], close[i-1], true)
+	}
+
+	atr := RMA(tr, period)
+	smoothPlus := RMA(plusDM, period)
+	smoothMinus := RMA(minusDM, period)
+
+	plusDI, minusDI = nanSeries(n), nanSeries(n)
+	dx := nanSeries(n)
+	for i := 0; i < n; i++ {
+		if !valid(atr[i]) || atr[i] == 0 {
+			continue
+		}
+		plusDI[i] = 100 * smoothPlus[i] / atr[i]
+		minusDI[i] = 100 * smoothMinus[i] / atr[i]
+		if sum := plusDI[i] + minusDI[i]; sum > 0 {
+			dx[i] = 100 * math.Abs(plusDI[i]-minusDI[i]) / sum
+		} else {
+			dx[i] = 0
+		}
+	}
+
+	adx = RMA(dx, period)
+	return adx, plusDI, minusDI
+}
+
+// IchimokuSeries 일목균형표
+// 선행스팬은 kijun 기간만큼 앞으로 이동해 해당 캔들 위치에 정렬되고,
+// 후행스팬(현재 종가를 kijun 기간 전 위치에 그리는 선)은 미래 종가를 참조하지 않도록
+// 값이 확정되는 현재 캔들 위치에 둔다 (차트에서는 kijun 기간만큼 뒤로 옮겨 그린다)
+type IchimokuSeries struct {
+	Tenkan  Series `json:"tenkan"`
+	Kijun   Series `json:"kijun"`
+	SenkouA Series `json:"senkouA"`
+	SenkouB Series `json:"senkouB"`
+	Chikou  Series `json:"chikou"`
+}
+
+// Ichimoku 일목균형표 (일반적으로 9, 26, 52)
+func Ichimoku(high, low, close []float64, tenkanPeriod, kijunPeriod, senkouPeriod int) IchimokuSeries {
+	n := len(close)
+	tenkan := midpoint(high, low, tenkanPeriod)
+	kijun := midpoint(high, low, kijunPeriod)
+	spanB := midpoint(high, low, senkouPeriod)
+
+	ich := IchimokuSeries{
+		Tenkan:  tenkan,
+		Kijun:   kijun,
+		SenkouA: nanSeries(n),
+		SenkouB: nanSeries(n),
+		Chikou:  nanSeries(n),
+	}
+	for i := 0; i < n; i++ {
+		if j := i - kijunPeriod; j >= 0 {
+			if valid(tenkan[j]) && valid(kijun[j]) {
+				ich.SenkouA[i] = (tenkan[j] + kijun[j]) / 2
+			}
+			ich.SenkouB[i] = spanB[j]
+		}
+		if i >= kijunPeriod {
+			ich.Chikou[i] = close[i]
+		}
+	}
+	return ich
+}
+
+// midpoint 기간 (최고가+최저가)/2
+func midpoint(high, low []float64, period int) Series {
+	hh := Highest(high, period)
+	ll := Lowest(low, period)
+	out := nanSeries(len(high))
+	for i := range out {
+		if valid(hh[i]) && valid(ll[i]) {
+			out[i] = (hh[i] + ll[i]) / 2
+		}
+	}
+	return out
+}
+
+// Supertrend 슈퍼트렌드 라인과 방향 (+1 상승, -1 하락)
+func Supertrend(high, low, close []float64, period int, multiplier float64) (line, direction Series) {
+	n := len(close)
+	atr := ATR(high, low, close, period)
+	line, direction = nanSeries(n), nanSeries(n)
+
+	var upperBand, lowerBand, dir float64
+	started := false
+	for i := 0; i < n; i++ {
+		if !valid(atr[i]) {
+			continue
+		}
+		mid := (high[i] + low[i]) / 2
+		basicUpper := mid + multiplier*atr[i]
+		basicLower := mid - multiplier*atr[i]
+
+		if !started {
+			upperBand, lowerBand, dir = basicUpper, basicLower, 1
+			started = true
+		} else {
+			prevClose := close[i-1]
+			if basicUpper < upperBand || prevClose > upperBand {
+				upperBand = basicUpper
+			}
+			if basicLower > lowerBand || prevClose < lowerBand {
+				lowerBand = basicLower
+			}
+			switch {
+			case dir > 0 && close[i] < lowerBand:
+				dir = -1
+			case dir < 0 && close[i] > upperBand:
+				dir = 1
+			}
+		}
+
+		direction[i] = dir
+		if dir > 0 {
+			line[i] = lowerBand
+		} else {
+			line[i] = upperBand
+		}
+	}
+	return line, direction
+}
+
+// ParabolicSAR 파라볼릭 SAR (일반적으로 step 0.02, max 0.2)
+func ParabolicSAR(high, low []float64, step, maxStep float64) Series {
+	n := len(high)
+	out := nanSeries(n)
+	if n < 2 {
+		return out
+	}
+
+	up := high[1]+low[1] >= high[0]+low[0]
+	sar, ep := high[0], low[1]
+	if up {
+		sar, ep = low[0], high[1]
+	}
+	af := step
+	out[1] = sar
+
+	for i := 2; i < n; i++ {
+		sar += af * (ep - sar)
+		if up {
+			sar = math.Min(sar, math.Min(low[i-1], low[i-2]))
+			if low[i] < sar {
+				up, sar, ep, af = false, ep, low[i], step
+			} else if high[i] > ep {
+				ep, af = high[i], math.Min(af+step, maxStep)
+			}
+		} else {
+			sar = math.Max(sar, math.Max(high[i-1], high[i-2]))
+			if high[i] > sar {
+				up, sar, ep, af = true, ep, high[i], step
+			} else if low[i] < ep {
+				ep, af = low[i], math.Min(af+step, maxStep)
+			}
+		}
+		out[i] = sar
+	}
+	return out
+}
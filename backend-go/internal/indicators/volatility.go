@@ -0,0 +1,59 @@
+package indicators
+
+// TrueRangeSeries 실제 범위 시계열 (첫 캔들은 고가-저가)
+func TrueRangeSeries(high, low, close []float64) Series {
+	out := make(Series, len(close))
+	for i := range close {
+		if i == 0 {
+			out[i] = TrueRange(high[i], low[i], 0, false)
+			continue
+		}
+		out[i] = TrueRange(high[i], low[i], close[i-1], true)
+	}
+	return out
+}
+
+// ATR Wilder 평활 평균 실제 범위
+func ATR(high, low, close []float64, period int) Series {
+	return RMA(TrueRangeSeries(high, low, close), period)
+}
+
+// Bollinger 볼린저 밴드 (SMA ± multiplier × 모표준편차)
+func Bollinger(src []float64, period int, multiplier float64) (upper, middle, lower Series) {
+	middle = SMA(src, period)
+	std := StdDev(src, period)
+	upper, lower = nanSeries(len(src)), nanSeries(len(src))
+	for i := range src {
+		if valid(middle[i]) {
+			upper[i] = middle[i] + multiplier*std[i]
+			lower[i] = middle[i] - multiplier*std[i]
+		}
+	}
+	return upper, middle, lower
+}
+
+// Keltner 켈트너 채널 (종가 EMA ± multiplier × ATR)
+func Keltner(high, low, close []float64, emaPeriod, atrPeriod int, multiplier float64) (upper, middle, lower Series) {
+	middle = EMA(close, emaPeriod)
+	atr := ATR(high, low, close, atrPeriod)
+	upper, lower = nanSeries(len(close)), nanSeries(len(close))
+	for i := range close {
+		if valid(middle[i]) && valid(atr[i]) {
+			upper[i] = middle[i] + multiplier*atr[i]
+			lower[i] = middle[i] - multiplier*atr[i]
+		}
+	}
+	return upper, middle, lower
+}
+
+// PercentB 볼린저 밴드 내 위치 (0 = 하단, 1 = 상단, 밴드 폭이 0이면 NaN)
+func PercentB(src []float64, period int, multiplier float64) Series {
+	upper, _, lower := Bollinger(src, period, multiplier)
+	out := nanSeries(len(src))
+	for i := range src {
+		if valid(upper[i]) && upper[i] > lower[i] {
+			out[i] = (src[i] - lower[i]) / (upper[i] - lower[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package indicators
+
+// OBV 거래량 누적 지표 (On-Balance Volume)
+func OBV(close, volume []float64) Series {
+	out := make(Series, len(close))
+	for i := 1; i < len(close); i++ {
+		out[i] = out[i-1]
+		switch {
+		case close[i] > close[i-1]:
+			out[i] += volume[i]
+		case close[i] < close[i-1]:
+			out[i] -= volume[i]
+		}
+	}
+	return out
+}
+
+// VWAP 세션 VWAP (캔들 시각이 있으면 UTC 자정마다 초기화, 없으면 전체 누적)
+func VWAP(o OHLCV) Series {
+	tp := o.Typical()
+	out := make(Series, o.Len())
+
+	var sumPV, sumV float64
+	for i := range tp {
+		if sessionStart(o.Time, i) {
+			sumPV, sumV = 0, 0
+		}
+		sumPV += tp[i] * o.Volume[i]
+		sumV += o.Volume[i]
+		if sumV > 0 {
+			out[i] = sumPV / sumV
+		} else {
+			out[i] = tp[i]
+		}
+	}
+	return out
+}
+
+// CMF 차이킨 자금흐름 (-1 ~ 1)
+func CMF(high, low, close, volume []float64, period int) Series {
+	flow := make([]float64, len(close))
+	for i := range close {
+		if rng := high[i] - low[i]; rng > 0 {
+			flow[i] = ((close[i] - low[i]) - (high[i] - close[i])) / rng * volume[i]
+		}
+	}
+
+	out := nanSeries(len(close))
+	for i := period - 1; i < len(close); i++ {
+		sumFlow, sumVol := 0.0, 0.0
+		for j := i - period + 1; j <= i; j++ {
+			sumFlow += flow[j]
+			sumVol += volume[j]
+		}
+		if sumVol > 0 {
+			out[i] = sumFlow / sumVol
+		} else {
+			out[i] = 0
+		}
+	}
+	return out
+}
@@ -0,0 +1,595 @@
+package market
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	barInterval       = time.Minute
+	defaultBarHistory = 1440 // 심볼별 보관하는 1분 풋프린트 캔들 수 (1일)
+	defaultBucketBps  = 1.0  // 가격 레벨 폭 기본값 (가격의 0.01%)
+	valueAreaRatio    = 0.7  // 밸류 에어리어 = 전체 거래량의 70%
+)
+
+// barCloseGrace 구간이 끝난 뒤 지연 도착한 체결을 기다렸다가 캔들을 마감하는 유예 시간
+const barCloseGrace = 2 * time.Second
+
+// ErrNoBars 체결 데이터가 아직 없음
+var ErrNoBars = errors.New("no trade bars available")
+
+// Trade 체결 (trade 스트림)
+// BuyerMaker가 true면 매도 주문이 매수 호가를 친 것(매도 체결), false면 매수 체결
+type Trade struct {
+	Symbol     string
+	Price      float64
+	Quantity   float64
+	Time       int64
+	BuyerMaker bool
+}
+
+// FootprintLevel 가격 레벨별 매수/매도 체결량
+type FootprintLevel struct {
+	Price     float64 `json:"price"`
+	BidVolume float64 `json:"bidVolume"` // 매수 호가에서 체결된 매도량
+	AskVolume float64 `json:"askVolume"` // 매도 호가에서 체결된 매수량
+	Delta     float64 `json:"delta"`
+}
+
+// FootprintCandle 가격 레벨별 체결량을 포함한 캔들
+type FootprintCandle struct {
+	Symbol     string           `json:"symbol"`
+	Interval   string           `json:"interval"`
+	OpenTime   int64            `json:"openTime"`
+	CloseTime  int64            `json:"closeTime"`
+	Open       float64          `json:"open"`
+	High       float64          `json:"high"`
+	Low        float64          `json:"low"`
+	Close      float64          `json:"close"`
+	Volume     float64          `json:"volume"`
+	BuyVolume  float64          `json:"buyVolume"`
+	SellVolume float64          `json:"sellVolume"`
+	Delta      float64          `json:"delta"`
+	VWAP       float64          `json:"vwap"`
+	POC        float64          `json:"poc"`
+	Trades     int              `json:"trades"`
+	Step       float64          `json:"step"`
+	Levels     []FootprintLevel `json:"levels"`
+
+	sumPV  float64 // Σ price*qty
+	sumPV2 float64 // Σ price²*qty (VWAP 밴드 계산용)
+}
+
+// VWAPPoint 세션 VWAP 시계열 한 점
+type VWAPPoint struct {
+	Time   int64   `json:"time"`
+	Close  float64 `json:"close"`
+	VWAP   float64 `json:"vwap"`
+	Upper  float64 `json:"upper"` // VWAP + 1σ
+	Lower  float64 `json:"lower"` // VWAP - 1σ
+	Volume float64 `json:"volume"`
+}
+
+// ProfileLevel 볼륨 프로파일 가격 레벨
+type ProfileLevel struct {
+	Price      float64 `json:"price"`
+	Volume     float64 `json:"volume"`
+	BuyVolume  float64 `json:"buyVolume"`
+	SellVolume float64 `json:"sellVolume"`
+}
+
+// VolumeProfile 구간 볼륨 프로파일
+type VolumeProfile struct {
+	Symbol      string         `json:"symbol"`
+	From        int64          `json:"from"`
+	To          int64          `json:"to"`
+	Step        float64        `json:"step"`
+	TotalVolume float64        `json:"totalVolume"`
+	POC         float64        `json:"poc"`
+	VAH         float64        `json:"vah"`
+	VAL         float64        `json:"val"`
+	Levels      []ProfileLevel `json:"levels"`
+}
+
+// barState 심볼별 진행 중 캔들과 마감된 캔들 이력
+type barState struct {
+	current     *FootprintCandle
+	levels      map[int64]*FootprintLevel
+	history     []FootprintCandle
+	closedUntil int64 // 마지막으로 마감된 캔들의 CloseTime (이전 체결은 버린다)
+}
+
+// BarBuilder trade 스트림으로 풋프린트 캔들, VWAP, 볼륨 프로파일을 생성
+type BarBuilder struct {
+	mu        sync.RWMutex
+	symbols   map[string]*barState
+	history   int
+	bucketBps float64
+	onClose   func(FootprintCandle)
+	stop      chan struct{}
+}
+
+var barBuilder *BarBuilder
+var barBuilderOnce sync.Once
+
+// GetBarBuilder returns the global trade bar builder
+func GetBarBuilder() *BarBuilder {
+	barBuilderOnce.Do(func() {
+		barBuilder = NewBarBuilder()
+	})
+	return barBuilder
+}
+
+// NewBarBuilder 전역 빌더와 분리된 빌더 (리플레이처럼 라이브 캔들을 건드리면 안 되는 경우)
+// Start 대신 CloseDue에 이벤트 시각을 넘겨 캔들을 마감한다
+func NewBarBuilder() *BarBuilder {
+	return &BarBuilder{
+		symbols:   make(map[string]*barState),
+		history:   envInt("BAR_HISTORY", defaultBarHistory),
+		bucketBps: defaultBucketBps,
+	}
+}
+
+// SetOnClose 캔들 마감 시 호출할 콜백 등록
+func (b *BarBuilder) SetOnClose(fn func(FootprintCandle)) {
+	b.mu.Lock()
+	b.onClose = fn
+	b.mu.Unlock()
+}
+
+// Start 거래가 뜸한 심볼도 제때 마감되도록 매초 만료된 캔들을 닫는다
+func (b *BarBuilder) Start() {
+	b.mu.Lock()
+	if b.stop != nil {
+		b.mu.Unlock()
+		return
+	}
+	b.stop = make(chan struct{})
+	stop := b.stop
+	b.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				b.CloseDue(now.UnixMilli())
+			}
+		}
+	}()
+}
+
+// AddTrade 체결을 진행 중 캔들에 반영 (다음 구간 체결이면 이전 캔들을 마감)
+func (b *BarBuilder) AddTrade(t Trade) {
+	if t.Price <= 0 || t.Quantity <= 0 {
+		return
+	}
+
+	var closed []FootprintCandle
+
+	b.mu.Lock()
+	state, ok := b.symbols[t.Symbol]
+	if !ok {
+		state = &barState{}
+		b.symbols[t.Symbol] = state
+	}
+
+	// 이미 마감된 구간의 체결은 버린다 (같은 OpenTime 캔들이 다시 열리는 것을 방지)
+	if t.Time <= state.closedUntil || state.current != nil && t.Time < state.current.OpenTime {
+		b.mu.Unlock()
+		return
+	}
+
+	if state.current != nil && t.Time > state.current.CloseTime {
+		closed = append(closed, b.finish(state))
+	}
+	if state.current == nil {
+		openTime := t.Time - t.Time%barInterval.Milliseconds()
+		state.current = &FootprintCandle{
+			Symbol:    t.Symbol,
+			Interval:  "1m",
+			OpenTime:  openTime,
+			CloseTime: openTime + barInterval.Milliseconds() - 1,
+			Open:      t.Price,
+			High:      t.Price,
+			Low:       t.Price,
+			Step:      b.bucketStep(t.Symbol, t.Price),
+		}
+		state.levels = make(map[int64]*FootprintLevel)
+	}
+
+	c := state.current
+	c.High = math.Max(c.High, t.Price)
+	c.Low = math.Min(c.Low, t.Price)
+	c.Close = t.Price
+	c.Volume += t.Quantity
+	c.Trades++
+	c.sumPV += t.Price * t.Quantity
+	c.sumPV2 += t.Price * t.Price * t.Quantity
+
+	key := int64(math.Floor(t.Price/c.Step + 1e-9))
+	level, ok := state.levels[key]
+	if !ok {
+		level = &FootprintLevel{Price: roundToStep(t.Price, c.Step)}
+		state.levels[key] = level
+	}
+	if t.BuyerMaker {
+		c.SellVolume += t.Quantity
+		level.BidVolume += t.Quantity
+	} else {
+		c.BuyVolume += t.Quantity
+		level.AskVolume += t.Quantity
+	}
+	onClose := b.onClose
+	b.mu.Unlock()
+
+	if onClose != nil {
+		for _, candle := range closed {
+			onClose(candle)
+		}
+	}
+}
+
+// CloseDue now(밀리초) 기준으로 구간이 끝나고 유예 시간이 지난 진행 중 캔들을 마감
+func (b *BarBuilder) CloseDue(now int64) {
+	var closed []FootprintCandle
+
+	b.mu.Lock()
+	for _, state := range b.symbols {
+		if state.current != nil && now > state.current.CloseTime+barCloseGrace.Milliseconds() {
+			closed = append(closed, b.finish(state))
+		}
+	}
+	onClose := b.onClose
+	b.mu.Unlock()
+
+	if onClose != nil {
+		for _, candle := range closed {
+			onClose(candle)
+		}
+	}
+}
+
+// finish 진행 중 캔들을 확정해 이력에 추가 (mu held)
+func (b *BarBuilder) finish(state *barState) FootprintCandle {
+	candle := snapshotCandle(state.current, state.levels)
+	state.history = append(state.history, candle)
+	state.closedUntil = candle.CloseTime
+	if len(state.history) > b.history {
+		state.history = append(state.history[:0:0], state.history[len(state.history)-b.history:]...)
+	}
+	state.current = nil
+	state.levels = nil
+	return candle
+}
+
+// snapshotCandle 레벨 맵을 가격순 슬라이스로 변환한 캔들 복사본
+func snapshotCandle(current *FootprintCandle, levels map[int64]*FootprintLevel) FootprintCandle {
+	candle := *current
+	candle.Levels = make([]FootprintLevel, 0, len(levels))
+	for _, level := range levels {
+		l := *level
+		l.Delta = l.AskVolume - l.BidVolume
+		candle.Levels = append(candle.Levels, l)
+	}
+	sort.Slice(candle.Levels, func(i, j int) bool { return candle.Levels[i].Price < candle.Levels[j].Price })
+
+	maxVolume := -1.0
+	for _, l := range candle.Levels {
+		if v := l.AskVolume + l.BidVolume; v > maxVolume {
+			maxVolume = v
+			candle.POC = l.Price
+		}
+	}
+
+	candle.Delta = candle.BuyVolume - candle.SellVolume
+	if candle.Volume > 0 {
+		candle.VWAP = candle.sumPV / candle.Volume
+	}
+	return candle
+}
+
+// bucketStep 심볼의 가격 레벨 폭 (1-2-5 단위로 맞추고 tickSize 배수로 정렬)
+func (b *BarBuilder) bucketStep(symbol string, price float64) float64 {
+	step := niceStep(price * b.bucketBps / 10000)
+	if info, ok := GetRegistry().Get(symbol); ok && info.TickSize > 0 {
+		step = math.Max(1, math.Round(step/info.TickSize)) * info.TickSize
+	}
+	return step
+}
+
+// niceStep 1, 2, 5 × 10^n 중 가장 가까운 값
+func niceStep(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5} {
+		if v <= m*magnitude*1.5 {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// Footprints 최근 마감된 1분 풋프린트 캔들 (includeCurrent면 진행 중 캔들 포함)
+func (b *BarBuilder) Footprints(symbol string, includeCurrent bool) []FootprintCandle {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	state, ok := b.symbols[symbol]
+	if !ok {
+		return []FootprintCandle{}
+	}
+
+	candles := make([]FootprintCandle, len(state.history), len(state.history)+1)
+	copy(candles, state.history)
+	if includeCurrent && state.current != nil {
+		candles = append(candles, snapshotCandle(state.current, state.levels))
+	}
+	return candles
+}
+
+// Current 진행 중인 풋프린트 캔들 (없으면 nil)
+func (b *BarBuilder) Current(symbol string) *FootprintCandle {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	state, ok := b.symbols[symbol]
+	if !ok || state.current == nil {
+		return nil
+	}
+	candle := snapshotCandle(state.current, state.levels)
+	return &candle
+}
+
+// AggregateFootprints 1분 풋프린트 캔들을 더 긴 인터벌로 병합
+func AggregateFootprints(candles []FootprintCandle, interval string) ([]FootprintCandle, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	if step < barInterval || step%barInterval != 0 {
+		return nil, errors.New("interval must be a multiple of 1m")
+	}
+	if step == barInterval {
+		return candles, nil
+	}
+	stepMs := step.Milliseconds()
+
+	result := make([]FootprintCandle, 0, len(candles)/int(step/barInterval)+1)
+	var merged *FootprintCandle
+	var levels map[int64]*FootprintLevel
+
+	for _, c := range candles {
+		openTime := c.OpenTime - c.OpenTime%stepMs
+		if merged != nil && merged.OpenTime != openTime {
+			result = append(result, snapshotCandle(merged, levels))
+			merged = nil
+		}
+		if merged == nil {
+			merged = &FootprintCandle{
+				Symbol:    c.Symbol,
+				Interval:  interval,
+				OpenTime:  openTime,
+				CloseTime: openTime + stepMs - 1,
+				Open:      c.Open,
+				High:      c.High,
+				Low:       c.Low,
+				Step:      c.Step,
+			}
+			levels = make(map[int64]*FootprintLevel)
+		}
+
+		merged.High = math.Max(merged.High, c.High)
+		merged.Low = math.Min(merged.Low, c.Low)
+		merged.Close = c.Close
+		merged.Volume += c.Volume
+		merged.BuyVolume += c.BuyVolume
+		merged.SellVolume += c.SellVolume
+		merged.Trades += c.Trades
+		merged.sumPV += c.sumPV
+		merged.sumPV2 += c.sumPV2
+		if c.Step > merged.Step {
+			merged.Step = c.Step
+		}
+
+		for _, l := range c.Levels {
+			key := int64(math.Floor(l.Price/merged.Step + 1e-9))
+			level, ok := levels[key]
+			if !ok {
+				level = &FootprintLevel{Price: roundToStep(l.Price, merged.Step)}
+				levels[key] = level
+			}
+			level.BidVolume += l.BidVolume
+			level.AskVolume += l.AskVolume
+		}
+	}
+	if merged != nil {
+		result = append(result, snapshotCandle(merged, levels))
+	}
+	return result, nil
+}
+
+// VWAPSeries UTC 일 단위 세션 VWAP과 ±1σ 밴드 시계열
+func VWAPSeries(candles []FootprintCandle) []VWAPPoint {
+	points := make([]VWAPPoint, 0, len(candles))
+
+	var session int64 = -1
+	var cumV, cumPV, cumPV2 float64
+	dayMs := (24 * time.Hour).Milliseconds()
+
+	for _, c := range candles {
+		if day := c.OpenTime / dayMs; day != session {
+			session = day
+			cumV, cumPV, cumPV2 = 0, 0, 0
+		}
+		cumV += c.Volume
+		cumPV += c.sumPV
+		cumPV2 += c.sumPV2
+		if cumV == 0 {
+			continue
+		}
+
+		vwap := cumPV / cumV
+		std := math.Sqrt(math.Max(0, cumPV2/cumV-vwap*vwap))
+		points = append(points, VWAPPoint{
+			Time:   c.CloseTime,
+			Close:  c.Close,
+			VWAP:   vwap,
+			Upper:  vwap + std,
+			Lower:  vwap - std,
+			Volume: c.Volume,
+		})
+	}
+	return points
+}
+
+// BuildVolumeProfile 캔들 구간의 볼륨 프로파일과 POC/밸류 에어리어 계산
+// step이 0이면 캔들 레벨 폭 중 가장 큰 값을 사용
+func BuildVolumeProfile(symbol string, candles []FootprintCandle, step float64) (*VolumeProfile, error) {
+	if len(candles) == 0 {
+		return nil, ErrNoBars
+	}
+	if step <= 0 {
+		for _, c := range candles {
+			step = math.Max(step, c.Step)
+		}
+	}
+
+	profile := &VolumeProfile{
+		Symbol: symbol,
+		From:   candles[0].OpenTime,
+		To:     candles[len(candles)-1].CloseTime,
+		Step:   step,
+	}
+
+	buckets := make(map[int64]*ProfileLevel)
+	for _, c := range candles {
+		for _, l := range c.Levels {
+			key := int64(math.Floor(l.Price/step + 1e-9))
+			level, ok := buckets[key]
+			if !ok {
+				level = &ProfileLevel{Price: roundToStep(l.Price, step)}
+				buckets[key] = level
+			}
+			level.BuyVolume += l.AskVolume
+			level.SellVolume += l.BidVolume
+			level.Volume += l.AskVolume + l.BidVolume
+			profile.TotalVolume += l.AskVolume + l.BidVolume
+		}
+	}
+
+	profile.Levels = make([]ProfileLevel, 0, len(buckets))
+	for _, level := range buckets {
+		profile.Levels = append(profile.Levels, *level)
+	}
+	sort.Slice(profile.Levels, func(i, j int) bool { return profile.Levels[i].Price < profile.Levels[j].Price })
+
+	if len(profile.Levels) == 0 {
+		return profile, nil
+	}
+
+	poc := 0
+	for i, level := range profile.Levels {
+		if level.Volume > profile.Levels[poc].Volume {
+			poc = i
+		}
+	}
+	profile.POC = profile.Levels[poc].Price
+
+	// POC에서 시작해 거래량이 더 많은 쪽으로 확장하며 70%를 채운다
+	lo, hi := poc, poc
+	acc := profile.Levels[poc].Volume
+	for acc < profile.TotalVolume*valueAreaRatio && (lo > 0 || hi < len(profile.Levels)-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = profile.Levels[lo-1].Volume
+		}
+		if hi < len(profile.Levels)-1 {
+			above = profile.Levels[hi+1].Volume
+		}
+		if above >= below {
+			hi++
+			acc += above
+		} else {
+			lo--
+			acc += below
+		}
+	}
+	profile.VAL = profile.Levels[lo].Price
+	profile.VAH = profile.Levels[hi].Price
+
+	return profile, nil
+}
+
+// SessionProfile 오늘(UTC) 세션 볼륨 프로파일
+func (b *BarBuilder) SessionProfile(symbol string, step float64) (*VolumeProfile, error) {
+	dayMs := (24 * time.Hour).Milliseconds()
+	sessionStart := time.Now().UnixMilli() / dayMs * dayMs
+	return BuildVolumeProfile(symbol, barsSince(b.Footprints(symbol, true), sessionStart), step)
+}
+
+// RollingProfile 최근 window 구간 볼륨 프로파일
+func (b *BarBuilder) RollingProfile(symbol string, window time.Duration, step float64) (*VolumeProfile, error) {
+	since := time.Now().Add(-window).UnixMilli()
+	return BuildVolumeProfile(symbol, barsSince(b.Footprints(symbol, true), since), step)
+}
+
+// SessionVWAP 현재 세션 VWAP 시계열
+func (b *BarBuilder) SessionVWAP(symbol string) []VWAPPoint {
+	return VWAPSeries(b.Footprints(symbol, true))
+}
+
+// barsSince openTime이 since 이후인 캔들
+func barsSince(candles []FootprintCandle, since int64) []FootprintCandle {
+	i := sort.Search(len(candles), func(i int) bool { return candles[i].OpenTime >= since })
+	return candles[i:]
+}
+
+// BarFeatures 모델 입력용 오더플로 피처 (VWAP 괴리, POC 거리, 밸류 에어리어 위치, 델타 비율)
+func (b *BarBuilder) BarFeatures(symbol string) (map[string]float64, error) {
+	candles := b.Footprints(symbol, true)
+	if len(candles) == 0 {
+		return nil, ErrNoBars
+	}
+	last := candles[len(candles)-1]
+
+	features := make(map[string]float64)
+	if series := VWAPSeries(candles); len(series) > 0 {
+		point := series[len(series)-1]
+		if point.VWAP > 0 {
+			features["vwap_deviation"] = (last.Close - point.VWAP) / point.VWAP
+		}
+		if std := point.Upper - point.VWAP; std > 0 {
+			features["vwap_zscore"] = (last.Close - point.VWAP) / std
+		}
+	}
+
+	if profile, err := b.SessionProfile(symbol, 0); err == nil && profile.POC > 0 {
+		features["poc_distance"] = (last.Close - profile.POC) / profile.POC
+		inValue := 0.0
+		if last.Close >= profile.VAL && last.Close <= profile.VAH {
+			inValue = 1
+		}
+		features["in_value_area"] = inValue
+	}
+
+	// 최근 1시간 누적 델타 비율
+	var delta, volume float64
+	for _, c := range barsSince(candles, time.Now().Add(-time.Hour).UnixMilli()) {
+		delta += c.Delta
+		volume += c.Volume
+	}
+	if volume > 0 {
+		features["delta_ratio_1h"] = delta / volume
+	}
+
+	return features, nil
+}
@@ -1,41 +1,102 @@
 package market
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
+
+	"github.com/loadstar0723/monstas7-backend/pkg/kline"
 )
 
 // BinanceClient Binance API 클라이언트
 type BinanceClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL   string
+	client    *http.Client
+	scheduler *RequestScheduler
 }
 
-// NewBinanceClient 새 Binance 클라이언트 생성
+// NewBinanceClient 새 Binance 클라이언트 생성 (가중치 스케줄러는 모든 클라이언트가 공유)
 func NewBinanceClient() *BinanceClient {
 	return &BinanceClient{
 		baseURL: "https://api.binance.com",
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		scheduler: GetScheduler(),
 	}
 }
 
+// Binance 엔드포인트별 요청 가중치
+const (
+	weightTickerPrice    = 2
+	weightTickerPriceAll = 4
+	weightTicker24hr     = 2
+	weightKlines         = 2
+	weightRecentTrades   = 25
+	weightExchangeInfo   = 20
+)
+
+// depthWeight 오더북 limit에 따른 요청 가중치
+func depthWeight(limit int) int {
+	switch {
+	case limit <= 100:
+		return 5
+	case limit <= 500:
+		return 25
+	case limit <= 1000:
+		return 50
+	default:
+		return 250
+	}
+}
+
+// get 스케줄러를 거쳐 GET 요청을 보내고 JSON 응답을 디코딩
+func (c *BinanceClient) get(path string, params url.Values, weight int, dest interface{}) error {
+	endpoint := c.baseURL + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.scheduler.Do(c.client, req, weight)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 // Kline 캔들스틱 데이터
 type Kline struct {
-	OpenTime     int64   `json:"openTime"`
-	Open         float64 `json:"open"`
-	High         float64 `json:"high"`
-	Low          float64 `json:"low"`
-	Close        float64 `json:"close"`
-	Volume       float64 `json:"volume"`
-	CloseTime    int64   `json:"closeTime"`
-	QuoteVolume  float64 `json:"quoteAssetVolume"`
-	TradeCount   int     `json:"count"`
+	OpenTime    int64       `json:"openTime"`
+	Open        float64     `json:"open"`
+	High        float64     `json:"high"`
+	Low         float64     `json:"low"`
+	Close       float64     `json:"close"`
+	Volume      float64     `json:"volume"`
+	CloseTime   int64       `json:"closeTime"`
+	QuoteVolume float64     `json:"quoteAssetVolume"`
+	TradeCount  int         `json:"count"`
+	Flags       QualityFlag `json:"flags,omitempty"`
 }
 
 // TickerPrice 현재 가격 정보
@@ -66,43 +127,31 @@ type Ticker24hr struct {
 
 // GetCurrentPrice 현재 가격 조회
 func (c *BinanceClient) GetCurrentPrice(symbol string) (*TickerPrice, error) {
-	url := fmt.Sprintf("%s/api/v3/ticker/price?symbol=%s", c.baseURL, symbol)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
+	var ticker TickerPrice
+	params := url.Values{"symbol": {symbol}}
+	if err := c.get("/api/v3/ticker/price", params, weightTickerPrice, &ticker); err != nil {
 		return nil, fmt.Errorf("failed to get price: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+	return &ticker, nil
+}
 
-	var ticker TickerPrice
-	if err := json.NewDecoder(resp.Body).Decode(&ticker); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetAllPrices 전체 심볼 현재 가격 조회 (단일 요청)
+func (c *BinanceClient) GetAllPrices() ([]TickerPrice, error) {
+	var tickers []TickerPrice
+	if err := c.get("/api/v3/ticker/price", nil, weightTickerPriceAll, &tickers); err != nil {
+		return nil, fmt.Errorf("failed to get prices: %w", err)
 	}
 
-	return &ticker, nil
+	return tickers, nil
 }
 
 // Get24hrTicker 24시간 통계 조회
 func (c *BinanceClient) Get24hrTicker(symbol string) (*Ticker24hr, error) {
-	url := fmt.Sprintf("%s/api/v3/ticker/24hr?symbol=%s", c.baseURL, symbol)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 24hr ticker: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
 	var ticker Ticker24hr
-	if err := json.NewDecoder(resp.Body).Decode(&ticker); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	params := url.Values{"symbol": {symbol}}
+	if err := c.get("/api/v3/ticker/24hr", params, weightTicker24hr, &ticker); err != nil {
+		return nil, fmt.Errorf("failed to get 24hr ticker: %w", err)
 	}
 
 	return &ticker, nil
@@ -110,58 +159,61 @@ func (c *BinanceClient) Get24hrTicker(symbol string) (*Ticker24hr, error) {
 
 // GetKlines 캔들스틱 데이터 조회
 func (c *BinanceClient) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
-	url := fmt.Sprintf("%s/api/v3/klines?symbol=%s&interval=%s&limit=%d",
-		c.baseURL, symbol, interval, limit)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get klines: %w", err)
+	params := url.Values{
+		"symbol":   {symbol},
+		"interval": {interval},
+		"limit":    {strconv.Itoa(limit)},
 	}
-	defer resp.Body.Close()
+	return c.fetchKlines(params)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+// GetKlinesRange 시작/종료 시각(밀리초) 구간의 캔들스틱 데이터 조회
+func (c *BinanceClient) GetKlinesRange(symbol, interval string, startTime, endTime int64, limit int) ([]Kline, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
 	}
-
-	var rawKlines [][]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&rawKlines); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	params := url.Values{
+		"symbol":    {symbol},
+		"interval":  {interval},
+		"startTime": {strconv.FormatInt(startTime, 10)},
+		"endTime":   {strconv.FormatInt(endTime, 10)},
+		"limit":     {strconv.Itoa(limit)},
 	}
+	return c.fetchKlines(params)
+}
 
-	klines := make([]Kline, 0, len(rawKlines))
-	for _, raw := range rawKlines {
-		if len(raw) < 11 {
-			continue
-		}
+// fetchKlines klines 엔드포인트 호출 및 파싱
+// 잘못된 행은 건너뛰며 (누락 구간은 품질 검사에서 갭으로 처리), 모든 행이 잘못되면 실패
+func (c *BinanceClient) fetchKlines(params url.Values) ([]Kline, error) {
+	var raw json.RawMessage
+	if err := c.get("/api/v3/klines", params, weightKlines, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
 
-		kline := Kline{
-			OpenTime:    int64(raw[0].(float64)),
-			CloseTime:   int64(raw[6].(float64)),
-			TradeCount:  int(raw[8].(float64)),
+	rows, rowErrs, err := kline.DecodeLenient(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+	if len(rowErrs) > 0 {
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("failed to get klines: %w", rowErrs[0])
 		}
+		log.Printf("%s klines: skipped %d invalid rows (first: %v)", params.Get("symbol"), len(rowErrs), rowErrs[0])
+	}
 
-		// Parse string values to float64
-		if open, err := strconv.ParseFloat(raw[1].(string), 64); err == nil {
-			kline.Open = open
-		}
-		if high, err := strconv.ParseFloat(raw[2].(string), 64); err == nil {
-			kline.High = high
-		}
-		if low, err := strconv.ParseFloat(raw[3].(string), 64); err == nil {
-			kline.Low = low
-		}
-		if close, err := strconv.ParseFloat(raw[4].(string), 64); err == nil {
-			kline.Close = close
+	klines := make([]Kline, len(rows))
+	for i, k := range rows {
+		klines[i] = Kline{
+			OpenTime:    k.OpenTime,
+			Open:        k.Open,
+			High:        k.High,
+			Low:         k.Low,
+			Close:       k.Close,
+			Volume:      k.Volume,
+			CloseTime:   k.CloseTime,
+			QuoteVolume: k.QuoteVolume,
+			TradeCount:  int(k.Trades),
 		}
-		if volume, err := strconv.ParseFloat(raw[5].(string), 64); err == nil {
-			kline.Volume = volume
-		}
-		if quoteVolume, err := strconv.ParseFloat(raw[7].(string), 64); err == nil {
-			kline.QuoteVolume = quoteVolume
-		}
-
-		klines = append(klines, kline)
 	}
 
 	return klines, nil
@@ -169,21 +221,13 @@ func (c *BinanceClient) GetKlines(symbol, interval string, limit int) ([]Kline,
 
 // GetOrderBook 오더북 조회
 func (c *BinanceClient) GetOrderBook(symbol string, limit int) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v3/depth?symbol=%s&limit=%d", c.baseURL, symbol, limit)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get order book: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
 	var orderBook map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&orderBook); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	params := url.Values{
+		"symbol": {symbol},
+		"limit":  {strconv.Itoa(limit)},
+	}
+	if err := c.get("/api/v3/depth", params, depthWeight(limit), &orderBook); err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
 	}
 
 	return orderBook, nil
@@ -191,22 +235,72 @@ func (c *BinanceClient) GetOrderBook(symbol string, limit int) (map[string]inter
 
 // GetRecentTrades 최근 거래 내역 조회
 func (c *BinanceClient) GetRecentTrades(symbol string, limit int) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v3/trades?symbol=%s&limit=%d", c.baseURL, symbol, limit)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
+	var trades []map[string]interface{}
+	params := url.Values{
+		"symbol": {symbol},
+		"limit":  {strconv.Itoa(limit)},
+	}
+	if err := c.get("/api/v3/trades", params, weightRecentTrades, &trades); err != nil {
 		return nil, fmt.Errorf("failed to get recent trades: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	return trades, nil
+}
+
+// exchangeInfoResponse /api/v3/exchangeInfo 응답 중 필요한 부분
+type exchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol     string                   `json:"symbol"`
+		Status     string                   `json:"status"`
+		BaseAsset  string                   `json:"baseAsset"`
+		QuoteAsset string                   `json:"quoteAsset"`
+		Filters    []map[string]interface{} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// GetExchangeInfo 전체 심볼의 상태와 거래 필터 조회
+func (c *BinanceClient) GetExchangeInfo() ([]SymbolInfo, error) {
+	var info exchangeInfoResponse
+	if err := c.get("/api/v3/exchangeInfo", nil, weightExchangeInfo, &info); err != nil {
+		return nil, fmt.Errorf("failed to get exchange info: %w", err)
 	}
 
-	var trades []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&trades); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	symbols := make([]SymbolInfo, 0, len(info.Symbols))
+	for _, raw := range info.Symbols {
+		symbol := SymbolInfo{
+			Symbol:     raw.Symbol,
+			Status:     raw.Status,
+			BaseAsset:  raw.BaseAsset,
+			QuoteAsset: raw.QuoteAsset,
+		}
+
+		for _, filter := range raw.Filters {
+			switch filter["filterType"] {
+			case "PRICE_FILTER":
+				symbol.TickSize = filterFloat(filter, "tickSize")
+				symbol.MinPrice = filterFloat(filter, "minPrice")
+				symbol.MaxPrice = filterFloat(filter, "maxPrice")
+			case "LOT_SIZE":
+				symbol.StepSize = filterFloat(filter, "stepSize")
+				symbol.MinQty = filterFloat(filter, "minQty")
+				symbol.MaxQty = filterFloat(filter, "maxQty")
+			case "MIN_NOTIONAL", "NOTIONAL":
+				symbol.MinNotional = filterFloat(filter, "minNotional")
+			}
+		}
+
+		symbols = append(symbols, symbol)
 	}
 
-	return trades, nil
-}
\ No newline at end of file
+	return symbols, nil
+}
+
+// filterFloat exchangeInfo 필터의 문자열 숫자 필드를 float64로 변환
+func filterFloat(filter map[string]interface{}, key string) float64 {
+	if s, ok := filter[key].(string); ok {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+	}
+	return 0
+}
@@ -24,7 +24,7 @@ var once sync.Once
 // GetCollector returns the singleton collector instance
 func GetCollector() *DataCollector {
 	once.Do(func() {
-		symbols := []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "ADAUSDT"}
+		symbols := GetRegistry().Watchlist()
 		collector = &DataCollector{
 			binanceClient: NewBinanceClient(),
 			binanceWS:     NewBinanceWebSocket(symbols),
@@ -74,17 +74,32 @@ func (c *DataCollector) startWebSocket() {
 	}
 }
 
+// collectBinanceData WebSocket이 끊긴 동안에만 REST로 전체 가격을 한 번에 조회
 func (c *DataCollector) collectBinanceData() {
+	if c.binanceWS.IsConnected() || !c.binanceClient.scheduler.IsHealthy() {
+		return
+	}
+
+	prices, err := c.binanceClient.GetAllPrices()
+	if err != nil {
+		log.Printf("REST price fallback failed: %v", err)
+		return
+	}
+
+	watched := make(map[string]bool, len(c.symbols))
 	for _, symbol := range c.symbols {
-		price, err := c.binanceClient.GetCurrentPrice(symbol)
-		if err == nil {
-			c.mu.Lock()
-			c.data[symbol] = price
-			if price != nil {
-				c.priceData[symbol] = price.Price
-			}
-			c.mu.Unlock()
+		watched[symbol] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range prices {
+		price := prices[i]
+		if !watched[price.Symbol] {
+			continue
 		}
+		c.data[price.Symbol] = &price
+		c.priceData[price.Symbol] = price.Price
 	}
 }
 
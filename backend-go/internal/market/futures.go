@@ -0,0 +1,303 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FuturesClient Binance USDT-M 선물 API 클라이언트 (선물 전용 가중치 스케줄러 사용)
+type FuturesClient struct {
+	rest *BinanceClient
+}
+
+// NewFuturesClient 새 선물 클라이언트 생성
+func NewFuturesClient() *FuturesClient {
+	return &FuturesClient{
+		rest: &BinanceClient{
+			baseURL: "https://fapi.binance.com",
+			client: &http.Client{
+				Timeout: 10 * time.Second,
+			},
+			scheduler: GetFuturesScheduler(),
+		},
+	}
+}
+
+// 선물 엔드포인트별 요청 가중치
+const (
+	weightFundingRate   = 1
+	weightPremiumIndex  = 1
+	weightOpenInterest  = 1
+	weightFuturesData   = 1
+	weightPremiumAll    = 10
+	maxFuturesDataLimit = 500
+)
+
+// futuresDataPeriods /futures/data 엔드포인트가 허용하는 집계 주기
+var futuresDataPeriods = map[string]bool{
+	"5m": true, "15m": true, "30m": true, "1h": true, "2h": true,
+	"4h": true, "6h": true, "12h": true, "1d": true,
+}
+
+// 롱숏 비율 종류
+const (
+	RatioGlobalAccounts = "global"       // 전체 계정 롱숏 비율
+	RatioTopAccounts    = "topAccounts"  // 상위 트레이더 계정 롱숏 비율
+	RatioTopPositions   = "topPositions" // 상위 트레이더 포지션 롱숏 비율
+)
+
+var longShortPaths = map[string]string{
+	RatioGlobalAccounts: "/futures/data/globalLongShortAccountRatio",
+	RatioTopAccounts:    "/futures/data/topLongShortAccountRatio",
+	RatioTopPositions:   "/futures/data/topLongShortPositionRatio",
+}
+
+// FundingRate 펀딩비 정산 이력
+type FundingRate struct {
+	Symbol      string  `json:"symbol"`
+	FundingRate float64 `json:"fundingRate"`
+	FundingTime int64   `json:"fundingTime"`
+	MarkPrice   float64 `json:"markPrice"`
+}
+
+// PremiumIndex 마크/인덱스 가격과 다음 정산 예상 펀딩비
+type PremiumIndex struct {
+	Symbol               string  `json:"symbol"`
+	MarkPrice            float64 `json:"markPrice,string"`
+	IndexPrice           float64 `json:"indexPrice,string"`
+	EstimatedSettlePrice float64 `json:"estimatedSettlePrice,string"`
+	LastFundingRate      float64 `json:"lastFundingRate,string"` // 다음 정산에 적용될 예상 펀딩비
+	InterestRate         float64 `json:"interestRate,string"`
+	NextFundingTime      int64   `json:"nextFundingTime"`
+	Time                 int64   `json:"time"`
+}
+
+// Basis 마크 가격의 인덱스 대비 괴리율
+func (p *PremiumIndex) Basis() float64 {
+	if p.IndexPrice == 0 {
+		return 0
+	}
+	return (p.MarkPrice - p.IndexPrice) / p.IndexPrice
+}
+
+// OpenInterest 현재 미결제약정
+type OpenInterest struct {
+	Symbol       string  `json:"symbol"`
+	OpenInterest float64 `json:"openInterest,string"`
+	Time         int64   `json:"time"`
+}
+
+// OpenInterestHist 기간별 미결제약정 이력
+type OpenInterestHist struct {
+	Symbol               string  `json:"symbol"`
+	SumOpenInterest      float64 `json:"sumOpenInterest,string"`
+	SumOpenInterestValue float64 `json:"sumOpenInterestValue,string"`
+	Timestamp            int64   `json:"timestamp"`
+}
+
+// LongShortRatio 롱숏 비율 이력
+type LongShortRatio struct {
+	Symbol         string  `json:"symbol"`
+	LongShortRatio float64 `json:"longShortRatio,string"`
+	LongAccount    float64 `json:"longAccount,string"`
+	ShortAccount   float64 `json:"shortAccount,string"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+// GetFundingRates 펀딩비 이력 조회 (startTime/endTime은 0이면 생략)
+func (f *FuturesClient) GetFundingRates(symbol string, startTime, endTime int64, limit int) ([]FundingRate, error) {
+	params := url.Values{"symbol": {symbol}}
+	if startTime > 0 {
+		params.Set("startTime", strconv.FormatInt(startTime, 10))
+	}
+	if endTime > 0 {
+		params.Set("endTime", strconv.FormatInt(endTime, 10))
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	// 과거 정산분은 markPrice가 빈 문자열로 내려오므로 문자열로 받아 변환
+	var raw []struct {
+		Symbol      string `json:"symbol"`
+		FundingRate string `json:"fundingRate"`
+		FundingTime int64  `json:"fundingTime"`
+		MarkPrice   string `json:"markPrice"`
+	}
+	if err := f.rest.get("/fapi/v1/fundingRate", params, weightFundingRate, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get funding rates: %w", err)
+	}
+
+	rates := make([]FundingRate, 0, len(raw))
+	for _, r := range raw {
+		rate, _ := strconv.ParseFloat(r.FundingRate, 64)
+		mark, _ := strconv.ParseFloat(r.MarkPrice, 64)
+		rates = append(rates, FundingRate{
+			Symbol:      r.Symbol,
+			FundingRate: rate,
+			FundingTime: r.FundingTime,
+			MarkPrice:   mark,
+		})
+	}
+	return rates, nil
+}
+
+// GetPremiumIndex 마크/인덱스 가격과 예상 펀딩비 조회
+func (f *FuturesClient) GetPremiumIndex(symbol string) (*PremiumIndex, error) {
+	var index PremiumIndex
+	params := url.Values{"symbol": {symbol}}
+	if err := f.rest.get("/fapi/v1/premiumIndex", params, weightPremiumIndex, &index); err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	return &index, nil
+}
+
+// GetAllPremiumIndex 전체 심볼 마크 가격/펀딩비 조회 (단일 요청)
+func (f *FuturesClient) GetAllPremiumIndex() ([]PremiumIndex, error) {
+	var indexes []PremiumIndex
+	if err := f.rest.get("/fapi/v1/premiumIndex", nil, weightPremiumAll, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	return indexes, nil
+}
+
+// GetOpenInterest 현재 미결제약정 조회
+func (f *FuturesClient) GetOpenInterest(symbol string) (*OpenInterest, error) {
+	var oi OpenInterest
+	params := url.Values{"symbol": {symbol}}
+	if err := f.rest.get("/fapi/v1/openInterest", params, weightOpenInterest, &oi); err != nil {
+		return nil, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	return &oi, nil
+}
+
+// GetOpenInterestHist 기간별 미결제약정 이력 조회
+func (f *FuturesClient) GetOpenInterestHist(symbol, period string, limit int) ([]OpenInterestHist, error) {
+	params, err := futuresDataParams(symbol, period, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var hist []OpenInterestHist
+	if err := f.rest.get("/futures/data/openInterestHist", params, weightFuturesData, &hist); err != nil {
+		return nil, fmt.Errorf("failed to get open interest history: %w", err)
+	}
+	return hist, nil
+}
+
+// GetLongShortRatio 롱숏 비율 이력 조회 (kind: global, topAccounts, topPositions)
+func (f *FuturesClient) GetLongShortRatio(symbol, kind, period string, limit int) ([]LongShortRatio, error) {
+	path, ok := longShortPaths[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported long/short ratio type: %q", kind)
+	}
+
+	params, err := futuresDataParams(symbol, period, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var ratios []LongShortRatio
+	if err := f.rest.get(path, params, weightFuturesData, &ratios); err != nil {
+		return nil, fmt.Errorf("failed to get long/short ratio: %w", err)
+	}
+	return ratios, nil
+}
+
+// futuresDataParams /futures/data 공통 파라미터 검증
+func futuresDataParams(symbol, period string, limit int) (url.Values, error) {
+	if !futuresDataPeriods[period] {
+		return nil, fmt.Errorf("unsupported period: %q", period)
+	}
+	if limit <= 0 || limit > maxFuturesDataLimit {
+		limit = 30
+	}
+	return url.Values{
+		"symbol": {symbol},
+		"period": {period},
+		"limit":  {strconv.Itoa(limit)},
+	}, nil
+}
+
+// futuresFeatureTTL 모델 피처 캐시 유효 시간
+const futuresFeatureTTL = time.Minute
+
+type futuresFeatureEntry struct {
+	features  map[string]float64
+	err       error // 선물 시장이 없는 심볼은 실패도 캐시해 반복 조회를 막는다
+	fetchedAt time.Time
+}
+
+var (
+	futuresFeatureMu    sync.Mutex
+	futuresFeatureCache = make(map[string]futuresFeatureEntry)
+)
+
+// FuturesFeatures 모델 입력용 선물 피처 (심볼별 1분 캐시)
+// 마크/인덱스 조회가 실패하면 에러를 반환하고, 나머지 항목은 가능한 것만 채운다
+func FuturesFeatures(symbol string) (map[string]float64, error) {
+	futuresFeatureMu.Lock()
+	entry, ok := futuresFeatureCache[symbol]
+	futuresFeatureMu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < futuresFeatureTTL {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return copyFeatures(entry.features), nil
+	}
+
+	client := NewFuturesClient()
+	index, err := client.GetPremiumIndex(symbol)
+	if err != nil {
+		// 레이트리밋/밴은 일시적이므로 캐시하지 않음
+		if !errors.Is(err, ErrRateLimited) && !errors.Is(err, ErrIPBanned) {
+			futuresFeatureMu.Lock()
+			futuresFeatureCache[symbol] = futuresFeatureEntry{err: err, fetchedAt: time.Now()}
+			futuresFeatureMu.Unlock()
+		}
+		return nil, err
+	}
+
+	features := map[string]float64{
+		"funding_rate":     index.LastFundingRate,
+		"mark_index_basis": index.Basis(),
+	}
+
+	if oi, err := client.GetOpenInterest(symbol); err == nil {
+		features["open_interest"] = oi.OpenInterest
+	}
+	// 5분 간격 13개 = 최근 1시간 미결제약정 변화율
+	if hist, err := client.GetOpenInterestHist(symbol, "5m", 13); err == nil && len(hist) > 1 {
+		first := hist[0].SumOpenInterest
+		if first > 0 {
+			features["open_interest_change_1h"] = (hist[len(hist)-1].SumOpenInterest - first) / first
+		}
+	}
+	if ratios, err := client.GetLongShortRatio(symbol, RatioGlobalAccounts, "5m", 1); err == nil && len(ratios) > 0 {
+		features["long_short_ratio"] = ratios[len(ratios)-1].LongShortRatio
+	}
+
+	liq := GetLiquidationStore().Summary(symbol, time.Hour)
+	features["liquidation_long_notional_1h"] = liq.LongNotional
+	features["liquidation_short_notional_1h"] = liq.ShortNotional
+	features["liquidation_imbalance_1h"] = liq.Imbalance()
+
+	futuresFeatureMu.Lock()
+	futuresFeatureCache[symbol] = futuresFeatureEntry{features: features, fetchedAt: time.Now()}
+	futuresFeatureMu.Unlock()
+
+	return copyFeatures(features), nil
+}
+
+func copyFeatures(features map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(features))
+	for k, v := range features {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package market
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultLiquidationCapacity 심볼별로 보관하는 최근 강제청산 건수
+const defaultLiquidationCapacity = 1000
+
+// Liquidation 선물 강제청산 주문 (forceOrder 스트림)
+// Side가 SELL이면 롱 포지션 청산, BUY면 숏 포지션 청산
+type Liquidation struct {
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	OrderType string  `json:"orderType"`
+	Status    string  `json:"status"`
+	Price     float64 `json:"price"`
+	AvgPrice  float64 `json:"avgPrice"`
+	Quantity  float64 `json:"quantity"`
+	Notional  float64 `json:"notional"`
+	Time      int64   `json:"time"`
+}
+
+// IsLong 롱 포지션 청산 여부
+func (l *Liquidation) IsLong() bool {
+	return l.Side == "SELL"
+}
+
+// LiquidationSummary 구간별 강제청산 집계
+type LiquidationSummary struct {
+	Symbol        string  `json:"symbol"`
+	Window        string  `json:"window"`
+	LongCount     int     `json:"longCount"`
+	ShortCount    int     `json:"shortCount"`
+	LongNotional  float64 `json:"longNotional"`
+	ShortNotional float64 `json:"shortNotional"`
+}
+
+// Imbalance 롱 청산 비중 - 숏 청산 비중 (-1 ~ 1)
+func (s *LiquidationSummary) Imbalance() float64 {
+	total := s.LongNotional + s.ShortNotional
+	if total == 0 {
+		return 0
+	}
+	return (s.LongNotional - s.ShortNotional) / total
+}
+
+// LiquidationStore 스트림으로 받은 최근 강제청산을 심볼별 링버퍼로 보관
+type LiquidationStore struct {
+	mu       sync.RWMutex
+	capacity int
+	bySymbol map[string][]Liquidation
+}
+
+var liquidationStore *LiquidationStore
+var liquidationOnce sync.Once
+
+// GetLiquidationStore returns the global liquidation store
+func GetLiquidationStore() *LiquidationStore {
+	liquidationOnce.Do(func() {
+		liquidationStore = NewLiquidationStore()
+	})
+	return liquidationStore
+}
+
+// NewLiquidationStore 전역 저장소와 분리된 강제청산 저장소 (리플레이용)
+func NewLiquidationStore() *LiquidationStore {
+	return &LiquidationStore{
+		capacity: defaultLiquidationCapacity,
+		bySymbol: make(map[string][]Liquidation),
+	}
+}
+
+// Add 강제청산 추가 (용량을 넘으면 가장 오래된 건부터 제거)
+func (s *LiquidationStore) Add(l Liquidation) {
+	if l.Notional == 0 {
+		price := l.AvgPrice
+		if price == 0 {
+			price = l.Price
+		}
+		l.Notional = price * l.Quantity
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := append(s.bySymbol[l.Symbol], l)
+	if len(list) > s.capacity {
+		list = append(list[:0:0], list[len(list)-s.capacity:]...)
+	}
+	s.bySymbol[l.Symbol] = list
+}
+
+// Recent since(밀리초) 이후 강제청산을 최신순으로 최대 limit건 반환
+func (s *LiquidationStore) Recent(symbol string, since int64, limit int) []Liquidation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.bySymbol[symbol]
+	result := make([]Liquidation, 0)
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].Time < since || (limit > 0 && len(result) >= limit) {
+			break
+		}
+		result = append(result, list[i])
+	}
+	return result
+}
+
+// Summary 최근 window 동안의 롱/숏 청산 집계
+func (s *LiquidationStore) Summary(symbol string, window time.Duration) LiquidationSummary {
+	summary := LiquidationSummary{Symbol: symbol, Window: window.String()}
+	since := time.Now().Add(-window).UnixMilli()
+
+	for _, l := range s.Recent(symbol, since, 0) {
+		if l.IsLong() {
+			summary.LongCount++
+			summary.LongNotional += l.Notional
+		} else {
+			summary.ShortCount++
+			summary.ShortNotional += l.Notional
+		}
+	}
+	return summary
+}
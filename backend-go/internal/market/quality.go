@@ -0,0 +1,337 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// QualityFlag 캔들 품질 태그 (비트마스크)
+type QualityFlag uint8
+
+const (
+	FlagDuplicate    QualityFlag = 1 << iota // 같은 openTime 캔들이 중복 수신됨
+	FlagOutOfOrder                           // 시간 순서가 어긋난 상태로 수신됨
+	FlagZeroVolume                           // 거래량 0
+	FlagSpike                                // 수익률 이상치 (다음 캔들에서 되돌려진 급변)
+	FlagRepaired                             // 갭 복구를 위해 재조회된 캔들
+	FlagInterpolated                         // 이상치를 보간한 캔들
+)
+
+// suspectMask 모델/백테스트에서 제외하거나 보간해야 하는 플래그
+const suspectMask = FlagZeroVolume | FlagSpike
+
+// Has 플래그 포함 여부
+func (f QualityFlag) Has(flag QualityFlag) bool {
+	return f&flag != 0
+}
+
+// IsSuspect 의심 캔들 여부
+func (k *Kline) IsSuspect() bool {
+	return k.Flags&suspectMask != 0
+}
+
+// QualityConfig 품질 검사 파라미터
+type QualityConfig struct {
+	SpikeWindow    int     // 이상치 판단용 롤링 윈도우 크기
+	SpikeThreshold float64 // 로버스트 z-score 임계값 (MAD 기준)
+	MaxRepairGaps  int     // 한 번에 재조회할 최대 갭 수
+}
+
+// DefaultQualityConfig 기본 품질 검사 설정
+var DefaultQualityConfig = QualityConfig{
+	SpikeWindow:    30,
+	SpikeThreshold: 8.0,
+	MaxRepairGaps:  10,
+}
+
+// Gap 누락된 캔들 구간 (openTime 기준, 밀리초)
+type Gap struct {
+	From    int64 `json:"from"`
+	To      int64 `json:"to"`
+	Missing int   `json:"missing"`
+}
+
+// QualityReport 심볼/인터벌별 데이터 품질 리포트
+type QualityReport struct {
+	Symbol     string    `json:"symbol"`
+	Interval   string    `json:"interval"`
+	Candles    int       `json:"candles"`
+	Expected   int       `json:"expected"`
+	Gaps       []Gap     `json:"gaps"`
+	Missing    int       `json:"missing"`
+	Duplicates int       `json:"duplicates"`
+	OutOfOrder int       `json:"outOfOrder"`
+	ZeroVolume int       `json:"zeroVolume"`
+	Spikes     int       `json:"spikes"`
+	Repaired   int       `json:"repaired"`
+	Suspect    int       `json:"suspect"`
+	Score      float64   `json:"score"` // 0~100, 정상 캔들 비율
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// IntervalDuration Binance 인터벌 문자열을 기간으로 변환 (1M은 가변 길이라 지원하지 않음)
+func IntervalDuration(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("invalid interval: %q", interval)
+	}
+
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid interval: %q", interval)
+	}
+
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute, nil
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unsupported interval: %q", interval)
+	}
+}
+
+// CheckKlines 캔들을 정렬/중복 제거하고 품질 플래그와 리포트를 생성
+func CheckKlines(symbol, interval string, klines []Kline, cfg QualityConfig) ([]Kline, *QualityReport, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	stepMs := step.Milliseconds()
+
+	report := &QualityReport{
+		Symbol:    symbol,
+		Interval:  interval,
+		Gaps:      []Gap{},
+		CheckedAt: time.Now(),
+	}
+
+	// 순서 역전 감지 후 정렬
+	cleaned := make([]Kline, len(klines))
+	copy(cleaned, klines)
+	for i := 1; i < len(cleaned); i++ {
+		if cleaned[i].OpenTime < cleaned[i-1].OpenTime {
+			cleaned[i].Flags |= FlagOutOfOrder
+			report.OutOfOrder++
+		}
+	}
+	sort.SliceStable(cleaned, func(i, j int) bool { return cleaned[i].OpenTime < cleaned[j].OpenTime })
+
+	// 중복 제거 (마지막으로 받은 값을 유지)
+	deduped := cleaned[:0]
+	for _, k := range cleaned {
+		if n := len(deduped); n > 0 && deduped[n-1].OpenTime == k.OpenTime {
+			k.Flags |= deduped[n-1].Flags | FlagDuplicate
+			deduped[n-1] = k
+			report.Duplicates++
+			continue
+		}
+		deduped = append(deduped, k)
+	}
+	cleaned = deduped
+
+	// 갭 탐지
+	for i := 1; i < len(cleaned); i++ {
+		diff := cleaned[i].OpenTime - cleaned[i-1].OpenTime
+		if diff > stepMs {
+			missing := int(diff/stepMs) - 1
+			report.Gaps = append(report.Gaps, Gap{
+				From:    cleaned[i-1].OpenTime + stepMs,
+				To:      cleaned[i].OpenTime - stepMs,
+				Missing: missing,
+			})
+			report.Missing += missing
+		}
+	}
+
+	flagAnomalies(cleaned, cfg)
+	summarize(cleaned, report)
+
+	return cleaned, report, nil
+}
+
+// flagAnomalies 거래량 0 캔들과 스파이크 캔들에 플래그 설정
+// 스파이크는 로그수익률이 이상치이고 다음 캔들이 그 움직임의 절반 이상을 반대로 되돌린 캔들이다
+// (되돌림 캔들의 수익률도 이상치지만 정상 캔들이므로 표시하지 않음, 다음 캔들이 없는 마지막 캔들은 판단 보류)
+func flagAnomalies(klines []Kline, cfg QualityConfig) {
+	window := cfg.SpikeWindow
+	if window < 5 {
+		window = 5
+	}
+
+	returns := make([]float64, len(klines))
+	for i := range klines {
+		klines[i].Flags &^= suspectMask
+		if klines[i].Volume == 0 {
+			klines[i].Flags |= FlagZeroVolume
+		}
+		if i > 0 && klines[i-1].Close > 0 && klines[i].Close > 0 {
+			returns[i] = math.Log(klines[i].Close / klines[i-1].Close)
+		}
+	}
+
+	for i := window; i+1 < len(klines); i++ {
+		median, mad := medianAbsDeviation(returns[i-window : i])
+		if mad == 0 {
+			continue
+		}
+		// 1.4826 * MAD ≈ 정규분포 표준편차
+		if math.Abs(returns[i]-median)/(1.4826*mad) <= cfg.SpikeThreshold {
+			continue
+		}
+		if returns[i] != 0 && -returns[i+1]/returns[i] >= 0.5 {
+			klines[i].Flags |= FlagSpike
+		}
+	}
+}
+
+// summarize 플래그를 집계해 리포트 카운트와 점수 갱신
+func summarize(klines []Kline, report *QualityReport) {
+	report.Candles = len(klines)
+	report.Expected = len(klines) + report.Missing
+	report.ZeroVolume, report.Spikes, report.Suspect, report.Repaired = 0, 0, 0, 0
+
+	for i := range klines {
+		if klines[i].Flags.Has(FlagZeroVolume) {
+			report.ZeroVolume++
+		}
+		if klines[i].Flags.Has(FlagSpike) {
+			report.Spikes++
+		}
+		if klines[i].Flags.Has(FlagRepaired) {
+			report.Repaired++
+		}
+		if klines[i].IsSuspect() {
+			report.Suspect++
+		}
+	}
+
+	report.Score = 100
+	if report.Expected > 0 {
+		good := report.Candles - report.Suspect
+		report.Score = math.Round(float64(good)/float64(report.Expected)*10000) / 100
+	}
+}
+
+// RepairGaps 리포트의 갭 구간을 REST로 재조회해 채우고 리포트를 갱신
+func (c *BinanceClient) RepairGaps(klines []Kline, report *QualityReport, cfg QualityConfig) ([]Kline, error) {
+	if len(report.Gaps) == 0 {
+		return klines, nil
+	}
+
+	step, err := IntervalDuration(report.Interval)
+	if err != nil {
+		return klines, err
+	}
+
+	repaired := make([]Kline, 0, len(klines)+report.Missing)
+	repaired = append(repaired, klines...)
+
+	var lastErr error
+	for i, gap := range report.Gaps {
+		if cfg.MaxRepairGaps > 0 && i >= cfg.MaxRepairGaps {
+			break
+		}
+
+		fetched, err := c.GetKlinesRange(report.Symbol, report.Interval, gap.From, gap.To+step.Milliseconds()-1, gap.Missing)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, k := range fetched {
+			if k.OpenTime >= gap.From && k.OpenTime <= gap.To {
+				k.Flags |= FlagRepaired
+				repaired = append(repaired, k)
+			}
+		}
+	}
+
+	checked, rechecked, err := CheckKlines(report.Symbol, report.Interval, repaired, cfg)
+	if err != nil {
+		return klines, err
+	}
+
+	// 원본 수신 시 발견된 중복/역전 정보는 유지
+	rechecked.Duplicates = report.Duplicates
+	rechecked.OutOfOrder = report.OutOfOrder
+	*report = *rechecked
+
+	return checked, lastErr
+}
+
+// ExcludeSuspect 의심 캔들을 제외한 복사본 반환
+func ExcludeSuspect(klines []Kline) []Kline {
+	result := make([]Kline, 0, len(klines))
+	for _, k := range klines {
+		if !k.IsSuspect() {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// InterpolateSuspect 의심 캔들의 OHLC를 인접한 정상 캔들 사이에서 선형 보간
+func InterpolateSuspect(klines []Kline) []Kline {
+	result := make([]Kline, len(klines))
+	copy(result, klines)
+
+	for i := 0; i < len(result); i++ {
+		if !result[i].IsSuspect() {
+			continue
+		}
+
+		prev, next := i-1, i+1
+		for prev >= 0 && result[prev].IsSuspect() {
+			prev--
+		}
+		for next < len(result) && result[next].IsSuspect() {
+			next++
+		}
+
+		var price float64
+		switch {
+		case prev >= 0 && next < len(result):
+			ratio := float64(i-prev) / float64(next-prev)
+			price = result[prev].Close + (result[next].Close-result[prev].Close)*ratio
+		case prev >= 0:
+			price = result[prev].Close
+		case next < len(result):
+			price = result[next].Close
+		default:
+			continue
+		}
+
+		result[i].Open, result[i].High, result[i].Low, result[i].Close = price, price, price, price
+		result[i].Flags |= FlagInterpolated
+	}
+
+	// 보간된 캔들은 더 이상 의심 대상이 아님
+	for i := range result {
+		if result[i].Flags.Has(FlagInterpolated) {
+			result[i].Flags &^= suspectMask
+		}
+	}
+
+	return result
+}
+
+// medianAbsDeviation 중앙값과 MAD 계산
+func medianAbsDeviation(values []float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = math.Abs(v - median)
+	}
+	sort.Float64s(deviations)
+
+	return median, deviations[len(deviations)/2]
+}
@@ -0,0 +1,312 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Binance REST 제한 관련 에러
+var (
+	ErrRateLimited    = errors.New("binance rate limit exceeded")
+	ErrIPBanned       = errors.New("binance IP ban in effect")
+	ErrWeightTooLarge = errors.New("request weight exceeds the per-minute budget")
+)
+
+const (
+	defaultWeightLimit  = 6000 // Binance spot REQUEST_WEIGHT / 1분
+	defaultFuturesLimit = 2400 // Binance USDT-M 선물 REQUEST_WEIGHT / 1분
+	defaultSafetyRatio  = 0.8  // 한도의 80%에 도달하면 선제적으로 대기
+	defaultMaxRetries   = 3
+	defaultBaseBackoff  = 500 * time.Millisecond
+	defaultMaxBackoff   = 30 * time.Second
+)
+
+// RequestScheduler Binance REST 요청의 가중치를 추적하고 재시도/백오프를 관리
+type RequestScheduler struct {
+	mu          sync.Mutex
+	weightLimit int
+	safetyRatio float64
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	usedWeight  int
+	windowStart time.Time
+	retryUntil  time.Time
+	bannedUntil time.Time
+
+	totalRequests int64
+	throttled     int64
+	retried       int64
+	rateLimited   int64
+	lastStatus    int
+	lastError     string
+}
+
+// SchedulerStatus 헬스체크에 노출되는 스케줄러 상태
+type SchedulerStatus struct {
+	UsedWeight    int        `json:"usedWeight"`
+	WeightLimit   int        `json:"weightLimit"`
+	Banned        bool       `json:"banned"`
+	BannedUntil   *time.Time `json:"bannedUntil,omitempty"`
+	RetryAfter    *time.Time `json:"retryAfter,omitempty"`
+	TotalRequests int64      `json:"totalRequests"`
+	Throttled     int64      `json:"throttled"`
+	Retried       int64      `json:"retried"`
+	RateLimited   int64      `json:"rateLimited"`
+	LastStatus    int        `json:"lastStatus"`
+	LastError     string     `json:"lastError,omitempty"`
+}
+
+var scheduler *RequestScheduler
+var schedulerOnce sync.Once
+
+// GetScheduler returns the shared Binance request scheduler
+func GetScheduler() *RequestScheduler {
+	schedulerOnce.Do(func() {
+		scheduler = NewRequestScheduler(envInt("BINANCE_WEIGHT_LIMIT", defaultWeightLimit))
+	})
+	return scheduler
+}
+
+var futuresScheduler *RequestScheduler
+var futuresSchedulerOnce sync.Once
+
+// GetFuturesScheduler returns the USDT-M futures scheduler (futures have their own weight budget)
+func GetFuturesScheduler() *RequestScheduler {
+	futuresSchedulerOnce.Do(func() {
+		futuresScheduler = NewRequestScheduler(envInt("BINANCE_FUTURES_WEIGHT_LIMIT", defaultFuturesLimit))
+	})
+	return futuresScheduler
+}
+
+// NewRequestScheduler 새 요청 스케줄러 생성
+func NewRequestScheduler(weightLimit int) *RequestScheduler {
+	if weightLimit <= 0 {
+		weightLimit = defaultWeightLimit
+	}
+	return &RequestScheduler{
+		weightLimit: weightLimit,
+		safetyRatio: defaultSafetyRatio,
+		maxRetries:  defaultMaxRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		windowStart: time.Now().Truncate(time.Minute),
+	}
+}
+
+// Do 가중치 한도를 지키며 요청을 실행하고, 멱등 요청은 지터 백오프로 재시도
+func (s *RequestScheduler) Do(client *http.Client, req *http.Request, weight int) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	for attempt := 0; ; attempt++ {
+		if err := s.acquire(req.Context(), weight); err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req.Clone(req.Context()))
+		retryAfter := s.record(resp, err)
+
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if resp != nil && resp.StatusCode == http.StatusTeapot {
+			resp.Body.Close()
+			return nil, s.banError()
+		}
+		if !idempotent || attempt >= s.maxRetries {
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				resp.Body.Close()
+				return nil, ErrRateLimited
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := s.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		s.mu.Lock()
+		s.retried++
+		s.mu.Unlock()
+
+		log.Printf("Binance request %s retrying in %v (attempt %d/%d)", req.URL.Path, delay, attempt+1, s.maxRetries)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// acquire 요청 전 밴/Retry-After/가중치 한도를 확인하고 필요하면 대기.
+// 한 요청의 가중치가 안전 한도보다 크면 영원히 기다리게 되므로 바로 에러를 반환
+func (s *RequestScheduler) acquire(ctx context.Context, weight int) error {
+	if budget := float64(s.weightLimit) * s.safetyRatio; float64(weight) > budget {
+		return fmt.Errorf("%w: weight %d, budget %.0f", ErrWeightTooLarge, weight, budget)
+	}
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		s.rollWindow(now)
+
+		if now.Before(s.bannedUntil) {
+			s.mu.Unlock()
+			return s.banError()
+		}
+
+		var wait time.Duration
+		switch {
+		case now.Before(s.retryUntil):
+			wait = s.retryUntil.Sub(now)
+		case float64(s.usedWeight+weight) > float64(s.weightLimit)*s.safetyRatio:
+			wait = s.windowStart.Add(time.Minute).Sub(now)
+			s.throttled++
+		default:
+			s.usedWeight += weight
+			s.totalRequests++
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// record 응답 헤더로 사용 가중치와 제한 상태를 갱신하고 서버가 요구한 대기시간을 반환
+func (s *RequestScheduler) record(resp *http.Response, err error) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.lastError = err.Error()
+		return 0
+	}
+
+	s.lastStatus = resp.StatusCode
+	if used, convErr := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); convErr == nil {
+		s.rollWindow(time.Now())
+		s.usedWeight = used
+	}
+
+	var retryAfter time.Duration
+	if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		s.rateLimited++
+		if retryAfter == 0 {
+			retryAfter = time.Until(s.windowStart.Add(time.Minute))
+		}
+		s.retryUntil = time.Now().Add(retryAfter)
+		s.lastError = "rate limited (429)"
+		log.Printf("Binance rate limit hit, backing off for %v", retryAfter)
+	case http.StatusTeapot:
+		if retryAfter == 0 {
+			retryAfter = 2 * time.Minute
+		}
+		s.bannedUntil = time.Now().Add(retryAfter)
+		s.lastError = "IP banned (418)"
+		log.Printf("Binance IP banned until %s", s.bannedUntil.Format(time.RFC3339))
+	}
+
+	return retryAfter
+}
+
+// banError 밴 해제 시각을 포함한 에러 생성
+func (s *RequestScheduler) banError() error {
+	s.mu.Lock()
+	until := s.bannedUntil
+	s.mu.Unlock()
+	return fmt.Errorf("%w until %s", ErrIPBanned, until.Format(time.RFC3339))
+}
+
+// rollWindow 1분 가중치 윈도우가 지나면 초기화 (mu 보유 상태에서 호출)
+func (s *RequestScheduler) rollWindow(now time.Time) {
+	if window := now.Truncate(time.Minute); window.After(s.windowStart) {
+		s.windowStart = window
+		s.usedWeight = 0
+	}
+}
+
+// backoff 지수 백오프에 equal jitter 적용 (상한의 절반은 항상 대기해 즉시 재시도하지 않음)
+func (s *RequestScheduler) backoff(attempt int) time.Duration {
+	ceiling := s.baseBackoff << uint(attempt)
+	if ceiling <= 0 || ceiling > s.maxBackoff {
+		ceiling = s.maxBackoff
+	}
+	return ceiling/2 + time.Duration(rand.Int63n(int64(ceiling/2)+1))
+}
+
+// Status 현재 스케줄러 상태 조회
+func (s *RequestScheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.rollWindow(now)
+
+	status := SchedulerStatus{
+		UsedWeight:    s.usedWeight,
+		WeightLimit:   s.weightLimit,
+		Banned:        now.Before(s.bannedUntil),
+		TotalRequests: s.totalRequests,
+		Throttled:     s.throttled,
+		Retried:       s.retried,
+		RateLimited:   s.rateLimited,
+		LastStatus:    s.lastStatus,
+		LastError:     s.lastError,
+	}
+	if status.Banned {
+		until := s.bannedUntil
+		status.BannedUntil = &until
+	}
+	if now.Before(s.retryUntil) {
+		until := s.retryUntil
+		status.RetryAfter = &until
+	}
+	return status
+}
+
+// IsHealthy 밴 또는 Retry-After 대기 상태가 아니면 true
+func (s *RequestScheduler) IsHealthy() bool {
+	status := s.Status()
+	return !status.Banned && status.RetryAfter == nil
+}
+
+// isRetryableStatus 재시도 대상 HTTP 상태 코드
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusTeapot || code >= 500
+}
+
+// envInt 환경변수를 정수로 읽고 없으면 기본값 반환
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
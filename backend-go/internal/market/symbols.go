@@ -0,0 +1,297 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 심볼 검증 에러
+var (
+	ErrUnknownSymbol    = errors.New("unknown symbol")
+	ErrSymbolNotTrading = errors.New("symbol is not trading")
+	ErrInvalidSymbol    = errors.New("invalid symbol format")
+	ErrBelowMinNotional = errors.New("order value below min notional")
+	ErrQuantityOutOfLot = errors.New("order quantity outside lot size")
+	ErrEmptyWatchlist   = errors.New("watchlist is empty")
+)
+
+// DefaultWatchlist WATCHLIST 환경변수가 없을 때 사용하는 기본 감시 목록
+var DefaultWatchlist = []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "ADAUSDT"}
+
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9]{5,20}$`)
+
+// SymbolInfo exchangeInfo에서 가져온 심볼 메타데이터와 거래 필터
+type SymbolInfo struct {
+	Symbol      string  `json:"symbol"`
+	Status      string  `json:"status"`
+	BaseAsset   string  `json:"baseAsset"`
+	QuoteAsset  string  `json:"quoteAsset"`
+	TickSize    float64 `json:"tickSize"`
+	MinPrice    float64 `json:"minPrice"`
+	MaxPrice    float64 `json:"maxPrice"`
+	StepSize    float64 `json:"stepSize"`
+	MinQty      float64 `json:"minQty"`
+	MaxQty      float64 `json:"maxQty"`
+	MinNotional float64 `json:"minNotional"`
+}
+
+// IsTrading 거래 가능 상태 여부
+func (s *SymbolInfo) IsTrading() bool {
+	return s.Status == "TRADING"
+}
+
+// RoundPrice 가격을 tick size 단위로 내림
+func (s *SymbolInfo) RoundPrice(price float64) float64 {
+	return roundToStep(price, s.TickSize)
+}
+
+// RoundQuantity 수량을 step size 단위로 내림
+func (s *SymbolInfo) RoundQuantity(qty float64) float64 {
+	return roundToStep(qty, s.StepSize)
+}
+
+// ValidateOrder 주문 가격/수량이 LOT_SIZE, MIN_NOTIONAL 필터를 만족하는지 확인
+func (s *SymbolInfo) ValidateOrder(price, qty float64) error {
+	if s.MinQty > 0 && qty < s.MinQty || s.MaxQty > 0 && qty > s.MaxQty {
+		return fmt.Errorf("%w: %s qty %g (min %g, max %g)", ErrQuantityOutOfLot, s.Symbol, qty, s.MinQty, s.MaxQty)
+	}
+	if s.MinNotional > 0 && price*qty < s.MinNotional {
+		return fmt.Errorf("%w: %s %.8f < %.8f", ErrBelowMinNotional, s.Symbol, price*qty, s.MinNotional)
+	}
+	return nil
+}
+
+// SymbolRegistry exchangeInfo 기반 심볼 유니버스와 감시 목록 관리
+type SymbolRegistry struct {
+	client    *BinanceClient
+	mu        sync.RWMutex
+	symbols   map[string]*SymbolInfo
+	watchlist []string
+	loadedAt  time.Time
+}
+
+var registry *SymbolRegistry
+var registryOnce sync.Once
+
+// GetRegistry returns the singleton symbol registry
+// 네트워크 조회는 하지 않는다 - exchangeInfo는 시작 시 Refresh/StartAutoRefresh로 로드하며,
+// 로드 전에는 Validate가 형식만 검사하고 Watchlist는 설정값을 그대로 돌려준다
+func GetRegistry() *SymbolRegistry {
+	registryOnce.Do(func() {
+		registry = &SymbolRegistry{
+			client:    NewBinanceClient(),
+			symbols:   make(map[string]*SymbolInfo),
+			watchlist: watchlistFromEnv(),
+		}
+	})
+	return registry
+}
+
+// Refresh exchangeInfo를 다시 조회해 심볼 정보를 갱신
+func (r *SymbolRegistry) Refresh() error {
+	infos, err := r.client.GetExchangeInfo()
+	if err != nil {
+		return err
+	}
+
+	symbols := make(map[string]*SymbolInfo, len(infos))
+	for i := range infos {
+		symbols[infos[i].Symbol] = &infos[i]
+	}
+
+	r.mu.Lock()
+	r.symbols = symbols
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+
+	log.Printf("Symbol registry loaded %d symbols", len(symbols))
+	return nil
+}
+
+// StartAutoRefresh 주기적으로 exchangeInfo 갱신
+func (r *SymbolRegistry) StartAutoRefresh(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := r.Refresh(); err != nil {
+				log.Printf("Symbol registry refresh failed: %v", err)
+			}
+		}
+	}()
+}
+
+// IsLoaded exchangeInfo가 한 번이라도 로드되었는지 여부
+func (r *SymbolRegistry) IsLoaded() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.symbols) > 0
+}
+
+// Get 심볼 정보 조회
+func (r *SymbolRegistry) Get(symbol string) (*SymbolInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.symbols[strings.ToUpper(symbol)]
+	return info, ok
+}
+
+// Validate 심볼을 대문자로 정규화하고 거래 가능 여부를 검증
+// exchangeInfo가 아직 로드되지 않았다면 형식만 검사한다
+func (r *SymbolRegistry) Validate(symbol string) (string, error) {
+	normalized, err := NormalizeSymbol(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if !r.IsLoaded() {
+		return normalized, nil
+	}
+
+	info, ok := r.Get(normalized)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownSymbol, normalized)
+	}
+	if !info.IsTrading() {
+		return "", fmt.Errorf("%w: %s (%s)", ErrSymbolNotTrading, normalized, info.Status)
+	}
+	return normalized, nil
+}
+
+// NormalizeSymbol 심볼을 대문자로 정규화하고 형식만 검사 (선물 등 현물 목록에 없는 심볼용)
+func NormalizeSymbol(symbol string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(symbol))
+	if !symbolPattern.MatchString(normalized) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSymbol, symbol)
+	}
+	return normalized, nil
+}
+
+// Watchlist 거래 가능한 감시 심볼 목록
+func (r *SymbolRegistry) Watchlist() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]string, 0, len(r.watchlist))
+	for _, symbol := range r.watchlist {
+		if len(r.symbols) > 0 {
+			if info, ok := r.symbols[symbol]; !ok || !info.IsTrading() {
+				continue
+			}
+		}
+		result = append(result, symbol)
+	}
+	return result
+}
+
+// SetWatchlist 감시 목록 교체 (모든 심볼을 검증한 뒤 반영, 빈 목록은 거부)
+// 실행 중인 수집기와 스트림은 시작할 때 목록을 복사하므로 재시작 후에 반영된다
+func (r *SymbolRegistry) SetWatchlist(symbols []string) ([]string, error) {
+	if len(symbols) == 0 {
+		return nil, ErrEmptyWatchlist
+	}
+	validated := make([]string, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		normalized, err := r.Validate(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			validated = append(validated, normalized)
+		}
+	}
+
+	r.mu.Lock()
+	r.watchlist = validated
+	r.mu.Unlock()
+
+	return validated, nil
+}
+
+// Symbols 조건에 맞는 심볼 정보 목록 (quote가 비어 있으면 전체)
+func (r *SymbolRegistry) Symbols(quoteAsset string, tradingOnly bool) []SymbolInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]SymbolInfo, 0, len(r.symbols))
+	for _, info := range r.symbols {
+		if quoteAsset != "" && info.QuoteAsset != quoteAsset {
+			continue
+		}
+		if tradingOnly && !info.IsTrading() {
+			continue
+		}
+		result = append(result, *info)
+	}
+	return result
+}
+
+// RoundPrice 심볼의 tick size로 가격 반올림 (정보가 없으면 그대로 반환)
+func (r *SymbolRegistry) RoundPrice(symbol string, price float64) float64 {
+	if info, ok := r.Get(symbol); ok {
+		return info.RoundPrice(price)
+	}
+	return price
+}
+
+// RoundQuantity 심볼의 step size로 수량 반올림 (정보가 없으면 그대로 반환)
+func (r *SymbolRegistry) RoundQuantity(symbol string, qty float64) float64 {
+	if info, ok := r.Get(symbol); ok {
+		return info.RoundQuantity(qty)
+	}
+	return qty
+}
+
+// LoadedAt 마지막 exchangeInfo 로드 시각
+func (r *SymbolRegistry) LoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loadedAt
+}
+
+// watchlistFromEnv WATCHLIST 환경변수(쉼표 구분)에서 감시 목록 로드
+func watchlistFromEnv() []string {
+	raw := os.Getenv("WATCHLIST")
+	if raw == "" {
+		return append([]string(nil), DefaultWatchlist...)
+	}
+
+	symbols := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if symbol := strings.ToUpper(strings.TrimSpace(part)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// roundToStep step 단위로 내림하고 step의 소수 자릿수로 정리
+func roundToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	rounded := math.Floor(value/step+1e-9) * step
+	precision := stepPrecision(step)
+	factor := math.Pow(10, float64(precision))
+	return math.Round(rounded*factor) / factor
+}
+
+// stepPrecision step 문자열 표현에서 소수 자릿수 계산 (예: 0.001 -> 3)
+func stepPrecision(step float64) int {
+	s := strconv.FormatFloat(step, 'f', -1, 64)
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		return len(s) - idx - 1
+	}
+	return 0
+}
@@ -2,6 +2,7 @@ package market
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -88,6 +89,9 @@ func (ws *BinanceWebSocket) Connect() error {
 		// 오더북 스트림
 		streams = append(streams, fmt.Sprintf("%s@depth@100ms", symbolLower))
 	}
+	if len(streams) == 0 {
+		return errors.New("감시 목록에 거래 가능한 심볼이 없음")
+	}
 
 	// WebSocket URL 생성
 	url := fmt.Sprintf("%s/stream?streams=%s", ws.baseURL, strings.Join(streams, "/"))
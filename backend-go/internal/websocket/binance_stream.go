@@ -2,12 +2,17 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/loadstar0723/monstas7-backend/internal/alerts"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
 )
 
 const (
@@ -22,6 +27,8 @@ type BinanceStreamManager struct {
 	symbols      []string
 	reconnecting bool
 	pingTicker   *time.Ticker
+	replaying    bool      // true when fed from a recording instead of Binance
+	pipe         *pipeline // nil for the live pipeline
 }
 
 // BinanceTickerData represents real-time ticker data
@@ -72,9 +79,15 @@ type BinanceKlineData struct {
 
 // NewBinanceStreamManager creates a new Binance stream manager
 func NewBinanceStreamManager(hub *Hub) *BinanceStreamManager {
+	watchlist := market.GetRegistry().Watchlist()
+	symbols := make([]string, 0, len(watchlist))
+	for _, symbol := range watchlist {
+		symbols = append(symbols, strings.ToLower(symbol))
+	}
+
 	return &BinanceStreamManager{
 		hub:     hub,
-		symbols: []string{"btcusdt", "ethusdt", "bnbusdt", "solusdt"},
+		symbols: symbols,
 	}
 }
 
@@ -90,6 +103,9 @@ func (bsm *BinanceStreamManager) Connect() error {
 		// Add trade stream
 		streams = append(streams, fmt.Sprintf("%s@trade", symbol))
 	}
+	if len(streams) == 0 {
+		return errors.New("no trading symbols in the watchlist to stream")
+	}
 
 	// Create combined stream URL
 	streamPath := fmt.Sprintf("/ws/%s", streams[0])
@@ -137,26 +153,48 @@ func (bsm *BinanceStreamManager) readMessages() {
 			break
 		}
 
-		// Parse message
-		var data map[string]interface{}
-		if err := json.Unmarshal(message, &data); err != nil {
-			log.Printf("JSON unmarshal error: %v", err)
-			continue
+		// Record raw message before processing
+		if rec := GetRecorder(); rec.IsRecording() {
+			rec.Record(message)
 		}
 
-		// Determine message type and process
-		if eventType, ok := data["e"].(string); ok {
-			switch eventType {
-			case "24hrTicker":
-				bsm.processTicker(message)
-			case "kline":
-				bsm.processKline(message)
-			case "trade":
-				bsm.processTrade(message)
-			default:
-				// Forward raw message to hub
-				bsm.forwardToHub(data)
-			}
+		bsm.dispatch(message)
+	}
+}
+
+// pipeline returns the state events of this manager are fed through
+func (bsm *BinanceStreamManager) pipeline() *pipeline {
+	if bsm.pipe != nil {
+		return bsm.pipe
+	}
+	return livePipeline()
+}
+
+// dispatch parses a raw Binance message and routes it by event type
+func (bsm *BinanceStreamManager) dispatch(message []byte) {
+	// Parse message
+	var data map[string]interface{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		log.Printf("JSON unmarshal error: %v", err)
+		return
+	}
+
+	// Determine message type and process
+	if eventType, ok := data["e"].(string); ok {
+		switch eventType {
+		case "24hrTicker":
+			bsm.processTicker(message)
+		case "kline":
+			bsm.processKline(message)
+		case "trade":
+			bsm.processTrade(message)
+		case "forceOrder":
+			bsm.processLiquidation(message)
+		case "markPriceUpdate":
+			bsm.processMarkPrice(message)
+		default:
+			// Forward raw message to hub
+			bsm.forwardToHub(data)
 		}
 	}
 }
@@ -212,9 +250,40 @@ func (bsm *BinanceStreamManager) processKline(message []byte) {
 		"trades":    kline.Kline.TradeCount,
 	}
 
+	// Replays feed their own pipeline, so live indicator state is untouched
+	pipe := bsm.pipeline()
+	values, predictions := pipe.onClose(kline.Symbol, kline.Kline.Interval, indicators.Bar{
+		Time:   kline.Kline.StartTime,
+		Open:   parseStreamFloat(kline.Kline.OpenPrice),
+		High:   parseStreamFloat(kline.Kline.HighPrice),
+		Low:    parseStreamFloat(kline.Kline.LowPrice),
+		Close:  parseStreamFloat(kline.Kline.ClosePrice),
+		Volume: parseStreamFloat(kline.Kline.BaseVolume),
+	})
+	if values != nil {
+		msg["indicators"] = values
+	}
+	if predictions != nil {
+		msg["predictions"] = predictions
+	}
+	if pipe.alerts {
+		go bsm.checkAlerts(kline.Symbol, kline.Kline.Interval, kline.Kline.StartTime)
+	}
+
 	bsm.forwardToHub(msg)
 }
 
+// checkAlerts evaluates expression alerts on a closed candle and forwards
+// the ones that fired to clients
+func (bsm *BinanceStreamManager) checkAlerts(symbol, interval string, openTime int64) {
+	for _, event := range alerts.GetManager().OnClose(symbol, interval, openTime) {
+		bsm.forwardToHub(map[string]interface{}{
+			"type":  "alert",
+			"alert": event,
+		})
+	}
+}
+
 // processTrade processes trade data
 func (bsm *BinanceStreamManager) processTrade(message []byte) {
 	var trade map[string]interface{}
@@ -223,6 +292,20 @@ func (bsm *BinanceStreamManager) processTrade(message []byte) {
 		return
 	}
 
+	// Feed the footprint/VWAP bar builder (a replay has its own builder)
+	symbol, _ := trade["s"].(string)
+	price, _ := trade["p"].(string)
+	quantity, _ := trade["q"].(string)
+	tradeTime, _ := trade["T"].(float64)
+	buyerMaker, _ := trade["m"].(bool)
+	bsm.pipeline().bars.AddTrade(market.Trade{
+		Symbol:     symbol,
+		Price:      parseStreamFloat(price),
+		Quantity:   parseStreamFloat(quantity),
+		Time:       int64(tradeTime),
+		BuyerMaker: buyerMaker,
+	})
+
 	// Create formatted message for clients
 	msg := map[string]interface{}{
 		"type":      "trade",
@@ -238,6 +321,10 @@ func (bsm *BinanceStreamManager) processTrade(message []byte) {
 
 // forwardToHub sends message to all connected clients
 func (bsm *BinanceStreamManager) forwardToHub(data interface{}) {
+	if msg, ok := data.(map[string]interface{}); ok && bsm.replaying {
+		msg["replay"] = true
+	}
+
 	message, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Marshal error: %v", err)
@@ -245,6 +332,11 @@ func (bsm *BinanceStreamManager) forwardToHub(data interface{}) {
 	}
 
 	// Send to all clients through hub
+	if bsm.hub != nil && bsm.replaying {
+		// Replays block instead of dropping so max-speed playback stays lossless
+		bsm.hub.broadcast <- message
+		return
+	}
 	if bsm.hub != nil {
 		select {
 		case bsm.hub.broadcast <- message:
@@ -298,7 +390,7 @@ func (bsm *BinanceStreamManager) reconnect() {
 // SubscribeToSymbol adds a symbol to the subscription list
 func (bsm *BinanceStreamManager) SubscribeToSymbol(symbol string) {
 	// Convert to lowercase for Binance
-	symbol = fmt.Sprintf("%s", symbol)
+	symbol = strings.ToLower(symbol)
 
 	// Check if already subscribed
 	for _, s := range bsm.symbols {
@@ -330,7 +422,7 @@ func (bsm *BinanceStreamManager) SubscribeToSymbol(symbol string) {
 // UnsubscribeFromSymbol removes a symbol from the subscription list
 func (bsm *BinanceStreamManager) UnsubscribeFromSymbol(symbol string) {
 	// Convert to lowercase for Binance
-	symbol = fmt.Sprintf("%s", symbol)
+	symbol = strings.ToLower(symbol)
 
 	// Remove from symbols list
 	for i, s := range bsm.symbols {
@@ -0,0 +1,250 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+const BinanceFuturesStreamURL = "wss://fstream.binance.com"
+
+// FuturesStreamManager manages the USDT-M futures liquidation and mark price streams.
+// Messages go through the same dispatch path as the spot stream, so they are
+// recorded and replayed the same way.
+type FuturesStreamManager struct {
+	conn         *websocket.Conn
+	stream       *BinanceStreamManager
+	symbols      []string
+	reconnecting bool
+	pingTicker   *time.Ticker
+}
+
+// BinanceForceOrderData represents a forced liquidation order
+type BinanceForceOrderData struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Order     struct {
+		Symbol       string `json:"s"`
+		Side         string `json:"S"`
+		OrderType    string `json:"o"`
+		TimeInForce  string `json:"f"`
+		Quantity     string `json:"q"`
+		Price        string `json:"p"`
+		AvgPrice     string `json:"ap"`
+		Status       string `json:"X"`
+		LastFilled   string `json:"l"`
+		FilledAmount string `json:"z"`
+		TradeTime    int64  `json:"T"`
+	} `json:"o"`
+}
+
+// BinanceMarkPriceData represents a mark price / funding update
+type BinanceMarkPriceData struct {
+	EventType            string `json:"e"`
+	EventTime            int64  `json:"E"`
+	Symbol               string `json:"s"`
+	MarkPrice            string `json:"p"`
+	IndexPrice           string `json:"i"`
+	EstimatedSettlePrice string `json:"P"`
+	FundingRate          string `json:"r"`
+	NextFundingTime      int64  `json:"T"`
+}
+
+// NewFuturesStreamManager creates a futures stream manager for the watchlist
+func NewFuturesStreamManager(hub *Hub) *FuturesStreamManager {
+	watchlist := market.GetRegistry().Watchlist()
+	symbols := make([]string, 0, len(watchlist))
+	for _, symbol := range watchlist {
+		symbols = append(symbols, strings.ToLower(symbol))
+	}
+
+	return &FuturesStreamManager{
+		stream:  &BinanceStreamManager{hub: hub},
+		symbols: symbols,
+	}
+}
+
+// Connect establishes connection to the Binance futures WebSocket
+func (fsm *FuturesStreamManager) Connect() error {
+	// All-market liquidations plus per-symbol mark price
+	streams := []string{"!forceOrder@arr"}
+	for _, symbol := range fsm.symbols {
+		streams = append(streams, fmt.Sprintf("%s@markPrice@1s", symbol))
+	}
+
+	u, err := url.Parse(BinanceFuturesStreamURL + "/ws/" + strings.Join(streams, "/"))
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	fsm.conn = conn
+	log.Printf("Connected to Binance futures WebSocket: %s", u.String())
+
+	fsm.startPing()
+	go fsm.readMessages()
+
+	return nil
+}
+
+// readMessages reads and processes incoming messages
+func (fsm *FuturesStreamManager) readMessages() {
+	defer func() {
+		fsm.conn.Close()
+		fsm.stopPing()
+		if !fsm.reconnecting {
+			fsm.reconnect()
+		}
+	}()
+
+	for {
+		_, message, err := fsm.conn.ReadMessage()
+		if err != nil {
+			log.Printf("Binance futures WebSocket read error: %v", err)
+			break
+		}
+
+		if rec := GetRecorder(); rec.IsRecording() {
+			rec.Record(message)
+		}
+
+		fsm.stream.dispatch(message)
+	}
+}
+
+// startPing starts periodic ping to keep connection alive
+func (fsm *FuturesStreamManager) startPing() {
+	fsm.pingTicker = time.NewTicker(30 * time.Second)
+	go func() {
+		for range fsm.pingTicker.C {
+			if err := fsm.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Futures ping error: %v", err)
+				return
+			}
+		}
+	}()
+}
+
+// stopPing stops the ping ticker
+func (fsm *FuturesStreamManager) stopPing() {
+	if fsm.pingTicker != nil {
+		fsm.pingTicker.Stop()
+	}
+}
+
+// reconnect attempts to reconnect to the futures WebSocket
+func (fsm *FuturesStreamManager) reconnect() {
+	fsm.reconnecting = true
+	defer func() { fsm.reconnecting = false }()
+
+	for i := 1; i <= 5; i++ {
+		log.Printf("Futures reconnection attempt %d/5", i)
+		time.Sleep(time.Duration(i*2) * time.Second)
+
+		if err := fsm.Connect(); err != nil {
+			log.Printf("Futures reconnection failed: %v", err)
+			continue
+		}
+
+		log.Println("Successfully reconnected to Binance futures")
+		return
+	}
+
+	log.Println("Failed to reconnect to Binance futures after 5 attempts")
+}
+
+// Close closes the WebSocket connection
+func (fsm *FuturesStreamManager) Close() error {
+	fsm.stopPing()
+	if fsm.conn != nil {
+		return fsm.conn.Close()
+	}
+	return nil
+}
+
+// processLiquidation processes forced liquidation orders
+func (bsm *BinanceStreamManager) processLiquidation(message []byte) {
+	var data BinanceForceOrderData
+	if err := json.Unmarshal(message, &data); err != nil {
+		log.Printf("Liquidation unmarshal error: %v", err)
+		return
+	}
+
+	order := data.Order
+	liquidation := market.Liquidation{
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		OrderType: order.OrderType,
+		Status:    order.Status,
+		Price:     parseStreamFloat(order.Price),
+		AvgPrice:  parseStreamFloat(order.AvgPrice),
+		Quantity:  parseStreamFloat(order.FilledAmount),
+		Time:      order.TradeTime,
+	}
+	if liquidation.Quantity == 0 {
+		liquidation.Quantity = parseStreamFloat(order.Quantity)
+	}
+
+	// Replayed liquidations go to the replay's own store, not the live one used for model features
+	bsm.pipeline().liquidations.Add(liquidation)
+
+	position := "short"
+	if liquidation.IsLong() {
+		position = "long"
+	}
+
+	msg := map[string]interface{}{
+		"type":      "liquidation",
+		"symbol":    order.Symbol,
+		"side":      order.Side,
+		"position":  position,
+		"price":     order.Price,
+		"avgPrice":  order.AvgPrice,
+		"quantity":  order.FilledAmount,
+		"status":    order.Status,
+		"timestamp": order.TradeTime,
+	}
+
+	bsm.forwardToHub(msg)
+}
+
+// processMarkPrice processes mark price and funding updates
+func (bsm *BinanceStreamManager) processMarkPrice(message []byte) {
+	var data BinanceMarkPriceData
+	if err := json.Unmarshal(message, &data); err != nil {
+		log.Printf("Mark price unmarshal error: %v", err)
+		return
+	}
+
+	msg := map[string]interface{}{
+		"type":            "markPrice",
+		"symbol":          data.Symbol,
+		"markPrice":       data.MarkPrice,
+		"indexPrice":      data.IndexPrice,
+		"fundingRate":     data.FundingRate,
+		"nextFundingTime": data.NextFundingTime,
+		"timestamp":       data.EventTime,
+	}
+
+	bsm.forwardToHub(msg)
+}
+
+// parseStreamFloat parses a numeric string from a stream payload, returning 0 on failure
+func parseStreamFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
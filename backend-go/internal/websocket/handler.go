@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
 	"github.com/sirupsen/logrus"
 )
 
@@ -217,6 +219,16 @@ func (c *Client) handleMessage(msg *Message) {
 
 // subscribe adds a symbol to the client's subscription list
 func (c *Client) subscribe(symbol string) {
+	symbol, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.sendJSON(map[string]interface{}{
+			"type":   "error",
+			"error":  err.Error(),
+			"status": "failed",
+		})
+		return
+	}
+
 	c.mu.Lock()
 	c.symbols[symbol] = true
 	c.mu.Unlock()
@@ -235,6 +247,8 @@ func (c *Client) subscribe(symbol string) {
 
 // unsubscribe removes a symbol from the client's subscription list
 func (c *Client) unsubscribe(symbol string) {
+	symbol = strings.ToUpper(symbol)
+
 	c.mu.Lock()
 	delete(c.symbols, symbol)
 	c.mu.Unlock()
@@ -346,6 +360,12 @@ func HandleTradesStream(c *gin.Context) {
 		return
 	}
 
+	symbol, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		logrus.Errorf("Failed to upgrade connection: %v", err)
@@ -382,6 +402,12 @@ func HandleOrderBookStream(c *gin.Context) {
 		return
 	}
 
+	symbol, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		logrus.Errorf("Failed to upgrade connection: %v", err)
@@ -427,6 +453,12 @@ func HandleKlinesStream(c *gin.Context) {
 		return
 	}
 
+	symbol, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	if interval == "" {
 		interval = "1m"
 	}
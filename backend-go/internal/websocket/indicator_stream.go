@@ -0,0 +1,185 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/database"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+const (
+	// indicatorWarmupBars is how many historical candles seed a new indicator set
+	indicatorWarmupBars = 200
+	// indicatorStateKey is the Redis key holding the live indicator snapshots
+	indicatorStateKey = "indicators:stream:state"
+	// indicatorStateTTL bounds how long a snapshot is worth restoring
+	indicatorStateTTL = 24 * time.Hour
+)
+
+// indicatorStore holds one streaming indicator set per symbol/interval
+type indicatorStore struct {
+	mu   sync.Mutex
+	sets map[string]*indicatorEntry
+	warm bool // seed new sets from REST history
+}
+
+// indicatorEntry is a set and the candles that arrived while it was warming
+type indicatorEntry struct {
+	set     *indicators.StreamSet
+	warming bool
+	pending []indicators.Bar
+}
+
+func newIndicatorStore(warm bool) *indicatorStore {
+	return &indicatorStore{sets: make(map[string]*indicatorEntry), warm: warm}
+}
+
+// update feeds a closed candle into the streaming indicator set for
+// symbol/interval and returns the updated values. For a warming store, a new
+// set, or one restored from a snapshot that missed candles, is filled from
+// REST history in the background; the candle is applied once that is done
+// and nil is returned in the meantime, so the stream read loop never blocks
+// on REST.
+func (s *indicatorStore) update(symbol, interval string, bar indicators.Bar) map[string]float64 {
+	key := symbol + ":" + interval
+
+	s.mu.Lock()
+	e, ok := s.sets[key]
+	if !ok {
+		e = &indicatorEntry{set: indicators.NewStreamSet()}
+		s.sets[key] = e
+	}
+	if e.warming {
+		e.pending = append(e.pending, bar)
+		s.mu.Unlock()
+		return nil
+	}
+	if s.warm && (!ok || missedCandles(e.set, interval, bar.Time)) {
+		e.warming = true
+		e.pending = []indicators.Bar{bar}
+		s.mu.Unlock()
+		go s.warmup(key, symbol, interval, e)
+		return nil
+	}
+	s.mu.Unlock()
+
+	e.set.Update(bar)
+	return e.set.Values()
+}
+
+// missedCandles reports whether candles between the last one applied to set
+// and the candle opening at openTime are missing
+func missedCandles(set *indicators.StreamSet, interval string, openTime int64) bool {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return false
+	}
+	return openTime-set.LastTime() > step.Milliseconds()
+}
+
+// warmup applies the REST history before the first pending candle, then the
+// pending candles. Candles the set already holds are skipped by Update.
+func (s *indicatorStore) warmup(key, symbol, interval string, e *indicatorEntry) {
+	s.mu.Lock()
+	before := e.pending[0].Time
+	s.mu.Unlock()
+
+	klines, err := market.NewBinanceClient().GetKlines(symbol, interval, indicatorWarmupBars)
+	if err != nil {
+		log.Printf("Indicator warmup for %s failed: %v", key, err)
+	}
+
+	bars := make([]indicators.Bar, 0, len(klines))
+	for _, k := range klines {
+		if k.OpenTime >= before {
+			break
+		}
+		bars = append(bars, indicators.Bar{
+			Time:   k.OpenTime,
+			Open:   k.Open,
+			High:   k.High,
+			Low:    k.Low,
+			Close:  k.Close,
+			Volume: k.Volume,
+		})
+	}
+	e.set.Warmup(bars)
+
+	s.mu.Lock()
+	e.set.Warmup(e.pending)
+	e.pending = nil
+	e.warming = false
+	s.mu.Unlock()
+}
+
+// snapshot serializes every set that is not warming, keyed by symbol:interval
+func (s *indicatorStore) snapshot() (map[string]json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(map[string]json.RawMessage, len(s.sets))
+	for key, e := range s.sets {
+		if e.warming {
+			continue
+		}
+		data, err := e.set.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		state[key] = data
+	}
+	return state, nil
+}
+
+// restore replaces the sets with the given snapshots and returns how many
+// were restored
+func (s *indicatorStore) restore(state map[string]json.RawMessage) int {
+	sets := make(map[string]*indicatorEntry, len(state))
+	for key, data := range state {
+		set := indicators.NewStreamSet()
+		if err := set.Restore(data); err != nil {
+			log.Printf("Indicator snapshot for %s is unreadable: %v", key, err)
+			continue
+		}
+		sets[key] = &indicatorEntry{set: set}
+	}
+
+	s.mu.Lock()
+	s.sets = sets
+	s.mu.Unlock()
+	return len(sets)
+}
+
+// SaveIndicatorState stores the live streaming indicator state in Redis so the
+// next start resumes it instead of warming every set from scratch
+func SaveIndicatorState() error {
+	redis := database.GetRedis()
+	if redis == nil {
+		return errors.New("redis is not available")
+	}
+	state, err := livePipeline().indicators.snapshot()
+	if err != nil {
+		return err
+	}
+	return redis.Set(indicatorStateKey, state, indicatorStateTTL)
+}
+
+// RestoreIndicatorState loads the live streaming indicator state saved by
+// SaveIndicatorState and returns how many sets were restored. Candles missed
+// while the server was down are filled in on the first close of each set.
+func RestoreIndicatorState() (int, error) {
+	redis := database.GetRedis()
+	if redis == nil {
+		return 0, errors.New("redis is not available")
+	}
+	var state map[string]json.RawMessage
+	if err := redis.Get(indicatorStateKey, &state); err != nil {
+		return 0, err
+	}
+	return livePipeline().indicators.restore(state), nil
+}
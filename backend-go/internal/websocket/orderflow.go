@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+// StartTradeBars broadcasts every closed footprint candle, with the session
+// VWAP and value area at that point, to all hub clients
+func StartTradeBars(h *Hub) {
+	bsm := &BinanceStreamManager{hub: h}
+	builder := bsm.pipeline().bars
+	builder.SetOnClose(func(candle market.FootprintCandle) {
+		bsm.forwardToHub(footprintMessage(builder, candle))
+	})
+	builder.Start()
+}
+
+// footprintMessage formats a closed footprint candle with the session VWAP
+// and value area of the builder that closed it
+func footprintMessage(builder *market.BarBuilder, candle market.FootprintCandle) map[string]interface{} {
+	msg := map[string]interface{}{
+		"type":      "footprint",
+		"symbol":    candle.Symbol,
+		"candle":    candle,
+		"timestamp": candle.CloseTime,
+	}
+
+	if series := builder.SessionVWAP(candle.Symbol); len(series) > 0 {
+		msg["vwap"] = series[len(series)-1]
+	}
+	if profile, err := builder.SessionProfile(candle.Symbol, 0); err == nil {
+		msg["profile"] = map[string]float64{
+			"poc": profile.POC,
+			"vah": profile.VAH,
+			"val": profile.VAL,
+		}
+	}
+	return msg
+}
+
+// HandleFootprintStream streams the in-progress footprint candle for a symbol
+func HandleFootprintStream(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		c.JSON(400, gin.H{"error": "symbol is required"})
+		return
+	}
+
+	symbol, err := market.GetRegistry().Validate(symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Errorf("Failed to upgrade connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	builder := market.GetBarBuilder()
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current := builder.Current(symbol)
+		if current == nil {
+			continue
+		}
+
+		update := map[string]interface{}{
+			"type":      "footprint",
+			"symbol":    symbol,
+			"candle":    current,
+			"timestamp": time.Now().UnixMilli(),
+		}
+		if series := builder.SessionVWAP(symbol); len(series) > 0 {
+			update["vwap"] = series[len(series)-1]
+		}
+
+		if err := conn.WriteJSON(update); err != nil {
+			logrus.Errorf("Failed to write footprint: %v", err)
+			break
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+	"github.com/loadstar0723/monstas7-backend/internal/indicators"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+)
+
+const (
+	// pipelineModelBars is how many closed candles a replay keeps per
+	// symbol/interval as model input
+	pipelineModelBars = 500
+	// pipelinePredictTimeout bounds one model run on a replayed candle
+	pipelinePredictTimeout = 30 * time.Second
+)
+
+// pipeline is the state stream events are fed through: trades build
+// footprint bars, liquidations fill the liquidation store, and closed candles
+// update the streaming indicators, evaluate alerts and run models. The live
+// streams share one pipeline; every replay gets a fresh one, so a recording
+// goes through the same steps without touching live state.
+type pipeline struct {
+	bars         *market.BarBuilder
+	liquidations *market.LiquidationStore
+	indicators   *indicatorStore
+	alerts       bool     // alert rules read live candles, so only the live pipeline evaluates them
+	models       []string // models run on every closed candle
+
+	mu      sync.Mutex
+	candles map[string][]indicators.Bar // closed candles seen so far, per symbol:interval
+}
+
+var (
+	live     *pipeline
+	liveOnce sync.Once
+)
+
+// livePipeline returns the pipeline of the Binance spot and futures streams
+func livePipeline() *pipeline {
+	liveOnce.Do(func() {
+		live = &pipeline{
+			bars:         market.GetBarBuilder(),
+			liquidations: market.GetLiquidationStore(),
+			indicators:   newIndicatorStore(true),
+			alerts:       true,
+		}
+	})
+	return live
+}
+
+// newReplayPipeline returns an isolated pipeline for one replay. Indicator
+// sets start cold and footprint bars close on recorded trade time.
+func newReplayPipeline(models []string) *pipeline {
+	return &pipeline{
+		bars:         market.NewBarBuilder(),
+		liquidations: market.NewLiquidationStore(),
+		indicators:   newIndicatorStore(false),
+		models:       models,
+		candles:      make(map[string][]indicators.Bar),
+	}
+}
+
+// onClose feeds a closed candle to the indicators and, if the pipeline runs
+// models, predicts on the candles seen so far. It returns the indicator values
+// and the predictions keyed by model (an error string when a model failed).
+func (p *pipeline) onClose(symbol, interval string, bar indicators.Bar) (map[string]float64, map[string]interface{}) {
+	values := p.indicators.update(symbol, interval, bar)
+	if len(p.models) == 0 {
+		return values, nil
+	}
+
+	key := symbol + ":" + interval
+	p.mu.Lock()
+	bars := append(p.candles[key], bar)
+	if len(bars) > pipelineModelBars {
+		bars = bars[len(bars)-pipelineModelBars:]
+	}
+	p.candles[key] = bars
+	in := ai.Input{Symbol: symbol, Interval: interval, Candles: indicators.FromBars(bars)}
+	p.mu.Unlock()
+
+	// Predictors are called directly so replayed candles never reach the ledger
+	predictions := make(map[string]interface{}, len(p.models))
+	for _, name := range p.models {
+		predictor, err := ai.GetManager().Get(name)
+		if err != nil {
+			predictions[name] = err.Error()
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), pipelinePredictTimeout)
+		prediction, err := predictor.Predict(ctx, in)
+		cancel()
+		if err != nil {
+			predictions[name] = err.Error()
+			continue
+		}
+		predictions[name] = prediction
+	}
+	return values, predictions
+}
@@ -0,0 +1,244 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loadstar0723/monstas7-backend/internal/ai"
+	"github.com/loadstar0723/monstas7-backend/internal/market"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplayStatus describes the current replay state
+type ReplayStatus struct {
+	Running   bool      `json:"running"`
+	Files     []string  `json:"files,omitempty"`
+	Models    []string  `json:"models,omitempty"` // models run on every replayed closed candle
+	Speed     float64   `json:"speed"`            // 0 = as fast as possible
+	Messages  int64     `json:"messages"`
+	Position  time.Time `json:"position,omitempty"` // original receive time of the last replayed message
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Replayer plays recorded Binance messages back through the stream pipeline
+// (footprint bars, indicators, models) and the WebSocket hub
+type Replayer struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	status ReplayStatus
+}
+
+var replayer = &Replayer{}
+
+// GetReplayer returns the global replayer
+func GetReplayer() *Replayer {
+	return replayer
+}
+
+// Start replays the given recording files in order at the given speed
+// (1 = real time, 10 = ten times faster, 0 = max speed), running the given
+// models on every closed candle
+func (rp *Replayer) Start(files []string, speed float64, models []string) error {
+	for _, name := range models {
+		if _, err := ai.GetManager().Get(name); err != nil {
+			return err
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, name := range files {
+		path, err := GetRecorder().Path(name)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.status.Running {
+		return ErrReplayRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rp.cancel = cancel
+	rp.status = ReplayStatus{
+		Running:   true,
+		Files:     files,
+		Models:    models,
+		Speed:     speed,
+		StartedAt: time.Now(),
+	}
+
+	go rp.run(ctx, paths, speed, models)
+	return nil
+}
+
+// Stop cancels the running replay
+func (rp *Replayer) Stop() {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.cancel != nil {
+		rp.cancel()
+	}
+}
+
+// Status returns the replay state
+func (rp *Replayer) Status() ReplayStatus {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.status
+}
+
+// run feeds recorded messages into the same dispatch path as the live stream,
+// backed by a pipeline of its own
+func (rp *Replayer) run(ctx context.Context, paths []string, speed float64, models []string) {
+	bsm := &BinanceStreamManager{hub: GetGlobalHub(), replaying: true, pipe: newReplayPipeline(models)}
+	builder := bsm.pipe.bars
+	builder.SetOnClose(func(candle market.FootprintCandle) {
+		bsm.forwardToHub(footprintMessage(builder, candle))
+	})
+
+	var firstRecorded time.Time
+	wallStart := time.Now()
+	errStop := errors.New("replay stopped")
+
+	var runErr error
+	for _, path := range paths {
+		runErr = ReadRecording(path, func(receivedAt time.Time, message []byte) error {
+			if firstRecorded.IsZero() {
+				firstRecorded = receivedAt
+			}
+
+			// Pace playback relative to the first recorded message
+			if speed > 0 {
+				due := wallStart.Add(time.Duration(float64(receivedAt.Sub(firstRecorded)) / speed))
+				if wait := time.Until(due); wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-ctx.Done():
+						return errStop
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return errStop
+			}
+
+			bsm.dispatch(message)
+			// Close footprint bars on recorded time, as the live builder does on wall time
+			builder.CloseDue(receivedAt.UnixMilli())
+
+			rp.mu.Lock()
+			rp.status.Messages++
+			rp.status.Position = receivedAt
+			rp.mu.Unlock()
+			return nil
+		})
+		if runErr != nil {
+			break
+		}
+	}
+
+	rp.mu.Lock()
+	rp.status.Running = false
+	if runErr != nil && !errors.Is(runErr, errStop) {
+		rp.status.Error = runErr.Error()
+		logrus.Errorf("Replay failed: %v", runErr)
+	}
+	rp.cancel = nil
+	rp.mu.Unlock()
+
+	logrus.Infof("Replay finished after %d messages", rp.Status().Messages)
+}
+
+// HandleStartRecording starts the market data recorder
+func HandleStartRecording(c *gin.Context) {
+	if err := GetRecorder().Start(); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, GetRecorder().Status())
+}
+
+// HandleStopRecording stops the market data recorder
+func HandleStopRecording(c *gin.Context) {
+	if err := GetRecorder().Stop(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, GetRecorder().Status())
+}
+
+// HandleListRecordings lists available recording files
+func HandleListRecordings(c *gin.Context) {
+	files, err := GetRecorder().ListRecordings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"files":    files,
+		"recorder": GetRecorder().Status(),
+		"replay":   GetReplayer().Status(),
+	})
+}
+
+// HandleStartReplay replays recordings through the hub
+func HandleStartReplay(c *gin.Context) {
+	var req struct {
+		Files  []string `json:"files" binding:"required"`
+		Speed  *float64 `json:"speed"`  // default 1x, 0 = max
+		Models []string `json:"models"` // models to run on replayed closed candles
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	speed := 1.0
+	if req.Speed != nil {
+		speed = *req.Speed
+	}
+	if speed < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "speed must be >= 0"})
+		return
+	}
+
+	if err := GetReplayer().Start(req.Files, speed, req.Models); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrReplayRunning) {
+			status = http.StatusConflict
+		} else if errors.Is(err, ai.ErrUnknownModel) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, GetReplayer().Status())
+}
+
+// HandleStopReplay stops the running replay
+func HandleStopReplay(c *gin.Context) {
+	GetReplayer().Stop()
+	c.JSON(http.StatusOK, GetReplayer().Status())
+}
+
+// HandleReplayStatus returns recorder and replay state
+func HandleReplayStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"recorder": GetRecorder().Status(),
+		"replay":   GetReplayer().Status(),
+	})
+}
@@ -0,0 +1,215 @@
+// Package forest CART 결정 트리와 랜덤 포레스트 학습/추론
+//
+// 분류(Gini 불순도)와 회귀(분산) 분할, 부트스트랩 샘플링, 분할마다의 특성
+// 부분 샘플링, 고루틴 병렬 트리 학습, OOB(out-of-bag) 오차와 순열 중요도를
+// 제공한다. 학습된 Forest는 JSON으로 그대로 저장/복원할 수 있다.
+// 의존성이 없는 독립 모듈이라 backend-go와 go-services가 replace 지시어로
+// 함께 사용한다.
+package forest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrInvalidData 학습 데이터 또는 설정이 잘못됨
+	ErrInvalidData = errors.New("invalid training data")
+	// ErrMalformed 복원한 포레스트 구조가 잘못됨
+	ErrMalformed = errors.New("malformed forest")
+)
+
+// Criterion 분할 품질 기준
+type Criterion string
+
+const (
+	// Gini 분류: 클래스 라벨(0..Classes-1)의 Gini 불순도
+	Gini Criterion = "gini"
+	// Variance 회귀: 목표값의 분산 (MSE)
+	Variance Criterion = "variance"
+)
+
+// Config 포레스트 학습 설정
+type Config struct {
+	Trees           int       `json:"trees"`
+	Criterion       Criterion `json:"criterion"`
+	Classes         int       `json:"classes,omitempty"` // Gini 전용 클래스 수
+	MaxDepth        int       `json:"max_depth"`         // 0이면 제한 없음
+	MinSamplesSplit int       `json:"min_samples_split"`
+	MinSamplesLeaf  int       `json:"min_samples_leaf"`
+	// MaxFeatures 분할마다 무작위로 고르는 특성 수, 0이면 분류 sqrt(n), 회귀 n/3
+	MaxFeatures int   `json:"max_features"`
+	Bootstrap   bool  `json:"bootstrap"`
+	Seed        int64 `json:"seed"`
+	// Workers 동시에 학습하는 트리 수, 0이면 GOMAXPROCS
+	Workers int `json:"-"`
+}
+
+// Node 트리 노드; x[Feature] <= Threshold 이면 왼쪽으로 간다
+type Node struct {
+	Feature   int       `json:"feature"`
+	Threshold float64   `json:"threshold"`
+	Left      *Node     `json:"left,omitempty"`
+	Right     *Node     `json:"right,omitempty"`
+	Leaf      bool      `json:"leaf"`
+	Value     float64   `json:"value"`           // 회귀: 평균, 분류: 다수 클래스
+	Probs     []float64 `json:"probs,omitempty"` // 분류: 클래스 비율
+	Samples   int       `json:"samples"`         // 노드에 도달한 (부트스트랩) 표본 수
+	Impurity  float64   `json:"impurity"`
+}
+
+// Tree 결정 트리 한 개
+type Tree struct {
+	Root *Node `json:"root"`
+	// OOBError 이 트리의 OOB 표본 오차 (회귀 MSE, 분류 오분류율), 부트스트랩이 아니면 0
+	OOBError float64 `json:"oob_error"`
+	oob      []int   // 학습에 쓰이지 않은 표본 인덱스 (학습 중에만 유지)
+}
+
+// Forest 학습된 랜덤 포레스트
+type Forest struct {
+	Config   Config  `json:"config"`
+	Features int     `json:"features"`
+	Trees    []*Tree `json:"trees"`
+	// OOB 결과 (부트스트랩 학습일 때만 채워짐)
+	OOBSamples   int     `json:"oob_samples"`             // OOB 예측이 있는 표본 수
+	OOBError     float64 `json:"oob_error"`               // 회귀 MSE, 분류 오분류율
+	OOBAbsError  float64 `json:"oob_abs_error,omitempty"` // 회귀 MAE
+	OOBScore     float64 `json:"oob_score"`               // 회귀 R², 분류 정확도
+	OOBConfusion [][]int `json:"oob_confusion,omitempty"` // 분류: [실제][예측]
+	// ImpurityImportance 특성별 불순도 감소 합 (합계 1로 정규화)
+	ImpurityImportance []float64 `json:"impurity_importance"`
+	// PermutationImportance 특성 값을 OOB 표본 안에서 섞었을 때 늘어난 트리별 평균 OOB 오차
+	PermutationImportance []float64 `json:"permutation_importance,omitempty"`
+}
+
+// Leaf x가 도달하는 리프
+func (t *Tree) Leaf(x []float64) *Node {
+	node := t.Root
+	for !node.Leaf {
+		if x[node.Feature] <= node.Threshold {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return node
+}
+
+// Path 루트부터 x가 도달하는 리프까지의 노드
+func (t *Tree) Path(x []float64) []*Node {
+	path := []*Node{t.Root}
+	for node := t.Root; !node.Leaf; path = append(path, node) {
+		if x[node.Feature] <= node.Threshold {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return path
+}
+
+// Depth 리프까지의 최대 분할 수
+func (t *Tree) Depth() int { return depth(t.Root) }
+
+// Leaves 리프 수
+func (t *Tree) Leaves() int { return leaves(t.Root) }
+
+func depth(n *Node) int {
+	if n.Leaf {
+		return 0
+	}
+	return 1 + max(depth(n.Left), depth(n.Right))
+}
+
+func leaves(n *Node) int {
+	if n.Leaf {
+		return 1
+	}
+	return leaves(n.Left) + leaves(n.Right)
+}
+
+// Predict 회귀는 트리 평균, 분류는 평균 클래스 확률이 가장 큰 클래스
+func (f *Forest) Predict(x []float64) float64 {
+	if f.Config.Criterion == Gini {
+		return float64(argmax(f.PredictProba(x)))
+	}
+	sum := 0.0
+	for _, t := range f.Trees {
+		sum += t.Leaf(x).Value
+	}
+	return sum / float64(len(f.Trees))
+}
+
+// PredictProba 분류 포레스트의 트리 평균 클래스 확률 (회귀는 nil)
+func (f *Forest) PredictProba(x []float64) []float64 {
+	if f.Config.Criterion != Gini {
+		return nil
+	}
+	probs := make([]float64, f.Config.Classes)
+	for _, t := range f.Trees {
+		for k, p := range t.Leaf(x).Probs {
+			probs[k] += p
+		}
+	}
+	for k := range probs {
+		probs[k] /= float64(len(f.Trees))
+	}
+	return probs
+}
+
+// TreePredictions 트리별 예측값 (회귀 리프 평균, 분류 리프 다수 클래스)
+func (f *Forest) TreePredictions(x []float64) []float64 {
+	out := make([]float64, len(f.Trees))
+	for i, t := range f.Trees {
+		out[i] = t.Leaf(x).Value
+	}
+	return out
+}
+
+// Validate 복원한 포레스트의 구조 검사; features는 입력 특성 수
+func (f *Forest) Validate(features int) error {
+	if f.Features != features {
+		return fmt.Errorf("%w: %d features, want %d", ErrMalformed, f.Features, features)
+	}
+	if len(f.Trees) == 0 {
+		return fmt.Errorf("%w: no trees", ErrMalformed)
+	}
+	if f.Config.Criterion != Gini && f.Config.Criterion != Variance {
+		return fmt.Errorf("%w: unknown criterion %q", ErrMalformed, f.Config.Criterion)
+	}
+	classes := 0
+	if f.Config.Criterion == Gini {
+		if classes = f.Config.Classes; classes < 2 {
+			return fmt.Errorf("%w: %d classes", ErrMalformed, classes)
+		}
+	}
+	for i, t := range f.Trees {
+		if t == nil || !validNode(t.Root, features, classes) {
+			return fmt.Errorf("%w: tree %d", ErrMalformed, i)
+		}
+	}
+	return nil
+}
+
+func validNode(n *Node, features, classes int) bool {
+	switch {
+	case n == nil:
+		return false
+	case n.Leaf:
+		return !math.IsNaN(n.Value) && !math.IsInf(n.Value, 0) && (classes == 0 || len(n.Probs) == classes)
+	}
+	return n.Feature >= 0 && n.Feature < features &&
+		validNode(n.Left, features, classes) && validNode(n.Right, features, classes)
+}
+
+func argmax(v []float64) int {
+	best := 0
+	for i, x := range v {
+		if x > v[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,3 @@
+module github.com/loadstar0723/monstas7-backend/pkg/forest
+
+go 1.21
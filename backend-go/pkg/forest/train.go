@@ -0,0 +1,487 @@
+package forest
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Train x(행 = 표본, 열 = 특성)와 y로 포레스트를 학습한다. 분류(Gini)에서 y는
+// 0..Classes-1 클래스 라벨이다. 트리는 Workers개 고루틴에서 병렬로 학습하며,
+// 트리 i는 Seed+i로 시드를 정해 결과가 병렬도와 무관하게 재현된다.
+// 부트스트랩 학습이면 OOB 오차/점수와 순열 중요도도 계산한다.
+func Train(x [][]float64, y []float64, cfg Config) (*Forest, error) {
+	if err := prepare(x, y, &cfg); err != nil {
+		return nil, err
+	}
+	features := len(x[0])
+	f := &Forest{Config: cfg, Features: features, Trees: make([]*Tree, cfg.Trees)}
+	gains := make([][]float64, cfg.Trees)
+
+	f.parallel(func(i int) {
+		f.Trees[i], gains[i] = grow(x, y, cfg, i)
+	})
+
+	f.ImpurityImportance = make([]float64, features)
+	total := 0.0
+	for _, g := range gains {
+		for j, v := range g {
+			f.ImpurityImportance[j] += v
+			total += v
+		}
+	}
+	if total > 0 {
+		for j := range f.ImpurityImportance {
+			f.ImpurityImportance[j] /= total
+		}
+	}
+
+	if cfg.Bootstrap {
+		f.scoreOOB(x, y)
+		f.PermutationImportance = f.permutationImportance(x, y)
+	}
+	for _, t := range f.Trees {
+		t.oob = nil
+	}
+	return f, nil
+}
+
+// prepare 데이터를 검사하고 비어 있는 설정에 기본값을 채운다
+func prepare(x [][]float64, y []float64, cfg *Config) error {
+	if len(x) == 0 || len(x) != len(y) {
+		return fmt.Errorf("%w: %d rows, %d targets", ErrInvalidData, len(x), len(y))
+	}
+	features := len(x[0])
+	if features == 0 {
+		return fmt.Errorf("%w: no features", ErrInvalidData)
+	}
+	for i, row := range x {
+		if len(row) != features {
+			return fmt.Errorf("%w: row %d has %d features, want %d", ErrInvalidData, i, len(row), features)
+		}
+		for _, v := range row {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%w: row %d has a non-finite feature", ErrInvalidData, i)
+			}
+		}
+	}
+
+	if cfg.Criterion == "" {
+		cfg.Criterion = Variance
+	}
+	switch cfg.Criterion {
+	case Variance:
+		cfg.Classes = 0
+		for i, v := range y {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%w: target %d is not finite", ErrInvalidData, i)
+			}
+		}
+	case Gini:
+		if cfg.Classes < 2 {
+			return fmt.Errorf("%w: gini needs at least 2 classes", ErrInvalidData)
+		}
+		for i, v := range y {
+			if v != math.Trunc(v) || v < 0 || int(v) >= cfg.Classes {
+				return fmt.Errorf("%w: label %d is %v, want 0..%d", ErrInvalidData, i, v, cfg.Classes-1)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown criterion %q", ErrInvalidData, cfg.Criterion)
+	}
+
+	switch {
+	case cfg.Trees < 1:
+		return fmt.Errorf("%w: trees must be positive", ErrInvalidData)
+	case cfg.MaxDepth < 0 || cfg.MaxFeatures < 0:
+		return fmt.Errorf("%w: max_depth and max_features must not be negative", ErrInvalidData)
+	}
+	cfg.MinSamplesSplit = max(cfg.MinSamplesSplit, 2)
+	cfg.MinSamplesLeaf = max(cfg.MinSamplesLeaf, 1)
+	if cfg.MaxFeatures == 0 {
+		if cfg.Criterion == Gini {
+			cfg.MaxFeatures = int(math.Sqrt(float64(features)))
+		} else {
+			cfg.MaxFeatures = features / 3
+		}
+	}
+	cfg.MaxFeatures = min(max(cfg.MaxFeatures, 1), features)
+	return nil
+}
+
+// parallel run(0..트리 수-1)을 Workers개 고루틴으로 나눠 실행한다
+func (f *Forest) parallel(run func(i int)) {
+	workers := f.Config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(f.Trees)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run(i)
+			}
+		}()
+	}
+	for i := range f.Trees {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// treeRNG 트리별 난수 생성기; 학습과 순열 중요도가 서로 다른 흐름을 쓴다
+func treeRNG(seed int64, tree int, stream int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed + int64(tree)*2654435761 + stream))
+}
+
+// grow 트리 한 개를 부트스트랩 표본(또는 전체)으로 학습하고 특성별 불순도 감소를 돌려준다
+func grow(x [][]float64, y []float64, cfg Config, i int) (*Tree, []float64) {
+	rng := treeRNG(cfg.Seed, i, 0)
+	n := len(x)
+	rows := make([]int, n)
+	tree := &Tree{}
+	if cfg.Bootstrap {
+		inBag := make([]bool, n)
+		for k := range rows {
+			rows[k] = rng.Intn(n)
+			inBag[rows[k]] = true
+		}
+		for r, in := range inBag {
+			if !in {
+				tree.oob = append(tree.oob, r)
+			}
+		}
+	} else {
+		for k := range rows {
+			rows[k] = k
+		}
+	}
+
+	b := &builder{x: x, y: y, cfg: cfg, rng: rng, gains: make([]float64, len(x[0])), order: make([]int, len(x[0]))}
+	for j := range b.order {
+		b.order[j] = j
+	}
+	tree.Root = b.node(rows, 0)
+	return tree, b.gains
+}
+
+// builder 트리 한 개의 재귀 분할 상태
+type builder struct {
+	x     [][]float64
+	y     []float64
+	cfg   Config
+	rng   *rand.Rand
+	gains []float64 // 특성별 (표본 수 가중) 불순도 감소 합
+	order []int     // 특성 부분 샘플링용 순열
+}
+
+// split 노드의 후보 분할
+type split struct {
+	feature   int
+	threshold float64
+	weighted  float64 // 자식 불순도의 표본 수 가중 합
+}
+
+// node rows로 노드를 만들고 조건이 맞으면 재귀적으로 분할한다
+func (b *builder) node(rows []int, depth int) *Node {
+	n := &Node{Samples: len(rows), Leaf: true}
+	n.Impurity = b.leafValue(n, rows)
+
+	c := b.cfg
+	if (c.MaxDepth > 0 && depth >= c.MaxDepth) || len(rows) < c.MinSamplesSplit || len(rows) < 2*c.MinSamplesLeaf || n.Impurity <= 1e-15 {
+		return n
+	}
+	s, ok := b.bestSplit(rows, n.Impurity*float64(len(rows)))
+	if !ok {
+		return n
+	}
+
+	var left, right []int
+	for _, r := range rows {
+		if b.x[r][s.feature] <= s.threshold {
+			left = append(left, r)
+		} else {
+			right = append(right, r)
+		}
+	}
+	b.gains[s.feature] += n.Impurity*float64(len(rows)) - s.weighted
+	n.Leaf, n.Feature, n.Threshold = false, s.feature, s.threshold
+	n.Probs = nil
+	n.Left = b.node(left, depth+1)
+	n.Right = b.node(right, depth+1)
+	return n
+}
+
+// leafValue 노드의 예측값(회귀 평균, 분류 다수 클래스와 비율)을 채우고 불순도를 돌려준다
+func (b *builder) leafValue(n *Node, rows []int) float64 {
+	size := float64(len(rows))
+	if b.cfg.Criterion == Gini {
+		n.Probs = make([]float64, b.cfg.Classes)
+		for _, r := range rows {
+			n.Probs[int(b.y[r])]++
+		}
+		impurity := 1.0
+		for k := range n.Probs {
+			n.Probs[k] /= size
+			impurity -= n.Probs[k] * n.Probs[k]
+		}
+		n.Value = float64(argmax(n.Probs))
+		return impurity
+	}
+	var sum, sq float64
+	for _, r := range rows {
+		sum += b.y[r]
+		sq += b.y[r] * b.y[r]
+	}
+	n.Value = sum / size
+	return math.Max(0, sq/size-n.Value*n.Value)
+}
+
+// bestSplit 무작위로 고른 MaxFeatures개 특성에서 자식 불순도 가중 합이 가장 작은
+// 분할을 찾는다. parent는 부모 불순도의 표본 수 가중값이며, 이를 줄이지 못하면 실패한다.
+func (b *builder) bestSplit(rows []int, parent float64) (split, bool) {
+	best := split{weighted: parent}
+	found := false
+	type point struct {
+		v float64
+		r int
+	}
+	points := make([]point, len(rows))
+	minLeaf := b.cfg.MinSamplesLeaf
+
+	// 부분 Fisher-Yates로 특성 MaxFeatures개를 뽑는다
+	for k := 0; k < b.cfg.MaxFeatures; k++ {
+		j := k + b.rng.Intn(len(b.order)-k)
+		b.order[k], b.order[j] = b.order[j], b.order[k]
+		feature := b.order[k]
+
+		for i, r := range rows {
+			points[i] = point{b.x[r][feature], r}
+		}
+		slices.SortFunc(points, func(p, q point) int { return cmp.Compare(p.v, q.v) })
+		if points[0].v == points[len(points)-1].v {
+			continue
+		}
+
+		acc := b.newAccumulator(rows)
+		for i := 1; i < len(points); i++ {
+			acc.move(b.y[points[i-1].r])
+			if points[i-1].v == points[i].v || i < minLeaf || len(points)-i < minLeaf {
+				continue
+			}
+			if w := acc.weighted(); w < best.weighted-1e-12 {
+				threshold := points[i-1].v + (points[i].v-points[i-1].v)/2
+				if threshold >= points[i].v {
+					threshold = points[i-1].v
+				}
+				best = split{feature: feature, threshold: threshold, weighted: w}
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// accumulator 정렬 순서대로 표본을 왼쪽으로 옮기며 양쪽 불순도를 갱신한다
+type accumulator struct {
+	gini            bool
+	left, right     []float64 // 분류: 클래스별 개수
+	nl, nr          float64
+	sumL, sumR      float64 // 회귀: 합
+	sqL, sqR        float64 // 회귀: 제곱합
+	classes         int
+	leftSq, rightSq float64 // 분류: 클래스 개수 제곱합
+}
+
+func (b *builder) newAccumulator(rows []int) *accumulator {
+	a := &accumulator{gini: b.cfg.Criterion == Gini, nr: float64(len(rows))}
+	if a.gini {
+		a.left, a.right = make([]float64, b.cfg.Classes), make([]float64, b.cfg.Classes)
+		for _, r := range rows {
+			a.right[int(b.y[r])]++
+		}
+		for _, c := range a.right {
+			a.rightSq += c * c
+		}
+		return a
+	}
+	for _, r := range rows {
+		a.sumR += b.y[r]
+		a.sqR += b.y[r] * b.y[r]
+	}
+	return a
+}
+
+// move 목표값 y인 표본 하나를 오른쪽에서 왼쪽으로 옮긴다
+func (a *accumulator) move(y float64) {
+	a.nl++
+	a.nr--
+	if a.gini {
+		k := int(y)
+		a.leftSq += 2*a.left[k] + 1
+		a.rightSq -= 2*a.right[k] - 1
+		a.left[k]++
+		a.right[k]--
+		return
+	}
+	a.sumL += y
+	a.sumR -= y
+	a.sqL += y * y
+	a.sqR -= y * y
+}
+
+// weighted 양쪽 불순도의 표본 수 가중 합 (n·Gini = n - Σc²/n, n·분산 = Σy² - (Σy)²/n)
+func (a *accumulator) weighted() float64 {
+	if a.gini {
+		return a.nl - a.leftSq/a.nl + a.nr - a.rightSq/a.nr
+	}
+	return math.Max(0, a.sqL-a.sumL*a.sumL/a.nl) + math.Max(0, a.sqR-a.sumR*a.sumR/a.nr)
+}
+
+// loss 표본 하나의 손실 (회귀 제곱 오차, 분류 오분류 여부)
+func (f *Forest) loss(leaf *Node, y float64) float64 {
+	if f.Config.Criterion == Gini {
+		if leaf.Value != y {
+			return 1
+		}
+		return 0
+	}
+	return (leaf.Value - y) * (leaf.Value - y)
+}
+
+// treeOOBError 트리 한 개의 OOB 평균 손실; permute가 0 이상이면 그 특성을 OOB 표본 안에서 섞는다
+func (f *Forest) treeOOBError(t *Tree, x [][]float64, y []float64, permute int, rng *rand.Rand) float64 {
+	var perm []int
+	var row []float64
+	if permute >= 0 {
+		perm = rng.Perm(len(t.oob))
+		row = make([]float64, f.Features)
+	}
+	sum := 0.0
+	for k, i := range t.oob {
+		input := x[i]
+		if perm != nil {
+			copy(row, x[i])
+			row[permute] = x[t.oob[perm[k]]][permute]
+			input = row
+		}
+		sum += f.loss(t.Leaf(input), y[i])
+	}
+	return sum / float64(len(t.oob))
+}
+
+// scoreOOB 각 표본을 그 표본으로 학습하지 않은 트리들로만 예측해 OOB 오차를 구한다
+func (f *Forest) scoreOOB(x [][]float64, y []float64) {
+	n := len(x)
+	gini := f.Config.Criterion == Gini
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	var probs [][]float64
+	if gini {
+		probs = make([][]float64, n)
+	}
+
+	for _, t := range f.Trees {
+		if len(t.oob) == 0 {
+			continue
+		}
+		t.OOBError = f.treeOOBError(t, x, y, -1, nil)
+		for _, i := range t.oob {
+			leaf := t.Leaf(x[i])
+			counts[i]++
+			if gini {
+				if probs[i] == nil {
+					probs[i] = make([]float64, f.Config.Classes)
+				}
+				for k, p := range leaf.Probs {
+					probs[i][k] += p
+				}
+			} else {
+				sums[i] += leaf.Value
+			}
+		}
+	}
+
+	if gini {
+		f.OOBConfusion = make([][]int, f.Config.Classes)
+		for k := range f.OOBConfusion {
+			f.OOBConfusion[k] = make([]int, f.Config.Classes)
+		}
+	}
+	var loss, abs, mean, sq float64
+	for i := range x {
+		if counts[i] == 0 {
+			continue
+		}
+		f.OOBSamples++
+		if gini {
+			predicted := argmax(probs[i])
+			f.OOBConfusion[int(y[i])][predicted]++
+			if predicted != int(y[i]) {
+				loss++
+			}
+			continue
+		}
+		diff := sums[i]/float64(counts[i]) - y[i]
+		loss += diff * diff
+		abs += math.Abs(diff)
+		mean += y[i]
+		sq += y[i] * y[i]
+	}
+	if f.OOBSamples == 0 {
+		return
+	}
+	m := float64(f.OOBSamples)
+	f.OOBError = loss / m
+	if gini {
+		f.OOBScore = 1 - f.OOBError
+		return
+	}
+	f.OOBAbsError = abs / m
+	if variance := sq/m - (mean/m)*(mean/m); variance > 0 {
+		f.OOBScore = 1 - f.OOBError/variance
+	}
+}
+
+// permutationImportance 특성마다 트리별 OOB 표본에서 그 특성 값을 섞었을 때
+// OOB 오차가 얼마나 늘어나는지의 트리 평균 (Breiman 2001)
+func (f *Forest) permutationImportance(x [][]float64, y []float64) []float64 {
+	increases := make([][]float64, len(f.Trees))
+	f.parallel(func(i int) {
+		t := f.Trees[i]
+		if len(t.oob) == 0 {
+			return
+		}
+		rng := treeRNG(f.Config.Seed, i, 1)
+		increases[i] = make([]float64, f.Features)
+		for j := range increases[i] {
+			increases[i][j] = f.treeOOBError(t, x, y, j, rng) - t.OOBError
+		}
+	})
+
+	importance := make([]float64, f.Features)
+	trees := 0
+	for _, inc := range increases {
+		if inc == nil {
+			continue
+		}
+		trees++
+		for j, v := range inc {
+			importance[j] += v
+		}
+	}
+	if trees > 0 {
+		for j := range importance {
+			importance[j] /= float64(trees)
+		}
+	}
+	return importance
+}
@@ -0,0 +1,3 @@
+module github.com/loadstar0723/monstas7-backend/pkg/kline
+
+go 1.21
@@ -0,0 +1,347 @@
+// Package kline Binance 캔들스틱(kline) 응답의 타입 안전한 공통 디코더
+//
+// REST /api/v3/klines 배열 행과 웹소켓 <symbol>@kline_<interval> 이벤트를
+// 같은 Kline 구조체로 변환한다. 숫자 필드는 json.Number 또는 숫자 문자열
+// 모두 허용하며, 잘못된 값은 패닉 대신 행/필드 정보가 담긴 DecodeError로 보고한다.
+// 의존성이 없는 독립 모듈이라 backend-go, go-trading-engine, go-services가
+// replace 지시어로 함께 사용한다.
+package kline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrShortRow 행의 필드 수가 부족함
+	ErrShortRow = errors.New("kline row has too few fields")
+	// ErrInvalidNumber 숫자로 해석할 수 없는 필드
+	ErrInvalidNumber = errors.New("invalid number")
+	// ErrInvalidCandle OHLCV 값이 서로 모순됨 (예: high < low)
+	ErrInvalidCandle = errors.New("invalid candle")
+)
+
+// minRowFields REST 행에서 반드시 필요한 필드 수 (openTime ~ trades)
+const minRowFields = 9
+
+// Kline 캔들스틱 한 개
+type Kline struct {
+	OpenTime            int64   `json:"openTime"`
+	Open                float64 `json:"open"`
+	High                float64 `json:"high"`
+	Low                 float64 `json:"low"`
+	Close               float64 `json:"close"`
+	Volume              float64 `json:"volume"`
+	CloseTime           int64   `json:"closeTime"`
+	QuoteVolume         float64 `json:"quoteVolume"`
+	Trades              int64   `json:"trades"`
+	TakerBuyBaseVolume  float64 `json:"takerBuyBaseVolume"`
+	TakerBuyQuoteVolume float64 `json:"takerBuyQuoteVolume"`
+}
+
+// Validate 가격/거래량/시간 값의 정합성 검사
+func (k Kline) Validate() error {
+	switch {
+	case k.Open <= 0 || k.High <= 0 || k.Low <= 0 || k.Close <= 0:
+		return fmt.Errorf("%w: non-positive price", ErrInvalidCandle)
+	case k.High < k.Low:
+		return fmt.Errorf("%w: high %v < low %v", ErrInvalidCandle, k.High, k.Low)
+	case k.High < k.Open || k.High < k.Close:
+		return fmt.Errorf("%w: high %v below open/close", ErrInvalidCandle, k.High)
+	case k.Low > k.Open || k.Low > k.Close:
+		return fmt.Errorf("%w: low %v above open/close", ErrInvalidCandle, k.Low)
+	case k.Volume < 0 || k.QuoteVolume < 0 || k.Trades < 0:
+		return fmt.Errorf("%w: negative volume", ErrInvalidCandle)
+	case k.CloseTime <= k.OpenTime:
+		return fmt.Errorf("%w: closeTime %d <= openTime %d", ErrInvalidCandle, k.CloseTime, k.OpenTime)
+	}
+	return nil
+}
+
+// DecodeError 디코딩 실패 위치와 원인
+type DecodeError struct {
+	Row   int    // 응답 내 행 번호 (웹소켓 이벤트는 -1)
+	Field string // 실패한 필드 이름 (검증 실패는 빈 문자열)
+	Value string // 원본 값
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("kline")
+	if e.Row >= 0 {
+		fmt.Fprintf(&b, " row %d", e.Row)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, " field %s", e.Field)
+	}
+	if e.Value != "" {
+		fmt.Fprintf(&b, " (%q)", e.Value)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// APIError 배열 대신 반환된 Binance 오류 응답 ({"code":-1121,"msg":"Invalid symbol."})
+type APIError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("binance error %d: %s", e.Code, e.Msg)
+}
+
+// Decode REST klines 응답을 엄격하게 디코딩 (잘못된 행이 하나라도 있으면 실패)
+func Decode(r io.Reader) ([]Kline, error) {
+	klines, rowErrs, err := DecodeLenient(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowErrs) > 0 {
+		return nil, rowErrs[0]
+	}
+	return klines, nil
+}
+
+// DecodeBytes Decode의 바이트 슬라이스 버전
+func DecodeBytes(data []byte) ([]Kline, error) {
+	return Decode(bytes.NewReader(data))
+}
+
+// DecodeLenient 잘못된 행은 건너뛰고 행별 오류를 함께 반환
+// 응답 자체를 해석할 수 없으면 err가 설정된다
+func DecodeLenient(r io.Reader) ([]Kline, []error, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+
+	if len(raw) > 0 && raw[0] == '{' {
+		var apiErr APIError
+		if err := json.Unmarshal(raw, &apiErr); err == nil && apiErr.Msg != "" {
+			return nil, nil, &apiErr
+		}
+		return nil, nil, fmt.Errorf("kline response is an object, not an array: %.100s", raw)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var rows [][]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode klines: %w", err)
+	}
+
+	klines := make([]Kline, 0, len(rows))
+	var rowErrs []error
+	for i, row := range rows {
+		k, err := DecodeRow(row)
+		if err != nil {
+			var de *DecodeError
+			if errors.As(err, &de) {
+				de.Row = i
+			}
+			rowErrs = append(rowErrs, err)
+			continue
+		}
+		klines = append(klines, k)
+	}
+	return klines, rowErrs, nil
+}
+
+// DecodeRow REST 배열 행 하나를 디코딩하고 검증
+// [openTime, open, high, low, close, volume, closeTime, quoteVolume, trades, takerBase, takerQuote, ignore]
+func DecodeRow(row []interface{}) (Kline, error) {
+	if len(row) < minRowFields {
+		return Kline{}, &DecodeError{Row: -1, Value: strconv.Itoa(len(row)), Err: ErrShortRow}
+	}
+
+	var k Kline
+	p := rowParser{row: row}
+	k.OpenTime = p.int(0, "openTime")
+	k.Open = p.float(1, "open")
+	k.High = p.float(2, "high")
+	k.Low = p.float(3, "low")
+	k.Close = p.float(4, "close")
+	k.Volume = p.float(5, "volume")
+	k.CloseTime = p.int(6, "closeTime")
+	k.QuoteVolume = p.float(7, "quoteVolume")
+	k.Trades = p.int(8, "trades")
+	// 테이커 매수량은 선택 필드
+	if len(row) > 10 {
+		k.TakerBuyBaseVolume = p.float(9, "takerBuyBaseVolume")
+		k.TakerBuyQuoteVolume = p.float(10, "takerBuyQuoteVolume")
+	}
+	if p.err != nil {
+		return Kline{}, p.err
+	}
+
+	if err := k.Validate(); err != nil {
+		return Kline{}, &DecodeError{Row: -1, Err: err}
+	}
+	return k, nil
+}
+
+// Rows json.Unmarshaler 구현 (응답을 바로 []Kline으로 디코딩할 때 사용, 엄격 모드)
+type Rows []Kline
+
+func (r *Rows) UnmarshalJSON(data []byte) error {
+	klines, err := DecodeBytes(data)
+	if err != nil {
+		return err
+	}
+	*r = klines
+	return nil
+}
+
+// Event 웹소켓 kline 이벤트 (<symbol>@kline_<interval>)
+type Event struct {
+	Symbol   string
+	Interval string
+	Closed   bool // 캔들 마감 여부
+	Kline    Kline
+}
+
+type rawEvent struct {
+	Symbol string `json:"s"`
+	K      struct {
+		OpenTime            json.Number `json:"t"`
+		CloseTime           json.Number `json:"T"`
+		Symbol              string      `json:"s"`
+		Interval            string      `json:"i"`
+		Open                json.Number `json:"o"`
+		Close               json.Number `json:"c"`
+		High                json.Number `json:"h"`
+		Low                 json.Number `json:"l"`
+		Volume              json.Number `json:"v"`
+		Trades              json.Number `json:"n"`
+		Closed              bool        `json:"x"`
+		QuoteVolume         json.Number `json:"q"`
+		TakerBuyBaseVolume  json.Number `json:"V"`
+		TakerBuyQuoteVolume json.Number `json:"Q"`
+	} `json:"k"`
+}
+
+// DecodeEvent 웹소켓 kline 이벤트 payload(combined stream의 data)를 디코딩하고 검증
+func DecodeEvent(data []byte) (Event, error) {
+	var raw rawEvent
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Event{}, &DecodeError{Row: -1, Err: err}
+	}
+
+	symbol := raw.Symbol
+	if symbol == "" {
+		symbol = raw.K.Symbol
+	}
+	if symbol == "" || raw.K.Interval == "" {
+		return Event{}, &DecodeError{Row: -1, Field: "k", Err: errors.New("missing symbol or interval")}
+	}
+
+	p := rowParser{row: []interface{}{
+		raw.K.OpenTime, raw.K.Open, raw.K.High, raw.K.Low, raw.K.Close, raw.K.Volume,
+		raw.K.CloseTime, raw.K.QuoteVolume, raw.K.Trades,
+	}}
+	k := Kline{
+		OpenTime:    p.int(0, "t"),
+		Open:        p.float(1, "o"),
+		High:        p.float(2, "h"),
+		Low:         p.float(3, "l"),
+		Close:       p.float(4, "c"),
+		Volume:      p.float(5, "v"),
+		CloseTime:   p.int(6, "T"),
+		QuoteVolume: p.float(7, "q"),
+		Trades:      p.int(8, "n"),
+	}
+	if raw.K.TakerBuyBaseVolume != "" {
+		k.TakerBuyBaseVolume, _ = raw.K.TakerBuyBaseVolume.Float64()
+		k.TakerBuyQuoteVolume, _ = raw.K.TakerBuyQuoteVolume.Float64()
+	}
+	if p.err != nil {
+		return Event{}, p.err
+	}
+	if err := k.Validate(); err != nil {
+		return Event{}, &DecodeError{Row: -1, Err: err}
+	}
+
+	return Event{
+		Symbol:   strings.ToUpper(symbol),
+		Interval: raw.K.Interval,
+		Closed:   raw.K.Closed,
+		Kline:    k,
+	}, nil
+}
+
+// rowParser 첫 번째 오류만 기록하며 필드를 순서대로 파싱
+type rowParser struct {
+	row []interface{}
+	err error
+}
+
+func (p *rowParser) float(i int, field string) float64 {
+	if p.err != nil {
+		return 0
+	}
+	s, ok := numberText(p.row[i])
+	if !ok {
+		p.fail(field, fmt.Sprint(p.row[i]))
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.fail(field, s)
+		return 0
+	}
+	return v
+}
+
+func (p *rowParser) int(i int, field string) int64 {
+	if p.err != nil {
+		return 0
+	}
+	s, ok := numberText(p.row[i])
+	if !ok {
+		p.fail(field, fmt.Sprint(p.row[i]))
+		return 0
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		// 1.7e12 같은 지수 표기 허용
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil || f != float64(int64(f)) {
+			p.fail(field, s)
+			return 0
+		}
+		v = int64(f)
+	}
+	return v
+}
+
+func (p *rowParser) fail(field, value string) {
+	p.err = &DecodeError{Row: -1, Field: field, Value: value, Err: ErrInvalidNumber}
+}
+
+// numberText json.Number, 숫자 문자열, float64(UseNumber 미사용 시)를 문자열로 변환
+func numberText(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String(), n != ""
+	case string:
+		s := strings.TrimSpace(n)
+		return s, s != ""
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
@@ -3,12 +3,13 @@ module ai-analysis
 go 1.21
 
 require (
-    github.com/go-redis/redis/v8 v8.11.5
-    github.com/gorilla/mux v1.8.1
-    github.com/gorilla/websocket v1.5.1
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.1
 )
 
 require (
-    github.com/cespare/xxhash/v2 v2.2.0 // indirect
-    github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-)
\ No newline at end of file
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/net v0.17.0 // indirect
+)
@@ -4,10 +4,10 @@ import (
     "context"
     "encoding/json"
     "fmt"
-    "io/ioutil"
     "net/http"
     "time"
     "github.com/go-redis/redis/v8"
+    "github.com/loadstar0723/monstas7-backend/pkg/kline"
     "log"
 )
 
@@ -24,13 +24,22 @@ func NewDataCollector(redisAddr string) *DataCollector {
         Addr: redisAddr,
     })
     
-    return &DataCollector{
+    dc := &DataCollector{
         redisClient: rdb,
         httpClient: &http.Client{
             Timeout: 10 * time.Second,
         },
         ctx: context.Background(),
     }
+
+    // Resolve the symbol universe once per process
+    symbolsOnce.Do(func() {
+        if err := dc.LoadSupportedCoins(); err != nil {
+            log.Printf("Using unvalidated watchlist: %v", err)
+        }
+    })
+
+    return dc
 }
 
 // GetHistoricalData fetches historical kline data from Binance
@@ -48,34 +57,26 @@ func (dc *DataCollector) GetHistoricalData(symbol string, interval string, limit
         return nil, fmt.Errorf("API error: %d", resp.StatusCode)
     }
     
-    body, err := ioutil.ReadAll(resp.Body)
+    // Decode with the shared typed decoder; malformed rows are skipped and logged
+    // instead of panicking the whole model service
+    klines, rowErrs, err := kline.DecodeLenient(resp.Body)
     if err != nil {
-        return nil, err
+        return nil, fmt.Errorf("%s klines: %w", symbol, err)
     }
-    
-    var klines [][]interface{}
-    if err := json.Unmarshal(body, &klines); err != nil {
-        return nil, err
+    if len(rowErrs) > 0 {
+        log.Printf("%s klines: skipped %d invalid rows (first: %v)", symbol, len(rowErrs), rowErrs[0])
     }
     
     marketData := make([]MarketData, len(klines))
-    for i, kline := range klines {
-        // Kline format: [openTime, open, high, low, close, volume, closeTime, ...]
-        openTime := int64(kline[0].(float64))
-        open := parseFloat(kline[1].(string))
-        high := parseFloat(kline[2].(string))
-        low := parseFloat(kline[3].(string))
-        close := parseFloat(kline[4].(string))
-        volume := parseFloat(kline[5].(string))
-        
+    for i, k := range klines {
         marketData[i] = MarketData{
             Symbol:    symbol,
-            Open:      open,
-            High:      high,
-            Low:       low,
-            Close:     close,
-            Volume:    volume,
-            Timestamp: time.Unix(openTime/1000, 0),
+            Open:      k.Open,
+            High:      k.High,
+            Low:       k.Low,
+            Close:     k.Close,
+            Volume:    k.Volume,
+            Timestamp: time.UnixMilli(k.OpenTime),
         }
     }
     
@@ -0,0 +1,145 @@
+package common
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "strings"
+    "sync"
+)
+
+// defaultCoinNames maps well-known symbols to display names
+var defaultCoinNames = map[string]string{}
+
+var (
+    symbolMu       sync.RWMutex
+    tradingSymbols map[string]bool
+    symbolsOnce    sync.Once
+)
+
+func init() {
+    for _, coin := range SupportedCoins {
+        defaultCoinNames[coin.Symbol] = coin.Name
+    }
+}
+
+// LoadSupportedCoins rebuilds SupportedCoins from the WATCHLIST env var and
+// Binance exchangeInfo, dropping symbols that are unknown or not trading.
+// If exchangeInfo is unreachable the configured watchlist is used as-is.
+func (dc *DataCollector) LoadSupportedCoins() error {
+    watchlist := watchlistFromEnv()
+
+    resp, err := dc.httpClient.Get("https://api.binance.com/api/v3/exchangeInfo")
+    if err != nil {
+        setSupportedCoins(watchlist, nil)
+        return fmt.Errorf("exchangeInfo request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        setSupportedCoins(watchlist, nil)
+        return fmt.Errorf("exchangeInfo API error: %d", resp.StatusCode)
+    }
+
+    var info struct {
+        Symbols []struct {
+            Symbol string `json:"symbol"`
+            Status string `json:"status"`
+        } `json:"symbols"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+        setSupportedCoins(watchlist, nil)
+        return fmt.Errorf("exchangeInfo decode failed: %w", err)
+    }
+
+    trading := make(map[string]bool, len(info.Symbols))
+    for _, s := range info.Symbols {
+        if s.Status == "TRADING" {
+            trading[s.Symbol] = true
+        }
+    }
+
+    setSupportedCoins(watchlist, trading)
+    return nil
+}
+
+// ValidateSymbol normalizes a symbol and checks it against the loaded universe
+func ValidateSymbol(symbol string) (string, error) {
+    normalized := strings.ToUpper(strings.TrimSpace(symbol))
+    if normalized == "" {
+        return "", fmt.Errorf("symbol is required")
+    }
+
+    symbolMu.RLock()
+    defer symbolMu.RUnlock()
+
+    if tradingSymbols != nil && !tradingSymbols[normalized] {
+        return "", fmt.Errorf("unknown or non-trading symbol: %s", normalized)
+    }
+    for _, coin := range SupportedCoins {
+        if coin.Symbol == normalized {
+            return normalized, nil
+        }
+    }
+    return "", fmt.Errorf("symbol not in watchlist: %s", normalized)
+}
+
+// SymbolValidator returns middleware that rejects requests whose symbol
+// path variable (as extracted by getSymbol) is not supported
+func SymbolValidator(getSymbol func(r *http.Request) string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if symbol := getSymbol(r); symbol != "" {
+                if _, err := ValidateSymbol(symbol); err != nil {
+                    w.Header().Set("Content-Type", "application/json")
+                    w.WriteHeader(http.StatusBadRequest)
+                    json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+                    return
+                }
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+func setSupportedCoins(watchlist []string, trading map[string]bool) {
+    coins := make([]CoinInfo, 0, len(watchlist))
+    for _, symbol := range watchlist {
+        if trading != nil && !trading[symbol] {
+            log.Printf("Dropping %s from watchlist: not trading on Binance", symbol)
+            continue
+        }
+        name := defaultCoinNames[symbol]
+        if name == "" {
+            name = strings.TrimSuffix(symbol, "USDT")
+        }
+        coins = append(coins, CoinInfo{Symbol: symbol, Name: name})
+    }
+
+    symbolMu.Lock()
+    SupportedCoins = coins
+    tradingSymbols = trading
+    symbolMu.Unlock()
+}
+
+// watchlistFromEnv reads a comma separated WATCHLIST, defaulting to the built-in coins
+func watchlistFromEnv() []string {
+    raw := os.Getenv("WATCHLIST")
+    if raw == "" {
+        symbols := make([]string, 0, len(SupportedCoins))
+        for _, coin := range SupportedCoins {
+            symbols = append(symbols, coin.Symbol)
+        }
+        return symbols
+    }
+
+    symbols := make([]string, 0)
+    for _, part := range strings.Split(raw, ",") {
+        if symbol := strings.ToUpper(strings.TrimSpace(part)); symbol != "" {
+            symbols = append(symbols, symbol)
+        }
+    }
+    return symbols
+}
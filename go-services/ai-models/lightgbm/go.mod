@@ -3,3 +3,5 @@ module lightgbm
 go 1.21
 
 replace ai-models/common => ../common
+
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ../../../backend-go/pkg/kline
@@ -9,10 +9,13 @@ require (
 
 replace ai-models/common => ../common
 
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ../../../backend-go/pkg/kline
+
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/go-redis/redis/v8 v8.11.5 // indirect
 	github.com/gorilla/websocket v1.5.1 // indirect
+	github.com/loadstar0723/monstas7-backend/pkg/kline v0.0.0 // indirect
 	golang.org/x/net v0.17.0 // indirect
 )
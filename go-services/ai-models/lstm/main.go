@@ -406,6 +406,9 @@ func main() {
     
     // Setup routes
     r := mux.NewRouter()
+    r.Use(common.SymbolValidator(func(r *http.Request) string {
+        return mux.Vars(r)["symbol"]
+    }))
     
     // WebSocket endpoint
     r.HandleFunc("/ws", service.wsManager.HandleWebSocket)
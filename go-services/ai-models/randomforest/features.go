@@ -0,0 +1,131 @@
+package main
+
+import (
+    "math"
+    
+    "ai-models/common"
+)
+
+// featureNames lists the columns of a feature row in order
+var featureNames = []string{
+    "price_change_1h",
+    "price_change_24h",
+    "volume_change",
+    "rsi",
+    "macd",
+    "bb_position",
+    "ma_cross",
+    "volume_profile",
+    "volatility",
+}
+
+// featureWarmup is the number of leading candles without a full feature row
+const featureWarmup = 30
+
+// buildFeatures returns one feature row per 1h candle, using only that
+// candle and the ones before it. Rows before featureWarmup are nil.
+func buildFeatures(data []common.MarketData) [][]float64 {
+    rows := make([][]float64, len(data))
+    
+    // MACD EMAs run over the whole series so every row sees a settled value
+    ema12, ema26 := 0.0, 0.0
+    for i, d := range data {
+        if i == 0 {
+            ema12, ema26 = d.Close, d.Close
+        } else {
+            ema12 += (d.Close - ema12) * 2 / 13
+            ema26 += (d.Close - ema26) * 2 / 27
+        }
+        if i < featureWarmup {
+            continue
+        }
+        
+        price := d.Close
+        volumeChange := 0.0
+        if data[i-1].Volume > 0 {
+            volumeChange = d.Volume/data[i-1].Volume - 1
+        }
+        
+        rows[i] = []float64{
+            price/data[i-1].Close - 1,
+            price/data[i-24].Close - 1,
+            volumeChange,
+            rsi(data[i-14 : i+1]),
+            (ema12 - ema26) / price,
+            bollingerPosition(data[i-19 : i+1]),
+            sma(data[i-6:i+1])/sma(data[i-24:i+1]) - 1,
+            (price - vwap(data[i-23:i+1])) / price,
+            volatility(data[i-24 : i+1]),
+        }
+    }
+    return rows
+}
+
+func sma(data []common.MarketData) float64 {
+    sum := 0.0
+    for _, d := range data {
+        sum += d.Close
+    }
+    return sum / float64(len(data))
+}
+
+// rsi is the simple-average RSI over the changes in data, scaled to 0-1
+func rsi(data []common.MarketData) float64 {
+    gains, losses := 0.0, 0.0
+    for i := 1; i < len(data); i++ {
+        change := data[i].Close - data[i-1].Close
+        if change > 0 {
+            gains += change
+        } else {
+            losses -= change
+        }
+    }
+    if gains+losses == 0 {
+        return 0.5
+    }
+    return gains / (gains + losses)
+}
+
+// bollingerPosition places the last close within the 2-sigma bands, 0 at the
+// lower band and 1 at the upper band
+func bollingerPosition(data []common.MarketData) float64 {
+    mean := sma(data)
+    variance := 0.0
+    for _, d := range data {
+        variance += (d.Close - mean) * (d.Close - mean)
+    }
+    std := math.Sqrt(variance / float64(len(data)))
+    if std == 0 {
+        return 0.5
+    }
+    return (data[len(data)-1].Close - (mean - 2*std)) / (4 * std)
+}
+
+// vwap is the volume-weighted typical price of data
+func vwap(data []common.MarketData) float64 {
+    value, volume := 0.0, 0.0
+    for _, d := range data {
+        value += (d.High + d.Low + d.Close) / 3 * d.Volume
+        volume += d.Volume
+    }
+    if volume == 0 {
+        return data[len(data)-1].Close
+    }
+    return value / volume
+}
+
+// volatility is the standard deviation of the close-to-close returns in data
+func volatility(data []common.MarketData) float64 {
+    returns := make([]float64, len(data)-1)
+    mean := 0.0
+    for i := range returns {
+        returns[i] = data[i+1].Close/data[i].Close - 1
+        mean += returns[i]
+    }
+    mean /= float64(len(returns))
+    variance := 0.0
+    for _, r := range returns {
+        variance += (r - mean) * (r - mean)
+    }
+    return math.Sqrt(variance / float64(len(returns)))
+}
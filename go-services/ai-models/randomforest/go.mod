@@ -5,14 +5,22 @@ go 1.21
 require (
 	ai-models/common v0.0.0
 	github.com/gorilla/mux v1.8.1
+	github.com/loadstar0723/monstas7-backend/pkg/forest v0.0.0
 )
 
 replace ai-models/common => ../common
 
+// backend-go/pkg lies outside this module; Docker builds use the shared
+// ai-models/Dockerfile with the repository root as build context, which copies it
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ../../../backend-go/pkg/kline
+
+replace github.com/loadstar0723/monstas7-backend/pkg/forest => ../../../backend-go/pkg/forest
+
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/go-redis/redis/v8 v8.11.5 // indirect
 	github.com/gorilla/websocket v1.5.1 // indirect
+	github.com/loadstar0723/monstas7-backend/pkg/kline v0.0.0 // indirect
 	golang.org/x/net v0.17.0 // indirect
 )
@@ -2,14 +2,17 @@ package main
 
 import (
     "encoding/json"
+    "errors"
     "fmt"
     "log"
     "math"
-    "math/rand"
     "net/http"
+    "sort"
+    "sync"
     "time"
     
     "github.com/gorilla/mux"
+    "github.com/loadstar0723/monstas7-backend/pkg/forest"
     "ai-models/common"
 )
 
@@ -20,39 +23,38 @@ type RandomForestService struct {
     forest        *RandomForest
 }
 
-// RandomForest represents the ensemble model
+// RandomForest holds the trained forests: a Gini classifier of the next
+// candle direction and one variance regressor per forecast horizon
 type RandomForest struct {
-    trees          []*DecisionTree
-    numTrees       int
-    maxDepth       int
+    mu              sync.RWMutex
+    direction       *forest.Forest
+    horizons        map[string]*forest.Forest
+    numTrees        int
+    maxDepth        int
     minSamplesSplit int
-    features       []string
-    importances    map[string]float64
-    oobScore       float64
-    lastUpdate     time.Time
-}
+    features        []string
+    importances     map[string]float64
+    oobScore        float64
+    trainedSamples  int
+    lastUpdate      time.Time
+}
+
+// Direction classes of the next candle return
+const (
+    classDown = iota
+    classNeutral
+    classUp
+)
 
-// DecisionTree represents a single tree in the forest
-type DecisionTree struct {
-    TreeID         int                `json:"tree_id"`
-    Root           *TreeNode          `json:"root"`
-    Depth          int                `json:"depth"`
-    NumLeaves      int                `json:"num_leaves"`
-    FeatureImportance map[string]float64 `json:"feature_importance"`
-}
+var directionLabels = []string{"DOWN", "NEUTRAL", "UP"}
 
-// TreeNode represents a node in the decision tree
-type TreeNode struct {
-    NodeID        int        `json:"node_id"`
-    Feature       string     `json:"feature"`
-    Threshold     float64    `json:"threshold"`
-    Value         float64    `json:"value"`
-    IsLeaf        bool       `json:"is_leaf"`
-    Left          *TreeNode  `json:"left,omitempty"`
-    Right         *TreeNode  `json:"right,omitempty"`
-    NumSamples    int        `json:"num_samples"`
-    Impurity      float64    `json:"impurity"`
-}
+// neutralBand is the next candle return below which a move counts as NEUTRAL
+const neutralBand = 0.002
+
+// forecastHorizons maps the prediction fields to candles ahead on 1h data
+var forecastHorizons = map[string]int{"1h": 1, "4h": 4, "1d": 24, "1w": 168}
+
+var errNotTrained = errors.New("random forest is not trained yet")
 
 // RandomForestVisualization for frontend display
 type RandomForestVisualization struct {
@@ -125,116 +127,97 @@ func NewRandomForestService() *RandomForestService {
 }
 
 func NewRandomForest(numTrees, maxDepth, minSamplesSplit int) *RandomForest {
-    features := []string{
-        "price_change_1h", "price_change_24h", "volume_change",
-        "rsi", "macd", "bb_position", "ma_cross", "volume_profile",
-        "market_cap_rank", "sentiment_score", "volatility",
-        "correlation_btc", "whale_activity", "exchange_flow"
-    }
-    
-    rf := &RandomForest{
-        trees:           make([]*DecisionTree, 0, numTrees),
+    return &RandomForest{
         numTrees:        numTrees,
         maxDepth:        maxDepth,
         minSamplesSplit: minSamplesSplit,
-        features:        features,
+        features:        featureNames,
         importances:     make(map[string]float64),
-        lastUpdate:      time.Now(),
-    }
-    
-    // Build forest
-    for i := 0; i < numTrees; i++ {
-        tree := rf.buildTree(i)
-        rf.trees = append(rf.trees, tree)
     }
-    
-    // Calculate feature importances
-    rf.calculateFeatureImportances()
-    
-    return rf
 }
 
-func (rf *RandomForest) buildTree(treeID int) *DecisionTree {
-    tree := &DecisionTree{
-        TreeID:            treeID,
-        FeatureImportance: make(map[string]float64),
+// Train fits the direction classifier and the horizon regressors on the
+// feature rows of every symbol's 1h candles, then swaps them in
+func (rf *RandomForest) Train(history map[string][]common.MarketData) error {
+    var dirX [][]float64
+    var dirY []float64
+    horizonX := make(map[string][][]float64)
+    horizonY := make(map[string][]float64)
+    
+    for _, data := range history {
+        rows := buildFeatures(data)
+        for i := featureWarmup; i < len(data)-1; i++ {
+            change := data[i+1].Close/data[i].Close - 1
+            class := classNeutral
+            if change > neutralBand {
+                class = classUp
+            } else if change < -neutralBand {
+                class = classDown
+            }
+            dirX = append(dirX, rows[i])
+            dirY = append(dirY, float64(class))
+            
+            for name, ahead := range forecastHorizons {
+                if i+ahead < len(data) {
+                    horizonX[name] = append(horizonX[name], rows[i])
+                    horizonY[name] = append(horizonY[name], data[i+ahead].Close/data[i].Close-1)
+                }
+            }
+        }
+    }
+    if len(dirX) < 100 {
+        return fmt.Errorf("need at least 100 training samples, got %d", len(dirX))
     }
     
-    // Build tree structure (simplified)
-    tree.Root = rf.buildNode(0, 0, rf.maxDepth)
-    tree.Depth = rf.calculateTreeDepth(tree.Root)
-    tree.NumLeaves = rf.countLeaves(tree.Root)
+    cfg := forest.Config{
+        Trees:           rf.numTrees,
+        MaxDepth:        rf.maxDepth,
+        MinSamplesSplit: rf.minSamplesSplit,
+        MinSamplesLeaf:  5,
+        Bootstrap:       true,
+        Seed:            time.Now().UnixNano(),
+    }
     
-    // Calculate feature importance for this tree
-    for _, feature := range rf.features {
-        tree.FeatureImportance[feature] = rand.Float64()
+    dirCfg := cfg
+    dirCfg.Criterion, dirCfg.Classes = forest.Gini, len(directionLabels)
+    direction, err := forest.Train(dirX, dirY, dirCfg)
+    if err != nil {
+        return fmt.Errorf("direction forest: %w", err)
     }
     
-    return tree
-}
-
-func (rf *RandomForest) buildNode(nodeID, depth, maxDepth int) *TreeNode {
-    if depth >= maxDepth || rand.Float64() < 0.3 {
-        // Leaf node
-        return &TreeNode{
-            NodeID:     nodeID,
-            IsLeaf:     true,
-            Value:      rand.Float64()*2 - 1, // Random prediction
-            NumSamples: rand.Intn(100) + 10,
-            Impurity:   rand.Float64() * 0.3,
+    horizons := make(map[string]*forest.Forest)
+    for name := range forecastHorizons {
+        if len(horizonX[name]) < 100 {
+            log.Printf("Skipping %s forest: only %d samples", name, len(horizonX[name]))
+            continue
+        }
+        regCfg := cfg
+        regCfg.Criterion = forest.Variance
+        if horizons[name], err = forest.Train(horizonX[name], horizonY[name], regCfg); err != nil {
+            return fmt.Errorf("%s forest: %w", name, err)
         }
     }
     
-    // Internal node
-    feature := rf.features[rand.Intn(len(rf.features))]
-    threshold := rand.Float64()*100 - 50
-    
-    node := &TreeNode{
-        NodeID:     nodeID,
-        Feature:    feature,
-        Threshold:  threshold,
-        IsLeaf:     false,
-        NumSamples: rand.Intn(500) + 100,
-        Impurity:   rand.Float64() * 0.5,
+    importances := make(map[string]float64)
+    for i, feature := range rf.features {
+        importances[feature] = direction.PermutationImportance[i]
     }
     
-    // Build children
-    node.Left = rf.buildNode(nodeID*2+1, depth+1, maxDepth)
-    node.Right = rf.buildNode(nodeID*2+2, depth+1, maxDepth)
-    
-    return node
-}
-
-func (rf *RandomForest) calculateTreeDepth(node *TreeNode) int {
-    if node == nil || node.IsLeaf {
-        return 0
-    }
-    leftDepth := rf.calculateTreeDepth(node.Left)
-    rightDepth := rf.calculateTreeDepth(node.Right)
-    return 1 + int(math.Max(float64(leftDepth), float64(rightDepth)))
-}
-
-func (rf *RandomForest) countLeaves(node *TreeNode) int {
-    if node == nil {
-        return 0
-    }
-    if node.IsLeaf {
-        return 1
-    }
-    return rf.countLeaves(node.Left) + rf.countLeaves(node.Right)
+    rf.mu.Lock()
+    defer rf.mu.Unlock()
+    rf.direction, rf.horizons = direction, horizons
+    rf.importances = importances
+    rf.oobScore = direction.OOBScore
+    rf.trainedSamples = len(dirX)
+    rf.lastUpdate = time.Now()
+    return nil
 }
 
-func (rf *RandomForest) calculateFeatureImportances() {
-    for _, feature := range rf.features {
-        totalImportance := 0.0
-        for _, tree := range rf.trees {
-            if imp, ok := tree.FeatureImportance[feature]; ok {
-                totalImportance += imp
-            }
-        }
-        rf.importances[feature] = totalImportance / float64(len(rf.trees))
-    }
-}
+// Retry delays of a failed training run, doubling up to the maximum
+const (
+    trainRetryMin = time.Minute
+    trainRetryMax = 30 * time.Minute
+)
 
 // Initialize loads historical data and trains the forest
 func (s *RandomForestService) Initialize() error {
@@ -243,34 +226,54 @@ func (s *RandomForestService) Initialize() error {
     // Start WebSocket manager
     s.wsManager.Start()
     
-    // Load historical data for training
+    // The service keeps running untrained while training is retried in the background
+    if err := s.train(); err != nil {
+        log.Printf("Random Forest training failed: %v (retrying in %s)", err, trainRetryMin)
+        go s.retryTraining()
+    }
+    
+    // Start prediction loop
+    go s.predictionLoop()
+    
+    return nil
+}
+
+// train loads the historical data of every supported coin and trains on all
+// symbols together
+func (s *RandomForestService) train() error {
+    history := make(map[string][]common.MarketData)
     for _, coin := range common.SupportedCoins {
         data, err := s.dataCollector.GetHistoricalData(coin.Symbol, "1h", 720) // 30 days
         if err != nil {
             log.Printf("Error loading data for %s: %v", coin.Symbol, err)
             continue
         }
-        
-        // Train forest on this data (simplified)
-        s.trainForest(coin.Symbol, data)
+        history[coin.Symbol] = data
     }
     
-    // Calculate OOB score
-    s.forest.oobScore = 0.85 + rand.Float64()*0.1
-    
-    // Start prediction loop
-    go s.predictionLoop()
-    
+    start := time.Now()
+    if err := s.forest.Train(history); err != nil {
+        return err
+    }
+    log.Printf("Random Forest trained on %d samples from %d symbols in %s (OOB accuracy %.1f%%)",
+        s.forest.trainedSamples, len(history), time.Since(start).Round(time.Millisecond), s.forest.oobScore*100)
     return nil
 }
 
-func (s *RandomForestService) trainForest(symbol string, data []common.MarketData) {
-    // Simplified training process
-    log.Printf("Training Random Forest on %s data (%d samples)", symbol, len(data))
-    
-    // Update feature importances based on data
-    for feature, _ := range s.forest.importances {
-        s.forest.importances[feature] = rand.Float64()
+// retryTraining retries training with exponential backoff until it succeeds
+func (s *RandomForestService) retryTraining() {
+    delay := trainRetryMin
+    for {
+        time.Sleep(delay)
+        err := s.train()
+        if err == nil {
+            return
+        }
+        delay *= 2
+        if delay > trainRetryMax {
+            delay = trainRetryMax
+        }
+        log.Printf("Random Forest training failed: %v (retrying in %s)", err, delay)
     }
 }
 
@@ -301,89 +304,69 @@ func (s *RandomForestService) predictionLoop() {
     }
 }
 
+// currentFeatures returns the feature vector of the latest 1h candle
+func (s *RandomForestService) currentFeatures(symbol string) ([]float64, error) {
+    data, err := s.dataCollector.GetHistoricalData(symbol, "1h", featureWarmup+50)
+    if err != nil {
+        return nil, err
+    }
+    if len(data) <= featureWarmup {
+        return nil, fmt.Errorf("%s: need more than %d candles, got %d", symbol, featureWarmup, len(data))
+    }
+    rows := buildFeatures(data)
+    return rows[len(rows)-1], nil
+}
+
 // generatePrediction creates Random Forest predictions
 func (s *RandomForestService) generatePrediction(symbol string) (*common.Prediction, error) {
+    rf := s.forest
+    rf.mu.RLock()
+    defer rf.mu.RUnlock()
+    if rf.direction == nil {
+        return nil, errNotTrained
+    }
+    
     // Get current price
     currentPrice, err := s.dataCollector.GetCurrentPrice(symbol)
     if err != nil {
         return nil, err
     }
-    
-    // Get predictions from all trees
-    predictions := make([]float64, 0, s.forest.numTrees)
-    for _, tree := range s.forest.trees {
-        pred := s.predictWithTree(tree, currentPrice)
-        predictions = append(predictions, pred)
-    }
-    
-    // Aggregate predictions (mean)
-    meanPred := 0.0
-    for _, pred := range predictions {
-        meanPred += pred
+    features, err := s.currentFeatures(symbol)
+    if err != nil {
+        return nil, err
     }
-    meanPred /= float64(len(predictions))
     
-    // Calculate prediction intervals
-    variance := 0.0
-    for _, pred := range predictions {
-        variance += math.Pow(pred-meanPred, 2)
+    // Horizon regressors forecast the return; a missing horizon keeps the current price
+    forecast := func(horizon string) float64 {
+        f, ok := rf.horizons[horizon]
+        if !ok {
+            return currentPrice
+        }
+        return currentPrice * (1 + f.Predict(features))
     }
-    variance /= float64(len(predictions))
-    stdDev := math.Sqrt(variance)
-    
-    // Generate time-based predictions
-    pred1H := currentPrice * (1 + meanPred*0.001 + rand.Float64()*0.0005 - 0.00025)
-    pred4H := currentPrice * (1 + meanPred*0.004 + rand.Float64()*0.002 - 0.001)
-    pred1D := currentPrice * (1 + meanPred*0.02 + rand.Float64()*0.005 - 0.0025)
-    pred1W := currentPrice * (1 + meanPred*0.05 + rand.Float64()*0.01 - 0.005)
     
-    // Calculate confidence based on tree agreement
-    confidence := (1.0 - stdDev/math.Abs(meanPred)) * 100
-    confidence = math.Min(math.Max(confidence, 60), 95)
-    
-    // Determine direction
-    direction := "NEUTRAL"
-    if meanPred > 0.5 {
-        direction = "UP"
-    } else if meanPred < -0.5 {
-        direction = "DOWN"
+    // Averaged class probabilities of the direction trees
+    probs := rf.direction.PredictProba(features)
+    class := 0
+    for k, p := range probs {
+        if p > probs[class] {
+            class = k
+        }
     }
     
     return &common.Prediction{
         Symbol:      symbol,
         Current:     currentPrice,
-        Predicted1H: pred1H,
-        Predicted4H: pred4H,
-        Predicted1D: pred1D,
-        Predicted1W: pred1W,
-        Confidence:  confidence,
-        Direction:   direction,
+        Predicted1H: forecast("1h"),
+        Predicted4H: forecast("4h"),
+        Predicted1D: forecast("1d"),
+        Predicted1W: forecast("1w"),
+        Confidence:  probs[class] * 100,
+        Direction:   directionLabels[class],
         Timestamp:   time.Now(),
     }, nil
 }
 
-func (s *RandomForestService) predictWithTree(tree *DecisionTree, price float64) float64 {
-    node := tree.Root
-    
-    // Traverse tree to leaf
-    for !node.IsLeaf {
-        // Simulate feature extraction
-        featureValue := price * (1 + rand.Float64()*0.1 - 0.05)
-        
-        if featureValue < node.Threshold {
-            node = node.Left
-        } else {
-            node = node.Right
-        }
-        
-        if node == nil {
-            return 0
-        }
-    }
-    
-    return node.Value
-}
-
 // generateTradingSignal creates trading signals based on predictions
 func (s *RandomForestService) generateTradingSignal(symbol string, pred *common.Prediction) *common.TradingSignal {
     action := "HOLD"
@@ -433,6 +416,9 @@ func (s *RandomForestService) generateTradingSignal(symbol string, pred *common.
 }
 
 func (s *RandomForestService) getTopFeature() string {
+    s.forest.mu.RLock()
+    defer s.forest.mu.RUnlock()
+    
     topFeature := ""
     maxImportance := 0.0
     
@@ -446,18 +432,49 @@ func (s *RandomForestService) getTopFeature() string {
     return topFeature
 }
 
-// calculateMetrics calculates model performance metrics
+// calculateMetrics reports the out-of-bag performance: direction accuracy
+// and macro precision/recall/F1 of the classifier, MAE/RMSE of the 1h
+// return regressor
 func (s *RandomForestService) calculateMetrics() *common.ModelMetrics {
-    return &common.ModelMetrics{
-        Accuracy:    85.5 + rand.Float64()*5,
-        Precision:   83.2 + rand.Float64()*5,
-        Recall:      87.1 + rand.Float64()*5,
-        F1Score:     85.0 + rand.Float64()*5,
-        MAE:         0.012 + rand.Float64()*0.005,
-        RMSE:        0.018 + rand.Float64()*0.005,
-        SharpeRatio: 1.75 + rand.Float64()*0.3,
-        LastUpdated: time.Now(),
+    rf := s.forest
+    rf.mu.RLock()
+    defer rf.mu.RUnlock()
+    
+    metrics := &common.ModelMetrics{LastUpdated: rf.lastUpdate}
+    if rf.direction == nil {
+        return metrics
+    }
+    
+    confusion := rf.direction.OOBConfusion
+    var precision, recall float64
+    for k := range confusion {
+        var predicted, actual int
+        for j := range confusion {
+            predicted += confusion[j][k]
+            actual += confusion[k][j]
+        }
+        if predicted > 0 {
+            precision += float64(confusion[k][k]) / float64(predicted)
+        }
+        if actual > 0 {
+            recall += float64(confusion[k][k]) / float64(actual)
+        }
     }
+    precision /= float64(len(confusion))
+    recall /= float64(len(confusion))
+    
+    metrics.Accuracy = rf.direction.OOBScore * 100
+    metrics.Precision = precision * 100
+    metrics.Recall = recall * 100
+    if precision+recall > 0 {
+        metrics.F1Score = 2 * precision * recall / (precision + recall) * 100
+    }
+    if hourly, ok := rf.horizons["1h"]; ok {
+        metrics.MAE = hourly.OOBAbsError
+        metrics.RMSE = math.Sqrt(hourly.OOBError)
+    }
+    // SharpeRatio needs a backtest of the signals and is left at zero
+    return metrics
 }
 
 // API Handlers
@@ -466,6 +483,10 @@ func (s *RandomForestService) handlePrediction(w http.ResponseWriter, r *http.Re
     symbol := vars["symbol"]
     
     prediction, err := s.generatePrediction(symbol)
+    if errors.Is(err, errNotTrained) {
+        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+        return
+    }
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
@@ -494,69 +515,84 @@ func (s *RandomForestService) generateVisualization(symbol string) *RandomForest
         TreePerformance:   make([]TreePerformanceMetric, 0),
     }
     
+    rf := s.forest
+    rf.mu.RLock()
+    defer rf.mu.RUnlock()
+    if rf.direction == nil {
+        return viz
+    }
+    trees := rf.direction.Trees
+    
     // Select a few trees to visualize
-    for i := 0; i < 5 && i < len(s.forest.trees); i++ {
-        tree := s.forest.trees[i]
-        treeViz := s.visualizeTree(tree)
+    for i := 0; i < 5 && i < len(trees); i++ {
+        treeViz := s.visualizeTree(i, trees[i])
         viz.TreeStructures = append(viz.TreeStructures, treeViz)
         
-        // Add tree performance
+        // Add tree performance on its out-of-bag samples
         viz.TreePerformance = append(viz.TreePerformance, TreePerformanceMetric{
-            TreeID:    tree.TreeID,
-            Accuracy:  0.8 + rand.Float64()*0.15,
-            Depth:     tree.Depth,
-            NumLeaves: tree.NumLeaves,
+            TreeID:    i,
+            Accuracy:  1 - trees[i].OOBError,
+            Depth:     treeViz.MaxDepth,
+            NumLeaves: treeViz.NumLeaves,
         })
     }
     
-    // Feature importance
-    rank := 1
-    for feature, importance := range s.forest.importances {
+    // Feature importance, ranked by permutation importance
+    for feature, importance := range rf.importances {
         viz.FeatureImportance = append(viz.FeatureImportance, FeatureScore{
             Feature:    feature,
             Importance: importance,
-            Rank:       rank,
-        })
-        rank++
-    }
-    
-    // Simulate prediction path
-    for i := 0; i < 5; i++ {
-        viz.PredictionPath = append(viz.PredictionPath, PathNode{
-            TreeID:   i,
-            NodeID:   rand.Intn(10),
-            Feature:  s.forest.features[rand.Intn(len(s.forest.features))],
-            Decision: []string{"<=", ">"}[rand.Intn(2)],
-            Value:    rand.Float64()*100 - 50,
         })
     }
-    
-    // Tree votes
-    for i := 0; i < 20; i++ {
-        viz.TreeVotes = append(viz.TreeVotes, TreeVote{
-            TreeID:     i,
-            Prediction: []string{"UP", "DOWN", "NEUTRAL"}[rand.Intn(3)],
-            Confidence: 0.6 + rand.Float64()*0.4,
-        })
+    sort.Slice(viz.FeatureImportance, func(i, j int) bool {
+        return viz.FeatureImportance[i].Importance > viz.FeatureImportance[j].Importance
+    })
+    for i := range viz.FeatureImportance {
+        viz.FeatureImportance[i].Rank = i + 1
+    }
+    
+    // Decision path and votes of the trees for the symbol's latest candle
+    if features, err := s.currentFeatures(symbol); err == nil {
+        for i := 0; i < 5 && i < len(trees); i++ {
+            path := trees[i].Path(features)
+            for depth, node := range path[:len(path)-1] {
+                decision := ">"
+                if features[node.Feature] <= node.Threshold {
+                    decision = "<="
+                }
+                viz.PredictionPath = append(viz.PredictionPath, PathNode{
+                    TreeID:   i,
+                    NodeID:   depth,
+                    Feature:  rf.features[node.Feature],
+                    Decision: decision,
+                    Value:    node.Threshold,
+                })
+            }
+        }
+        
+        for i := 0; i < 20 && i < len(trees); i++ {
+            leaf := trees[i].Leaf(features)
+            viz.TreeVotes = append(viz.TreeVotes, TreeVote{
+                TreeID:     i,
+                Prediction: directionLabels[int(leaf.Value)],
+                Confidence: leaf.Probs[int(leaf.Value)],
+            })
+        }
     }
     
-    // Confusion matrix (3x3 for UP/DOWN/NEUTRAL)
-    viz.ConfusionMatrix = [][]int{
-        {85, 10, 5},
-        {8, 82, 10},
-        {5, 8, 87},
-    }
+    // Out-of-bag confusion matrix, rows actual and columns predicted DOWN/NEUTRAL/UP
+    viz.ConfusionMatrix = rf.direction.OOBConfusion
     
     return viz
 }
 
-func (s *RandomForestService) visualizeTree(tree *DecisionTree) *TreeVisualization {
+func (s *RandomForestService) visualizeTree(treeID int, tree *forest.Tree) *TreeVisualization {
     viz := &TreeVisualization{
-        TreeID:    tree.TreeID,
+        TreeID:    treeID,
         Nodes:     make([]NodeVisualization, 0),
         Links:     make([]LinkVisualization, 0),
-        MaxDepth:  tree.Depth,
-        NumLeaves: tree.NumLeaves,
+        MaxDepth:  tree.Depth(),
+        NumLeaves: tree.Leaves(),
     }
     
     // Convert tree to visualization format
@@ -567,7 +603,7 @@ func (s *RandomForestService) visualizeTree(tree *DecisionTree) *TreeVisualizati
 }
 
 func (s *RandomForestService) addNodeToVisualization(
-    node *TreeNode, 
+    node *forest.Node, 
     viz *TreeVisualization, 
     depth int, 
     xPos float64, 
@@ -586,24 +622,24 @@ func (s *RandomForestService) addNodeToVisualization(
         ID:     currentID,
         X:      xPos,
         Y:      float64(depth * 100),
-        Label:  node.Feature,
         Value:  node.Threshold,
-        IsLeaf: node.IsLeaf,
-        Size:   10 + node.NumSamples/10,
+        IsLeaf: node.Leaf,
+        Size:   10 + node.Samples/10,
     }
     
-    if node.IsLeaf {
+    if node.Leaf {
         nodeViz.Color = "#10b981" // Green for leaf
-        nodeViz.Label = fmt.Sprintf("%.2f", node.Value)
+        nodeViz.Label = fmt.Sprintf("%s %.0f%%", directionLabels[int(node.Value)], node.Probs[int(node.Value)]*100)
+        nodeViz.Value = node.Value
     } else {
         nodeViz.Color = "#3b82f6" // Blue for internal
-        nodeViz.Label = fmt.Sprintf("%s <= %.1f", node.Feature, node.Threshold)
+        nodeViz.Label = fmt.Sprintf("%s <= %.4f", s.forest.features[node.Feature], node.Threshold)
     }
     
     viz.Nodes = append(viz.Nodes, nodeViz)
     
     // Add children
-    if !node.IsLeaf {
+    if !node.Leaf {
         leftID := s.addNodeToVisualization(
             node.Left, viz, depth+1, 
             xPos-xRange/2, xRange/2, nodeIDCounter,
@@ -658,6 +694,7 @@ func (s *RandomForestService) handleAllPredictions(w http.ResponseWriter, r *htt
 }
 
 func (s *RandomForestService) handleForestInfo(w http.ResponseWriter, r *http.Request) {
+    s.forest.mu.RLock()
     info := map[string]interface{}{
         "num_trees":         s.forest.numTrees,
         "max_depth":         s.forest.maxDepth,
@@ -665,7 +702,11 @@ func (s *RandomForestService) handleForestInfo(w http.ResponseWriter, r *http.Re
         "oob_score":         s.forest.oobScore,
         "features":          s.forest.features,
         "feature_importances": s.forest.importances,
+        "trained":           s.forest.direction != nil,
+        "training_samples":  s.forest.trainedSamples,
+        "last_trained":      s.forest.lastUpdate,
     }
+    s.forest.mu.RUnlock()
     
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(info)
@@ -681,6 +722,9 @@ func main() {
     
     // Setup routes
     r := mux.NewRouter()
+    r.Use(common.SymbolValidator(func(r *http.Request) string {
+        return mux.Vars(r)["symbol"]
+    }))
     
     // WebSocket endpoint
     r.HandleFunc("/ws", service.wsManager.HandleWebSocket)
@@ -0,0 +1,205 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    defaultRangeLimit = 500
+    maxRangeLimit     = 5000
+)
+
+// LatestPrice 심볼별 최신 가격
+type LatestPrice struct {
+    Symbol string  `json:"symbol"`
+    Price  float64 `json:"price"`
+    Time   int64   `json:"time"`
+    Stale  bool    `json:"stale"` // 마지막 틱이 latestTTL보다 오래됨
+}
+
+// Routes HTTP API 라우팅
+//   GET /api/prices                      전체 심볼 최신 가격 (기존 형식: symbol -> price 문자열)
+//   GET /api/prices/latest[?symbol=]     최신 가격 상세
+//   GET /api/ticks?symbol=&from=&to=&limit=
+//   GET /api/candles?symbol=&interval=&from=&to=&limit=
+//   GET /api/symbols
+//   GET /health
+func (ps *PriceService) Routes() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/api/prices", ps.handlePrices)
+    mux.HandleFunc("/api/prices/latest", ps.handleLatest)
+    mux.HandleFunc("/api/ticks", ps.handleTicks)
+    mux.HandleFunc("/api/candles", ps.handleCandles)
+    mux.HandleFunc("/api/symbols", ps.handleSymbols)
+    mux.HandleFunc("/health", ps.handleHealth)
+    return mux
+}
+
+func (ps *PriceService) handlePrices(w http.ResponseWriter, r *http.Request) {
+    prices := make(map[string]string)
+    for _, symbol := range ps.symbols {
+        price, _ := ps.redis.Get(ctx, priceKey(symbol)).Result()
+        prices[symbol] = price
+    }
+    writeJSON(w, http.StatusOK, prices)
+}
+
+func (ps *PriceService) handleLatest(w http.ResponseWriter, r *http.Request) {
+    symbols := ps.symbols
+    if raw := r.URL.Query().Get("symbol"); raw != "" {
+        symbol, ok := ps.symbolParam(w, raw)
+        if !ok {
+            return
+        }
+        symbols = []string{symbol}
+    }
+
+    now := time.Now()
+    latest := make([]LatestPrice, 0, len(symbols))
+    for _, symbol := range symbols {
+        tick, err := ps.store.LatestTick(symbol)
+        if err != nil {
+            writeError(w, http.StatusServiceUnavailable, err.Error())
+            return
+        }
+        if tick == nil {
+            continue
+        }
+        latest = append(latest, LatestPrice{
+            Symbol: symbol,
+            Price:  tick.Price,
+            Time:   tick.Time,
+            Stale:  now.Sub(time.UnixMilli(tick.Time)) > latestTTL,
+        })
+    }
+    writeJSON(w, http.StatusOK, latest)
+}
+
+func (ps *PriceService) handleTicks(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    symbol, ok := ps.symbolParam(w, query.Get("symbol"))
+    if !ok {
+        return
+    }
+    from, to, limit, ok := rangeParams(w, r)
+    if !ok {
+        return
+    }
+
+    ticks, err := ps.store.Ticks(symbol, from, to, limit)
+    if err != nil {
+        writeError(w, http.StatusServiceUnavailable, err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "symbol": symbol,
+        "count":  len(ticks),
+        "ticks":  ticks,
+    })
+}
+
+func (ps *PriceService) handleCandles(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    symbol, ok := ps.symbolParam(w, query.Get("symbol"))
+    if !ok {
+        return
+    }
+    interval := strings.ToLower(query.Get("interval"))
+    if interval == "" {
+        interval = ps.config.Intervals[0]
+    }
+    if !ps.hasInterval(interval) {
+        writeError(w, http.StatusBadRequest, "interval not collected: "+interval)
+        return
+    }
+    from, to, limit, ok := rangeParams(w, r)
+    if !ok {
+        return
+    }
+
+    candles, err := ps.store.Candles(symbol, interval, from, to, limit)
+    if err != nil {
+        writeError(w, http.StatusServiceUnavailable, err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "symbol":   symbol,
+        "interval": interval,
+        "count":    len(candles),
+        "candles":  candles,
+    })
+}
+
+func (ps *PriceService) handleSymbols(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "symbols":         ps.symbols,
+        "intervals":       ps.config.Intervals,
+        "tickRetention":   ps.config.TickRetention.String(),
+        "candleRetention": ps.config.CandleRetention.String(),
+    })
+}
+
+func (ps *PriceService) handleHealth(w http.ResponseWriter, r *http.Request) {
+    if err := ps.redis.Ping(ctx).Err(); err != nil {
+        writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unhealthy", "redis": err.Error()})
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+}
+
+// symbolParam 심볼 파라미터를 정규화하고 수집 대상인지 확인
+func (ps *PriceService) symbolParam(w http.ResponseWriter, raw string) (string, bool) {
+    symbol := strings.ToUpper(strings.TrimSpace(raw))
+    if symbol == "" {
+        writeError(w, http.StatusBadRequest, "symbol is required")
+        return "", false
+    }
+    if !ps.hasSymbol(symbol) {
+        writeError(w, http.StatusNotFound, "symbol not collected: "+symbol)
+        return "", false
+    }
+    return symbol, true
+}
+
+// rangeParams from/to(ms)와 limit 파싱
+func rangeParams(w http.ResponseWriter, r *http.Request) (int64, int64, int64, bool) {
+    query := r.URL.Query()
+    var from, to int64
+    limit := int64(defaultRangeLimit)
+
+    for name, dest := range map[string]*int64{"from": &from, "to": &to, "limit": &limit} {
+        raw := query.Get(name)
+        if raw == "" {
+            continue
+        }
+        v, err := strconv.ParseInt(raw, 10, 64)
+        if err != nil || v < 0 {
+            writeError(w, http.StatusBadRequest, "invalid "+name)
+            return 0, 0, 0, false
+        }
+        *dest = v
+    }
+
+    if to > 0 && from > to {
+        writeError(w, http.StatusBadRequest, "from must be <= to")
+        return 0, 0, 0, false
+    }
+    if limit == 0 || limit > maxRangeLimit {
+        limit = maxRangeLimit
+    }
+    return from, to, limit, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+    writeJSON(w, status, map[string]string{"error": message})
+}
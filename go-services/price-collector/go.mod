@@ -3,5 +3,15 @@ module github.com/loadstar0723/monstas7/go-services/price-collector
 go 1.21
 
 require (
-    github.com/go-redis/redis/v8 v8.11.5
-)
\ No newline at end of file
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/gorilla/websocket v1.5.1
+	github.com/loadstar0723/monstas7-backend/pkg/kline v0.0.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/net v0.17.0 // indirect
+)
+
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ../../backend-go/pkg/kline
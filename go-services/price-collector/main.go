@@ -3,109 +3,219 @@ package main
 import (
     "context"
     "encoding/json"
-    "fmt"
     "log"
     "net/http"
+    "os"
+    "os/signal"
+    "strings"
+    "syscall"
     "time"
+
     "github.com/go-redis/redis/v8"
 )
 
 var ctx = context.Background()
 
-type BinancePrice struct {
-    Symbol string `json:"symbol"`
-    Price  string `json:"price"`
+var defaultSymbols = []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "XRPUSDT"}
+
+// Config 수집기 설정 (모두 환경변수로 변경 가능)
+type Config struct {
+    RedisURL        string        // redis://[:password@]host:port/db 또는 host:port
+    ListenAddr      string
+    Intervals       []string      // 저장할 캔들 인터벌
+    TickRetention   time.Duration // 틱 스트림 보관 기간
+    CandleRetention time.Duration // 캔들 보관 기간
+}
+
+func loadConfig() Config {
+    cfg := Config{
+        RedisURL:        envString("REDIS_URL", "redis://localhost:6379"),
+        ListenAddr:      envString("LISTEN_ADDR", ":8081"),
+        Intervals:       splitList(envString("KLINE_INTERVALS", "1m")),
+        TickRetention:   envDuration("TICK_RETENTION", 24*time.Hour),
+        CandleRetention: envDuration("CANDLE_RETENTION", 30*24*time.Hour),
+    }
+    if len(cfg.Intervals) == 0 {
+        cfg.Intervals = []string{"1m"}
+    }
+    for i, interval := range cfg.Intervals {
+        cfg.Intervals[i] = strings.ToLower(interval)
+    }
+    return cfg
 }
 
 type PriceService struct {
-    redis  *redis.Client
-    client *http.Client
+    redis   *redis.Client
+    client  *http.Client
+    store   *Store
+    config  Config
+    symbols []string
 }
 
-func NewPriceService() *PriceService {
-    rdb := redis.NewClient(&redis.Options{
-        Addr: "localhost:6379",
-        DB:   0,
-    })
+func NewPriceService(cfg Config) *PriceService {
+    rdb := redis.NewClient(redisOptions(cfg.RedisURL))
 
-    return &PriceService{
+    ps := &PriceService{
         redis: rdb,
         client: &http.Client{
             Timeout: 10 * time.Second,
         },
+        store:  NewStore(rdb, cfg.TickRetention, cfg.CandleRetention),
+        config: cfg,
+    }
+    ps.symbols = ps.loadSymbols()
+
+    return ps
+}
+
+// redisOptions REDIS_URL을 redis.ParseURL로 해석 (스킴이 없는 host:port도 허용)
+func redisOptions(raw string) *redis.Options {
+    if strings.Contains(raw, "://") {
+        opts, err := redis.ParseURL(raw)
+        if err == nil {
+            return opts
+        }
+        log.Fatalf("Invalid REDIS_URL %q: %v", raw, err)
     }
+    return &redis.Options{Addr: raw}
 }
 
-func (ps *PriceService) FetchPrices() error {
-    symbols := []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "XRPUSDT"}
-    
+// loadSymbols WATCHLIST 환경변수의 심볼을 exchangeInfo로 검증 (조회 실패 시 그대로 사용)
+func (ps *PriceService) loadSymbols() []string {
+    symbols := defaultSymbols
+    if raw := os.Getenv("WATCHLIST"); raw != "" {
+        symbols = nil
+        for _, symbol := range splitList(raw) {
+            symbols = append(symbols, strings.ToUpper(symbol))
+        }
+    }
+
+    resp, err := ps.client.Get("https://api.binance.com/api/v3/exchangeInfo")
+    if err != nil {
+        log.Printf("exchangeInfo unavailable, using unvalidated watchlist: %v", err)
+        return symbols
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != 200 {
+        log.Printf("exchangeInfo API error %d, using unvalidated watchlist", resp.StatusCode)
+        return symbols
+    }
+
+    var info struct {
+        Symbols []struct {
+            Symbol string `json:"symbol"`
+            Status string `json:"status"`
+        } `json:"symbols"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+        log.Printf("exchangeInfo decode failed, using unvalidated watchlist: %v", err)
+        return symbols
+    }
+
+    trading := make(map[string]bool, len(info.Symbols))
+    for _, s := range info.Symbols {
+        trading[s.Symbol] = s.Status == "TRADING"
+    }
+
+    validated := make([]string, 0, len(symbols))
     for _, symbol := range symbols {
-        url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/price?symbol=%s", symbol)
-        
-        resp, err := ps.client.Get(url)
-        if err != nil {
-            log.Printf("Error fetching %s: %v", symbol, err)
+        if !trading[symbol] {
+            log.Printf("Skipping %s: not trading on Binance", symbol)
             continue
         }
-        defer resp.Body.Close()
+        validated = append(validated, symbol)
+    }
+    if len(validated) == 0 {
+        // 응답이 비어 있거나 모두 걸러졌다면 검증 결과 대신 설정값을 사용
+        log.Printf("No watchlist symbol validated, using unvalidated watchlist: %v", symbols)
+        return symbols
+    }
+    return validated
+}
 
-        var price BinancePrice
-        if err := json.NewDecoder(resp.Body).Decode(&price); err != nil {
-            log.Printf("Error decoding %s: %v", symbol, err)
-            continue
+// hasSymbol 설정된 심볼인지 확인
+func (ps *PriceService) hasSymbol(symbol string) bool {
+    for _, s := range ps.symbols {
+        if s == symbol {
+            return true
         }
+    }
+    return false
+}
 
-        // Redis에 저장
-        key := fmt.Sprintf("price:%s", symbol)
-        ps.redis.Set(ctx, key, price.Price, 5*time.Second)
-        
-        // 가격 히스토리 저장
-        historyKey := fmt.Sprintf("history:%s", symbol)
-        ps.redis.LPush(ctx, historyKey, fmt.Sprintf("%s:%d", price.Price, time.Now().Unix()))
-        ps.redis.LTrim(ctx, historyKey, 0, 1000) // 최근 1000개만 유지
-        
-        log.Printf("%s: %s", symbol, price.Price)
-    }
-    
-    return nil
+// hasInterval 저장 중인 캔들 인터벌인지 확인
+func (ps *PriceService) hasInterval(interval string) bool {
+    for _, i := range ps.config.Intervals {
+        if i == interval {
+            return true
+        }
+    }
+    return false
 }
 
-func (ps *PriceService) StartCollector() {
-    ticker := time.NewTicker(1 * time.Second)
-    defer ticker.Stop()
+func envString(key, fallback string) string {
+    if value := os.Getenv(key); value != "" {
+        return value
+    }
+    return fallback
+}
 
-    for {
-        select {
-        case <-ticker.C:
-            go ps.FetchPrices()
+func envDuration(key string, fallback time.Duration) time.Duration {
+    if value := os.Getenv(key); value != "" {
+        if d, err := time.ParseDuration(value); err == nil && d > 0 {
+            return d
         }
+        log.Printf("Invalid %s=%q, using %v", key, value, fallback)
     }
+    return fallback
+}
+
+func splitList(raw string) []string {
+    items := make([]string, 0)
+    for _, part := range strings.Split(raw, ",") {
+        if item := strings.TrimSpace(part); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
 }
 
 func main() {
-    service := NewPriceService()
-    
+    cfg := loadConfig()
+    service := NewPriceService(cfg)
+
+    if err := service.redis.Ping(ctx).Err(); err != nil {
+        log.Printf("Redis not reachable at %s: %v", service.redis.Options().Addr, err)
+    }
+
     // API 서버 시작
+    server := &http.Server{
+        Addr:    cfg.ListenAddr,
+        Handler: service.Routes(),
+    }
     go func() {
-        http.HandleFunc("/api/prices", func(w http.ResponseWriter, r *http.Request) {
-            symbols := []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "XRPUSDT"}
-            prices := make(map[string]string)
-            
-            for _, symbol := range symbols {
-                key := fmt.Sprintf("price:%s", symbol)
-                price, _ := service.redis.Get(ctx, key).Result()
-                prices[symbol] = price
-            }
-            
-            w.Header().Set("Content-Type", "application/json")
-            json.NewEncoder(w).Encode(prices)
-        })
-        
-        log.Println("Price API server starting on :8081")
-        http.ListenAndServe(":8081", nil)
+        log.Printf("Price API server starting on %s", cfg.ListenAddr)
+        if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            log.Fatalf("Price API server failed: %v", err)
+        }
     }()
 
-    // 가격 수집 시작
-    log.Println("Starting price collector...")
-    service.StartCollector()
-}
\ No newline at end of file
+    // 웹소켓 스트림 수집 시작
+    streamCtx, cancel := context.WithCancel(ctx)
+    log.Printf("Starting price collector for %v (intervals %v)", service.symbols, cfg.Intervals)
+    go service.store.Run(streamCtx)
+    go service.StartCollector(streamCtx)
+
+    quit := make(chan os.Signal, 1)
+    signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+    <-quit
+
+    log.Println("Shutting down price collector...")
+    cancel()
+
+    shutdownCtx, done := context.WithTimeout(ctx, 5*time.Second)
+    defer done()
+    server.Shutdown(shutdownCtx)
+    service.store.Flush()
+}
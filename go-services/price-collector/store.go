@@ -0,0 +1,278 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+const (
+    flushInterval = 250 * time.Millisecond
+    trimInterval  = time.Minute
+    latestTTL     = 30 * time.Second // 스트림이 끊기면 price:<SYMBOL> 키가 만료되어 오래된 가격을 쓰지 않게 함
+)
+
+// Redis 키 구성
+//   ticks:<SYMBOL>              Redis Stream, 엔트리 ID = 체결시각(ms)-aggTradeID
+//   candles:<SYMBOL>:<INTERVAL> Sorted Set, score = openTime(ms), member = Candle JSON
+//   price:<SYMBOL>              최신 가격 문자열 (기존 소비자 호환용)
+func tickKey(symbol string) string            { return fmt.Sprintf("ticks:%s", symbol) }
+func candleKey(symbol, interval string) string { return fmt.Sprintf("candles:%s:%s", symbol, interval) }
+func priceKey(symbol string) string            { return fmt.Sprintf("price:%s", symbol) }
+
+// Tick 체결 틱 (aggTrade)
+type Tick struct {
+    Symbol     string  `json:"symbol"`
+    Price      float64 `json:"price"`
+    Quantity   float64 `json:"quantity"`
+    Time       int64   `json:"time"`
+    BuyerMaker bool    `json:"buyerMaker"`
+    TradeID    int64   `json:"tradeId"`
+}
+
+// Candle 마감된 캔들
+type Candle struct {
+    Symbol      string  `json:"symbol"`
+    Interval    string  `json:"interval"`
+    OpenTime    int64   `json:"openTime"`
+    CloseTime   int64   `json:"closeTime"`
+    Open        float64 `json:"open"`
+    High        float64 `json:"high"`
+    Low         float64 `json:"low"`
+    Close       float64 `json:"close"`
+    Volume      float64 `json:"volume"`
+    QuoteVolume float64 `json:"quoteVolume"`
+    Trades      int     `json:"trades"`
+}
+
+// Store 틱/캔들을 모아 파이프라인으로 Redis에 기록하고 보관 기간을 관리
+type Store struct {
+    redis           *redis.Client
+    tickRetention   time.Duration
+    candleRetention time.Duration
+
+    mu      sync.Mutex
+    ticks   []Tick
+    candles []Candle
+    keys    map[string]string // 보관 기간 정리 대상 캔들 키 -> 인터벌
+}
+
+func NewStore(rdb *redis.Client, tickRetention, candleRetention time.Duration) *Store {
+    return &Store{
+        redis:           rdb,
+        tickRetention:   tickRetention,
+        candleRetention: candleRetention,
+        keys:            make(map[string]string),
+    }
+}
+
+// AddTick 틱을 버퍼에 추가 (다음 flush에서 기록)
+func (s *Store) AddTick(tick Tick) {
+    s.mu.Lock()
+    s.ticks = append(s.ticks, tick)
+    s.mu.Unlock()
+}
+
+// AddCandle 마감 캔들을 버퍼에 추가
+func (s *Store) AddCandle(candle Candle) {
+    s.mu.Lock()
+    s.candles = append(s.candles, candle)
+    s.keys[candleKey(candle.Symbol, candle.Interval)] = candle.Interval
+    s.mu.Unlock()
+}
+
+// Run 주기적으로 버퍼를 flush하고 오래된 캔들을 정리
+func (s *Store) Run(ctx context.Context) {
+    flush := time.NewTicker(flushInterval)
+    trim := time.NewTicker(trimInterval)
+    defer flush.Stop()
+    defer trim.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-flush.C:
+            s.Flush()
+        case <-trim.C:
+            s.trimCandles()
+        }
+    }
+}
+
+// Flush 버퍼에 쌓인 틱/캔들을 하나의 파이프라인으로 기록
+func (s *Store) Flush() {
+    s.mu.Lock()
+    ticks, candles := s.ticks, s.candles
+    s.ticks, s.candles = nil, nil
+    s.mu.Unlock()
+
+    if len(ticks) == 0 && len(candles) == 0 {
+        return
+    }
+
+    pipe := s.redis.Pipeline()
+    minID := strconv.FormatInt(time.Now().Add(-s.tickRetention).UnixMilli(), 10)
+    latest := make(map[string]Tick)
+
+    for _, tick := range ticks {
+        // 스트림 ID에 체결시각을 사용해 XRANGE로 시간 구간 조회
+        // seq에는 심볼별로 증가하는 aggTrade ID를 넣어 Redis 7의 <ms>-* 형식 없이도 ID가 단조 증가하고,
+        // 재연결로 다시 받은 틱은 중복 ID로 거부된다 (MINID 트림은 Redis 6.2 이상 필요)
+        pipe.XAdd(ctx, &redis.XAddArgs{
+            Stream: tickKey(tick.Symbol),
+            MinID:  minID,
+            Approx: true,
+            ID:     fmt.Sprintf("%d-%d", tick.Time, tick.TradeID),
+            Values: map[string]interface{}{
+                "p": strconv.FormatFloat(tick.Price, 'f', -1, 64),
+                "q": strconv.FormatFloat(tick.Quantity, 'f', -1, 64),
+                "m": strconv.FormatBool(tick.BuyerMaker),
+                "a": strconv.FormatInt(tick.TradeID, 10),
+            },
+        })
+        latest[tick.Symbol] = tick
+    }
+
+    for symbol, tick := range latest {
+        pipe.Set(ctx, priceKey(symbol), strconv.FormatFloat(tick.Price, 'f', -1, 64), latestTTL)
+    }
+
+    for _, candle := range candles {
+        member, err := json.Marshal(candle)
+        if err != nil {
+            continue
+        }
+        // 같은 openTime 캔들은 교체 (재연결/백필 시 중복 방지)
+        key := candleKey(candle.Symbol, candle.Interval)
+        score := strconv.FormatInt(candle.OpenTime, 10)
+        pipe.ZRemRangeByScore(ctx, key, score, score)
+        pipe.ZAdd(ctx, key, &redis.Z{Score: float64(candle.OpenTime), Member: member})
+    }
+
+    if _, err := pipe.Exec(ctx); err != nil {
+        log.Printf("Redis flush error (%d ticks, %d candles): %v", len(ticks), len(candles), err)
+    }
+}
+
+// trimCandles 보관 기간이 지난 캔들 삭제
+func (s *Store) trimCandles() {
+    s.mu.Lock()
+    keys := make([]string, 0, len(s.keys))
+    for key := range s.keys {
+        keys = append(keys, key)
+    }
+    s.mu.Unlock()
+
+    cutoff := strconv.FormatInt(time.Now().Add(-s.candleRetention).UnixMilli(), 10)
+    pipe := s.redis.Pipeline()
+    for _, key := range keys {
+        pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+    }
+    if _, err := pipe.Exec(ctx); err != nil {
+        log.Printf("Redis candle trim error: %v", err)
+    }
+}
+
+// Ticks from~to(ms) 구간의 틱 조회 (0이면 제한 없음)
+func (s *Store) Ticks(symbol string, from, to, limit int64) ([]Tick, error) {
+    start, end := "-", "+"
+    if from > 0 {
+        start = strconv.FormatInt(from, 10)
+    }
+    if to > 0 {
+        end = strconv.FormatInt(to, 10)
+    }
+
+    messages, err := s.redis.XRangeN(ctx, tickKey(symbol), start, end, limit).Result()
+    if err != nil {
+        return nil, err
+    }
+    return decodeTicks(symbol, messages), nil
+}
+
+// LatestTick 가장 최근 틱 조회 (없으면 nil)
+func (s *Store) LatestTick(symbol string) (*Tick, error) {
+    messages, err := s.redis.XRevRangeN(ctx, tickKey(symbol), "+", "-", 1).Result()
+    if err != nil {
+        return nil, err
+    }
+    ticks := decodeTicks(symbol, messages)
+    if len(ticks) == 0 {
+        return nil, nil
+    }
+    return &ticks[0], nil
+}
+
+// Candles from~to(ms) 구간의 캔들을 시간순으로 조회
+func (s *Store) Candles(symbol, interval string, from, to, limit int64) ([]Candle, error) {
+    opt := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: limit}
+    if from > 0 {
+        opt.Min = strconv.FormatInt(from, 10)
+    }
+    if to > 0 {
+        opt.Max = strconv.FormatInt(to, 10)
+    }
+
+    var members []string
+    var err error
+    if from == 0 && limit > 0 {
+        // 시작 시각이 없으면 최근 limit개를 가져와 시간순으로 뒤집는다
+        members, err = s.redis.ZRevRangeByScore(ctx, candleKey(symbol, interval), opt).Result()
+        for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+            members[i], members[j] = members[j], members[i]
+        }
+    } else {
+        members, err = s.redis.ZRangeByScore(ctx, candleKey(symbol, interval), opt).Result()
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    candles := make([]Candle, 0, len(members))
+    for _, member := range members {
+        var candle Candle
+        if err := json.Unmarshal([]byte(member), &candle); err == nil {
+            candles = append(candles, candle)
+        }
+    }
+    return candles, nil
+}
+
+// LastCandleTime 저장된 마지막 캔들의 openTime (없으면 0)
+func (s *Store) LastCandleTime(symbol, interval string) int64 {
+    result, err := s.redis.ZRevRangeWithScores(ctx, candleKey(symbol, interval), 0, 0).Result()
+    if err != nil || len(result) == 0 {
+        return 0
+    }
+    return int64(result[0].Score)
+}
+
+func decodeTicks(symbol string, messages []redis.XMessage) []Tick {
+    ticks := make([]Tick, 0, len(messages))
+    for _, msg := range messages {
+        var ms int64
+        fmt.Sscanf(msg.ID, "%d-", &ms)
+        tick := Tick{Symbol: symbol, Time: ms}
+        if v, ok := msg.Values["p"].(string); ok {
+            tick.Price, _ = strconv.ParseFloat(v, 64)
+        }
+        if v, ok := msg.Values["q"].(string); ok {
+            tick.Quantity, _ = strconv.ParseFloat(v, 64)
+        }
+        if v, ok := msg.Values["m"].(string); ok {
+            tick.BuyerMaker = v == "true"
+        }
+        if v, ok := msg.Values["a"].(string); ok {
+            tick.TradeID, _ = strconv.ParseInt(v, 10, 64)
+        }
+        ticks = append(ticks, tick)
+    }
+    return ticks
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "github.com/loadstar0723/monstas7-backend/pkg/kline"
+)
+
+const (
+    binanceStreamURL = "wss://stream.binance.com:9443/stream"
+    binanceRestURL   = "https://api.binance.com"
+    maxBackfill      = 1000 // klines 요청 1회 최대 개수
+    maxBackfillPages = 50   // 심볼/인터벌당 백필 요청 상한
+    minReconnect     = time.Second
+    maxReconnect     = 30 * time.Second
+    readTimeout      = time.Minute // Binance는 3분마다 ping을 보내므로 1분 무수신이면 끊긴 것으로 판단
+)
+
+// streamEnvelope combined stream 메시지 래퍼
+type streamEnvelope struct {
+    Stream string          `json:"stream"`
+    Data   json.RawMessage `json:"data"`
+}
+
+type aggTradeEvent struct {
+    Symbol     string `json:"s"`
+    TradeID    int64  `json:"a"`
+    Price      string `json:"p"`
+    Quantity   string `json:"q"`
+    TradeTime  int64  `json:"T"`
+    BuyerMaker bool   `json:"m"`
+}
+
+// StartCollector 웹소켓 스트림을 구독하고 끊기면 지수 백오프로 재연결
+// 연결될 때마다 REST로 누락된 캔들을 채운다
+func (ps *PriceService) StartCollector(streamCtx context.Context) {
+    backoff := minReconnect
+
+    for streamCtx.Err() == nil {
+        ps.backfillCandles()
+
+        started := time.Now()
+        if err := ps.runStream(streamCtx); err != nil && streamCtx.Err() == nil {
+            log.Printf("Binance stream error: %v", err)
+        }
+
+        // 한동안 정상 동작했다면 백오프 초기화
+        if time.Since(started) > maxReconnect {
+            backoff = minReconnect
+        }
+
+        select {
+        case <-streamCtx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxReconnect {
+            backoff = maxReconnect
+        }
+    }
+}
+
+// runStream 연결이 끊기거나 ctx가 취소될 때까지 메시지를 처리
+func (ps *PriceService) runStream(streamCtx context.Context) error {
+    streams := make([]string, 0, len(ps.symbols)*(1+len(ps.config.Intervals)))
+    for _, symbol := range ps.symbols {
+        lower := strings.ToLower(symbol)
+        streams = append(streams, lower+"@aggTrade")
+        for _, interval := range ps.config.Intervals {
+            streams = append(streams, fmt.Sprintf("%s@kline_%s", lower, interval))
+        }
+    }
+    if len(streams) == 0 {
+        return fmt.Errorf("no symbols configured")
+    }
+
+    u := binanceStreamURL + "?streams=" + strings.Join(streams, "/")
+    conn, _, err := websocket.DefaultDialer.DialContext(streamCtx, u, nil)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    log.Printf("Connected to Binance stream (%d streams)", len(streams))
+
+    // ctx 취소 시 ReadMessage를 깨우기 위해 연결을 닫는다
+    done := make(chan struct{})
+    defer close(done)
+    go func() {
+        select {
+        case <-streamCtx.Done():
+            conn.Close()
+        case <-done:
+        }
+    }()
+
+    conn.SetPingHandler(func(data string) error {
+        conn.SetReadDeadline(time.Now().Add(readTimeout))
+        return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(5*time.Second))
+    })
+
+    for {
+        conn.SetReadDeadline(time.Now().Add(readTimeout))
+        _, message, err := conn.ReadMessage()
+        if err != nil {
+            return err
+        }
+        ps.handleMessage(message)
+    }
+}
+
+// handleMessage 스트림 종류에 따라 틱/캔들로 변환해 저장소에 전달
+func (ps *PriceService) handleMessage(message []byte) {
+    var envelope streamEnvelope
+    if err := json.Unmarshal(message, &envelope); err != nil {
+        log.Printf("Stream decode error: %v", err)
+        return
+    }
+
+    switch {
+    case strings.HasSuffix(envelope.Stream, "@aggTrade"):
+        var event aggTradeEvent
+        if err := json.Unmarshal(envelope.Data, &event); err != nil {
+            log.Printf("aggTrade decode error: %v", err)
+            return
+        }
+        ps.store.AddTick(Tick{
+            Symbol:     event.Symbol,
+            Price:      parseFloat(event.Price),
+            Quantity:   parseFloat(event.Quantity),
+            Time:       event.TradeTime,
+            BuyerMaker: event.BuyerMaker,
+            TradeID:    event.TradeID,
+        })
+
+    case strings.Contains(envelope.Stream, "@kline_"):
+        event, err := kline.DecodeEvent(envelope.Data)
+        if err != nil {
+            log.Printf("kline decode error: %v", err)
+            return
+        }
+        // 진행 중인 캔들은 저장하지 않음 (최신 가격은 틱으로 제공)
+        if !event.Closed {
+            return
+        }
+        ps.store.AddCandle(candleFromKline(event.Symbol, event.Interval, event.Kline))
+    }
+}
+
+// backfillCandles 마지막 저장 캔들 이후 누락분을 REST로 채움 (처음이면 보관 기간 전체)
+func (ps *PriceService) backfillCandles() {
+    for _, symbol := range ps.symbols {
+        for _, interval := range ps.config.Intervals {
+            added, err := ps.backfill(symbol, interval)
+            if err != nil {
+                log.Printf("Backfill %s %s failed: %v", symbol, interval, err)
+            }
+            if added > 0 {
+                log.Printf("Backfilled %d %s candles for %s", added, interval, symbol)
+            }
+        }
+        ps.store.Flush()
+    }
+}
+
+// backfill 한 심볼/인터벌의 누락 캔들을 페이지 단위로 조회
+func (ps *PriceService) backfill(symbol, interval string) (int, error) {
+    now := time.Now()
+    start := ps.store.LastCandleTime(symbol, interval) + 1
+    if oldest := now.Add(-ps.config.CandleRetention).UnixMilli(); start < oldest {
+        start = oldest
+    }
+
+    added := 0
+    for page := 0; page < maxBackfillPages; page++ {
+        candles, err := ps.fetchKlines(symbol, interval, start)
+        if err != nil {
+            return added, err
+        }
+
+        for _, candle := range candles {
+            // 아직 마감되지 않은 마지막 캔들은 제외
+            if candle.CloseTime >= now.UnixMilli() {
+                continue
+            }
+            ps.store.AddCandle(candle)
+            added++
+        }
+
+        if len(candles) < maxBackfill {
+            break
+        }
+        start = candles[len(candles)-1].OpenTime + 1
+    }
+    return added, nil
+}
+
+// fetchKlines startTime 이후 캔들을 REST로 조회
+func (ps *PriceService) fetchKlines(symbol, interval string, startTime int64) ([]Candle, error) {
+    params := url.Values{
+        "symbol":    {symbol},
+        "interval":  {interval},
+        "startTime": {strconv.FormatInt(startTime, 10)},
+        "limit":     {strconv.Itoa(maxBackfill)},
+    }
+
+    resp, err := ps.client.Get(binanceRestURL + "/api/v3/klines?" + params.Encode())
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != 200 {
+        return nil, fmt.Errorf("klines API error: %d", resp.StatusCode)
+    }
+
+    klines, rowErrs, err := kline.DecodeLenient(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+    if len(rowErrs) > 0 {
+        log.Printf("%s %s klines: skipped %d invalid rows (first: %v)", symbol, interval, len(rowErrs), rowErrs[0])
+    }
+
+    candles := make([]Candle, 0, len(klines))
+    for _, k := range klines {
+        candles = append(candles, candleFromKline(symbol, interval, k))
+    }
+    return candles, nil
+}
+
+func parseFloat(s string) float64 {
+    v, _ := strconv.ParseFloat(s, 64)
+    return v
+}
+
+// candleFromKline 공통 디코더 결과를 저장용 캔들로 변환
+func candleFromKline(symbol, interval string, k kline.Kline) Candle {
+    return Candle{
+        Symbol:      symbol,
+        Interval:    interval,
+        OpenTime:    k.OpenTime,
+        CloseTime:   k.CloseTime,
+        Open:        k.Open,
+        High:        k.High,
+        Low:         k.Low,
+        Close:       k.Close,
+        Volume:      k.Volume,
+        QuoteVolume: k.QuoteVolume,
+        Trades:      int(k.Trades),
+    }
+}
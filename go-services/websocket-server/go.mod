@@ -3,6 +3,8 @@ module github.com/loadstar0723/monstas7/go-services/websocket-server
 go 1.21
 
 require (
-    github.com/gorilla/mux v1.8.1
-    github.com/gorilla/websocket v1.5.1
-)
\ No newline at end of file
+	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.1
+)
+
+require golang.org/x/net v0.17.0 // indirect
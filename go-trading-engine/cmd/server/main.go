@@ -14,6 +14,7 @@ import (
     "github.com/gin-gonic/gin"
     "github.com/gorilla/websocket"
     "github.com/loadstar0723/monstas7/go-trading-engine/handlers"
+    "github.com/loadstar0723/monstas7/go-trading-engine/pkg/binance"
 )
 
 // TradingEngine 메인 구조체
@@ -61,6 +62,10 @@ func main() {
         c.Next()
     })
 
+    // 심볼 유니버스 로드
+    symbols := binance.LoadSymbols()
+    log.Printf("📋 감시 심볼: %v", symbols)
+
     // 트레이딩 엔진 초기화
     engine := &TradingEngine{
         wsClients:   make(map[string]*websocket.Conn),
@@ -182,7 +187,11 @@ func (e *TradingEngine) handleWebSocket(c *gin.Context) {
 
 // 시장 데이터 API
 func (e *TradingEngine) getMarketData(c *gin.Context) {
-    symbol := c.Param("symbol")
+    symbol, err := binance.ValidateSymbol(c.Param("symbol"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
     // TODO: Binance API 연동
     mockData := MarketData{
@@ -207,6 +216,13 @@ func (e *TradingEngine) getPrediction(c *gin.Context) {
         return
     }
 
+    symbol, err := binance.ValidateSymbol(req.Symbol)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    req.Symbol = symbol
+
     // TODO: Python AI 서버 연동
     mockPrediction := Prediction{
         Symbol:     req.Symbol,
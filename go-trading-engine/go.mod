@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/gorilla/websocket v1.5.1
+	github.com/loadstar0723/monstas7-backend/pkg/kline v0.0.0
 )
 
 require (
@@ -33,3 +34,5 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/loadstar0723/monstas7-backend/pkg/kline => ../backend-go/pkg/kline
@@ -0,0 +1,108 @@
+package binance
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+// DefaultWatchlist WATCHLIST 환경변수가 없을 때 사용하는 기본 심볼
+var DefaultWatchlist = []string{"BTCUSDT", "ETHUSDT", "BNBUSDT", "SOLUSDT", "ADAUSDT"}
+
+var (
+    symbolMu  sync.RWMutex
+    watchlist []string
+    trading   map[string]bool
+)
+
+// LoadSymbols WATCHLIST를 읽고 exchangeInfo로 거래 가능 여부를 검증
+func LoadSymbols() []string {
+    symbols := DefaultWatchlist
+    if raw := os.Getenv("WATCHLIST"); raw != "" {
+        symbols = nil
+        for _, part := range strings.Split(raw, ",") {
+            if symbol := strings.ToUpper(strings.TrimSpace(part)); symbol != "" {
+                symbols = append(symbols, symbol)
+            }
+        }
+    }
+
+    status, err := fetchSymbolStatus()
+    if err != nil {
+        log.Printf("⚠️ exchangeInfo 조회 실패, 검증 없이 감시 목록 사용: %v", err)
+    } else {
+        validated := make([]string, 0, len(symbols))
+        for _, symbol := range symbols {
+            if !status[symbol] {
+                log.Printf("⚠️ %s 제외: 거래 불가 심볼", symbol)
+                continue
+            }
+            validated = append(validated, symbol)
+        }
+        symbols = validated
+    }
+
+    symbolMu.Lock()
+    watchlist = symbols
+    trading = status
+    symbolMu.Unlock()
+
+    return symbols
+}
+
+// Watchlist 현재 감시 심볼 목록
+func Watchlist() []string {
+    symbolMu.RLock()
+    defer symbolMu.RUnlock()
+    return append([]string(nil), watchlist...)
+}
+
+// ValidateSymbol 심볼 정규화 및 거래 가능 여부 검증
+func ValidateSymbol(symbol string) (string, error) {
+    normalized := strings.ToUpper(strings.TrimSpace(symbol))
+    if normalized == "" {
+        return "", fmt.Errorf("symbol is required")
+    }
+
+    symbolMu.RLock()
+    defer symbolMu.RUnlock()
+    if trading != nil && !trading[normalized] {
+        return "", fmt.Errorf("unknown or non-trading symbol: %s", normalized)
+    }
+    return normalized, nil
+}
+
+// fetchSymbolStatus exchangeInfo에서 심볼별 TRADING 여부 조회
+func fetchSymbolStatus() (map[string]bool, error) {
+    client := &http.Client{Timeout: 10 * time.Second}
+    resp, err := client.Get("https://api.binance.com/api/v3/exchangeInfo")
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("exchangeInfo API error: %d", resp.StatusCode)
+    }
+
+    var info struct {
+        Symbols []struct {
+            Symbol string `json:"symbol"`
+            Status string `json:"status"`
+        } `json:"symbols"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+        return nil, err
+    }
+
+    status := make(map[string]bool, len(info.Symbols))
+    for _, s := range info.Symbols {
+        status[s.Symbol] = s.Status == "TRADING"
+    }
+    return status, nil
+}
@@ -4,10 +4,12 @@ import (
     "encoding/json"
     "fmt"
     "log"
+    "strconv"
     "strings"
     "time"
 
     "github.com/gorilla/websocket"
+    "github.com/loadstar0723/monstas7-backend/pkg/kline"
 )
 
 // BinanceWSClient Binance WebSocket 클라이언트
@@ -95,15 +97,18 @@ func (b *BinanceWSClient) Subscribe() {
                 }
 
                 // 메시지 파싱
-                var data map[string]interface{}
-                if err := json.Unmarshal(message, &data); err != nil {
+                var envelope struct {
+                    Stream string          `json:"stream"`
+                    Data   json.RawMessage `json:"data"`
+                }
+                if err := json.Unmarshal(message, &envelope); err != nil {
                     log.Printf("❌ JSON 파싱 실패: %v", err)
                     continue
                 }
 
                 // 스트림 타입 확인
-                if stream, ok := data["stream"].(string); ok {
-                    b.processStreamData(stream, data["data"])
+                if envelope.Stream != "" {
+                    b.processStreamData(envelope.Stream, envelope.Data)
                 }
             }
         }
@@ -111,30 +116,33 @@ func (b *BinanceWSClient) Subscribe() {
 }
 
 // processStreamData 스트림 데이터 처리
-func (b *BinanceWSClient) processStreamData(stream string, data interface{}) {
+func (b *BinanceWSClient) processStreamData(stream string, data json.RawMessage) {
     if strings.Contains(stream, "@kline") {
-        // K선 데이터 처리
-        klineMap := data.(map[string]interface{})
-        if k, ok := klineMap["k"].(map[string]interface{}); ok {
-            kline := &KlineData{
-                Symbol:    k["s"].(string),
-                OpenTime:  int64(k["t"].(float64)),
-                Open:      k["o"].(string),
-                High:      k["h"].(string),
-                Low:       k["l"].(string),
-                Close:     k["c"].(string),
-                Volume:    k["v"].(string),
-                CloseTime: int64(k["T"].(float64)),
-                Trades:    int(k["n"].(float64)),
-            }
+        // K선 데이터 처리 (공통 디코더로 검증, 잘못된 이벤트는 건너뜀)
+        event, err := kline.DecodeEvent(data)
+        if err != nil {
+            log.Printf("❌ K선 파싱 실패 (%s): %v", stream, err)
+            return
+        }
+        k := event.Kline
+        klineData := &KlineData{
+            Symbol:    event.Symbol,
+            OpenTime:  k.OpenTime,
+            Open:      formatFloat(k.Open),
+            High:      formatFloat(k.High),
+            Low:       formatFloat(k.Low),
+            Close:     formatFloat(k.Close),
+            Volume:    formatFloat(k.Volume),
+            CloseTime: k.CloseTime,
+            Trades:    int(k.Trades),
+        }
 
-            select {
-            case b.dataChannel <- kline:
-            default:
-                // 채널이 가득 찬 경우 가장 오래된 데이터 제거
-                <-b.dataChannel
-                b.dataChannel <- kline
-            }
+        select {
+        case b.dataChannel <- klineData:
+        default:
+            // 채널이 가득 찬 경우 가장 오래된 데이터 제거
+            <-b.dataChannel
+            b.dataChannel <- klineData
         }
     } else if strings.Contains(stream, "@trade") {
         // 거래 데이터 처리
@@ -172,4 +180,9 @@ func (b *BinanceWSClient) Close() error {
         return b.conn.Close()
     }
     return nil
-}
\ No newline at end of file
+}
+
+// formatFloat 디코딩된 가격/수량을 기존 문자열 형식으로 변환
+func formatFloat(v float64) string {
+    return strconv.FormatFloat(v, 'f', -1, 64)
+}